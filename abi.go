@@ -0,0 +1,79 @@
+package clefclient
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// abiWordSize is the size of a single ABI encoded word
+const abiWordSize = 32
+
+// encodeUintWord encodes an unsigned integer as a 32 byte ABI word
+func encodeUintWord(v *big.Int) ([]byte, error) {
+	if v.Sign() < 0 || v.BitLen() > 256 {
+		return nil, fmt.Errorf("value %s does not fit in uint256", v)
+	}
+	return v.FillBytes(make([]byte, abiWordSize)), nil
+}
+
+// encodeAddressWord encodes a hex address as a 32 byte ABI word
+func encodeAddressWord(address string) ([]byte, error) {
+	b, err := decodeHexData(address)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 20 {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+	word := make([]byte, abiWordSize)
+	copy(word[12:], b)
+	return word, nil
+}
+
+// decodeDynamicOffset returns the position of the dynamic argument at index
+// together with its length word
+func decodeDynamicOffset(data []byte, index int) (int, int, error) {
+	head := index * abiWordSize
+	if len(data) < head+abiWordSize {
+		return 0, 0, fmt.Errorf("abi data too short for argument %d", index)
+	}
+	offset := new(big.Int).SetBytes(data[head : head+abiWordSize])
+	if !offset.IsInt64() || offset.Int64() > int64(len(data)-abiWordSize) {
+		return 0, 0, fmt.Errorf("invalid offset for argument %d", index)
+	}
+	start := int(offset.Int64())
+	length := new(big.Int).SetBytes(data[start : start+abiWordSize])
+	if !length.IsInt64() || length.Int64() > int64(len(data)) {
+		return 0, 0, fmt.Errorf("invalid length for argument %d", index)
+	}
+	return start + abiWordSize, int(length.Int64()), nil
+}
+
+// decodeABIString decodes the string argument at index
+func decodeABIString(data []byte, index int) (string, error) {
+	start, length, err := decodeDynamicOffset(data, index)
+	if err != nil {
+		return "", err
+	}
+	if start+length > len(data) {
+		return "", fmt.Errorf("abi string argument %d out of bounds", index)
+	}
+	return string(data[start : start+length]), nil
+}
+
+// decodeUintArray decodes the uint256[] argument at index
+func decodeUintArray(data []byte, index int) ([]*big.Int, error) {
+	start, length, err := decodeDynamicOffset(data, index)
+	if err != nil {
+		return nil, err
+	}
+	if length > (len(data)-start)/abiWordSize {
+		return nil, fmt.Errorf("abi array argument %d out of bounds", index)
+	}
+	values := make([]*big.Int, length)
+	for i := range values {
+		pos := start + i*abiWordSize
+		values[i] = new(big.Int).SetBytes(data[pos : pos+abiWordSize])
+	}
+	return values, nil
+}
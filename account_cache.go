@@ -0,0 +1,120 @@
+package clefclient
+
+import (
+	"sync"
+	"time"
+)
+
+// AccountCache caches the account list of a ClefClient for a limited time,
+// so that frequent lookups do not reach Clef or its approval UI
+type AccountCache struct {
+	client Signer
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	accounts []string
+	expires  time.Time
+	// refreshing counts the fetches in flight, during which an expired list
+	// is still served
+	refreshing int
+	// generation is incremented by Invalidate, so that fetches started
+	// before do not store their result
+	generation uint64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stop      chan struct{}
+}
+
+// NewAccountCache creates an AccountCache whose entries expire after ttl
+func NewAccountCache(client Signer, ttl time.Duration) *AccountCache {
+	return &AccountCache{client: client, ttl: ttl, stop: make(chan struct{})}
+}
+
+// ListAccounts returns the cached account list, fetching it from Clef when
+// the cache is empty or expired. While a fetch is in flight, the expired
+// list is returned instead of waiting for Clef.
+func (c *AccountCache) ListAccounts() ([]string, error) {
+	c.mu.Lock()
+	if c.accounts != nil && (time.Now().Before(c.expires) || c.refreshing > 0) {
+		accounts := append([]string(nil), c.accounts...)
+		c.mu.Unlock()
+		return accounts, nil
+	}
+	c.mu.Unlock()
+	return c.fetch()
+}
+
+// HasAccount reports whether the given address is in the cached account list
+func (c *AccountCache) HasAccount(address string) (bool, error) {
+	accounts, err := c.ListAccounts()
+	if err != nil {
+		return false, err
+	}
+	return containsAddress(accounts, address), nil
+}
+
+// Invalidate drops the cached account list, e.g. after creating an account
+func (c *AccountCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accounts = nil
+	c.generation++
+}
+
+// Refresh fetches the account list from Clef and resets the expiry
+func (c *AccountCache) Refresh() error {
+	_, err := c.fetch()
+	return err
+}
+
+// fetch gets the account list from Clef without holding c.mu, so that
+// lookups are not blocked meanwhile, and stores it
+func (c *AccountCache) fetch() ([]string, error) {
+	c.mu.Lock()
+	c.refreshing++
+	generation := c.generation
+	c.mu.Unlock()
+
+	accounts, err := c.client.ListAccounts()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshing--
+	if err != nil {
+		return nil, err
+	}
+	if accounts == nil {
+		accounts = []string{}
+	}
+	if generation == c.generation {
+		c.accounts = accounts
+		c.expires = time.Now().Add(c.ttl)
+	}
+	return append([]string(nil), accounts...), nil
+}
+
+// StartRefresh refreshes the cache in the background every interval until
+// Close is called. Failed refreshes keep the previous account list. Only the
+// first call starts a refresh.
+func (c *AccountCache) StartRefresh(interval time.Duration) {
+	c.startOnce.Do(func() { go c.refreshEvery(interval) })
+}
+
+func (c *AccountCache) refreshEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.Refresh()
+		}
+	}
+}
+
+// Close stops the background refresh
+func (c *AccountCache) Close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
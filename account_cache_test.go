@@ -0,0 +1,113 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupCountingAccountServer serves account_list and counts the calls
+func setupCountingAccountServer(t *testing.T, accounts []string) (*ClefClient, *httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "account_list", req.Method)
+		calls.Add(1)
+
+		result, _ := json.Marshal(accounts)
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: result})
+	}))
+	return NewHTTPClient(server.URL), server, &calls
+}
+
+func TestHasAccount(t *testing.T) {
+	client, server, _ := setupCountingAccountServer(t, []string{"0xAbC"})
+	defer server.Close()
+
+	ok, err := client.HasAccount("0xabc")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = client.HasAccount("0xdef")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestAccountCacheTTLAndInvalidate(t *testing.T) {
+	client, server, calls := setupCountingAccountServer(t, []string{"0x01", "0x02"})
+	defer server.Close()
+
+	cache := NewAccountCache(client, time.Hour)
+	defer cache.Close()
+
+	for i := 0; i < 3; i++ {
+		ok, err := cache.HasAccount("0x02")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+
+	cache.Invalidate()
+	accounts, err := cache.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x01", "0x02"}, accounts)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestAccountCacheExpiry(t *testing.T) {
+	client, server, calls := setupCountingAccountServer(t, []string{})
+	defer server.Close()
+
+	cache := NewAccountCache(client, time.Millisecond)
+	_, err := cache.ListAccounts()
+	assert.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	_, err = cache.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestAccountCacheBackgroundRefresh(t *testing.T) {
+	client, server, calls := setupCountingAccountServer(t, []string{"0x01"})
+	defer server.Close()
+
+	cache := NewAccountCache(client, time.Hour)
+	cache.StartRefresh(time.Millisecond)
+	assert.Eventually(t, func() bool { return calls.Load() >= 2 }, time.Second, time.Millisecond)
+	cache.Close()
+}
+
+func TestAccountCacheServesStaleWhileRefreshing(t *testing.T) {
+	gate := make(chan struct{})
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			<-gate
+		}
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: json.RawMessage(`["0x01"]`)})
+	}))
+	defer server.Close()
+
+	cache := NewAccountCache(NewHTTPClient(server.URL), time.Millisecond)
+	defer cache.Close()
+	_, err := cache.ListAccounts()
+	assert.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+
+	// A slow refresh does not block lookups
+	done := make(chan error)
+	go func() { done <- cache.Refresh() }()
+	assert.Eventually(t, func() bool { return calls.Load() == 2 }, time.Second, time.Millisecond)
+	ok, err := cache.HasAccount("0x01")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	close(gate)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(2), calls.Load())
+}
@@ -0,0 +1,225 @@
+package clefclient
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RiskCategory classifies the counterparty behind an address
+type RiskCategory string
+
+// Risk categories of address book entries
+const (
+	RiskUnknown    RiskCategory = ""
+	RiskInternal   RiskCategory = "internal"
+	RiskTrusted    RiskCategory = "trusted"
+	RiskExchange   RiskCategory = "exchange"
+	RiskSuspicious RiskCategory = "suspicious"
+	RiskSanctioned RiskCategory = "sanctioned"
+)
+
+// AddressBookEntry maps an address to a human label
+type AddressBookEntry struct {
+	Address  string       `json:"address"`
+	Label    string       `json:"label"`
+	Category RiskCategory `json:"category,omitempty"`
+	// ENSName is the name the entry was added by, if any
+	ENSName string `json:"ensName,omitempty"`
+}
+
+// AddressBookStore persists address book entries. Get returns nil for
+// unknown addresses. Addresses are passed in lowercase.
+type AddressBookStore interface {
+	Get(address string) (*AddressBookEntry, error)
+	Put(entry *AddressBookEntry) error
+	Delete(address string) error
+	List() ([]*AddressBookEntry, error)
+}
+
+// AddressBook labels addresses for audit logs, approval prompts and policies
+type AddressBook struct {
+	store    AddressBookStore
+	resolver *ENSResolver
+}
+
+// NewAddressBook creates an address book on the given store. The resolver is
+// used for entries added by ENS name and may be nil.
+func NewAddressBook(store AddressBookStore, resolver *ENSResolver) *AddressBook {
+	return &AddressBook{store: store, resolver: resolver}
+}
+
+// Add adds or replaces an entry. The address may be an ENS name, which is
+// resolved once and recorded in ENSName.
+func (ab *AddressBook) Add(entry AddressBookEntry) error {
+	if IsENSName(entry.Address) {
+		if ab.resolver == nil {
+			return fmt.Errorf("cannot add %s: no ENS resolver configured", entry.Address)
+		}
+		resolution, err := ab.resolver.Resolve(entry.Address)
+		if err != nil {
+			return err
+		}
+		entry.ENSName = resolution.Name
+		entry.Address = resolution.Address
+	}
+	address, err := ChecksumAddress(entry.Address)
+	if err != nil {
+		return err
+	}
+	entry.Address = address
+	return ab.store.Put(&entry)
+}
+
+// Remove removes the entry of address
+func (ab *AddressBook) Remove(address string) error {
+	return ab.store.Delete(strings.ToLower(address))
+}
+
+// Lookup returns the entry of address, or nil if it is unknown
+func (ab *AddressBook) Lookup(address string) (*AddressBookEntry, error) {
+	return ab.store.Get(strings.ToLower(address))
+}
+
+// LookupLabel returns the label and category of address. Lookup errors are
+// treated as unknown addresses.
+func (ab *AddressBook) LookupLabel(address string) (string, string, bool) {
+	entry, err := ab.Lookup(address)
+	if err != nil || entry == nil {
+		return "", "", false
+	}
+	return entry.Label, string(entry.Category), true
+}
+
+// Entries returns all entries ordered by address
+func (ab *AddressBook) Entries() ([]*AddressBookEntry, error) {
+	entries, err := ab.store.List()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Address) < strings.ToLower(entries[j].Address)
+	})
+	return entries, nil
+}
+
+// describe returns "label (category)" for known addresses
+func (ab *AddressBook) describe(address string) (string, bool) {
+	label, category, ok := ab.LookupLabel(address)
+	if !ok {
+		return "", false
+	}
+	if category != "" {
+		label += " (" + category + ")"
+	}
+	return label, true
+}
+
+// MemoryAddressBookStore keeps address book entries in memory
+type MemoryAddressBookStore struct {
+	mu      sync.RWMutex
+	entries map[string]*AddressBookEntry
+}
+
+// NewMemoryAddressBookStore creates an empty MemoryAddressBookStore
+func NewMemoryAddressBookStore() *MemoryAddressBookStore {
+	return &MemoryAddressBookStore{entries: map[string]*AddressBookEntry{}}
+}
+
+// Get implements AddressBookStore
+func (s *MemoryAddressBookStore) Get(address string) (*AddressBookEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if entry, ok := s.entries[address]; ok {
+		copied := *entry
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+// Put implements AddressBookStore
+func (s *MemoryAddressBookStore) Put(entry *AddressBookEntry) error {
+	copied := *entry
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[strings.ToLower(entry.Address)] = &copied
+	return nil
+}
+
+// Delete implements AddressBookStore
+func (s *MemoryAddressBookStore) Delete(address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, address)
+	return nil
+}
+
+// List implements AddressBookStore
+func (s *MemoryAddressBookStore) List() ([]*AddressBookEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entries := make([]*AddressBookEntry, 0, len(s.entries))
+	for _, entry := range s.entries {
+		copied := *entry
+		entries = append(entries, &copied)
+	}
+	return entries, nil
+}
+
+// FileAddressBookStore keeps address book entries in a JSON file, rewritten on
+// every change
+type FileAddressBookStore struct {
+	path string
+	mem  *MemoryAddressBookStore
+	mu   sync.Mutex
+}
+
+// NewFileAddressBookStore loads the address book at path, which need not exist
+func NewFileAddressBookStore(path string) (*FileAddressBookStore, error) {
+	s := &FileAddressBookStore{path: path, mem: NewMemoryAddressBookStore()}
+	var entries []*AddressBookEntry
+	if err := readJSONFile(path, &entries); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load address book: %w", err)
+	}
+	for _, entry := range entries {
+		s.mem.Put(entry)
+	}
+	return s, nil
+}
+
+// Get implements AddressBookStore
+func (s *FileAddressBookStore) Get(address string) (*AddressBookEntry, error) {
+	return s.mem.Get(address)
+}
+
+// Put implements AddressBookStore
+func (s *FileAddressBookStore) Put(entry *AddressBookEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem.Put(entry)
+	return s.save()
+}
+
+// Delete implements AddressBookStore
+func (s *FileAddressBookStore) Delete(address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem.Delete(address)
+	return s.save()
+}
+
+// List implements AddressBookStore
+func (s *FileAddressBookStore) List() ([]*AddressBookEntry, error) {
+	return s.mem.List()
+}
+
+func (s *FileAddressBookStore) save() error {
+	entries, _ := s.mem.List()
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.ToLower(entries[i].Address) < strings.ToLower(entries[j].Address)
+	})
+	return writeJSONFile(s.path, entries)
+}
@@ -0,0 +1,63 @@
+package clefclient
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressBook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "addressbook.json")
+	store, err := NewFileAddressBookStore(path)
+	assert.NoError(t, err)
+
+	resolver := NewENSResolver(ENSConfig{Backend: &fakeENSBackend{
+		resolver:  "0x4976fb03C32e5B8cfe2b6cCB31c09Ba78EBaBa41",
+		addresses: map[string]string{encodeHexData(Namehash("vitalik.eth")): "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"},
+	}})
+	book := NewAddressBook(store, resolver)
+	assert.NoError(t, book.Add(AddressBookEntry{Address: "vitalik.eth", Label: "Vitalik", Category: RiskTrusted}))
+	assert.NoError(t, book.Add(AddressBookEntry{Address: "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf", Label: "Treasury", Category: RiskInternal}))
+	assert.Error(t, book.Add(AddressBookEntry{Address: "0x1234", Label: "Broken"}))
+
+	// Reload from disk
+	store, err = NewFileAddressBookStore(path)
+	assert.NoError(t, err)
+	book = NewAddressBook(store, nil)
+
+	entry, err := book.Lookup("0xD8DA6BF26964AF9D7EED9E03E53415D37AA96045")
+	assert.NoError(t, err)
+	assert.Equal(t, &AddressBookEntry{
+		Address:  "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045",
+		Label:    "Vitalik",
+		Category: RiskTrusted,
+		ENSName:  "vitalik.eth",
+	}, entry)
+
+	label, category, ok := book.LookupLabel("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf")
+	assert.True(t, ok)
+	assert.Equal(t, "Treasury", label)
+	assert.Equal(t, "internal", category)
+
+	assert.NoError(t, book.Remove("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"))
+	entries, err := book.Entries()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.ErrorContains(t, book.Add(AddressBookEntry{Address: "other.eth"}), "no ENS resolver")
+}
+
+func TestAuditAddressBookLabels(t *testing.T) {
+	book := NewAddressBook(NewMemoryAddressBookStore(), nil)
+	assert.NoError(t, book.Add(AddressBookEntry{Address: "0x0000000000000000000000000000000000000002", Label: "Exchange", Category: RiskExchange}))
+
+	client, server := setupHTTPTestServer(t, "account_signTransaction", SignTxResponse{Raw: "0xraw"})
+	defer server.Close()
+	store := &MemoryAuditStore{}
+	client.EnableAudit(NewAuditLog(AuditConfig{Store: store, AddressBook: book}))
+
+	_, err := client.SignTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"0x0000000000000000000000000000000000000002": "Exchange (exchange)"}, store.Entries()[0].Labels)
+}
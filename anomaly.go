@@ -0,0 +1,238 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrAnomaly is returned for signing requests blocked by an AnomalyDetector
+var ErrAnomaly = errors.New("signing request blocked as anomalous")
+
+// Anomaly is what an AnomalyDetector found unusual about a signing request
+type Anomaly struct {
+	// Reason describes the anomaly, e.g. "12 requests within 1m0s"
+	Reason string
+	// Block refuses the request instead of only flagging it
+	Block bool
+}
+
+// AnomalyDetector inspects signing requests before they are sent to Clef,
+// e.g. for requests at unusual hours, to new counterparties or in bursts.
+// Detectors run on the goroutine of the call and delay it.
+type AnomalyDetector interface {
+	// Detect returns the anomaly of e, nil if there is none. history holds
+	// the earlier requests of the same account within the history window,
+	// oldest first; the Err of those answered is set.
+	Detect(e *SignEvent, history []SignEvent) *Anomaly
+}
+
+// AnomalyDetectorFunc adapts a function to the AnomalyDetector interface
+type AnomalyDetectorFunc func(e *SignEvent, history []SignEvent) *Anomaly
+
+// Detect implements AnomalyDetector
+func (f AnomalyDetectorFunc) Detect(e *SignEvent, history []SignEvent) *Anomaly {
+	return f(e, history)
+}
+
+// AnomalyConfig configures anomaly detection, see EnableAnomalyDetection
+type AnomalyConfig struct {
+	Detectors []AnomalyDetector
+	// History is how long requests are remembered, 24 hours if zero
+	History time.Duration
+	// OnAnomaly is called for every anomaly found, blocking or not, e.g. to
+	// alert on flagged requests
+	OnAnomaly func(e *SignEvent, a *Anomaly)
+}
+
+// EnableAnomalyDetection runs the detectors of config on every subsequent
+// signing request, with the recent requests of its account. Requests with a
+// blocking anomaly fail with both ErrPolicyViolation and ErrAnomaly and are
+// not sent; flagged requests are only reported to OnAnomaly. The history is
+// kept in memory and only holds the requests made through the client.
+func (cc *ClefClient) EnableAnomalyDetection(config AnomalyConfig) {
+	if !cc.ready() {
+		return
+	}
+	if config.History <= 0 {
+		config.History = 24 * time.Hour
+	}
+	cc.transport = &anomalyTransport{next: cc.transport, config: config, now: time.Now, history: map[string][]*SignEvent{}}
+}
+
+// anomalyTransport is a transport decorator running anomaly detectors
+type anomalyTransport struct {
+	next   transport
+	config AnomalyConfig
+	now    func() time.Time
+
+	// mu serializes the detection, so that the requests of a burst see each
+	// other in their history
+	mu      sync.Mutex
+	history map[string][]*SignEvent
+}
+
+func (t *anomalyTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if !signingMethods[method] {
+		return t.next.call(ctx, method, params)
+	}
+	events, err := t.detect(method, []interface{}{params})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.call(ctx, method, params)
+	if err == nil && resp.Error != nil {
+		t.answered(events[0], newCallError(method, resp.Error))
+	} else {
+		t.answered(events[0], err)
+	}
+	return resp, err
+}
+
+// callBatch rejects the whole batch if one of its requests is blocked. The
+// entries of the batch are in the history of the following ones.
+func (t *anomalyTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	if !signingMethods[method] {
+		return t.next.callBatch(ctx, method, params)
+	}
+	events, err := t.detect(method, params)
+	if err != nil {
+		return nil, err
+	}
+	resps, err := t.next.callBatch(ctx, method, params)
+	for i, e := range events {
+		callErr := err
+		if err == nil && resps[i].Error != nil {
+			callErr = newCallError(method, resps[i].Error)
+		}
+		t.answered(e, callErr)
+	}
+	return resps, err
+}
+
+func (t *anomalyTransport) close() error {
+	return t.next.close()
+}
+
+// detect runs the detectors on the requests and adds them to the history,
+// unless one of them is blocked
+func (t *anomalyTransport) detect(method string, params []interface{}) ([]*SignEvent, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.prune(now)
+	events := make([]*SignEvent, len(params))
+	pending := map[string][]SignEvent{}
+	for i, p := range params {
+		e := newSignEvent(method, p, now)
+		account := strings.ToLower(e.Account)
+		history := append(t.recent(account), pending[account]...)
+		for _, detector := range t.config.Detectors {
+			a := detector.Detect(e, history)
+			if a == nil {
+				continue
+			}
+			if t.config.OnAnomaly != nil {
+				t.config.OnAnomaly(e, a)
+			}
+			if a.Block {
+				err := fmt.Errorf("%w: %w: %s", ErrPolicyViolation, ErrAnomaly, a.Reason)
+				if len(params) > 1 {
+					err = fmt.Errorf("batch entry %d: %w", i+1, err)
+				}
+				return nil, err
+			}
+		}
+		events[i] = e
+		pending[account] = append(pending[account], *e)
+	}
+	for _, e := range events {
+		account := strings.ToLower(e.Account)
+		t.history[account] = append(t.history[account], e)
+	}
+	return events, nil
+}
+
+// recent returns copies of the history of account
+func (t *anomalyTransport) recent(account string) []SignEvent {
+	history := make([]SignEvent, len(t.history[account]))
+	for i, e := range t.history[account] {
+		history[i] = *e
+	}
+	return history
+}
+
+// prune forgets the requests older than the history window
+func (t *anomalyTransport) prune(now time.Time) {
+	since := now.Add(-t.config.History)
+	for account, events := range t.history {
+		i := 0
+		for i < len(events) && events[i].Requested.Before(since) {
+			i++
+		}
+		if i == len(events) {
+			delete(t.history, account)
+		} else {
+			t.history[account] = events[i:]
+		}
+	}
+}
+
+// answered records the outcome of a request in the history
+func (t *anomalyTransport) answered(e *SignEvent, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e.Duration = t.now().Sub(e.Requested)
+	e.Err = err
+}
+
+// BurstDetector flags, or blocks, an account's requests beyond limit within
+// window
+func BurstDetector(limit int, window time.Duration, block bool) AnomalyDetector {
+	return AnomalyDetectorFunc(func(e *SignEvent, history []SignEvent) *Anomaly {
+		count := 1
+		for _, h := range history {
+			if !h.Requested.Before(e.Requested.Add(-window)) {
+				count++
+			}
+		}
+		if count <= limit {
+			return nil
+		}
+		return &Anomaly{Reason: fmt.Sprintf("%d requests of %s within %s", count, e.Account, window), Block: block}
+	})
+}
+
+// NewCounterpartyDetector flags, or blocks, transactions to addresses the
+// account sent no transaction to within the history window. Denied and
+// failed transactions do not count.
+func NewCounterpartyDetector(block bool) AnomalyDetector {
+	return AnomalyDetectorFunc(func(e *SignEvent, history []SignEvent) *Anomaly {
+		if e.Tx == nil || e.Tx.To == "" {
+			return nil
+		}
+		for _, h := range history {
+			if h.Tx != nil && h.Err == nil && strings.EqualFold(h.Tx.To, e.Tx.To) {
+				return nil
+			}
+		}
+		return &Anomaly{Reason: fmt.Sprintf("%s never sent to %s", e.Account, e.Tx.To), Block: block}
+	})
+}
+
+// UnusualHoursDetector flags, or blocks, requests made outside the hours
+// from start to end, e.g. 8 to 18, in loc. The range wraps around midnight
+// if end is before start.
+func UnusualHoursDetector(start, end int, loc *time.Location, block bool) AnomalyDetector {
+	return AnomalyDetectorFunc(func(e *SignEvent, history []SignEvent) *Anomaly {
+		hour := e.Requested.In(loc).Hour()
+		if start <= end && hour >= start && hour < end || start > end && (hour >= start || hour < end) {
+			return nil
+		}
+		return &Anomaly{Reason: fmt.Sprintf("request of %s at %s, outside %02d:00-%02d:00", e.Account, e.Requested.In(loc).Format("15:04"), start, end), Block: block}
+	})
+}
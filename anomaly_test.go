@@ -0,0 +1,92 @@
+package clefclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableAnomalyDetection(t *testing.T) {
+	alice := "0x0000000000000000000000000000000000000001"
+	bob := "0x0000000000000000000000000000000000000002"
+	carol := "0x0000000000000000000000000000000000000003"
+	tx := func(to, value string) *Transaction {
+		return &Transaction{From: alice, To: to, Value: value}
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var anomalies []string
+	var histories []int
+	next := &approvingTransport{rejected: map[string]bool{"0x3": true}}
+	cc := &ClefClient{transport: next}
+	cc.EnableAnomalyDetection(AnomalyConfig{
+		Detectors: []AnomalyDetector{
+			AnomalyDetectorFunc(func(e *SignEvent, history []SignEvent) *Anomaly {
+				histories = append(histories, len(history))
+				return nil
+			}),
+			NewCounterpartyDetector(false),
+			BurstDetector(3, time.Minute, true),
+		},
+		History:   time.Hour,
+		OnAnomaly: func(e *SignEvent, a *Anomaly) { anomalies = append(anomalies, a.Reason) },
+	})
+	cc.transport.(*anomalyTransport).now = func() time.Time { return now }
+
+	// New counterparties are flagged, but sent
+	_, err := cc.SignTransaction(tx(bob, "0x1"))
+	assert.NoError(t, err)
+	_, err = cc.SignTransaction(tx(bob, "0x1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{alice + " never sent to " + bob}, anomalies)
+	assert.Equal(t, []int{0, 1}, histories)
+	assert.Equal(t, 2, next.calls)
+
+	// Denied transactions are in the history with their error
+	_, err = cc.SignTransaction(tx(carol, "0x3"))
+	assert.Error(t, err)
+	history := cc.transport.(*anomalyTransport).recent(alice)
+	assert.Len(t, history, 3)
+	assert.ErrorIs(t, history[2].Err, ErrSigner)
+
+	// Bursts are blocked and not sent
+	anomalies = nil
+	_, err = cc.SignTransaction(tx(bob, "0x1"))
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.ErrorIs(t, err, ErrAnomaly)
+	assert.ErrorContains(t, err, "4 requests of "+alice+" within 1m0s")
+	assert.Equal(t, 3, next.calls)
+
+	// The history window slides, forgetting counterparties
+	now = now.Add(time.Hour + time.Second)
+	anomalies = nil
+	_, err = cc.SignTransaction(tx(bob, "0x1"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{alice + " never sent to " + bob}, anomalies)
+
+	// Entries of a batch are in the history of the following ones
+	now = now.Add(time.Hour)
+	_, err = cc.SignTransactions([]*Transaction{tx(bob, "0x1"), tx(bob, "0x1"), tx(bob, "0x1"), tx(bob, "0x1")})
+	assert.ErrorIs(t, err, ErrAnomaly)
+	assert.ErrorContains(t, err, "batch entry 4")
+	assert.Equal(t, 4, next.calls)
+	// Blocked batches are not in the history
+	assert.Len(t, cc.transport.(*anomalyTransport).recent(alice), 1)
+}
+
+func TestUnusualHoursDetector(t *testing.T) {
+	at := func(hour int) *SignEvent {
+		return &SignEvent{Account: "0x0000000000000000000000000000000000000001", Requested: time.Date(2026, 1, 1, hour, 30, 0, 0, time.UTC)}
+	}
+	office := UnusualHoursDetector(8, 18, time.UTC, true)
+	assert.Nil(t, office.Detect(at(8), nil))
+	assert.Nil(t, office.Detect(at(17), nil))
+	a := office.Detect(at(18), nil)
+	assert.Equal(t, &Anomaly{Reason: "request of 0x0000000000000000000000000000000000000001 at 18:30, outside 08:00-18:00", Block: true}, a)
+
+	night := UnusualHoursDetector(22, 6, time.UTC, false)
+	assert.Nil(t, night.Detect(at(23), nil))
+	assert.Nil(t, night.Detect(at(5), nil))
+	assert.NotNil(t, night.Detect(at(12), nil))
+}
@@ -0,0 +1,460 @@
+package clefclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrAuditFailed is returned when a call could not be recorded in the audit
+// log. If its request could not be recorded, the call is not sent to Clef;
+// if only its outcome could not, the call may have succeeded, but its result
+// is withheld.
+var ErrAuditFailed = errors.New("failed to record audit entry")
+
+// redactedValue replaces redacted fields in audit entries
+const redactedValue = "[REDACTED]"
+
+// TxSummary is the part of a transaction recorded in audit entries
+type TxSummary struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Value   string `json:"value,omitempty"`
+	Nonce   string `json:"nonce,omitempty"`
+	ChainID string `json:"chainId,omitempty"`
+}
+
+// AuditEntry is the record of a single request made to Clef, or of its
+// outcome
+type AuditEntry struct {
+	Seq uint64 `json:"seq"`
+	// RequestSeq is the Seq of the requested entry of an outcome
+	RequestSeq uint64            `json:"requestSeq,omitempty"`
+	Time       time.Time         `json:"time"`
+	Method     string            `json:"method"`
+	Account    string            `json:"account,omitempty"`
+	Tx         *TxSummary        `json:"tx,omitempty"`
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`
+	Latency    time.Duration     `json:"latency"`
+	Caller     map[string]string `json:"caller,omitempty"`
+	// ClientID identifies the client that made the request, see
+	// WithClientID
+	ClientID string `json:"clientId,omitempty"`
+	// Labels maps the addresses involved to their address book labels
+	Labels map[string]string `json:"labels,omitempty"`
+	// Intent is the template a transaction was instantiated from
+	Intent   *TxIntent       `json:"intent,omitempty"`
+	Request  json.RawMessage `json:"request,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	// PrevHash and Hash chain the entries, so that removed or altered
+	// entries can be detected
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
+
+// Audit outcomes. Every call is recorded as requested before it is sent,
+// then as succeeded or failed.
+const (
+	AuditOutcomeRequested = "requested"
+	AuditOutcomeSuccess   = "success"
+	AuditOutcomeError     = "error"
+)
+
+// AuditStore persists audit entries
+type AuditStore interface {
+	Append(entry *AuditEntry) error
+}
+
+// AuditResumer is implemented by audit stores keeping the entries of
+// earlier runs, so that a new AuditLog continues their hash chain instead of
+// starting another one
+type AuditResumer interface {
+	// Last returns the last stored entry, nil if there is none
+	Last() (*AuditEntry, error)
+}
+
+// RedactionRule modifies an audit entry before it is stored
+type RedactionRule func(entry *AuditEntry)
+
+// AuditConfig configures an AuditLog
+type AuditConfig struct {
+	Store AuditStore
+	// Caller is metadata identifying the caller, attached to every entry
+	Caller map[string]string
+	// Redactions are applied to every entry before it is stored
+	Redactions []RedactionRule
+	// AddressBook, if set, labels the account and recipient of entries
+	AddressBook *AddressBook
+	// Templates, if set, attaches the intent of templated transactions
+	Templates *TxTemplateRegistry
+}
+
+// AuditLog records every request made through a ClefClient
+type AuditLog struct {
+	config AuditConfig
+
+	mu       sync.Mutex
+	resumed  bool
+	seq      uint64
+	prevHash string
+}
+
+// NewAuditLog creates a new AuditLog. If the store is an AuditResumer, the
+// log continues the chain of its last entry.
+func NewAuditLog(config AuditConfig) *AuditLog {
+	return &AuditLog{config: config}
+}
+
+// record redacts, chains and stores an entry
+func (l *AuditLog) record(entry *AuditEntry) error {
+	entry.Caller = l.config.Caller
+	if l.config.AddressBook != nil {
+		addresses := []string{entry.Account}
+		if entry.Tx != nil {
+			addresses = append(addresses, entry.Tx.To)
+		}
+		for _, address := range addresses {
+			if label, ok := l.config.AddressBook.describe(address); ok {
+				if entry.Labels == nil {
+					entry.Labels = map[string]string{}
+				}
+				entry.Labels[address] = label
+			}
+		}
+	}
+	if l.config.Templates != nil && entry.Tx != nil {
+		var tx Transaction
+		if json.Unmarshal(entry.Request, &tx) == nil {
+			if intent, ok := l.config.Templates.IntentOf(&tx); ok {
+				entry.Intent = intent
+			}
+		}
+	}
+	for _, redact := range l.config.Redactions {
+		redact(entry)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.resume(); err != nil {
+		return err
+	}
+	l.seq++
+	entry.Seq = l.seq
+	entry.PrevHash = l.prevHash
+	entry.Hash = ""
+	content, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(content)
+	entry.Hash = hex.EncodeToString(sum[:])
+
+	if err := l.config.Store.Append(entry); err != nil {
+		l.seq--
+		return err
+	}
+	l.prevHash = entry.Hash
+	return nil
+}
+
+// resume continues the chain of the last entry of the store, once
+func (l *AuditLog) resume() error {
+	if l.resumed {
+		return nil
+	}
+	if r, ok := l.config.Store.(AuditResumer); ok {
+		last, err := r.Last()
+		if err != nil {
+			return fmt.Errorf("failed to resume audit chain: %w", err)
+		}
+		if last != nil {
+			l.seq = last.Seq
+			l.prevHash = last.Hash
+		}
+	}
+	l.resumed = true
+	return nil
+}
+
+// VerifyAuditChain checks that entries form an unbroken hash chain
+func VerifyAuditChain(entries []*AuditEntry) error {
+	prevHash := ""
+	for _, entry := range entries {
+		if entry.PrevHash != prevHash {
+			return fmt.Errorf("audit entry %d does not follow its predecessor", entry.Seq)
+		}
+		check := *entry
+		check.Hash = ""
+		content, err := json.Marshal(&check)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != entry.Hash {
+			return fmt.Errorf("audit entry %d has been modified", entry.Seq)
+		}
+		prevHash = entry.Hash
+	}
+	return nil
+}
+
+// RedactFields returns a rule replacing the given JSON fields of requests
+// and responses with a placeholder, at any depth, e.g. in the transaction
+// of a signed transaction response or the Safe transaction of array params
+func RedactFields(fields ...string) RedactionRule {
+	return func(entry *AuditEntry) {
+		entry.Request = redactJSON(entry.Request, fields)
+		entry.Response = redactJSON(entry.Response, fields)
+	}
+}
+
+// DefaultRedaction redacts signatures, signed transactions, signed data and
+// passwords, the fields of DefaultWireRedactions
+var DefaultRedaction = RedactFields(DefaultWireRedactions...)
+
+// redactJSON replaces fields of a JSON value, leaving other values untouched
+func redactJSON(raw json.RawMessage, fields []string) json.RawMessage {
+	var v interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &v) != nil {
+		return raw
+	}
+	redacted, err := json.Marshal(redactFieldValues(v, fields))
+	if err != nil {
+		return raw
+	}
+	return redacted
+}
+
+// MemoryAuditStore keeps audit entries in memory
+type MemoryAuditStore struct {
+	mu      sync.Mutex
+	entries []*AuditEntry
+}
+
+// Append implements AuditStore
+func (s *MemoryAuditStore) Append(entry *AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// Last implements AuditResumer
+func (s *MemoryAuditStore) Last() (*AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) == 0 {
+		return nil, nil
+	}
+	return s.entries[len(s.entries)-1], nil
+}
+
+// Entries returns the recorded entries
+func (s *MemoryAuditStore) Entries() []*AuditEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*AuditEntry(nil), s.entries...)
+}
+
+// FileAuditStore appends audit entries as JSON lines to a file
+type FileAuditStore struct {
+	mu   sync.Mutex
+	file *os.File
+	last *AuditEntry
+}
+
+// NewFileAuditStore opens the file in append-only mode, creating it if
+// needed. The entries already in the file are read, so that the chain of
+// the last one can be continued.
+func NewFileAuditStore(path string) (*FileAuditStore, error) {
+	last, err := lastAuditEntry(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileAuditStore{file: file, last: last}, nil
+}
+
+// lastAuditEntry returns the last entry of the audit log at path, nil if it
+// is empty or does not exist
+func lastAuditEntry(path string) (*AuditEntry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var last *AuditEntry
+	dec := json.NewDecoder(file)
+	for {
+		var entry AuditEntry
+		err := dec.Decode(&entry)
+		if err == io.EOF {
+			return last, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log %s: %w", path, err)
+		}
+		last = &entry
+	}
+}
+
+// Last implements AuditResumer
+func (s *FileAuditStore) Last() (*AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last, nil
+}
+
+// Append implements AuditStore
+func (s *FileAuditStore) Append(entry *AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.last = entry
+	return nil
+}
+
+// Close closes the underlying file
+func (s *FileAuditStore) Close() error {
+	return s.file.Close()
+}
+
+// EnableAudit records every subsequent request made by the client in the
+// audit log, before it is sent to Clef and once it is answered, so that
+// requests in flight during a crash are still on record. Requests that
+// cannot be recorded are not sent.
+func (cc *ClefClient) EnableAudit(log *AuditLog) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &auditTransport{next: cc.transport, log: log}
+}
+
+// auditTransport is a transport decorator recording calls in an AuditLog
+type auditTransport struct {
+	next transport
+	log  *AuditLog
+}
+
+func (t *auditTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	start := time.Now()
+	seq, err := t.requested(ctx, method, params, start)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.call(ctx, method, params)
+	if auditErr := t.answered(ctx, method, params, seq, start, resp, err); auditErr != nil {
+		return nil, auditErr
+	}
+	return resp, err
+}
+
+// callBatch records every request of the batch before sending it
+func (t *auditTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	start := time.Now()
+	seqs := make([]uint64, len(params))
+	for i, p := range params {
+		var err error
+		if seqs[i], err = t.requested(ctx, method, p, start); err != nil {
+			return nil, err
+		}
+	}
+	resps, err := t.next.callBatch(ctx, method, params)
+	for i, p := range params {
+		var resp *rpcResponse
+		callErr := err
+		if err == nil {
+			resp = resps[i]
+			if resp.Error != nil {
+				callErr = newCallError(method, resp.Error)
+			}
+		}
+		if auditErr := t.answered(ctx, method, p, seqs[i], start, resp, callErr); auditErr != nil {
+			return nil, auditErr
+		}
+	}
+	return resps, err
+}
+
+func (t *auditTransport) close() error {
+	return t.next.close()
+}
+
+// requested records a request about to be sent and returns its Seq
+func (t *auditTransport) requested(ctx context.Context, method string, params interface{}, start time.Time) (uint64, error) {
+	entry := newAuditEntry(method, params, start)
+	entry.ClientID = requestClientID(ctx)
+	if err := t.log.record(entry); err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrAuditFailed, err)
+	}
+	return entry.Seq, nil
+}
+
+// answered records the outcome of the request with the given Seq
+func (t *auditTransport) answered(ctx context.Context, method string, params interface{}, seq uint64, start time.Time, resp *rpcResponse, err error) error {
+	entry := newAuditEntry(method, params, start)
+	entry.ClientID = requestClientID(ctx)
+	entry.RequestSeq = seq
+	entry.Latency = time.Since(start)
+	entry.Outcome = AuditOutcomeSuccess
+	if err != nil {
+		entry.Outcome = AuditOutcomeError
+		entry.Error = err.Error()
+	} else if resp != nil {
+		entry.Response = resp.Result
+	}
+	if err := t.log.record(entry); err != nil {
+		return fmt.Errorf("%w: %v", ErrAuditFailed, err)
+	}
+	return nil
+}
+
+// newAuditEntry builds the unredacted requested entry for a call
+func newAuditEntry(method string, params interface{}, start time.Time) *AuditEntry {
+	entry := &AuditEntry{
+		Time:    start.UTC(),
+		Method:  method,
+		Outcome: AuditOutcomeRequested,
+	}
+	if params != nil {
+		entry.Request, _ = json.Marshal(params)
+	}
+
+	switch p := params.(type) {
+	case *Transaction:
+		entry.Account = p.From
+		entry.Tx = &TxSummary{From: p.From, To: p.To, Value: p.Value, Nonce: p.Nonce, ChainID: p.ChainID}
+	case *SignDataRequest:
+		entry.Account = p.Address
+	case *TypedDataRequest:
+		entry.Account = p.Address
+	}
+	return entry
+}
@@ -0,0 +1,164 @@
+package clefclient
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingAuditStore accepts the given number of entries, then rejects every
+// entry
+type failingAuditStore struct {
+	MemoryAuditStore
+	accept int
+}
+
+func (s *failingAuditStore) Append(entry *AuditEntry) error {
+	if len(s.Entries()) >= s.accept {
+		return errors.New("disk full")
+	}
+	return s.MemoryAuditStore.Append(entry)
+}
+
+func TestAuditRecordsCalls(t *testing.T) {
+	expected := &SignDataResponse{Signature: testSignature(0x01)}
+	client, server := setupHTTPTestServer(t, "account_signData", expected)
+	defer server.Close()
+
+	store := &MemoryAuditStore{}
+	client.EnableAudit(NewAuditLog(AuditConfig{
+		Store:      store,
+		Caller:     map[string]string{"service": "payouts"},
+		Redactions: []RedactionRule{DefaultRedaction},
+	}))
+
+	_, err := client.SignData(&SignDataRequest{Address: "0x01", Data: "0x1234"})
+	assert.NoError(t, err)
+	_, err = client.SignData(&SignDataRequest{Address: "0x02", Data: "0x5678"})
+	assert.NoError(t, err)
+
+	// Every call is recorded before it is sent and once it is answered
+	entries := store.Entries()
+	assert.Len(t, entries, 4)
+	assert.Equal(t, "account_signData", entries[0].Method)
+	assert.Equal(t, "0x01", entries[0].Account)
+	assert.Equal(t, AuditOutcomeRequested, entries[0].Outcome)
+	assert.JSONEq(t, `{"address":"0x01","data":"[REDACTED]"}`, string(entries[0].Request))
+	assert.Empty(t, entries[0].Response)
+	assert.Equal(t, "0x01", entries[1].Account)
+	assert.Equal(t, AuditOutcomeSuccess, entries[1].Outcome)
+	assert.Equal(t, entries[0].Seq, entries[1].RequestSeq)
+	assert.Equal(t, "payouts", entries[1].Caller["service"])
+	assert.JSONEq(t, `{"address":"0x01","data":"[REDACTED]"}`, string(entries[1].Request))
+	assert.JSONEq(t, `{"signature":"[REDACTED]"}`, string(entries[1].Response))
+	assert.Equal(t, "0x02", entries[3].Account)
+	assert.NoError(t, VerifyAuditChain(entries))
+
+	entries[0].Account = "0x03"
+	assert.ErrorContains(t, VerifyAuditChain(entries), "modified")
+	assert.ErrorContains(t, VerifyAuditChain(entries[1:]), "predecessor")
+}
+
+func TestAuditRecordsTransactionSummaryAndErrors(t *testing.T) {
+	client := NewHTTPClient("http://127.0.0.1:0")
+	store := &MemoryAuditStore{}
+	client.EnableAudit(NewAuditLog(AuditConfig{Store: store}))
+
+	_, err := client.SignTransaction(&Transaction{From: "0x01", To: "0x02", Value: "0x1"})
+	assert.Error(t, err)
+
+	entries := store.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, AuditOutcomeRequested, entries[0].Outcome)
+	assert.Equal(t, AuditOutcomeError, entries[1].Outcome)
+	assert.NotEmpty(t, entries[1].Error)
+	assert.Equal(t, &TxSummary{From: "0x01", To: "0x02", Value: "0x1"}, entries[0].Tx)
+	assert.Equal(t, &TxSummary{From: "0x01", To: "0x02", Value: "0x1"}, entries[1].Tx)
+}
+
+func TestDefaultRedaction(t *testing.T) {
+	entry := &AuditEntry{
+		Request:  json.RawMessage(`[{"to":"0x02","data":"0x1234","nonce":"0x1"},"0x01","0x"]`),
+		Response: json.RawMessage(`{"raw":"0xf86c","tx":{"to":"0x02","input":"0x1234","r":"0x1","s":"0x2","v":"0x25","hash":"0xab"}}`),
+	}
+	DefaultRedaction(entry)
+	assert.JSONEq(t, `[{"to":"0x02","data":"[REDACTED]","nonce":"0x1"},"0x01","0x"]`, string(entry.Request))
+	assert.JSONEq(t, `{"raw":"[REDACTED]","tx":{"to":"0x02","input":"[REDACTED]","r":"[REDACTED]","s":"[REDACTED]","v":"[REDACTED]","hash":"0xab"}}`, string(entry.Response))
+
+	// Values that are not JSON are left alone
+	entry = &AuditEntry{Request: json.RawMessage(`not json`)}
+	RedactFields("data")(entry)
+	assert.Equal(t, `not json`, string(entry.Request))
+}
+
+func TestAuditStoreFailureWithholdsResult(t *testing.T) {
+	client, server := setupHTTPTestServer(t, "account_version", &VersionResponse{Version: "6.1.0"})
+	defer server.Close()
+	store := &failingAuditStore{accept: 1}
+	client.EnableAudit(NewAuditLog(AuditConfig{Store: store}))
+
+	// The request is on record, the result is withheld
+	_, err := client.Version()
+	assert.ErrorIs(t, err, ErrAuditFailed)
+	if entries := store.Entries(); assert.Len(t, entries, 1) {
+		assert.Equal(t, AuditOutcomeRequested, entries[0].Outcome)
+	}
+}
+
+func TestAuditFailsClosed(t *testing.T) {
+	next := &approvingTransport{}
+	cc := &ClefClient{transport: next}
+	cc.EnableAudit(NewAuditLog(AuditConfig{Store: &failingAuditStore{}}))
+
+	_, err := cc.SignTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001"})
+	assert.ErrorIs(t, err, ErrAuditFailed)
+	_, err = cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001"}})
+	assert.ErrorIs(t, err, ErrAuditFailed)
+	assert.Equal(t, 0, next.calls)
+
+	// Batches are only sent once all their requests are on record
+	store := &failingAuditStore{accept: 1}
+	cc = &ClefClient{transport: next}
+	cc.EnableAudit(NewAuditLog(AuditConfig{Store: store}))
+	_, err = cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001"}, {From: "0x0000000000000000000000000000000000000001"}})
+	assert.ErrorIs(t, err, ErrAuditFailed)
+	assert.Equal(t, 0, next.calls)
+}
+
+func TestFileAuditStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	store, err := NewFileAuditStore(path)
+	assert.NoError(t, err)
+
+	log := NewAuditLog(AuditConfig{Store: store})
+	assert.NoError(t, log.record(&AuditEntry{Method: "account_list"}))
+	assert.NoError(t, log.record(&AuditEntry{Method: "account_version"}))
+	assert.NoError(t, store.Close())
+
+	// After a restart the chain continues
+	store, err = NewFileAuditStore(path)
+	assert.NoError(t, err)
+	log = NewAuditLog(AuditConfig{Store: store})
+	assert.NoError(t, log.record(&AuditEntry{Method: "account_list"}))
+	assert.NoError(t, store.Close())
+
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var entries []*AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		entries = append(entries, &entry)
+	}
+	assert.Len(t, entries, 3)
+	assert.Equal(t, uint64(3), entries[2].Seq)
+	assert.NoError(t, VerifyAuditChain(entries))
+}
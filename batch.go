@@ -0,0 +1,66 @@
+package clefclient
+
+import "fmt"
+
+// maxBatchSize is the number of requests sent in a single JSON-RPC batch,
+// well below the default batch limit of geth-based servers
+const maxBatchSize = 100
+
+// SignTxResult holds the outcome of signing a single transaction in a batch
+type SignTxResult struct {
+	Response *SignTxResponse
+	Err      error
+}
+
+// SignTransactions signs the given transactions using JSON-RPC batches.
+// Results are returned in the order of txs, each carrying its own error.
+// The returned error is only set when a whole batch could not be exchanged.
+func (cc *ClefClient) SignTransactions(txs []*Transaction) ([]SignTxResult, error) {
+	if !cc.ready() {
+		return nil, ErrNilClient
+	}
+	for i, tx := range txs {
+		if tx == nil {
+			return nil, nilRequestError(fmt.Sprintf("transaction %d", i))
+		}
+	}
+	results := make([]SignTxResult, 0, len(txs))
+	for start := 0; start < len(txs); start += maxBatchSize {
+		end := start + maxBatchSize
+		if end > len(txs) {
+			end = len(txs)
+		}
+
+		params := make([]interface{}, end-start)
+		for i, tx := range txs[start:end] {
+			p, err := cc.fillNetwork(cc.fillAccount(tx))
+			if err != nil {
+				return results, &CallError{Method: "account_signTransaction", Request: summarizeRequest(tx), Err: err}
+			}
+			params[i] = p
+		}
+
+		ctx, cancel := cc.context()
+		resps, err := cc.callTransport().callBatch(ctx, "account_signTransaction", params)
+		cancel()
+		if err != nil {
+			return results, err
+		}
+
+		for i, resp := range resps {
+			if resp.Error != nil {
+				err := newCallError("account_signTransaction", resp.Error)
+				results = append(results, SignTxResult{Err: &CallError{Method: "account_signTransaction", Request: summarizeRequest(params[i]), Err: err}})
+				continue
+			}
+
+			var result SignTxResponse
+			if err := decodeResult("account_signTransaction", resp, &result); err != nil {
+				results = append(results, SignTxResult{Err: err})
+				continue
+			}
+			results = append(results, SignTxResult{Response: &result})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,113 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// batchResponses answers a batch of account_signTransaction requests in
+// reverse order, rejecting transactions without a nonce
+func batchResponses(t *testing.T, reqs []struct {
+	Method string      `json:"method"`
+	Params Transaction `json:"params"`
+	ID     int         `json:"id"`
+}) []rpcResponse {
+	resps := make([]rpcResponse, 0, len(reqs))
+	for i := len(reqs) - 1; i >= 0; i-- {
+		assert.Equal(t, "account_signTransaction", reqs[i].Method)
+		resp := rpcResponse{Jsonrpc: "2.0", ID: reqs[i].ID}
+		if reqs[i].Params.Nonce == "" {
+			resp.Error = &rpcError{Code: -32000, Message: "nonce required"}
+		} else {
+			resp.Result, _ = json.Marshal(SignTxResponse{Raw: "0xraw" + reqs[i].Params.Nonce})
+		}
+		resps = append(resps, resp)
+	}
+	return resps
+}
+
+func TestSignTransactionsHTTP(t *testing.T) {
+	batches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqs []struct {
+			Method string      `json:"method"`
+			Params Transaction `json:"params"`
+			ID     int         `json:"id"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&reqs))
+		batches++
+		json.NewEncoder(w).Encode(batchResponses(t, reqs))
+	}))
+	defer server.Close()
+
+	txs := make([]*Transaction, maxBatchSize+1)
+	for i := range txs {
+		txs[i] = &Transaction{From: "0x0000000000000000000000000000000000000001", Nonce: encodeQuantity(big.NewInt(int64(i)))}
+	}
+	txs[1].Nonce = ""
+
+	client := NewHTTPClient(server.URL)
+	results, err := client.SignTransactions(txs)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, batches)
+	assert.Len(t, results, len(txs))
+	assert.Equal(t, "0xraw0x0", results[0].Response.Raw)
+	assert.EqualError(t, results[1].Err, "account_signTransaction (from=0x0000000000000000000000000000000000000001): nonce required")
+	assert.Equal(t, "0xraw0x64", results[maxBatchSize].Response.Raw)
+}
+
+func TestSignTransactionsIPC(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "clef-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	listener, err := net.Listen("unix", filepath.Join(tmpDir, "clef.ipc"))
+	assert.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		var reqs []struct {
+			Method string      `json:"method"`
+			Params Transaction `json:"params"`
+			ID     int         `json:"id"`
+		}
+		assert.NoError(t, json.NewDecoder(conn).Decode(&reqs))
+		assert.NoError(t, json.NewEncoder(conn).Encode(batchResponses(t, reqs)))
+	}()
+
+	client, err := NewIPCClient(filepath.Join(tmpDir, "clef.ipc"))
+	assert.NoError(t, err)
+	defer client.Close()
+
+	results, err := client.SignTransactions([]*Transaction{
+		{From: "0x0000000000000000000000000000000000000001", Nonce: "0x1"},
+		{From: "0x0000000000000000000000000000000000000001", Nonce: "0x2"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xraw0x1", results[0].Response.Raw)
+	assert.Equal(t, "0xraw0x2", results[1].Response.Raw)
+}
+
+func TestOrderBatchResponsesMissing(t *testing.T) {
+	resps := orderBatchResponses(2, []rpcResponse{{ID: 2}})
+	assert.Equal(t, 2, resps[1].ID)
+	assert.NotNil(t, resps[0].Error)
+}
+
+func TestCheckBatchEnvelopes(t *testing.T) {
+	assert.NoError(t, checkBatchEnvelopes(2, []rpcResponse{{Jsonrpc: "2.0", ID: 2}, {Jsonrpc: "2.0", ID: 1}}))
+	assert.EqualError(t, checkBatchEnvelopes(2, []rpcResponse{{Jsonrpc: "2.0", ID: 3}}), "invalid response: id 3 does not match any request of the batch")
+	assert.ErrorIs(t, checkBatchEnvelopes(1, []rpcResponse{{ID: 1}}), ErrMalformedResponse)
+}
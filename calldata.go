@@ -0,0 +1,252 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// abiArgument is a function input of a JSON ABI
+type abiArgument struct {
+	Name       string        `json:"name"`
+	Type       string        `json:"type"`
+	Components []abiArgument `json:"components"`
+}
+
+// abiFunction is a function entry of a JSON ABI
+type abiFunction struct {
+	Type   string        `json:"type"`
+	Name   string        `json:"name"`
+	Inputs []abiArgument `json:"inputs"`
+}
+
+// signature returns the canonical signature, e.g. transfer(address,uint256)
+func (f *abiFunction) signature() string {
+	types := make([]string, len(f.Inputs))
+	for i, input := range f.Inputs {
+		types[i] = input.canonicalType()
+	}
+	return f.Name + "(" + strings.Join(types, ",") + ")"
+}
+
+// canonicalType expands tuples to their component types
+func (a *abiArgument) canonicalType() string {
+	if !strings.HasPrefix(a.Type, "tuple") {
+		return a.Type
+	}
+	types := make([]string, len(a.Components))
+	for i, c := range a.Components {
+		types[i] = c.canonicalType()
+	}
+	return "(" + strings.Join(types, ",") + ")" + strings.TrimPrefix(a.Type, "tuple")
+}
+
+// elem returns the element argument of an array argument and the array
+// length, -1 for dynamic arrays
+func (a *abiArgument) elem() (*abiArgument, int, error) {
+	open := strings.LastIndex(a.Type, "[")
+	elem := &abiArgument{Name: a.Name, Type: a.Type[:open], Components: a.Components}
+	size := a.Type[open+1 : len(a.Type)-1]
+	if size == "" {
+		return elem, -1, nil
+	}
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 0 {
+		return nil, 0, fmt.Errorf("invalid type %s", a.Type)
+	}
+	return elem, n, nil
+}
+
+// isDynamic reports whether the argument is encoded in the tail
+func (a *abiArgument) isDynamic() bool {
+	switch {
+	case a.Type == "string", a.Type == "bytes", strings.HasSuffix(a.Type, "[]"):
+		return true
+	case strings.HasSuffix(a.Type, "]"):
+		elem, _, err := a.elem()
+		return err == nil && elem.isDynamic()
+	case a.Type == "tuple":
+		for i := range a.Components {
+			if a.Components[i].isDynamic() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BuildCallData encodes a call of method with args as transaction data. The
+// method is a function name of the ABI, or its full signature such as
+// "transfer(address,uint256)" when the name is overloaded. Integers may be
+// given as Go integers, *big.Int or decimal/hex strings, bytes as []byte or
+// hex strings, arrays as slices and tuples as []interface{} or maps keyed by
+// component name.
+func BuildCallData(abiJSON, method string, args ...interface{}) (string, error) {
+	var entries []abiFunction
+	if err := json.Unmarshal([]byte(abiJSON), &entries); err != nil {
+		return "", fmt.Errorf("invalid abi: %w", err)
+	}
+	var matches []*abiFunction
+	for i := range entries {
+		f := &entries[i]
+		if f.Type != "function" && f.Type != "" {
+			continue
+		}
+		if f.signature() == method || (f.Name == method && len(f.Inputs) == len(args)) {
+			matches = append(matches, f)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no method %s with %d arguments in abi", method, len(args))
+	case 1:
+	default:
+		return "", fmt.Errorf("method %s is overloaded, use its signature", method)
+	}
+	return encodeCall(matches[0], args)
+}
+
+// encodeCall encodes the selector of f followed by its arguments
+func encodeCall(f *abiFunction, args []interface{}) (string, error) {
+	if len(args) != len(f.Inputs) {
+		return "", fmt.Errorf("%s expects %d arguments, got %d", f.Name, len(f.Inputs), len(args))
+	}
+	encoded, err := encodeArguments(f.Inputs, args)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", f.Name, err)
+	}
+	selector := keccak256([]byte(f.signature()))[:4]
+	return encodeHexData(append(selector, encoded...)), nil
+}
+
+// encodeArguments encodes values as a tuple of args, static values in the
+// head and dynamic ones in the tail
+func encodeArguments(args []abiArgument, values []interface{}) ([]byte, error) {
+	encoded := make([][]byte, len(args))
+	headSize := 0
+	for i := range args {
+		var err error
+		if encoded[i], err = encodeArgument(&args[i], values[i]); err != nil {
+			name := args[i].Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			return nil, fmt.Errorf("argument %s: %w", name, err)
+		}
+		if args[i].isDynamic() {
+			headSize += abiWordSize
+		} else {
+			headSize += len(encoded[i])
+		}
+	}
+
+	var head, tail []byte
+	for i := range args {
+		if !args[i].isDynamic() {
+			head = append(head, encoded[i]...)
+			continue
+		}
+		offset, _ := encodeUintWord(big.NewInt(int64(headSize + len(tail))))
+		head = append(head, offset...)
+		tail = append(tail, encoded[i]...)
+	}
+	return append(head, tail...), nil
+}
+
+// encodeArgument encodes a single value of arg
+func encodeArgument(arg *abiArgument, value interface{}) ([]byte, error) {
+	switch {
+	case strings.HasSuffix(arg.Type, "]"):
+		elem, size, err := arg.elem()
+		if err != nil {
+			return nil, err
+		}
+		items, err := abiSlice(value)
+		if err != nil {
+			return nil, err
+		}
+		if size >= 0 && len(items) != size {
+			return nil, fmt.Errorf("expected %d elements, got %d", size, len(items))
+		}
+		elems := make([]abiArgument, len(items))
+		for i := range elems {
+			elems[i] = *elem
+			elems[i].Name = fmt.Sprintf("%s[%d]", arg.Name, i)
+		}
+		encoded, err := encodeArguments(elems, items)
+		if err != nil {
+			return nil, err
+		}
+		if size >= 0 {
+			return encoded, nil
+		}
+		length, _ := encodeUintWord(big.NewInt(int64(len(items))))
+		return append(length, encoded...), nil
+
+	case arg.Type == "tuple":
+		values, err := abiTuple(arg, value)
+		if err != nil {
+			return nil, err
+		}
+		return encodeArguments(arg.Components, values)
+
+	case arg.Type == "string", arg.Type == "bytes":
+		var b []byte
+		if s, ok := value.(string); ok && arg.Type == "string" {
+			b = []byte(s)
+		} else {
+			var err error
+			if b, err = typedBytes(value); err != nil {
+				return nil, err
+			}
+		}
+		length, _ := encodeUintWord(big.NewInt(int64(len(b))))
+		padded := make([]byte, (len(b)+abiWordSize-1)/abiWordSize*abiWordSize)
+		copy(padded, b)
+		return append(length, padded...), nil
+	}
+	return encodeStaticWord(arg.Type, value)
+}
+
+// abiSlice converts an array value of any slice type to []interface{}
+func abiSlice(value interface{}) ([]interface{}, error) {
+	if items, ok := value.([]interface{}); ok {
+		return items, nil
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected array, got %T", value)
+	}
+	items := make([]interface{}, v.Len())
+	for i := range items {
+		items[i] = v.Index(i).Interface()
+	}
+	return items, nil
+}
+
+// abiTuple returns the component values of a tuple given positionally or by
+// component name
+func abiTuple(arg *abiArgument, value interface{}) ([]interface{}, error) {
+	if fields, ok := value.(map[string]interface{}); ok {
+		values := make([]interface{}, len(arg.Components))
+		for i, c := range arg.Components {
+			v, ok := fields[c.Name]
+			if !ok {
+				return nil, fmt.Errorf("missing tuple component %s", c.Name)
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+	values, err := abiSlice(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(arg.Components) {
+		return nil, fmt.Errorf("expected %d tuple components, got %d", len(arg.Components), len(values))
+	}
+	return values, nil
+}
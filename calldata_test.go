@@ -0,0 +1,63 @@
+package clefclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testABI = `[
+	{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "amount", "type": "uint256"}]},
+	{"type": "function", "name": "f", "inputs": [
+		{"name": "a", "type": "uint256"}, {"name": "b", "type": "uint32[]"}, {"name": "c", "type": "bytes10"}, {"name": "d", "type": "bytes"}
+	]},
+	{"type": "function", "name": "submit", "inputs": [{"name": "order", "type": "tuple", "components": [
+		{"name": "owner", "type": "address"}, {"name": "memo", "type": "string"}
+	]}]},
+	{"type": "function", "name": "safeTransferFrom", "inputs": [{"type": "address"}, {"type": "address"}, {"type": "uint256"}]},
+	{"type": "function", "name": "safeTransferFrom", "inputs": [{"type": "address"}, {"type": "address"}, {"type": "uint256"}, {"type": "bytes"}]},
+	{"type": "event", "name": "Transfer", "inputs": []}
+]`
+
+func TestBuildCallData(t *testing.T) {
+	data, err := BuildCallData(testABI, "transfer", "0x0000000000000000000000000000000000000002", big.NewInt(1000))
+	assert.NoError(t, err)
+	assert.Equal(t, "0xa9059cbb"+word("2")+word("3e8"), data)
+
+	// example from the Solidity ABI specification
+	data, err = BuildCallData(testABI, "f", "0x123", []uint32{0x456, 0x789}, []byte("1234567890"), []byte("Hello, world!"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x8be65246"+
+		word("123")+word("80")+"3132333435363738393000000000000000000000000000000000000000000000"+word("e0")+
+		word("2")+word("456")+word("789")+
+		word("d")+"48656c6c6f2c20776f726c642100000000000000000000000000000000000000", data)
+}
+
+func TestBuildCallDataTuple(t *testing.T) {
+	byPosition, err := BuildCallData(testABI, "submit", []interface{}{"0x0000000000000000000000000000000000000001", "hi"})
+	assert.NoError(t, err)
+	byName, err := BuildCallData(testABI, "submit((address,string))", map[string]interface{}{
+		"owner": "0x0000000000000000000000000000000000000001",
+		"memo":  "hi",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, byPosition, byName)
+	assert.Equal(t, encodeHexData(keccak256([]byte("submit((address,string))"))[:4])+
+		word("20")+word("1")+word("40")+word("2")+"6869"+word("")[4:], byName)
+}
+
+func TestBuildCallDataErrors(t *testing.T) {
+	data, err := BuildCallData(testABI, "safeTransferFrom", "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x42842e0e", data[:10])
+
+	_, err = BuildCallData(testABI, "approve", "0x01", 1)
+	assert.ErrorContains(t, err, "no method approve")
+	_, err = BuildCallData(testABI, "transfer", "0x01", 1)
+	assert.ErrorContains(t, err, "argument to")
+	_, err = BuildCallData(testABI, "transfer", "0x0000000000000000000000000000000000000002", -1)
+	assert.ErrorContains(t, err, "does not fit")
+	_, err = BuildCallData("{", "transfer")
+	assert.ErrorContains(t, err, "invalid abi")
+}
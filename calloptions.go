@@ -0,0 +1,76 @@
+package clefclient
+
+import (
+	"net/http"
+	"time"
+)
+
+// CallOption configures the calls of a client derived with WithCallOptions
+type CallOption func(*ClefClient)
+
+// CallTimeout bounds the time of the calls, replacing the timeout of the
+// client. Zero lifts the bound.
+func CallTimeout(timeout time.Duration) CallOption {
+	return func(cc *ClefClient) {
+		cc.timeout = timeout
+	}
+}
+
+// CallHeaders adds header to the requests of the calls, replacing headers of
+// the same name. It has no effect on IPC clients.
+func CallHeaders(header http.Header) CallOption {
+	return func(cc *ClefClient) {
+		merged := cc.header.Clone()
+		if merged == nil {
+			merged = http.Header{}
+		}
+		for name, values := range header {
+			merged[name] = values
+		}
+		cc.header = merged
+	}
+}
+
+// CallRetry retries the calls according to policy instead of the retry
+// policy of the client
+func CallRetry(policy RetryPolicy) CallOption {
+	return func(cc *ClefClient) {
+		policy = policy.withDefaults()
+		cc.retry = &policy
+	}
+}
+
+// IdempotencyKey sends key in the Idempotency-Key header of the calls, for
+// proxies in front of Clef that deduplicate requests. Clef itself ignores
+// it. It has no effect on IPC clients.
+func IdempotencyKey(key string) CallOption {
+	return CallHeaders(http.Header{"Idempotency-Key": {key}})
+}
+
+// WithCallOptions returns a shallow copy of the client whose calls apply
+// opts, e.g. a short timeout for reads alongside a long one for interactive
+// signing. The copy shares the transport of the client. The methods of the
+// client take no options so that it keeps implementing Signer and Client.
+func (cc *ClefClient) WithCallOptions(opts ...CallOption) *ClefClient {
+	if cc == nil {
+		return nil
+	}
+	c := *cc
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// callTransport returns the transport of the calls of the client, applying
+// the policies of its network and retrying as set by CallRetry
+func (cc *ClefClient) callTransport() transport {
+	t := cc.transport
+	if cc.network != nil {
+		t = &policyTransport{next: t, policies: cc.network.policies()}
+	}
+	if cc.retry != nil {
+		t = &retryTransport{next: t, policy: *cc.retry, override: true}
+	}
+	return t
+}
@@ -0,0 +1,71 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallHeaders(t *testing.T) {
+	var headers []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = append(headers, r.Header.Clone())
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: json.RawMessage(`[]`)})
+	}))
+	defer server.Close()
+
+	cc := NewHTTPClient(server.URL, WithHeaders(http.Header{"X-Tenant": {"a"}}))
+	_, err := cc.WithCallOptions(CallHeaders(http.Header{"X-Tenant": {"b"}}), IdempotencyKey("tx-42")).ListAccounts()
+	assert.NoError(t, err)
+	_, err = cc.ListAccounts()
+	assert.NoError(t, err)
+
+	assert.Len(t, headers, 2)
+	assert.Equal(t, "b", headers[0].Get("X-Tenant"))
+	assert.Equal(t, "tx-42", headers[0].Get("Idempotency-Key"))
+	assert.Equal(t, "a", headers[1].Get("X-Tenant"))
+	assert.Empty(t, headers[1].Get("Idempotency-Key"))
+}
+
+func TestCallTimeout(t *testing.T) {
+	cc := &ClefClient{transport: &blockingTransport{}, timeout: time.Minute}
+
+	start := time.Now()
+	_, err := cc.WithCallOptions(CallTimeout(10 * time.Millisecond)).ListAccounts()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Minute)
+	assert.Equal(t, time.Minute, cc.timeout)
+}
+
+func TestCallRetry(t *testing.T) {
+	// Enables retries on a client without them
+	flaky := &flakyTransport{failures: 2}
+	cc := &ClefClient{transport: flaky}
+	_, err := cc.WithCallOptions(CallRetry(RetryPolicy{Backoff: time.Millisecond})).ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, flaky.calls)
+
+	// Replaces the retries of the client instead of multiplying them
+	flaky = &flakyTransport{failures: 10}
+	cc = NewHTTPClient("http://localhost", WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}))
+	cc.transport.(*retryTransport).next = flaky
+	_, err = cc.WithCallOptions(CallRetry(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})).ListAccounts()
+	assert.ErrorIs(t, err, ErrConnection)
+	assert.Equal(t, 2, flaky.calls)
+
+}
+
+// blockingTransport answers calls once their context is done
+type blockingTransport struct {
+	countingTransport
+}
+
+func (t *blockingTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	<-ctx.Done()
+	return nil, contextError(ctx.Err())
+}
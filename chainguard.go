@@ -0,0 +1,137 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrWrongChain is returned by clients with a chain guard for requests and
+// signed transactions of another chain than the expected one. Refused
+// requests match ErrPolicyViolation too.
+var ErrWrongChain = errors.New("wrong chain ID")
+
+// EnableChainGuard makes the client refuse signing requests for another
+// chain than chainID, as RequireChainID does, and signed transactions whose
+// EIP-155 or typed transaction chain ID is not chainID, e.g. from a Clef
+// started with the --chainid of another environment. Legacy transactions
+// signed without replay protection are refused too. Typed data signatures
+// do not carry the chain ID, so only their requests are checked.
+func (cc *ClefClient) EnableChainGuard(chainID *big.Int) {
+	if !cc.ready() {
+		return
+	}
+	t := &chainGuardTransport{next: cc.transport, chainID: chainID, policy: RequireChainID(chainID)}
+	cc.transport = t
+	cc.checks = append(cc.checks, t.checkRequest)
+}
+
+// chainGuardTransport is a transport decorator checking the chain ID of
+// signing requests and signed transactions
+type chainGuardTransport struct {
+	next    transport
+	chainID *big.Int
+	policy  DryRunPolicy
+}
+
+func (t *chainGuardTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if err := t.checkRequest(method, params); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.call(ctx, method, params)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	if err := t.checkResult(method, resp.Result); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// callBatch fails the whole batch if one of its requests or results is for
+// another chain
+func (t *chainGuardTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	for i, p := range params {
+		if err := t.checkRequest(method, p); err != nil {
+			return nil, fmt.Errorf("batch entry %d: %w", i+1, err)
+		}
+	}
+	resps, err := t.next.callBatch(ctx, method, params)
+	if err != nil {
+		return resps, err
+	}
+	for i, resp := range resps {
+		if resp == nil || resp.Error != nil {
+			continue
+		}
+		if err := t.checkResult(method, resp.Result); err != nil {
+			return nil, fmt.Errorf("batch entry %d: %w", i+1, err)
+		}
+	}
+	return resps, nil
+}
+
+func (t *chainGuardTransport) close() error {
+	return t.next.close()
+}
+
+func (t *chainGuardTransport) checkRequest(method string, params interface{}) error {
+	if !signingMethods[method] {
+		return nil
+	}
+	if err := t.policy(method, params); err != nil {
+		return fmt.Errorf("%w: %w: %w", ErrPolicyViolation, ErrWrongChain, err)
+	}
+	return nil
+}
+
+// checkResult checks the chain ID of a signed transaction
+func (t *chainGuardTransport) checkResult(method string, result json.RawMessage) error {
+	if method != "account_signTransaction" || len(result) == 0 || string(result) == "null" {
+		return nil
+	}
+	var signed SignTxResponse
+	if err := json.Unmarshal(result, &signed); err != nil {
+		// Malformed results are reported by the method
+		return nil
+	}
+	chainID, err := signedChainID(&signed)
+	if err != nil {
+		return fmt.Errorf("%w: signed transaction: %w", ErrWrongChain, err)
+	}
+	if chainID.Cmp(t.chainID) != 0 {
+		return fmt.Errorf("%w: transaction was signed for chain %s, expected %s", ErrWrongChain, chainID, t.chainID)
+	}
+	return nil
+}
+
+// signedChainID returns the chain ID a transaction was signed for: the
+// first field of typed transactions, or the chain ID encoded in the V of
+// EIP-155 legacy transactions
+func signedChainID(signed *SignTxResponse) (*big.Int, error) {
+	raw, err := decodeHexData(signed.Raw)
+	if err != nil || len(raw) == 0 {
+		return nil, fmt.Errorf("invalid raw transaction")
+	}
+	if raw[0] >= 0xc0 {
+		v, err := decodeQuantity(signed.Tx.V)
+		if err != nil {
+			return nil, fmt.Errorf("invalid v %q", signed.Tx.V)
+		}
+		if v.Cmp(big.NewInt(35)) < 0 {
+			return nil, fmt.Errorf("legacy transaction without replay protection")
+		}
+		return v.Sub(v, big.NewInt(35)).Rsh(v, 1), nil
+	}
+	payload, list, _, err := rlpSplit(raw[1:])
+	if err != nil || !list {
+		return nil, fmt.Errorf("invalid raw transaction")
+	}
+	chainID, list, _, err := rlpSplit(payload)
+	if err != nil || list {
+		return nil, fmt.Errorf("invalid raw transaction")
+	}
+	return new(big.Int).SetBytes(chainID), nil
+}
@@ -0,0 +1,112 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// signedTxTransport answers transaction signing requests with a fixed
+// signed transaction, whatever was asked
+type signedTxTransport struct {
+	signed *SignTxResponse
+	calls  int
+}
+
+func (t *signedTxTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	t.calls++
+	raw, _ := json.Marshal(t.signed)
+	return &rpcResponse{Result: raw}, nil
+}
+
+func (t *signedTxTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	resps := make([]*rpcResponse, len(params))
+	for i := range params {
+		resps[i], _ = t.call(ctx, method, params[i])
+	}
+	return resps, nil
+}
+
+func (t *signedTxTransport) close() error {
+	return nil
+}
+
+func TestEnableChainGuard(t *testing.T) {
+	sign := func(chainID uint64, tx Transaction) *SignTxResponse {
+		signer := NewMemorySigner(chainID)
+		tx.From, _ = signer.NewAccount()
+		tx.ChainID = ""
+		signed, err := signer.SignTransaction(&tx)
+		assert.NoError(t, err)
+		return signed
+	}
+	legacy := Transaction{To: "0x0000000000000000000000000000000000000002", Gas: "0x5208", GasPrice: "0x1", Nonce: "0x0"}
+	dynamicFee := Transaction{To: "0x0000000000000000000000000000000000000002", Gas: "0x5208", MaxFeePerGas: "0x2", MaxPriorityFeePerGas: "0x1", Nonce: "0x0"}
+	request := &Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002", ChainID: "0xaa36a7"}
+
+	for _, tx := range []Transaction{legacy, dynamicFee} {
+		transport := &signedTxTransport{signed: sign(11155111, tx)}
+		cc := &ClefClient{transport: transport}
+		cc.EnableChainGuard(big.NewInt(11155111))
+		_, err := cc.SignTransaction(request)
+		assert.NoError(t, err)
+
+		transport.signed = sign(1, tx)
+		_, err = cc.SignTransaction(request)
+		assert.ErrorIs(t, err, ErrWrongChain)
+		assert.ErrorContains(t, err, "signed for chain 1, expected 11155111")
+
+		results, err := cc.SignTransactions([]*Transaction{request})
+		assert.ErrorIs(t, err, ErrWrongChain)
+		assert.Empty(t, results)
+	}
+
+	// Requests for another chain are not sent
+	transport := &signedTxTransport{signed: sign(1, legacy)}
+	cc := &ClefClient{transport: transport}
+	cc.EnableChainGuard(big.NewInt(11155111))
+	_, err := cc.SignTransaction(&Transaction{From: request.From, To: request.To, ChainID: "0x1"})
+	assert.ErrorIs(t, err, ErrWrongChain)
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.Equal(t, 0, transport.calls)
+
+	// Legacy transactions without replay protection
+	unprotected := sign(1, legacy)
+	unprotected.Tx.V = "0x1b"
+	transport.signed = unprotected
+	cc = &ClefClient{transport: transport}
+	cc.EnableChainGuard(big.NewInt(1))
+	_, err = cc.SignTransaction(&Transaction{From: request.From, To: request.To, ChainID: "0x1"})
+	assert.ErrorIs(t, err, ErrWrongChain)
+	assert.ErrorContains(t, err, "without replay protection")
+}
+
+func TestRLPSplit(t *testing.T) {
+	long := make([]byte, 60)
+	encoded := rlpList(rlpBytes([]byte{0x05}), rlpBytes(long), rlpList())
+	payload, list, rest, err := rlpSplit(encoded)
+	assert.NoError(t, err)
+	assert.True(t, list)
+	assert.Empty(t, rest)
+
+	first, list, payload, err := rlpSplit(payload)
+	assert.NoError(t, err)
+	assert.False(t, list)
+	assert.Equal(t, []byte{0x05}, first)
+	second, _, payload, err := rlpSplit(payload)
+	assert.NoError(t, err)
+	assert.Equal(t, long, second)
+	third, list, payload, err := rlpSplit(payload)
+	assert.NoError(t, err)
+	assert.True(t, list)
+	assert.Empty(t, third)
+	assert.Empty(t, payload)
+
+	_, _, _, err = rlpSplit([]byte{0xb8})
+	assert.Error(t, err)
+	_, _, _, err = rlpSplit([]byte{0x83, 0x01})
+	assert.Error(t, err)
+}
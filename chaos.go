@@ -0,0 +1,147 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrChaosDisconnect is returned for calls dropped by the chaos transport. It
+// is classified as ErrConnection.
+var ErrChaosDisconnect = errors.New("chaos: connection dropped")
+
+// ChaosConfig configures the faults injected by EnableChaos. Rates are
+// probabilities between 0 and 1, applied to every call independently.
+type ChaosConfig struct {
+	// Latency is added to every call, plus a random part up to Jitter
+	Latency time.Duration
+	Jitter  time.Duration
+	// DisconnectRate is the rate of calls failing with ErrChaosDisconnect.
+	// Half of them fail before reaching Clef and half after, so the caller
+	// cannot tell whether the request was processed.
+	DisconnectRate float64
+	// DuplicateRate is the rate of calls answered with the previous
+	// response again instead of their own
+	DuplicateRate float64
+	// CorruptRate is the rate of calls whose result is truncated
+	CorruptRate float64
+	// Methods limits the chaos to the given methods, all if empty
+	Methods []string
+	// Seed makes the injected faults reproducible, a random seed if 0
+	Seed int64
+}
+
+// EnableChaos injects latency, disconnects and duplicated or corrupted
+// responses into every subsequent request made by the client, for
+// resilience testing of code built on it. It must never be enabled in
+// production.
+func (cc *ClefClient) EnableChaos(config ChaosConfig) {
+	if !cc.ready() {
+		return
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	cc.transport = &chaosTransport{next: cc.transport, config: config, rand: rand.New(rand.NewSource(seed))}
+}
+
+// chaosTransport is a transport decorator injecting faults
+type chaosTransport struct {
+	next   transport
+	config ChaosConfig
+
+	mu   sync.Mutex
+	rand *rand.Rand
+	last *rpcResponse
+}
+
+// chaosPlan is the set of faults drawn for a single call
+type chaosPlan struct {
+	delay                 time.Duration
+	dropBefore, dropAfter bool
+	duplicate, corrupt    bool
+}
+
+func (t *chaosTransport) plan(method string) chaosPlan {
+	if len(t.config.Methods) > 0 && !slices.Contains(t.config.Methods, method) {
+		return chaosPlan{}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p := chaosPlan{delay: t.config.Latency}
+	if t.config.Jitter > 0 {
+		p.delay += time.Duration(t.rand.Int63n(int64(t.config.Jitter)))
+	}
+	if t.rand.Float64() < t.config.DisconnectRate {
+		if t.rand.Intn(2) == 0 {
+			p.dropBefore = true
+		} else {
+			p.dropAfter = true
+		}
+	}
+	p.duplicate = t.rand.Float64() < t.config.DuplicateRate
+	p.corrupt = t.rand.Float64() < t.config.CorruptRate
+	return p
+}
+
+// mangle applies the response faults of p to resp and remembers it as the
+// last response
+func (t *chaosTransport) mangle(p chaosPlan, resp *rpcResponse) *rpcResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := resp
+	if p.duplicate && t.last != nil {
+		out = t.last
+	}
+	t.last = resp
+	if p.corrupt && len(out.Result) > 0 {
+		corrupted := *out
+		corrupted.Result = out.Result[:len(out.Result)/2]
+		out = &corrupted
+	}
+	return out
+}
+
+func (t *chaosTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	p := t.plan(method)
+	time.Sleep(p.delay)
+	if p.dropBefore {
+		return nil, connectionError(ErrChaosDisconnect)
+	}
+	resp, err := t.next.call(ctx, method, params)
+	if p.dropAfter {
+		return nil, connectionError(ErrChaosDisconnect)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t.mangle(p, resp), nil
+}
+
+func (t *chaosTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	p := t.plan(method)
+	time.Sleep(p.delay)
+	if p.dropBefore {
+		return nil, connectionError(ErrChaosDisconnect)
+	}
+	resps, err := t.next.callBatch(ctx, method, params)
+	if p.dropAfter {
+		return nil, connectionError(ErrChaosDisconnect)
+	}
+	if err != nil {
+		return nil, err
+	}
+	mangled := make([]*rpcResponse, len(resps))
+	for i, resp := range resps {
+		mangled[i] = t.mangle(p, resp)
+	}
+	return mangled, nil
+}
+
+func (t *chaosTransport) close() error {
+	return t.next.close()
+}
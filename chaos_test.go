@@ -0,0 +1,93 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingTransport answers every call with its sequence number
+type countingTransport struct {
+	calls int
+}
+
+func (t *countingTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	t.calls++
+	return &rpcResponse{Result: json.RawMessage(fmt.Sprintf(`["0x%040x"]`, t.calls))}, nil
+}
+
+func (t *countingTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	resps := make([]*rpcResponse, len(params))
+	for i := range params {
+		resps[i], _ = t.call(ctx, method, params[i])
+	}
+	return resps, nil
+}
+
+func (t *countingTransport) close() error {
+	return nil
+}
+
+func TestChaosDisconnect(t *testing.T) {
+	next := &countingTransport{}
+	cc := &ClefClient{transport: next}
+	cc.EnableChaos(ChaosConfig{DisconnectRate: 1, Seed: 1})
+
+	for i := 0; i < 20; i++ {
+		_, err := cc.ListAccounts()
+		assert.True(t, errors.Is(err, ErrChaosDisconnect))
+	}
+	// Some calls reached the server before being dropped, others did not
+	assert.Greater(t, next.calls, 0)
+	assert.Less(t, next.calls, 20)
+}
+
+func TestChaosDuplicateAndCorrupt(t *testing.T) {
+	cc := &ClefClient{transport: &countingTransport{}}
+	cc.EnableChaos(ChaosConfig{DuplicateRate: 1, Seed: 1})
+
+	first, err := cc.ListAccounts()
+	assert.NoError(t, err)
+	second, err := cc.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+
+	cc = &ClefClient{transport: &countingTransport{}}
+	cc.EnableChaos(ChaosConfig{CorruptRate: 1, Seed: 1})
+	_, err = cc.ListAccounts()
+	assert.Error(t, err)
+}
+
+func TestChaosLatencyAndMethods(t *testing.T) {
+	cc := &ClefClient{transport: &countingTransport{}}
+	cc.EnableChaos(ChaosConfig{Latency: 20 * time.Millisecond, CorruptRate: 1, Methods: []string{"account_new"}})
+
+	start := time.Now()
+	_, err := cc.ListAccounts()
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+
+	start = time.Now()
+	_, err = cc.NewAccount()
+	assert.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestChaosReproducible(t *testing.T) {
+	outcomes := func() []bool {
+		cc := &ClefClient{transport: &countingTransport{}}
+		cc.EnableChaos(ChaosConfig{DisconnectRate: 0.5, Seed: 42})
+		var ok []bool
+		for i := 0; i < 10; i++ {
+			_, err := cc.ListAccounts()
+			ok = append(ok, err == nil)
+		}
+		return ok
+	}
+	assert.Equal(t, outcomes(), outcomes())
+}
@@ -2,10 +2,13 @@ package clefclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
+	"reflect"
+	"strings"
+	"time"
 )
 
 // rpcClient represents a client to interact with the clef JSON-RPC interface.
@@ -36,8 +39,12 @@ type rpcResponse struct {
 
 // rpcError represents a JSON-RPC error.
 type rpcError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+
+	// result is the result sent along with the error, see settleResponse
+	result json.RawMessage
 }
 
 // call sends a JSON-RPC request and returns the response.
@@ -54,55 +61,228 @@ func (c *rpcClient) call(method string, params interface{}) (*rpcResponse, error
 
 	resp, err := http.Post(c.url, "application/json", bytes.NewBuffer(reqBody))
 	if err != nil {
-		return nil, err
+		return nil, connectionError(err)
 	}
 	defer resp.Body.Close()
 
+	body, err := jsonBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
 	var rpcResp rpcResponse
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+	if err := json.NewDecoder(body).Decode(&rpcResp); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if err := checkEnvelope(&rpcResp, 1); err != nil {
 		return nil, err
 	}
+	settleResponse(&rpcResp)
 
 	if rpcResp.Error != nil {
-		return nil, errors.New(rpcResp.Error.Message)
+		return nil, newCallError(method, rpcResp.Error)
 	}
 
 	return &rpcResp, nil
 }
 
+// ready reports whether the client has a transport. Enable methods on a
+// client that is not ready do nothing, so calls keep failing with
+// ErrNilClient
+func (cc *ClefClient) ready() bool {
+	return cc != nil && cc.transport != nil
+}
+
+// call sends a request through the transport, failing instead of panicking
+// when the client is not initialized or a request argument is nil. Errors
+// are returned as *CallError.
+func (cc *ClefClient) call(method string, params interface{}) (*rpcResponse, error) {
+	if !cc.ready() {
+		return nil, ErrNilClient
+	}
+	args, ok := params.([]interface{})
+	if !ok {
+		args = []interface{}{params}
+	}
+	for _, arg := range args {
+		if v := reflect.ValueOf(arg); arg != nil && v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, nilRequestError(method)
+		}
+	}
+	filled, err := cc.fillNetwork(cc.fillAccount(params))
+	if err != nil {
+		return nil, &CallError{Method: method, Request: summarizeRequest(params), Err: err}
+	}
+	params = filled
+	ctx, cancel := cc.context()
+	defer cancel()
+	resp, err := cc.callTransport().call(ctx, method, params)
+	if err != nil {
+		return nil, &CallError{Method: method, Request: summarizeRequest(params), Err: err}
+	}
+	return resp, nil
+}
+
+// decodeResult unmarshals the result of a call of method into v, failing
+// with ErrEmptyResult when Clef answered with a null or missing result
+func decodeResult(method string, resp *rpcResponse, v interface{}) error {
+	if len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return malformedError(fmt.Errorf("%s: %w", method, ErrEmptyResult))
+	}
+	return json.Unmarshal(resp.Result, v)
+}
+
+// checkSignature verifies that a signature returned by method is 65 bytes
+// of hex with a recovery id of 0 or 1, plain or offset by 27
+func checkSignature(method, signature string) error {
+	sig, err := decodeHexData(signature)
+	if err != nil {
+		return malformedError(fmt.Errorf("%s: invalid signature: %w", method, err))
+	}
+	if len(sig) != 65 {
+		return malformedError(fmt.Errorf("%s: invalid signature: %d bytes, want 65", method, len(sig)))
+	}
+	if v := sig[64]; v != 0 && v != 1 && v != 27 && v != 28 {
+		return malformedError(fmt.Errorf("%s: invalid signature: recovery id %d out of range", method, v))
+	}
+	return nil
+}
+
 // ClefClient represents a higher-level client to interact with clef.
 type ClefClient struct {
 	transport transport
+	// endpoint is the URL or socket path of Clef
+	endpoint string
+	// ctx is the context of the calls, see WithContext
+	ctx context.Context
+	// timeout bounds the time of every call, see WithTimeout
+	timeout time.Duration
+	// header is added to the requests of every call, see CallHeaders
+	header http.Header
+	// retry replaces the retry policy of the client, see CallRetry
+	retry *RetryPolicy
+	// defaultAccount signs requests that name no account, see
+	// WithDefaultAccount
+	defaultAccount string
+	// network is the network signed for, see WithNetwork
+	network *Network
+	// clientID identifies the client in requests and audit entries, see
+	// WithClientID
+	clientID string
+	// checks are the request checks of the policies enabled on the
+	// transport, which dry runs apply too
+	checks []DryRunPolicy
 }
 
 // NewHTTPClient creates a new ClefClient using HTTP transport
-func NewHTTPClient(url string) *ClefClient {
-	return &ClefClient{transport: newHTTPTransport(url)}
+func NewHTTPClient(url string, opts ...Option) *ClefClient {
+	return newClient(newHTTPTransport(url), url, opts)
 }
 
 // NewIPCClient creates a new ClefClient using IPC transport
-func NewIPCClient(socketPath string) (*ClefClient, error) {
-	transport, err := newIPCTransport(socketPath)
+func NewIPCClient(socketPath string, opts ...Option) (*ClefClient, error) {
+	transport, err := newIPCTransport(context.Background(), socketPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create IPC transport: %w", err)
 	}
-	return &ClefClient{transport: transport}, nil
+	return newClient(transport, socketPath, opts), nil
+}
+
+// WithContext returns a shallow copy of the client whose calls carry ctx.
+// The copy shares the transport of the client. Canceling ctx aborts HTTP
+// requests, and the spans of EnableTracing are children of the span in ctx.
+func (cc *ClefClient) WithContext(ctx context.Context) *ClefClient {
+	if cc == nil {
+		return nil
+	}
+	c := *cc
+	c.ctx = ctx
+	return &c
+}
+
+// context returns the context of a call of the client, carrying its headers
+// and identity and bounded by its timeout. The returned function releases the context.
+func (cc *ClefClient) context() (context.Context, context.CancelFunc) {
+	ctx := cc.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if cc.header != nil {
+		ctx = withRequestHeader(ctx, cc.header)
+	}
+	if cc.clientID != "" {
+		ctx = withClientID(ctx, cc.clientID)
+	}
+	if cc.timeout > 0 {
+		return context.WithTimeout(ctx, cc.timeout)
+	}
+	return ctx, func() {}
+}
+
+// WithDefaultAccount returns a shallow copy of the client that signs with
+// address when a Transaction has no From or a signing request no Address.
+// The copy shares the transport of the client. It fails if Clef does not
+// manage address.
+func (cc *ClefClient) WithDefaultAccount(address string) (*ClefClient, error) {
+	managed, err := cc.HasAccount(address)
+	if err != nil {
+		return nil, err
+	}
+	if !managed {
+		return nil, fmt.Errorf("default account %s is not managed by clef", address)
+	}
+	c := *cc
+	c.defaultAccount = address
+	return &c, nil
+}
+
+// fillAccount returns params with the default account of the client filled
+// in, copying requests instead of modifying them
+func (cc *ClefClient) fillAccount(params interface{}) interface{} {
+	if cc.defaultAccount == "" {
+		return params
+	}
+	switch p := params.(type) {
+	case *Transaction:
+		if p.From == "" {
+			tx := *p
+			tx.From = cc.defaultAccount
+			return &tx
+		}
+	case *SignDataRequest:
+		if p.Address == "" {
+			req := *p
+			req.Address = cc.defaultAccount
+			return &req
+		}
+	case *TypedDataRequest:
+		if p.Address == "" {
+			req := *p
+			req.Address = cc.defaultAccount
+			return &req
+		}
+	}
+	return params
 }
 
 // Close closes the underlying transport
 func (cc *ClefClient) Close() error {
+	if !cc.ready() {
+		return ErrNilClient
+	}
 	return cc.transport.close()
 }
 
 // NewAccount creates a new account
 func (cc *ClefClient) NewAccount() (string, error) {
-	resp, err := cc.transport.call("account_new", nil)
+	resp, err := cc.call("account_new", nil)
 	if err != nil {
 		return "", err
 	}
 
 	var address string
-	if err := json.Unmarshal(resp.Result, &address); err != nil {
+	if err := decodeResult("account_new", resp, &address); err != nil {
 		return "", err
 	}
 	return address, nil
@@ -110,27 +290,47 @@ func (cc *ClefClient) NewAccount() (string, error) {
 
 // ListAccounts returns the list of available accounts
 func (cc *ClefClient) ListAccounts() ([]string, error) {
-	resp, err := cc.transport.call("account_list", nil)
+	resp, err := cc.call("account_list", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	var accounts []string
-	if err := json.Unmarshal(resp.Result, &accounts); err != nil {
+	if err := decodeResult("account_list", resp, &accounts); err != nil {
 		return nil, err
 	}
 	return accounts, nil
 }
 
-// SignTransaction signs the given transaction
+// HasAccount reports whether the given address is managed by clef
+func (cc *ClefClient) HasAccount(address string) (bool, error) {
+	accounts, err := cc.ListAccounts()
+	if err != nil {
+		return false, err
+	}
+	return containsAddress(accounts, address), nil
+}
+
+// containsAddress reports whether address is in accounts, ignoring case
+func containsAddress(accounts []string, address string) bool {
+	for _, account := range accounts {
+		if strings.EqualFold(account, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignTransaction signs the given transaction. The transaction is checked
+// with Validate before it is sent.
 func (cc *ClefClient) SignTransaction(tx *Transaction) (*SignTxResponse, error) {
-	resp, err := cc.transport.call("account_signTransaction", tx)
+	resp, err := cc.call("account_signTransaction", tx)
 	if err != nil {
 		return nil, err
 	}
 
 	var result SignTxResponse
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := decodeResult("account_signTransaction", resp, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -138,13 +338,16 @@ func (cc *ClefClient) SignTransaction(tx *Transaction) (*SignTxResponse, error)
 
 // SignData signs the given data
 func (cc *ClefClient) SignData(req *SignDataRequest) (*SignDataResponse, error) {
-	resp, err := cc.transport.call("account_signData", req)
+	resp, err := cc.call("account_signData", req)
 	if err != nil {
 		return nil, err
 	}
 
 	var result SignDataResponse
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := decodeResult("account_signData", resp, &result); err != nil {
+		return nil, err
+	}
+	if err := checkSignature("account_signData", result.Signature); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -152,13 +355,16 @@ func (cc *ClefClient) SignData(req *SignDataRequest) (*SignDataResponse, error)
 
 // SignTypedData signs the given typed data
 func (cc *ClefClient) SignTypedData(req *TypedDataRequest) (*SignDataResponse, error) {
-	resp, err := cc.transport.call("account_signTypedData", req)
+	resp, err := cc.call("account_signTypedData", req)
 	if err != nil {
 		return nil, err
 	}
 
 	var result SignDataResponse
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := decodeResult("account_signTypedData", resp, &result); err != nil {
+		return nil, err
+	}
+	if err := checkSignature("account_signTypedData", result.Signature); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -166,13 +372,13 @@ func (cc *ClefClient) SignTypedData(req *TypedDataRequest) (*SignDataResponse, e
 
 // EcRecover recovers the address from the given signature
 func (cc *ClefClient) EcRecover(req *EcRecoverRequest) (*EcRecoverResponse, error) {
-	resp, err := cc.transport.call("account_ecRecover", req)
+	resp, err := cc.call("account_ecRecover", req)
 	if err != nil {
 		return nil, err
 	}
 
 	var result EcRecoverResponse
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := decodeResult("account_ecRecover", resp, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -180,13 +386,13 @@ func (cc *ClefClient) EcRecover(req *EcRecoverRequest) (*EcRecoverResponse, erro
 
 // Version returns the version of the clef service
 func (cc *ClefClient) Version() (*VersionResponse, error) {
-	resp, err := cc.transport.call("account_version", nil)
+	resp, err := cc.call("account_version", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	var result VersionResponse
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	if err := decodeResult("account_version", resp, &result); err != nil {
 		return nil, err
 	}
 	return &result, nil
@@ -1,12 +1,17 @@
 package clefclient
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -420,3 +425,212 @@ func TestVersionIPC(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 }
+
+func TestResponseEnvelope(t *testing.T) {
+	tests := []struct {
+		body string
+		err  string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"result":[]}`, ""},
+		{`{"jsonrpc":"1.0","id":1,"result":[]}`, `invalid response: jsonrpc version "1.0", want "2.0"`},
+		{`{"id":1,"result":[]}`, `invalid response: jsonrpc version "", want "2.0"`},
+		{`{"jsonrpc":"2.0","id":2,"result":[]}`, "invalid response: id 2 does not match request id 1"},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tt.body))
+		}))
+		_, err := NewHTTPClient(server.URL).ListAccounts()
+		server.Close()
+
+		if tt.err == "" {
+			assert.NoError(t, err)
+			continue
+		}
+		assert.EqualError(t, err, "account_list: "+tt.err)
+		assert.ErrorIs(t, err, ErrMalformedResponse)
+	}
+}
+
+func TestSignTransactionValidates(t *testing.T) {
+	client, server := setupHTTPTestServer(t, "account_signTransaction", nil)
+	defer server.Close()
+
+	_, err := client.SignTransaction(&Transaction{To: "0x0000000000000000000000000000000000000002"})
+	assert.EqualError(t, err, "account_signTransaction (to=0x0000000000000000000000000000000000000002): transaction: from is required")
+}
+
+func TestEmptyResult(t *testing.T) {
+	for _, body := range []string{`{"jsonrpc":"2.0","id":1,"result":null}`, `{"jsonrpc":"2.0","id":1}`} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		_, err := NewHTTPClient(server.URL).Version()
+		server.Close()
+
+		assert.EqualError(t, err, "account_version: clef returned an empty result")
+		assert.ErrorIs(t, err, ErrEmptyResult)
+		assert.ErrorIs(t, err, ErrMalformedResponse)
+	}
+}
+
+func TestNonJSONAnswer(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		err    string
+		class  error
+	}{
+		{
+			http.StatusBadGateway, "<html><body>502 Bad Gateway</body></html>",
+			`non-JSON answer from an intermediary in front of clef: HTTP/1.1 502 Bad Gateway (text/html): "<html><body>502 Bad Gateway</body></html>"`,
+			ErrConnection,
+		},
+		{
+			http.StatusOK, "  <html>login</html>",
+			`non-JSON answer from an intermediary in front of clef: HTTP/1.1 200 OK (text/html): "<html>login</html>"`,
+			ErrMalformedResponse,
+		},
+	}
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+		_, err := NewHTTPClient(server.URL).ListAccounts()
+		server.Close()
+
+		assert.EqualError(t, err, "account_list: "+tt.err)
+		assert.ErrorIs(t, err, tt.class)
+	}
+}
+
+func TestNilSafety(t *testing.T) {
+	for _, cc := range []*ClefClient{nil, {}} {
+		_, err := cc.ListAccounts()
+		assert.ErrorIs(t, err, ErrNilClient)
+		_, err = cc.SignTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001"})
+		assert.ErrorIs(t, err, ErrNilClient)
+		_, err = cc.SignTransactions([]*Transaction{{}})
+		assert.ErrorIs(t, err, ErrNilClient)
+		assert.ErrorIs(t, cc.Close(), ErrNilClient)
+
+		cc.EnablePolicies(RequireChainID(big.NewInt(1)))
+		cc.EnableChainGuard(big.NewInt(1))
+		cc.EnableAudit(NewAuditLog(AuditConfig{Store: &MemoryAuditStore{}}))
+		cc.EnableSpendingLimits(NewMemorySpendingStore())
+		cc.EnableCompat()
+		_, err = cc.ListAccounts()
+		assert.ErrorIs(t, err, ErrNilClient)
+	}
+
+	client, server := setupHTTPTestServer(t, "account_signTransaction", nil)
+	defer server.Close()
+
+	_, err := client.SignTransaction(nil)
+	assert.EqualError(t, err, "account_signTransaction: nil request")
+	assert.ErrorIs(t, err, ErrNilRequest)
+	_, err = client.SignData(nil)
+	assert.ErrorIs(t, err, ErrNilRequest)
+	_, err = client.SignGnosisSafeTx("0x0000000000000000000000000000000000000001", nil, "")
+	assert.ErrorIs(t, err, ErrNilRequest)
+	_, err = client.SignTransactions([]*Transaction{nil})
+	assert.EqualError(t, err, "transaction 0: nil request")
+	_, err = client.SpeedUpTx(nil, 10)
+	assert.ErrorIs(t, err, ErrNilRequest)
+	assert.ErrorIs(t, (*Transaction)(nil).Validate(), ErrNilRequest)
+}
+
+func TestSignDataValidatesSignature(t *testing.T) {
+	tests := []struct {
+		signature string
+		err       string
+	}{
+		{"0x" + strings.Repeat("ab", 64) + "1c", ""},
+		{"0x" + strings.Repeat("ab", 64) + "01", ""},
+		{"0xsig", `account_signData: invalid signature: invalid hex data "0xsig": encoding/hex: invalid byte: U+0073 's'`},
+		{"0x" + strings.Repeat("ab", 64), "account_signData: invalid signature: 64 bytes, want 65"},
+		{"0x" + strings.Repeat("ab", 64) + "1d", "account_signData: invalid signature: recovery id 29 out of range"},
+	}
+	for _, tt := range tests {
+		client, server := setupHTTPTestServer(t, "account_signData", SignDataResponse{Signature: tt.signature})
+		_, err := client.SignData(&SignDataRequest{Address: "0x0000000000000000000000000000000000000001", Data: "0x00"})
+		server.Close()
+
+		if tt.err == "" {
+			assert.NoError(t, err)
+			continue
+		}
+		assert.EqualError(t, err, tt.err)
+		assert.ErrorIs(t, err, ErrMalformedResponse)
+	}
+}
+
+// shortWriteConn writes at most max bytes per call, failing once fail bytes
+// were written if fail is set
+type shortWriteConn struct {
+	net.Conn
+	max, fail, written int
+}
+
+func (c *shortWriteConn) Write(b []byte) (int, error) {
+	if c.fail > 0 && c.written >= c.fail {
+		return 0, errors.New("broken pipe")
+	}
+	if len(b) > c.max {
+		b = b[:c.max]
+	}
+	n, err := c.Conn.Write(b)
+	c.written += n
+	return n, err
+}
+
+func TestIPCWriteFrame(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	tr := &ipcTransport{conn: &shortWriteConn{Conn: client, max: 1000}, dec: json.NewDecoder(client)}
+
+	payload, _ := json.Marshal(map[string]string{"data": strings.Repeat("line\n", 100000)})
+	received := make(chan []byte)
+	go func() {
+		line, _ := bufio.NewReader(server).ReadBytes('\n')
+		received <- line
+	}()
+	assert.NoError(t, tr.writeFrame(payload))
+	assert.Equal(t, append(payload, '\n'), <-received)
+
+	client, server = net.Pipe()
+	defer server.Close()
+	go io.Copy(io.Discard, server)
+	tr = &ipcTransport{conn: &shortWriteConn{Conn: client, max: 10, fail: 20}, dec: json.NewDecoder(client)}
+	err := tr.writeFrame(payload)
+	assert.ErrorIs(t, err, ErrConnection)
+	err = tr.writeFrame([]byte("{}"))
+	assert.ErrorContains(t, err, "ipc connection closed after a partial write")
+}
+
+func TestWithDefaultAccount(t *testing.T) {
+	account := "0x0000000000000000000000000000000000000001"
+	cc := &ClefClient{transport: &countingTransport{}}
+
+	_, err := cc.WithDefaultAccount("0x0000000000000000000000000000000000000002")
+	assert.EqualError(t, err, "default account 0x0000000000000000000000000000000000000002 is not managed by clef")
+
+	cc = &ClefClient{transport: &countingTransport{}}
+	derived, err := cc.WithDefaultAccount(account)
+	assert.NoError(t, err)
+	assert.Empty(t, cc.defaultAccount)
+
+	next := &versionedTransport{version: "6.0.0"}
+	derived.transport = next
+	req := &SignDataRequest{ContentType: ContentTypeTextPlain, Data: "0x01"}
+	_, err = derived.SignData(req)
+	assert.NoError(t, err)
+	_, err = derived.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000003", Data: "0x01"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, account, next.params[0].(*SignDataRequest).Address)
+	assert.Equal(t, "0x0000000000000000000000000000000000000003", next.params[1].(*SignDataRequest).Address)
+	assert.Empty(t, req.Address)
+}
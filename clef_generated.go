@@ -0,0 +1,46 @@
+// Code generated by clefgen from clef.openrpc.json. DO NOT EDIT.
+
+package clefclient
+
+import (
+	"math/big"
+)
+
+// GnosisSafeTx a Gnosis Safe transaction as exported by the Safe transaction service
+type GnosisSafeTx struct {
+	Signature  string `json:"signature"`
+	SafeTxHash string `json:"safeTxHash"`
+	Sender     string `json:"sender"`
+	Safe       string `json:"safe"`
+	To         string `json:"to"`
+	// Value is decimal wei
+	Value string `json:"value"`
+	// GasPrice is decimal wei
+	GasPrice       string   `json:"gasPrice"`
+	Data           string   `json:"data"`
+	Operation      uint8    `json:"operation"`
+	GasToken       string   `json:"gasToken"`
+	RefundReceiver string   `json:"refundReceiver"`
+	BaseGas        *big.Int `json:"baseGas"`
+	SafeTxGas      *big.Int `json:"safeTxGas"`
+	Nonce          *big.Int `json:"nonce"`
+	ChainID        string   `json:"chainId"`
+}
+
+// SignGnosisSafeTx signs a Gnosis Safe transaction and returns it with the signature filled in
+func (cc *ClefClient) SignGnosisSafeTx(signerAddress string, gnosisTx *GnosisSafeTx, methodSelector string) (*GnosisSafeTx, error) {
+	params := []interface{}{signerAddress, gnosisTx}
+	if methodSelector != "" {
+		params = append(params, methodSelector)
+	}
+	resp, err := cc.call("account_signGnosisSafeTx", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result GnosisSafeTx
+	if err := decodeResult("account_signGnosisSafeTx", resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
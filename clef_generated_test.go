@@ -0,0 +1,54 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignGnosisSafeTx(t *testing.T) {
+	safeTx := &GnosisSafeTx{
+		SafeTxHash: "0x28bf85e7d4b0a3ab3c3a2e9e8f8a8e44e1e3e7b7d3a5c1f2b2d7c6e2a6f0a5b1",
+		Sender:     "0x1234567890123456789012345678901234567890",
+		Safe:       "0x2222222222222222222222222222222222222222",
+		To:         "0x3333333333333333333333333333333333333333",
+		Value:      "1000000000000000000",
+		GasPrice:   "0",
+		Data:       "0x",
+		ChainID:    "0x1",
+	}
+	signed := *safeTx
+	signed.Signature = "0xabcdef"
+
+	client, server := setupHTTPTestServer(t, "account_signGnosisSafeTx", signed)
+	defer server.Close()
+
+	result, err := client.SignGnosisSafeTx("0x1234567890123456789012345678901234567890", safeTx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xabcdef", result.Signature)
+	assert.Equal(t, "0x1", result.ChainID)
+}
+
+func TestSignGnosisSafeTxOptionalParam(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		assert.NoError(t, json.Unmarshal(body, &req))
+		assert.Len(t, req.Params, 3)
+		assert.JSONEq(t, `"0x0000abcd"`, string(req.Params[2]))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"signature":"0x01"}}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL)
+	result, err := client.SignGnosisSafeTx("0x1234567890123456789012345678901234567890", &GnosisSafeTx{}, "0x0000abcd")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x01", result.Signature)
+}
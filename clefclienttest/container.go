@@ -0,0 +1,188 @@
+package clefclienttest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/AxLabs/clef-client/process"
+)
+
+// DefaultClefImage is the Docker image started when ContainerConfig.Image
+// is empty. The alltools images of go-ethereum ship the clef binary.
+const DefaultClefImage = "ethereum/client-go:alltools-stable"
+
+// AutoApproveRules is a Clef ruleset approving every listing, transaction
+// and data signing request, for integration tests only
+const AutoApproveRules = `
+function ApproveListing() { return "Approve" }
+function ApproveTx(r) { return "Approve" }
+function ApproveSignData(r) { return "Approve" }
+function OnApprovedTx(tx) {}
+function OnSignerStartup(info) {}
+`
+
+// ContainerConfig configures StartContainer
+type ContainerConfig struct {
+	// Image is the Docker image to run, DefaultClefImage if empty
+	Image string
+	// Keys are the 0x-prefixed private keys of the keystore. One random
+	// account is created when empty.
+	Keys []string
+	// ChainID is Clef's --chainid, 1337 if 0
+	ChainID uint64
+	// Rules is the ruleset Clef runs with, AutoApproveRules if empty
+	Rules string
+	// StartTimeout bounds the wait for Clef to answer, one minute if 0
+	StartTimeout time.Duration
+}
+
+// Container is a real Clef running in Docker
+type Container struct {
+	// ID is the Docker container ID
+	ID string
+	// URL is Clef's HTTP endpoint
+	URL string
+	// Accounts are the addresses of the keystore, in the order of the keys
+	Accounts []string
+	// Client is connected to URL
+	Client *clefclient.ClefClient
+}
+
+// startScript initializes Clef's master seed, stores the account passwords,
+// attests the rules and starts Clef. Secrets are passed in the environment.
+const startScript = `set -e
+flags="--configdir /clef/config --keystore /clef/keystore --suppress-bootwarn --lightkdf"
+printf '%s\n%s\n' "$CLEF_MASTER" "$CLEF_MASTER" | clef $flags init >/dev/null
+for account in $CLEF_ACCOUNTS; do
+	printf '%s\n%s\n%s\n' "$CLEF_PASSWORD" "$CLEF_PASSWORD" "$CLEF_MASTER" | clef $flags setpw "$account" >/dev/null
+done
+printf '%s\n' "$CLEF_MASTER" | clef $flags attest "$CLEF_RULES_HASH" >/dev/null
+printf '%s\n' "$CLEF_MASTER" | clef $flags --chainid "$CLEF_CHAINID" --rules /clef/rules.js \
+	--http --http.addr 0.0.0.0 --http.port 8550 --http.vhosts '*'
+`
+
+// StartContainer runs Clef in Docker with a pre-seeded keystore and the
+// configured rules, waits until it answers and returns it connected. The
+// container is removed when the test finishes. The test is skipped when
+// Docker is not available.
+func StartContainer(t testing.TB, config ContainerConfig) *Container {
+	t.Helper()
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("clefclienttest: docker is not available")
+	}
+	if config.Image == "" {
+		config.Image = DefaultClefImage
+	}
+	if config.ChainID == 0 {
+		config.ChainID = 1337
+	}
+	if config.Rules == "" {
+		config.Rules = AutoApproveRules
+	}
+	if config.StartTimeout == 0 {
+		config.StartTimeout = time.Minute
+	}
+
+	dir := t.TempDir()
+	keystore := filepath.Join(dir, "keystore")
+	if err := os.MkdirAll(keystore, 0700); err != nil {
+		t.Fatalf("clefclienttest: %v", err)
+	}
+	master, password := randomSecret(t), randomSecret(t)
+	accounts := seedKeystore(t, keystore, config.Keys, password)
+	rules := process.NewRuleset([]byte(config.Rules))
+	if err := os.WriteFile(filepath.Join(dir, "rules.js"), rules.Source, 0600); err != nil {
+		t.Fatalf("clefclienttest: %v", err)
+	}
+
+	id := docker(t, "run", "--detach",
+		"--publish", "127.0.0.1::8550",
+		"--volume", dir+":/clef",
+		"--env", "CLEF_MASTER="+master,
+		"--env", "CLEF_PASSWORD="+password,
+		"--env", "CLEF_ACCOUNTS="+strings.Join(accounts, " "),
+		"--env", "CLEF_RULES_HASH="+rules.Hash,
+		"--env", fmt.Sprintf("CLEF_CHAINID=%d", config.ChainID),
+		"--entrypoint", "sh",
+		config.Image, "-c", startScript)
+	t.Cleanup(func() {
+		exec.Command("docker", "rm", "--force", id).Run()
+	})
+
+	port := docker(t, "port", id, "8550/tcp")
+	c := &Container{
+		ID:       id,
+		URL:      "http://" + strings.Split(port, "\n")[0],
+		Accounts: accounts,
+	}
+	c.Client = clefclient.NewHTTPClient(c.URL)
+
+	deadline := time.Now().Add(config.StartTimeout)
+	for {
+		_, err := c.Client.Version()
+		if err == nil {
+			return c
+		}
+		if time.Now().After(deadline) {
+			logs, _ := exec.Command("docker", "logs", id).CombinedOutput()
+			t.Fatalf("clefclienttest: clef did not start: %v\n%s", err, logs)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// seedKeystore writes keystore files for keys, or a random key when there
+// are none, and returns their addresses
+func seedKeystore(t testing.TB, dir string, keys []string, password string) []string {
+	if len(keys) == 0 {
+		keys = []string{"0x" + randomSecret(t)}
+	}
+	signer := clefclient.NewMemorySigner(1)
+	accounts := make([]string, len(keys))
+	for i, key := range keys {
+		address, err := signer.ImportKey(key)
+		if err != nil {
+			t.Fatalf("clefclienttest: key %d: %v", i, err)
+		}
+		b, _ := hex.DecodeString(strings.TrimPrefix(key, "0x"))
+		if err := writeKeystoreFile(dir, address, b, password); err != nil {
+			t.Fatalf("clefclienttest: %v", err)
+		}
+		accounts[i] = address
+	}
+	return accounts
+}
+
+// randomSecret returns 32 random bytes in hex
+func randomSecret(t testing.TB) string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("clefclienttest: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// docker runs a docker command and returns its trimmed output
+func docker(t testing.TB, args ...string) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("clefclienttest: docker %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(out.String())
+}
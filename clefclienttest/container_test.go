@@ -0,0 +1,34 @@
+package clefclienttest_test
+
+import (
+	"strings"
+	"testing"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/AxLabs/clef-client/clefclienttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts Clef in Docker")
+	}
+	clef := clefclienttest.StartContainer(t, clefclienttest.ContainerConfig{
+		Keys: []string{"0x" + strings.Repeat("46", 32)},
+	})
+	assert.Equal(t, []string{"0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F"}, clef.Accounts)
+
+	accounts, err := clef.Client.ListAccounts()
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+
+	resp, err := clef.Client.SignData(&clefclient.SignDataRequest{
+		ContentType: clefclient.ContentTypeTextPlain,
+		Address:     clef.Accounts[0],
+		Data:        "0x68656c6c6f",
+	})
+	assert.NoError(t, err)
+	recovered, err := clefclient.RecoverAddress(clefclient.TextHash([]byte("hello")), resp.Signature)
+	assert.NoError(t, err)
+	assert.Equal(t, clef.Accounts[0], recovered)
+}
@@ -0,0 +1,119 @@
+package clefclienttest
+
+import (
+	"net/http"
+	"time"
+)
+
+// Error codes of the failures simulated by the predefined faults
+const (
+	// CodeDenied is the code of Clef's error for requests rejected by the user
+	CodeDenied = -32000
+	// CodeRateLimited is the code commonly used by proxies for rate limits
+	CodeRateLimited = -32005
+)
+
+// Fault is a failure the fake injects into its answers. Faults can be
+// combined, e.g. a Delay followed by an Error.
+type Fault struct {
+	// Delay is waited before answering
+	Delay time.Duration
+	// Error replaces the result of the call
+	Error *Error
+	// HTTPStatus is the status code of HTTP answers, ignored over IPC
+	HTTPStatus int
+	// Malformed sends a truncated answer that is not valid JSON
+	Malformed bool
+	// Disconnect closes the connection after sending part of the answer
+	Disconnect bool
+	// Times limits the fault to the next n calls, 0 injects it into all
+	Times int
+}
+
+// transportLevel reports whether the fault affects the whole answer rather
+// than the response of a single call
+func (f *Fault) transportLevel() bool {
+	return f.HTTPStatus != 0 || f.Malformed || f.Disconnect
+}
+
+// FaultDenied answers as Clef does when the user rejects a request
+func FaultDenied() Fault {
+	return Fault{Error: &Error{Code: CodeDenied, Message: "Request denied"}}
+}
+
+// FaultApprovalTimeout answers after the given time with the error Clef
+// returns when no approval arrives in time
+func FaultApprovalTimeout(after time.Duration) Fault {
+	return Fault{Delay: after, Error: &Error{Code: CodeDenied, Message: "request timed out"}}
+}
+
+// FaultSlow answers normally after the given time
+func FaultSlow(after time.Duration) Fault {
+	return Fault{Delay: after}
+}
+
+// FaultMalformed answers with invalid JSON
+func FaultMalformed() Fault {
+	return Fault{Malformed: true}
+}
+
+// FaultDisconnect drops the connection in the middle of the answer
+func FaultDisconnect() Fault {
+	return Fault{Disconnect: true}
+}
+
+// FaultRateLimited answers with HTTP 429 and a rate limit error, as a rate
+// limiting proxy in front of Clef does
+func FaultRateLimited() Fault {
+	return Fault{
+		HTTPStatus: http.StatusTooManyRequests,
+		Error:      &Error{Code: CodeRateLimited, Message: "rate limit exceeded"},
+	}
+}
+
+// Inject makes calls of method fail with f, before any response installed
+// for it. An empty method injects the fault into calls of every method.
+// Injecting again replaces the previous fault.
+func (s *Server) Inject(method string, f Fault) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[method] = &f
+	return s
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = make(map[string]*Fault)
+}
+
+// takeFault returns the fault for a call of method, counting it against
+// the fault's Times. The caller must hold s.mu.
+func (s *Server) takeFault(method string) *Fault {
+	for _, key := range []string{method, ""} {
+		f, ok := s.faults[key]
+		if !ok {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				delete(s.faults, key)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// wait sleeps for d unless the server is closed first
+func (s *Server) wait(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	select {
+	case <-time.After(d):
+	case <-s.done:
+	}
+}
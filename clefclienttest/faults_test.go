@@ -0,0 +1,86 @@
+package clefclienttest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AxLabs/clef-client/clefclienttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFaultDenied(t *testing.T) {
+	clef := clefclienttest.NewServer(t)
+	clef.Respond("account_list", []string{account})
+	clef.Inject("account_list", clefclienttest.FaultDenied())
+
+	_, err := clef.Client().ListAccounts()
+	assert.EqualError(t, err, "account_list: Request denied")
+	clef.AssertCalled(t, "account_list")
+}
+
+func TestFaultTimes(t *testing.T) {
+	clef := clefclienttest.NewServer(t)
+	clef.Respond("account_list", []string{account})
+	fault := clefclienttest.FaultRateLimited()
+	fault.Times = 1
+	clef.Inject("", fault)
+	client := clef.Client()
+
+	_, err := client.ListAccounts()
+	assert.EqualError(t, err, "account_list: rate limit exceeded")
+	accounts, err := client.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{account}, accounts)
+}
+
+func TestFaultSlowAndTimeout(t *testing.T) {
+	clef := clefclienttest.NewServer(t)
+	clef.Respond("account_list", []string{account})
+	clef.Inject("account_list", clefclienttest.FaultSlow(50*time.Millisecond))
+	client := clef.Client()
+
+	start := time.Now()
+	_, err := client.ListAccounts()
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	clef.Inject("account_list", clefclienttest.FaultApprovalTimeout(10*time.Millisecond))
+	_, err = client.ListAccounts()
+	assert.EqualError(t, err, "account_list: request timed out")
+
+	clef.ClearFaults()
+	_, err = client.ListAccounts()
+	assert.NoError(t, err)
+}
+
+func TestFaultMalformedAndDisconnect(t *testing.T) {
+	for _, newServer := range []func(testing.TB) *clefclienttest.Server{clefclienttest.NewServer, clefclienttest.NewIPCServer} {
+		clef := newServer(t)
+		clef.Respond("account_list", []string{account})
+
+		clef.Inject("account_list", clefclienttest.FaultMalformed())
+		_, err := clef.Client().ListAccounts()
+		assert.Error(t, err)
+
+		clef.Inject("account_list", clefclienttest.FaultDisconnect())
+		_, err = clef.Client().ListAccounts()
+		assert.Error(t, err)
+	}
+}
+
+func TestFaultSlowAbortedByClose(t *testing.T) {
+	clef := clefclienttest.NewServer(t)
+	clef.Inject("", clefclienttest.FaultSlow(time.Hour))
+	client := clef.Client()
+
+	done := make(chan struct{})
+	go func() {
+		client.ListAccounts()
+		close(done)
+	}()
+	for len(clef.Calls()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clef.Close()
+	<-done
+}
@@ -0,0 +1,278 @@
+package clefclienttest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// Transaction types of generated fixtures
+const (
+	TxTypeLegacy     = "legacy"
+	TxTypeDynamicFee = "1559"
+)
+
+// Fixture is a request to Clef and its response
+type Fixture struct {
+	// Name identifies the fixture and names its file
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	// Params are the params the client sends, compared as JSON. Fixtures
+	// without params answer any call of the method.
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result"`
+}
+
+// FixtureConfig parameterizes GenerateFixtures
+type FixtureConfig struct {
+	// ChainID of the transactions and typed data, 1 if zero
+	ChainID uint64
+	// TxTypes are the transaction types to sign, all if empty
+	TxTypes []string
+	// Accounts is the number of accounts, 1 if zero
+	Accounts int
+	// Count is the number of fixtures per account, transaction type and
+	// request kind, 1 if zero
+	Count int
+	// Seed derives the keys and values, so that a seed always produces the
+	// same fixtures
+	Seed string
+}
+
+// GenerateFixtures produces realistic fixtures for the account listing,
+// transaction, personal message and typed data signing, and ecRecover
+// methods: valid checksummed addresses, signatures and raw transactions, as
+// the MemorySigner would return them. Keys are derived from the seed and
+// must only be used in tests.
+func GenerateFixtures(config FixtureConfig) ([]Fixture, error) {
+	if config.ChainID == 0 {
+		config.ChainID = 1
+	}
+	if len(config.TxTypes) == 0 {
+		config.TxTypes = []string{TxTypeLegacy, TxTypeDynamicFee}
+	}
+	if config.Accounts <= 0 {
+		config.Accounts = 1
+	}
+	if config.Count <= 0 {
+		config.Count = 1
+	}
+	for _, txType := range config.TxTypes {
+		if txType != TxTypeLegacy && txType != TxTypeDynamicFee {
+			return nil, fmt.Errorf("unknown transaction type %q, use %s or %s", txType, TxTypeLegacy, TxTypeDynamicFee)
+		}
+	}
+
+	g := &fixtureGenerator{seed: config.Seed, signer: clefclient.NewMemorySigner(config.ChainID)}
+	var accounts []string
+	for i := 0; i < config.Accounts; i++ {
+		account, err := g.signer.ImportKey("0x" + hex.EncodeToString(g.key(i)))
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	if err := g.add("account_list", "account_list", nil, accounts); err != nil {
+		return nil, err
+	}
+
+	chainID := fmt.Sprintf("0x%x", config.ChainID)
+	for a, account := range accounts {
+		for i := 0; i < config.Count; i++ {
+			for _, txType := range config.TxTypes {
+				tx := g.transaction(account, chainID, txType, a, i)
+				signed, err := g.signer.SignTransaction(tx)
+				if err != nil {
+					return nil, err
+				}
+				if err := g.add(fmt.Sprintf("account_signTransaction_%s_%d_%d", txType, a, i), "account_signTransaction", tx, signed); err != nil {
+					return nil, err
+				}
+			}
+
+			message := fmt.Sprintf("0x%x", fmt.Sprintf("fixture message %d of account %d", i, a))
+			req := &clefclient.SignDataRequest{ContentType: clefclient.ContentTypeTextPlain, Address: account, Data: message}
+			signed, err := g.signer.SignData(req)
+			if err != nil {
+				return nil, err
+			}
+			if err := g.add(fmt.Sprintf("account_signData_%d_%d", a, i), "account_signData", req, signed); err != nil {
+				return nil, err
+			}
+			ecRecover := &clefclient.EcRecoverRequest{Data: message, Signature: signed.Signature}
+			if err := g.add(fmt.Sprintf("account_ecRecover_%d_%d", a, i), "account_ecRecover", ecRecover, &clefclient.EcRecoverResponse{Address: account}); err != nil {
+				return nil, err
+			}
+
+			typed := &clefclient.TypedDataRequest{Address: account, TypedData: g.typedData(config.ChainID, account, a, i)}
+			if signed, err = g.signer.SignTypedData(typed); err != nil {
+				return nil, err
+			}
+			if err := g.add(fmt.Sprintf("account_signTypedData_%d_%d", a, i), "account_signTypedData", typed, signed); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g.fixtures, nil
+}
+
+// fixtureGenerator derives the keys and values of fixtures from a seed
+type fixtureGenerator struct {
+	seed     string
+	signer   *clefclient.MemorySigner
+	fixtures []Fixture
+}
+
+// derive returns 32 bytes determined by the seed, label and indices
+func (g *fixtureGenerator) derive(label string, indices ...int) []byte {
+	h := sha256.New()
+	h.Write([]byte("clefclienttest fixture\x00" + g.seed + "\x00" + label))
+	for _, i := range indices {
+		binary.Write(h, binary.BigEndian, int64(i))
+	}
+	return h.Sum(nil)
+}
+
+// key returns the private key of account i. Derived keys are valid unless
+// the hash exceeds the curve order, which is practically impossible.
+func (g *fixtureGenerator) key(i int) []byte {
+	return g.derive("key", i)
+}
+
+func (g *fixtureGenerator) address(label string, indices ...int) string {
+	address, _ := clefclient.ChecksumAddress("0x" + hex.EncodeToString(g.derive(label, indices...)[:20]))
+	return address
+}
+
+func (g *fixtureGenerator) amount(label string, indices ...int) *big.Int {
+	// Below 10 ether
+	v := new(big.Int).SetBytes(g.derive(label, indices...)[:8])
+	return v.Mod(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(19), nil))
+}
+
+// transaction returns the i-th transaction of account a. Odd transactions
+// are ERC-20 transfers, even ones plain value transfers.
+func (g *fixtureGenerator) transaction(account, chainID, txType string, a, i int) *clefclient.Transaction {
+	tx := &clefclient.Transaction{
+		From:    account,
+		Nonce:   fmt.Sprintf("0x%x", i),
+		ChainID: chainID,
+	}
+	if i%2 == 0 {
+		tx.To = g.address("recipient", a, i)
+		tx.Value = fmt.Sprintf("0x%x", g.amount("value", a, i))
+		tx.Gas = "0x5208"
+	} else {
+		recipient := g.derive("recipient", a, i)[:20]
+		amount := g.amount("value", a, i)
+		tx.To = g.address("token", a)
+		tx.Data = fmt.Sprintf("0xa9059cbb%064x%064x", recipient, amount)
+		tx.Value = "0x0"
+		tx.Gas = "0xfde8"
+	}
+	if txType == TxTypeDynamicFee {
+		tx.MaxPriorityFeePerGas = "0x3b9aca00"
+		tx.MaxFeePerGas = fmt.Sprintf("0x%x", 20_000_000_000+int64(i)*1_000_000_000)
+	} else {
+		tx.GasPrice = fmt.Sprintf("0x%x", 20_000_000_000+int64(i)*1_000_000_000)
+	}
+	return tx
+}
+
+// typedData returns an EIP-2612 permit of the token of account a
+func (g *fixtureGenerator) typedData(chainID uint64, account string, a, i int) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"types":{"EIP712Domain":[{"name":"name","type":"string"},{"name":"version","type":"string"},`+
+		`{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],"Permit":[{"name":"owner","type":"address"},`+
+		`{"name":"spender","type":"address"},{"name":"value","type":"uint256"},{"name":"nonce","type":"uint256"},{"name":"deadline","type":"uint256"}]},`+
+		`"primaryType":"Permit","domain":{"name":"Fixture Token","version":"1","chainId":%d,"verifyingContract":"%s"},`+
+		`"message":{"owner":"%s","spender":"%s","value":"%s","nonce":%d,"deadline":%d}}`,
+		chainID, g.address("token", a), account, g.address("spender", a, i), g.amount("permit", a, i), i, 4102444800))
+}
+
+func (g *fixtureGenerator) add(name, method string, params, result interface{}) error {
+	fixture := Fixture{Name: name, Method: method}
+	var err error
+	if params != nil {
+		if fixture.Params, err = json.Marshal(params); err != nil {
+			return err
+		}
+	}
+	if fixture.Result, err = json.Marshal(result); err != nil {
+		return err
+	}
+	g.fixtures = append(g.fixtures, fixture)
+	return nil
+}
+
+// WriteFixtures writes each fixture to a file named after it in dir
+func WriteFixtures(dir string, fixtures []Fixture) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, fixture := range fixtures {
+		data, err := json.MarshalIndent(fixture, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, fixture.Name+".json"), append(data, '\n'), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFixtures reads the fixtures written to dir by WriteFixtures, sorted
+// by name
+func LoadFixtures(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	fixtures := make([]Fixture, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var fixture Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if fixture.Method == "" {
+			return nil, fmt.Errorf("%s: fixture has no method", path)
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+// ServeFixtures answers the methods of fixtures with the result of the
+// fixture whose params match those of the call, replacing any previous
+// response. Calls matching no fixture fail.
+func (s *Server) ServeFixtures(fixtures []Fixture) *Server {
+	byMethod := map[string][]Fixture{}
+	for _, fixture := range fixtures {
+		byMethod[fixture.Method] = append(byMethod[fixture.Method], fixture)
+	}
+	for method, candidates := range byMethod {
+		s.Handle(method, func(params json.RawMessage) (interface{}, error) {
+			for _, fixture := range candidates {
+				if fixture.Params == nil || jsonEqual(fixture.Params, params) {
+					return fixture.Result, nil
+				}
+			}
+			return nil, &Error{Code: CodeServer, Message: fmt.Sprintf("no fixture for %s with params %s", method, strings.TrimSpace(string(params)))}
+		})
+	}
+	return s
+}
@@ -0,0 +1,74 @@
+package clefclienttest_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/AxLabs/clef-client/clefclienttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateFixtures(t *testing.T) {
+	config := clefclienttest.FixtureConfig{ChainID: 11155111, TxTypes: []string{clefclienttest.TxTypeDynamicFee}, Accounts: 2, Count: 2, Seed: "test"}
+	fixtures, err := clefclienttest.GenerateFixtures(config)
+	assert.NoError(t, err)
+	// account_list, then per account and index a transaction, a message,
+	// its recovery and typed data
+	assert.Len(t, fixtures, 1+2*2*4)
+
+	again, err := clefclienttest.GenerateFixtures(config)
+	assert.NoError(t, err)
+	assert.Equal(t, fixtures, again)
+	config.Seed = "other"
+	other, err := clefclienttest.GenerateFixtures(config)
+	assert.NoError(t, err)
+	assert.NotEqual(t, fixtures[0].Result, other[0].Result)
+
+	dir := t.TempDir()
+	assert.NoError(t, clefclienttest.WriteFixtures(dir, fixtures))
+	loaded, err := clefclienttest.LoadFixtures(dir)
+	assert.NoError(t, err)
+	assert.Len(t, loaded, len(fixtures))
+
+	clef := clefclienttest.NewServer(t).ServeFixtures(loaded)
+	client := clef.Client()
+	accounts, err := client.ListAccounts()
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 2)
+
+	for _, fixture := range fixtures {
+		switch fixture.Method {
+		case "account_signTransaction":
+			var tx clefclient.Transaction
+			assert.NoError(t, json.Unmarshal(fixture.Params, &tx))
+			assert.Equal(t, "0xaa36a7", tx.ChainID)
+			assert.NotEmpty(t, tx.MaxFeePerGas)
+			signed, err := client.SignTransaction(&tx)
+			assert.NoError(t, err)
+			assert.Contains(t, signed.Raw, "0x02")
+		case "account_signData":
+			var req clefclient.SignDataRequest
+			assert.NoError(t, json.Unmarshal(fixture.Params, &req))
+			signed, err := client.SignData(&req)
+			assert.NoError(t, err)
+			data, _ := hex.DecodeString(strings.TrimPrefix(req.Data, "0x"))
+			signer, err := clefclient.RecoverAddress(clefclient.TextHash(data), signed.Signature)
+			assert.NoError(t, err)
+			assert.Equal(t, req.Address, signer)
+		case "account_signTypedData":
+			var req clefclient.TypedDataRequest
+			assert.NoError(t, json.Unmarshal(fixture.Params, &req))
+			_, err := client.SignTypedData(&req)
+			assert.NoError(t, err)
+		}
+	}
+
+	_, err = client.SignData(&clefclient.SignDataRequest{Address: accounts[0], Data: "0x00"})
+	assert.ErrorContains(t, err, "no fixture for account_signData")
+
+	_, err = clefclienttest.GenerateFixtures(clefclienttest.FixtureConfig{TxTypes: []string{"4844"}})
+	assert.ErrorContains(t, err, "unknown transaction type")
+}
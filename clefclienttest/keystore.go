@@ -0,0 +1,95 @@
+package clefclienttest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// Light scrypt parameters, as written by geth and Clef with --lightkdf
+const (
+	keystoreScryptN = 1 << 12
+	keystoreScryptR = 8
+	keystoreScryptP = 6
+)
+
+type keystoreKey struct {
+	Address string         `json:"address"`
+	Crypto  keystoreCrypto `json:"crypto"`
+	ID      string         `json:"id"`
+	Version int            `json:"version"`
+}
+
+type keystoreCrypto struct {
+	Cipher       string `json:"cipher"`
+	CipherText   string `json:"ciphertext"`
+	CipherParams struct {
+		IV string `json:"iv"`
+	} `json:"cipherparams"`
+	KDF       string `json:"kdf"`
+	KDFParams struct {
+		DKLen int    `json:"dklen"`
+		N     int    `json:"n"`
+		P     int    `json:"p"`
+		R     int    `json:"r"`
+		Salt  string `json:"salt"`
+	} `json:"kdfparams"`
+	MAC string `json:"mac"`
+}
+
+// writeKeystoreFile encrypts the private key of address with password into
+// a version 3 keystore file in dir
+func writeKeystoreFile(dir, address string, key []byte, password string) error {
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	id := make([]byte, 16)
+	for _, b := range [][]byte{salt, iv, id} {
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+	}
+	derived, err := scrypt.Key([]byte(password), salt, keystoreScryptN, keystoreScryptR, keystoreScryptP, 32)
+	if err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(derived[:16])
+	if err != nil {
+		return err
+	}
+	ciphertext := make([]byte, len(key))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, key)
+	mac := sha3.NewLegacyKeccak256()
+	mac.Write(derived[16:32])
+	mac.Write(ciphertext)
+
+	address = strings.ToLower(strings.TrimPrefix(address, "0x"))
+	k := keystoreKey{Address: address, Version: 3}
+	k.ID = fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:])
+	k.Crypto.Cipher = "aes-128-ctr"
+	k.Crypto.CipherText = hex.EncodeToString(ciphertext)
+	k.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	k.Crypto.KDF = "scrypt"
+	k.Crypto.KDFParams.DKLen = 32
+	k.Crypto.KDFParams.N = keystoreScryptN
+	k.Crypto.KDFParams.P = keystoreScryptP
+	k.Crypto.KDFParams.R = keystoreScryptR
+	k.Crypto.KDFParams.Salt = hex.EncodeToString(salt)
+	k.Crypto.MAC = hex.EncodeToString(mac.Sum(nil))
+
+	data, err := json.Marshal(&k)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("UTC--%s--%s", time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z"), address)
+	return os.WriteFile(filepath.Join(dir, name), data, 0600)
+}
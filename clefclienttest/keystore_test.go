@@ -0,0 +1,46 @@
+package clefclienttest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestWriteKeystoreFile(t *testing.T) {
+	dir := t.TempDir()
+	key, _ := hex.DecodeString("4646464646464646464646464646464646464646464646464646464646464646")
+	assert.NoError(t, writeKeystoreFile(dir, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", key, "secret"))
+
+	files, err := filepath.Glob(filepath.Join(dir, "UTC--*--9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	data, err := os.ReadFile(files[0])
+	assert.NoError(t, err)
+
+	var k keystoreKey
+	assert.NoError(t, json.Unmarshal(data, &k))
+	assert.Equal(t, 3, k.Version)
+	salt, _ := hex.DecodeString(k.Crypto.KDFParams.Salt)
+	iv, _ := hex.DecodeString(k.Crypto.CipherParams.IV)
+	ciphertext, _ := hex.DecodeString(k.Crypto.CipherText)
+	derived, err := scrypt.Key([]byte("secret"), salt, k.Crypto.KDFParams.N, k.Crypto.KDFParams.R, k.Crypto.KDFParams.P, 32)
+	assert.NoError(t, err)
+
+	mac := sha3.NewLegacyKeccak256()
+	mac.Write(derived[16:32])
+	mac.Write(ciphertext)
+	assert.Equal(t, k.Crypto.MAC, hex.EncodeToString(mac.Sum(nil)))
+
+	block, _ := aes.NewCipher(derived[:16])
+	plain := make([]byte, len(ciphertext))
+	cipher.NewCTR(block, iv).XORKeyStream(plain, ciphertext)
+	assert.Equal(t, key, plain)
+}
@@ -0,0 +1,175 @@
+package clefclienttest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// ErrNotMocked is returned by Mock methods without a function set
+var ErrNotMocked = errors.New("clefclienttest: method not mocked")
+
+// MockCall is a method call recorded by Mock
+type MockCall struct {
+	Method string
+	Args   []interface{}
+}
+
+// Mock implements clefclient.Client with a function field per method, for
+// unit tests that need no server at all. Methods whose function is nil
+// return ErrNotMocked. Every call is recorded.
+type Mock struct {
+	NewAccountFunc       func() (string, error)
+	ListAccountsFunc     func() ([]string, error)
+	HasAccountFunc       func(address string) (bool, error)
+	SignTransactionFunc  func(tx *clefclient.Transaction) (*clefclient.SignTxResponse, error)
+	SignTransactionsFunc func(txs []*clefclient.Transaction) ([]clefclient.SignTxResult, error)
+	SignDataFunc         func(req *clefclient.SignDataRequest) (*clefclient.SignDataResponse, error)
+	SignTypedDataFunc    func(req *clefclient.TypedDataRequest) (*clefclient.SignDataResponse, error)
+	SignGnosisSafeTxFunc func(signerAddress string, gnosisTx *clefclient.GnosisSafeTx, methodSelector string) (*clefclient.GnosisSafeTx, error)
+	EcRecoverFunc        func(req *clefclient.EcRecoverRequest) (*clefclient.EcRecoverResponse, error)
+	VersionFunc          func() (*clefclient.VersionResponse, error)
+	CloseFunc            func() error
+
+	mu    sync.Mutex
+	calls []MockCall
+}
+
+var _ clefclient.Client = (*Mock)(nil)
+
+// Calls returns the calls made so far, in order
+func (m *Mock) Calls() []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockCall(nil), m.calls...)
+}
+
+// CallsTo returns the calls of the named method, e.g. "SignTransaction"
+func (m *Mock) CallsTo(method string) []MockCall {
+	var calls []MockCall
+	for _, c := range m.Calls() {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+func (m *Mock) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, MockCall{Method: method, Args: args})
+}
+
+func notMocked(method string) error {
+	return fmt.Errorf("%w: %s", ErrNotMocked, method)
+}
+
+// NewAccount calls NewAccountFunc
+func (m *Mock) NewAccount() (string, error) {
+	m.record("NewAccount")
+	if m.NewAccountFunc == nil {
+		return "", notMocked("NewAccount")
+	}
+	return m.NewAccountFunc()
+}
+
+// ListAccounts calls ListAccountsFunc
+func (m *Mock) ListAccounts() ([]string, error) {
+	m.record("ListAccounts")
+	if m.ListAccountsFunc == nil {
+		return nil, notMocked("ListAccounts")
+	}
+	return m.ListAccountsFunc()
+}
+
+// HasAccount calls HasAccountFunc
+func (m *Mock) HasAccount(address string) (bool, error) {
+	m.record("HasAccount", address)
+	if m.HasAccountFunc == nil {
+		return false, notMocked("HasAccount")
+	}
+	return m.HasAccountFunc(address)
+}
+
+// SignTransaction calls SignTransactionFunc
+func (m *Mock) SignTransaction(tx *clefclient.Transaction) (*clefclient.SignTxResponse, error) {
+	m.record("SignTransaction", tx)
+	if m.SignTransactionFunc == nil {
+		return nil, notMocked("SignTransaction")
+	}
+	return m.SignTransactionFunc(tx)
+}
+
+// SignTransactions calls SignTransactionsFunc, or SignTransactionFunc for
+// each transaction when only that is set
+func (m *Mock) SignTransactions(txs []*clefclient.Transaction) ([]clefclient.SignTxResult, error) {
+	m.record("SignTransactions", txs)
+	if m.SignTransactionsFunc != nil {
+		return m.SignTransactionsFunc(txs)
+	}
+	if m.SignTransactionFunc == nil {
+		return nil, notMocked("SignTransactions")
+	}
+	results := make([]clefclient.SignTxResult, len(txs))
+	for i, tx := range txs {
+		results[i].Response, results[i].Err = m.SignTransactionFunc(tx)
+	}
+	return results, nil
+}
+
+// SignData calls SignDataFunc
+func (m *Mock) SignData(req *clefclient.SignDataRequest) (*clefclient.SignDataResponse, error) {
+	m.record("SignData", req)
+	if m.SignDataFunc == nil {
+		return nil, notMocked("SignData")
+	}
+	return m.SignDataFunc(req)
+}
+
+// SignTypedData calls SignTypedDataFunc
+func (m *Mock) SignTypedData(req *clefclient.TypedDataRequest) (*clefclient.SignDataResponse, error) {
+	m.record("SignTypedData", req)
+	if m.SignTypedDataFunc == nil {
+		return nil, notMocked("SignTypedData")
+	}
+	return m.SignTypedDataFunc(req)
+}
+
+// SignGnosisSafeTx calls SignGnosisSafeTxFunc
+func (m *Mock) SignGnosisSafeTx(signerAddress string, gnosisTx *clefclient.GnosisSafeTx, methodSelector string) (*clefclient.GnosisSafeTx, error) {
+	m.record("SignGnosisSafeTx", signerAddress, gnosisTx, methodSelector)
+	if m.SignGnosisSafeTxFunc == nil {
+		return nil, notMocked("SignGnosisSafeTx")
+	}
+	return m.SignGnosisSafeTxFunc(signerAddress, gnosisTx, methodSelector)
+}
+
+// EcRecover calls EcRecoverFunc
+func (m *Mock) EcRecover(req *clefclient.EcRecoverRequest) (*clefclient.EcRecoverResponse, error) {
+	m.record("EcRecover", req)
+	if m.EcRecoverFunc == nil {
+		return nil, notMocked("EcRecover")
+	}
+	return m.EcRecoverFunc(req)
+}
+
+// Version calls VersionFunc
+func (m *Mock) Version() (*clefclient.VersionResponse, error) {
+	m.record("Version")
+	if m.VersionFunc == nil {
+		return nil, notMocked("Version")
+	}
+	return m.VersionFunc()
+}
+
+// Close calls CloseFunc, succeeding when it is not set
+func (m *Mock) Close() error {
+	m.record("Close")
+	if m.CloseFunc == nil {
+		return nil
+	}
+	return m.CloseFunc()
+}
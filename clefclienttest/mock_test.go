@@ -0,0 +1,40 @@
+package clefclienttest_test
+
+import (
+	"errors"
+	"testing"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/AxLabs/clef-client/clefclienttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMock(t *testing.T) {
+	mock := &clefclienttest.Mock{
+		ListAccountsFunc: func() ([]string, error) {
+			return []string{account}, nil
+		},
+		SignTransactionFunc: func(tx *clefclient.Transaction) (*clefclient.SignTxResponse, error) {
+			if tx.Nonce == "0x1" {
+				return nil, errors.New("Request denied")
+			}
+			return &clefclient.SignTxResponse{Raw: "0x01"}, nil
+		},
+	}
+	var client clefclient.Client = mock
+
+	accounts, err := client.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{account}, accounts)
+
+	results, err := client.SignTransactions([]*clefclient.Transaction{{Nonce: "0x0"}, {Nonce: "0x1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x01", results[0].Response.Raw)
+	assert.EqualError(t, results[1].Err, "Request denied")
+
+	_, err = client.Version()
+	assert.ErrorIs(t, err, clefclienttest.ErrNotMocked)
+
+	assert.Len(t, mock.Calls(), 3)
+	assert.Len(t, mock.CallsTo("SignTransactions"), 1)
+}
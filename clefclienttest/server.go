@@ -0,0 +1,463 @@
+// Package clefclienttest provides a programmable fake Clef for tests. A Server
+// answers JSON-RPC over HTTP or IPC with canned results or handler functions
+// and records every call, so tests can assert on what the code under test
+// asked Clef to do without running a real signer.
+//
+//	clef := clefclienttest.NewServer(t)
+//	clef.Respond("account_list", []string{"0x1234..."})
+//	accounts, err := clef.Client().ListAccounts()
+//	clef.AssertCalled(t, "account_list")
+package clefclienttest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/stretchr/testify/assert"
+)
+
+// Error codes used by the fake for failed calls
+const (
+	CodeMethodNotFound = -32601
+	CodeServer         = -32000
+)
+
+// Error is returned by a Handler to answer with a specific JSON-RPC error
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	// Data is sent as the data member of the error if not nil
+	Data interface{} `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Handler computes the result of a call from its raw JSON params. A non-nil
+// error is sent as a JSON-RPC error, with its code if it is an *Error.
+type Handler func(params json.RawMessage) (interface{}, error)
+
+// Call is a request received by the fake
+type Call struct {
+	Method string
+	Params json.RawMessage
+}
+
+// Server is a fake Clef serving JSON-RPC over HTTP or IPC
+type Server struct {
+	// URL is the HTTP endpoint, empty for IPC servers
+	URL string
+	// IPCPath is the socket path, empty for HTTP servers
+	IPCPath string
+
+	t        testing.TB
+	mu       sync.Mutex
+	handlers map[string]Handler
+	faults   map[string]*Fault
+	calls    []Call
+	http     *httptest.Server
+	listener net.Listener
+	dir      string
+	conns    map[net.Conn]bool
+	wg       sync.WaitGroup
+	done     chan struct{}
+	closing  sync.Once
+}
+
+type request struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type response struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+func newServer(t testing.TB) *Server {
+	return &Server{
+		t:        t,
+		handlers: make(map[string]Handler),
+		faults:   make(map[string]*Fault),
+		conns:    make(map[net.Conn]bool),
+		done:     make(chan struct{}),
+	}
+}
+
+// NewServer starts a fake Clef on a local HTTP endpoint. It is closed when
+// the test finishes.
+func NewServer(t testing.TB) *Server {
+	s := newServer(t)
+	s.http = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.http.URL
+	t.Cleanup(s.Close)
+	return s
+}
+
+// NewIPCServer starts a fake Clef on a unix socket. It is closed when the test
+// finishes.
+func NewIPCServer(t testing.TB) *Server {
+	// Socket paths are limited to about 100 bytes, too short for t.TempDir
+	// with long test names
+	dir, err := os.MkdirTemp("", "clef")
+	if err != nil {
+		t.Fatalf("clefclienttest: %v", err)
+	}
+	s := newServer(t)
+	s.dir = dir
+	s.IPCPath = filepath.Join(dir, "clef.ipc")
+	if s.listener, err = net.Listen("unix", s.IPCPath); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("clefclienttest: %v", err)
+	}
+	s.wg.Add(1)
+	go s.acceptIPC()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a ClefClient connected to the fake
+func (s *Server) Client() *clefclient.ClefClient {
+	if s.http != nil {
+		return clefclient.NewHTTPClient(s.URL)
+	}
+	client, err := clefclient.NewIPCClient(s.IPCPath)
+	if err != nil {
+		s.t.Fatalf("clefclienttest: %v", err)
+	}
+	s.t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// Close stops the fake. It is safe to call more than once.
+func (s *Server) Close() {
+	s.closing.Do(func() { close(s.done) })
+	if s.http != nil {
+		s.http.Close()
+	}
+	if s.listener != nil {
+		s.listener.Close()
+		s.mu.Lock()
+		for conn := range s.conns {
+			conn.Close()
+		}
+		s.mu.Unlock()
+		s.wg.Wait()
+		os.RemoveAll(s.dir)
+	}
+}
+
+// Handle installs h for method, replacing any previous response
+func (s *Server) Handle(method string, h Handler) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = h
+	return s
+}
+
+// Respond answers every call of method with result
+func (s *Server) Respond(method string, result interface{}) *Server {
+	return s.Handle(method, func(json.RawMessage) (interface{}, error) {
+		return result, nil
+	})
+}
+
+// RespondError answers every call of method with a JSON-RPC error
+func (s *Server) RespondError(method string, code int, message string) *Server {
+	return s.Handle(method, func(json.RawMessage) (interface{}, error) {
+		return nil, &Error{Code: code, Message: message}
+	})
+}
+
+// RespondSequence answers successive calls of method with results in order,
+// repeating the last one once they are used up. An error result is sent as
+// a JSON-RPC error.
+func (s *Server) RespondSequence(method string, results ...interface{}) *Server {
+	if len(results) == 0 {
+		s.t.Fatalf("clefclienttest: no results for %s", method)
+	}
+	var mu sync.Mutex
+	next := 0
+	return s.Handle(method, func(json.RawMessage) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		result := results[next]
+		if next < len(results)-1 {
+			next++
+		}
+		if err, ok := result.(error); ok {
+			return nil, err
+		}
+		return result, nil
+	})
+}
+
+// ServeSigner answers the account listing and signing methods from signer,
+// e.g. a clefclient.MemorySigner, so that code under test gets valid
+// signatures from the fake
+func (s *Server) ServeSigner(signer clefclient.Signer) *Server {
+	s.Handle("account_list", func(json.RawMessage) (interface{}, error) {
+		return signer.ListAccounts()
+	})
+	s.Handle("account_signTransaction", func(params json.RawMessage) (interface{}, error) {
+		var tx clefclient.Transaction
+		if err := json.Unmarshal(params, &tx); err != nil {
+			return nil, err
+		}
+		return signer.SignTransaction(&tx)
+	})
+	s.Handle("account_signData", func(params json.RawMessage) (interface{}, error) {
+		var req clefclient.SignDataRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		return signer.SignData(&req)
+	})
+	return s.Handle("account_signTypedData", func(params json.RawMessage) (interface{}, error) {
+		var req clefclient.TypedDataRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		return signer.SignTypedData(&req)
+	})
+}
+
+// Calls returns the calls received so far, in order
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallsTo returns the calls of method received so far, in order
+func (s *Server) CallsTo(method string) []Call {
+	var calls []Call
+	for _, c := range s.Calls() {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls, keeping the responses
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+// AssertCalled asserts that method was called
+func (s *Server) AssertCalled(t testing.TB, method string) bool {
+	t.Helper()
+	if len(s.CallsTo(method)) == 0 {
+		return assert.Fail(t, fmt.Sprintf("%s was not called", method), "calls: %v", s.methods())
+	}
+	return true
+}
+
+// AssertCalledWith asserts that some call of method sent params, compared as
+// JSON. Params is the value the client passes, e.g. a request struct or a
+// []interface{} of positional arguments.
+func (s *Server) AssertCalledWith(t testing.TB, method string, params interface{}) bool {
+	t.Helper()
+	if !s.AssertCalled(t, method) {
+		return false
+	}
+	want, err := json.Marshal(params)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	var got []string
+	for _, c := range s.CallsTo(method) {
+		if jsonEqual(want, c.Params) {
+			return true
+		}
+		got = append(got, string(c.Params))
+	}
+	return assert.Fail(t, fmt.Sprintf("%s was not called with %s", method, want), "received params: %v", got)
+}
+
+// AssertNotCalled asserts that method was never called
+func (s *Server) AssertNotCalled(t testing.TB, method string) bool {
+	t.Helper()
+	if n := len(s.CallsTo(method)); n > 0 {
+		return assert.Fail(t, fmt.Sprintf("%s was called %d times", method, n))
+	}
+	return true
+}
+
+// AssertNumberOfCalls asserts that method was called n times
+func (s *Server) AssertNumberOfCalls(t testing.TB, method string, n int) bool {
+	t.Helper()
+	return assert.Len(t, s.CallsTo(method), n, "calls of %s", method)
+}
+
+func (s *Server) methods() []string {
+	calls := s.Calls()
+	methods := make([]string, len(calls))
+	for i, c := range calls {
+		methods[i] = c.Method
+	}
+	return methods
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	answer, fault := s.dispatch(body)
+	out, _ := json.Marshal(answer)
+	status := http.StatusOK
+	if fault != nil {
+		switch {
+		case fault.Disconnect:
+			if conn, buf, err := http.NewResponseController(w).Hijack(); err == nil {
+				fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n", len(out))
+				buf.Write(out[:len(out)/2])
+				buf.Flush()
+				conn.Close()
+			}
+			return
+		case fault.Malformed:
+			out = out[:len(out)/2]
+		}
+		if fault.HTTPStatus != 0 {
+			status = fault.HTTPStatus
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(out, '\n'))
+}
+
+func (s *Server) acceptIPC() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			dec := json.NewDecoder(bufio.NewReader(conn))
+			for {
+				var body json.RawMessage
+				if err := dec.Decode(&body); err != nil {
+					return
+				}
+				answer, fault := s.dispatch(body)
+				out, _ := json.Marshal(answer)
+				if fault != nil && fault.Disconnect {
+					conn.Write(out[:len(out)/2])
+					return
+				}
+				if fault != nil && fault.Malformed {
+					out = out[:len(out)/2]
+				}
+				if _, err := conn.Write(append(out, '\n')); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// dispatch answers a single request or a batch, returning the injected
+// fault affecting the whole answer, if any
+func (s *Server) dispatch(body json.RawMessage) (interface{}, *Fault) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var reqs []request
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			return response{Jsonrpc: "2.0", Error: &Error{Code: -32700, Message: err.Error()}}, nil
+		}
+		resps := make([]response, len(reqs))
+		var fault *Fault
+		for i := range reqs {
+			var f *Fault
+			resps[i], f = s.answer(&reqs[i])
+			if fault == nil && f != nil && f.transportLevel() {
+				fault = f
+			}
+		}
+		return resps, fault
+	}
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return response{Jsonrpc: "2.0", Error: &Error{Code: -32700, Message: err.Error()}}, nil
+	}
+	resp, fault := s.answer(&req)
+	if fault != nil && !fault.transportLevel() {
+		fault = nil
+	}
+	return resp, fault
+}
+
+func (s *Server) answer(req *request) (response, *Fault) {
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: req.Method, Params: req.Params})
+	h := s.handlers[req.Method]
+	fault := s.takeFault(req.Method)
+	s.mu.Unlock()
+
+	resp := response{Jsonrpc: "2.0", ID: req.ID}
+	if fault != nil {
+		s.wait(fault.Delay)
+		if fault.Error != nil {
+			resp.Error = fault.Error
+			return resp, fault
+		}
+	}
+	if h == nil {
+		resp.Error = &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)}
+		return resp, fault
+	}
+	result, err := h(req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{Code: CodeServer, Message: err.Error()}
+		}
+		resp.Error = rpcErr
+		return resp, fault
+	}
+	resp.Result = result
+	return resp, fault
+}
+
+func jsonEqual(a, b []byte) bool {
+	var x, y interface{}
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return false
+	}
+	ax, _ := json.Marshal(x)
+	by, _ := json.Marshal(y)
+	return bytes.Equal(ax, by)
+}
@@ -0,0 +1,161 @@
+package clefclienttest_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/AxLabs/clef-client/clefclienttest"
+	"github.com/stretchr/testify/assert"
+)
+
+const account = "0x1234567890123456789012345678901234567890"
+
+func TestServerHTTP(t *testing.T) {
+	clef := clefclienttest.NewServer(t)
+	clef.Respond("account_list", []string{account})
+
+	accounts, err := clef.Client().ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{account}, accounts)
+	clef.AssertCalled(t, "account_list")
+	clef.AssertNotCalled(t, "account_signTransaction")
+}
+
+func TestServerIPC(t *testing.T) {
+	clef := clefclienttest.NewIPCServer(t)
+	clef.Respond("account_version", map[string]string{"version": "6.0.0"})
+	client := clef.Client()
+
+	for i := 0; i < 2; i++ {
+		version, err := client.Version()
+		assert.NoError(t, err)
+		assert.Equal(t, "6.0.0", version.Version)
+	}
+	clef.AssertNumberOfCalls(t, "account_version", 2)
+}
+
+func TestServerAssertParams(t *testing.T) {
+	clef := clefclienttest.NewServer(t)
+	clef.Respond("account_signData", map[string]string{"signature": "0x" + strings.Repeat("ab", 64) + "1b"})
+
+	_, err := clef.Client().SignData(&clefclient.SignDataRequest{
+		ContentType: "text/plain",
+		Address:     account,
+		Data:        "0x68656c6c6f",
+	})
+	assert.NoError(t, err)
+	clef.AssertCalledWith(t, "account_signData", map[string]string{
+		"content_type": "text/plain",
+		"address":      account,
+		"data":         "0x68656c6c6f",
+	})
+
+	mock := &testing.T{}
+	assert.False(t, clef.AssertCalledWith(mock, "account_signData", map[string]string{"address": account}))
+}
+
+func TestServerErrorsAndHandlers(t *testing.T) {
+	clef := clefclienttest.NewServer(t)
+	clef.RespondError("account_new", -32000, "Request denied")
+	clef.Handle("account_ecRecover", func(params json.RawMessage) (interface{}, error) {
+		var req clefclient.EcRecoverRequest
+		if err := json.Unmarshal(params, &req); err != nil {
+			return nil, err
+		}
+		assert.Equal(t, "0xabcd", req.Signature)
+		return map[string]string{"address": account}, nil
+	})
+	client := clef.Client()
+
+	_, err := client.NewAccount()
+	assert.EqualError(t, err, "account_new: Request denied")
+
+	_, err = client.ListAccounts()
+	assert.ErrorContains(t, err, "does not exist")
+
+	recovered, err := client.EcRecover(&clefclient.EcRecoverRequest{Data: "0x00", Signature: "0xabcd"})
+	assert.NoError(t, err)
+	assert.Equal(t, account, recovered.Address)
+
+	methods := []string{}
+	for _, c := range clef.Calls() {
+		methods = append(methods, c.Method)
+	}
+	assert.Equal(t, []string{"account_new", "account_list", "account_ecRecover"}, methods)
+
+	clef.Reset()
+	assert.Empty(t, clef.Calls())
+}
+
+func TestServerRespondSequence(t *testing.T) {
+	clef := clefclienttest.NewServer(t)
+	clef.RespondSequence("account_list",
+		&clefclienttest.Error{Code: -32000, Message: "busy"},
+		[]string{account},
+	)
+	client := clef.Client()
+
+	_, err := client.ListAccounts()
+	assert.EqualError(t, err, "account_list: busy")
+	for i := 0; i < 2; i++ {
+		accounts, err := client.ListAccounts()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{account}, accounts)
+	}
+}
+
+func TestServerBatch(t *testing.T) {
+	clef := clefclienttest.NewIPCServer(t)
+	clef.Respond("account_signTransaction", map[string]interface{}{"raw": "0x01", "tx": map[string]interface{}{}})
+
+	txs := []*clefclient.Transaction{
+		{From: account, To: account, Gas: "0x5208", Value: "0x1", Nonce: "0x0"},
+		{From: account, To: account, Gas: "0x5208", Value: "0x1", Nonce: "0x1"},
+	}
+	results, err := clef.Client().SignTransactions(txs)
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	clef.AssertNumberOfCalls(t, "account_signTransaction", 2)
+}
+
+func TestServerServeSigner(t *testing.T) {
+	signer := clefclient.NewMemorySigner(1)
+	from, err := signer.NewAccount()
+	assert.NoError(t, err)
+
+	clef := clefclienttest.NewServer(t).ServeSigner(signer)
+	client := clef.Client()
+
+	accounts, err := client.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{from}, accounts)
+
+	resp, err := client.SignData(&clefclient.SignDataRequest{ContentType: "text/plain", Address: from, Data: "0x68656c6c6f"})
+	assert.NoError(t, err)
+	recovered, err := clefclient.RecoverAddress(clefclient.TextHash([]byte("hello")), resp.Signature)
+	assert.NoError(t, err)
+	assert.Equal(t, from, recovered)
+
+	_, err = client.SignTransaction(&clefclient.Transaction{From: account, To: account, Gas: "0x5208", Nonce: "0x0"})
+	assert.ErrorContains(t, err, "unknown account")
+}
+
+func TestServerErrorData(t *testing.T) {
+	clef := clefclienttest.NewServer(t)
+	clef.Handle("account_signTransaction", func(json.RawMessage) (interface{}, error) {
+		return nil, &clefclienttest.Error{
+			Code:    clefclienttest.CodeServer,
+			Message: "invalid transaction",
+			Data:    map[string]string{"field": "gas"},
+		}
+	})
+
+	_, err := clef.Client().SignTransaction(&clefclient.Transaction{From: account})
+	var rpcErr *clefclient.RPCError
+	if assert.ErrorAs(t, err, &rpcErr) {
+		assert.JSONEq(t, `{"field":"gas"}`, string(rpcErr.Data))
+	}
+	assert.EqualError(t, err, `account_signTransaction (from=`+account+`): invalid transaction: {"field":"gas"}`)
+}
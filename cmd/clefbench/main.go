@@ -0,0 +1,224 @@
+// Command clefbench drives a configurable mix of calls against a Clef endpoint
+// and reports throughput and latency percentiles, for capacity planning of
+// signing infrastructure. Signing calls need rules approving them.
+//
+//	clefbench -clef http://localhost:8550 -duration 30s -concurrency 8 -mix list=4,version=4,ecrecover=1,sign=1
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// op is a benchmarked call
+type op struct {
+	name string
+	call func(c *clefclient.ClefClient) error
+}
+
+// stats collects the outcomes of one op
+type stats struct {
+	latencies []time.Duration
+	errors    int
+	lastError error
+}
+
+func main() {
+	endpoint := flag.String("clef", "", "Clef IPC path or HTTP URL")
+	duration := flag.Duration("duration", 30*time.Second, "how long to run")
+	concurrency := flag.Int("concurrency", 4, "number of concurrent clients")
+	mix := flag.String("mix", "list=4,version=4,ecrecover=1,sign=1", "weighted calls to make, of list, version, ecrecover and sign")
+	account := flag.String("account", "", "account signing requests, the first listed account if empty")
+	flag.Parse()
+	if *endpoint == "" || *concurrency < 1 {
+		flag.Usage()
+		log.Fatal("-clef is required")
+	}
+
+	clients := make([]*clefclient.ClefClient, *concurrency)
+	for i := range clients {
+		clients[i] = connect(*endpoint)
+		defer clients[i].Close()
+	}
+	ops, err := parseMix(*mix, clients[0], *account)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	results := make(map[string]*stats)
+	for _, o := range ops {
+		results[o.name] = &stats{}
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	start := time.Now()
+	deadline := start.Add(*duration)
+	for i, client := range clients {
+		wg.Add(1)
+		go func(client *clefclient.ClefClient, seed int64) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(seed))
+			for time.Now().Before(deadline) {
+				o := ops[r.Intn(len(ops))]
+				callStart := time.Now()
+				err := o.call(client)
+				latency := time.Since(callStart)
+
+				mu.Lock()
+				s := results[o.name]
+				s.latencies = append(s.latencies, latency)
+				if err != nil {
+					s.errors++
+					s.lastError = err
+				}
+				mu.Unlock()
+			}
+		}(client, start.UnixNano()+int64(i))
+	}
+	wg.Wait()
+	report(results, time.Since(start))
+}
+
+func connect(endpoint string) *clefclient.ClefClient {
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return clefclient.NewHTTPClient(endpoint)
+	}
+	client, err := clefclient.NewIPCClient(endpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return client
+}
+
+// parseMix builds the list of ops to pick from, each op repeated by its weight
+func parseMix(mix string, client *clefclient.ClefClient, account string) ([]op, error) {
+	var ops []op
+	for _, entry := range strings.Split(mix, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(entry), "=")
+		n := 1
+		if found {
+			var err error
+			if n, err = strconv.Atoi(weight); err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid weight in %q", entry)
+			}
+		}
+		var o op
+		switch name {
+		case "list":
+			o = op{name, func(c *clefclient.ClefClient) error {
+				_, err := c.ListAccounts()
+				return err
+			}}
+		case "version":
+			o = op{name, func(c *clefclient.ClefClient) error {
+				_, err := c.Version()
+				return err
+			}}
+		case "ecrecover":
+			req, err := ecRecoverRequest()
+			if err != nil {
+				return nil, err
+			}
+			o = op{name, func(c *clefclient.ClefClient) error {
+				_, err := c.EcRecover(req)
+				return err
+			}}
+		case "sign":
+			if account == "" {
+				accounts, err := client.ListAccounts()
+				if err != nil {
+					return nil, fmt.Errorf("listing accounts for sign: %w", err)
+				}
+				if len(accounts) == 0 {
+					return nil, fmt.Errorf("no account to sign with, use -account")
+				}
+				account = accounts[0]
+			}
+			req := &clefclient.SignDataRequest{
+				ContentType: clefclient.ContentTypeTextPlain,
+				Address:     account,
+				Data:        "0x636c656662656e6368", // "clefbench"
+			}
+			o = op{name, func(c *clefclient.ClefClient) error {
+				_, err := c.SignData(req)
+				return err
+			}}
+		default:
+			return nil, fmt.Errorf("unknown call %q in mix", name)
+		}
+		for i := 0; i < n; i++ {
+			ops = append(ops, o)
+		}
+	}
+	if len(ops) == 0 {
+		return nil, fmt.Errorf("empty mix")
+	}
+	return ops, nil
+}
+
+// ecRecoverRequest returns a valid personal message signature to recover,
+// made with a throwaway key
+func ecRecoverRequest() (*clefclient.EcRecoverRequest, error) {
+	signer := clefclient.NewMemorySigner(1)
+	address, err := signer.NewAccount()
+	if err != nil {
+		return nil, err
+	}
+	data := "0x636c656662656e6368"
+	sig, err := signer.SignData(&clefclient.SignDataRequest{ContentType: clefclient.ContentTypeTextPlain, Address: address, Data: data})
+	if err != nil {
+		return nil, err
+	}
+	return &clefclient.EcRecoverRequest{Data: data, Signature: sig.Signature}, nil
+}
+
+func report(results map[string]*stats, elapsed time.Duration) {
+	names := make([]string, 0, len(results))
+	total := 0
+	for name, s := range results {
+		names = append(names, name)
+		total += len(s.latencies)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("%d calls in %s, %.1f calls/s\n\n", total, elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds())
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "call\tcalls\terrors\tcalls/s\tp50\tp90\tp99\tmax\t")
+	for _, name := range names {
+		s := results[name]
+		sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%s\t%s\t%s\t%s\t\n", name, len(s.latencies), s.errors,
+			float64(len(s.latencies))/elapsed.Seconds(),
+			percentile(s.latencies, 50), percentile(s.latencies, 90), percentile(s.latencies, 99), percentile(s.latencies, 100))
+	}
+	w.Flush()
+	for _, name := range names {
+		if err := results[name].lastError; err != nil {
+			fmt.Printf("\nlast %s error: %v", name, err)
+		}
+	}
+	fmt.Println()
+}
+
+// percentile returns the p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (len(sorted)*p + 99) / 100
+	if i > 0 {
+		i--
+	}
+	return sorted[i].Round(time.Microsecond)
+}
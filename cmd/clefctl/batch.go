@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// batchRow is a transaction to sign, read from a CSV or JSONL file
+type batchRow struct {
+	To    string `json:"to"`
+	Value string `json:"value"`
+	Data  string `json:"data"`
+	Gas   string `json:"gas"`
+}
+
+// batchResult is a line of the output of sign-batch
+type batchResult struct {
+	Row   int    `json:"row"`
+	To    string `json:"to"`
+	Nonce string `json:"nonce"`
+	Hash  string `json:"hash"`
+	Raw   string `json:"raw"`
+}
+
+// batchSummary is written with -output json once the batch is signed
+type batchSummary struct {
+	Signed int          `json:"signed"`
+	Failed int          `json:"failed"`
+	Errors []batchError `json:"errors,omitempty"`
+}
+
+type batchError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// signBatch signs the transactions of a CSV or JSONL file one by one and
+// writes the signed transactions as JSON lines for later broadcast
+func signBatch(fs *flag.FlagSet, out *output) func() error {
+	endpoint := clefFlag(fs)
+	account := fs.String("account", "", "account to sign with")
+	in := fs.String("in", "", "CSV file with a to, value, data and optional gas header, or JSONL file of such objects")
+	outFile := fs.String("out", "", "file to write the signed transactions to, as JSON lines")
+	chainID := fs.String("chain-id", "", "chain ID of the transactions")
+	nonce := fs.String("nonce", "", "nonce of the first transaction, fetched from -node if empty")
+	node := fs.String("node", "", "node RPC URL to fetch the nonce from")
+	gas := fs.String("gas", "0x5208", "gas limit of rows without one")
+	maxFee := fs.String("max-fee", "", "maxFeePerGas, in wei")
+	maxPriorityFee := fs.String("max-priority-fee", "", "maxPriorityFeePerGas, in wei")
+	gasPrice := fs.String("gas-price", "", "legacy gas price, in wei, instead of -max-fee")
+	return func() error {
+		if *account == "" || *in == "" || *outFile == "" || *chainID == "" {
+			fs.Usage()
+			return fmt.Errorf("-account, -in, -out and -chain-id are required")
+		}
+		if (*gasPrice == "") == (*maxFee == "") {
+			return fmt.Errorf("one of -gas-price and -max-fee is required")
+		}
+
+		rows, err := readBatch(*in)
+		if err != nil {
+			return err
+		}
+
+		template := clefclient.Transaction{From: *account}
+		for _, f := range []struct {
+			name  string
+			value string
+			field *string
+		}{
+			{"-chain-id", *chainID, &template.ChainID},
+			{"-max-fee", *maxFee, &template.MaxFeePerGas},
+			{"-max-priority-fee", *maxPriorityFee, &template.MaxPriorityFeePerGas},
+			{"-gas-price", *gasPrice, &template.GasPrice},
+		} {
+			if f.value == "" {
+				continue
+			}
+			if *f.field, err = quantity(f.value); err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+
+		next, err := firstNonce(*nonce, *node, *account)
+		if err != nil {
+			return err
+		}
+
+		client, err := connect(*endpoint)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		f, err := os.OpenFile(*outFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		summary := signRows(client, rows, template, *gas, next, json.NewEncoder(f))
+		if err := f.Sync(); err != nil {
+			return err
+		}
+		if out.json() {
+			if err := out.writeJSON(summary); err != nil {
+				return err
+			}
+		}
+		if summary.Failed > 0 {
+			return errCheckFailed
+		}
+		return nil
+	}
+}
+
+// signRows signs the rows one by one, writing the signed transactions to
+// enc. Rows that fail do not use up a nonce, so the signed transactions stay
+// sequential.
+func signRows(signer clefclient.Signer, rows []batchRow, template clefclient.Transaction, gas string, next *big.Int, enc *json.Encoder) batchSummary {
+	var summary batchSummary
+	for i, row := range rows {
+		tx, err := row.transaction(template, gas, next)
+		if err == nil {
+			var signed *clefclient.SignTxResponse
+			if signed, err = signer.SignTransaction(tx); err == nil {
+				err = enc.Encode(batchResult{Row: i + 1, To: tx.To, Nonce: tx.Nonce, Hash: signed.Tx.Hash, Raw: signed.Raw})
+				next.Add(next, big.NewInt(1))
+			}
+		}
+		if err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, batchError{Row: i + 1, Error: err.Error()})
+			fmt.Fprintf(os.Stderr, "\nrow %d: %v\n", i+1, err)
+		} else {
+			summary.Signed++
+		}
+		fmt.Fprintf(os.Stderr, "\r%d/%d signed, %d failed", summary.Signed, len(rows), summary.Failed)
+	}
+	fmt.Fprintln(os.Stderr)
+	return summary
+}
+
+// readBatch reads the rows of a .csv file, or of a JSONL file otherwise
+func readBatch(path string) ([]batchRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return readBatchCSV(f)
+	}
+
+	var rows []batchRow
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var row batchRow
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+func readBatchCSV(r io.Reader) ([]batchRow, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := map[string]int{}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["to"]; !ok {
+		return nil, fmt.Errorf("csv header has no to column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	rows := make([]batchRow, 0, len(records)-1)
+	for _, record := range records[1:] {
+		rows = append(rows, batchRow{To: field(record, "to"), Value: field(record, "value"), Data: field(record, "data"), Gas: field(record, "gas")})
+	}
+	return rows, nil
+}
+
+// transaction builds the transaction of the row
+func (row batchRow) transaction(template clefclient.Transaction, gas string, nonce *big.Int) (*clefclient.Transaction, error) {
+	tx := template
+	tx.To = row.To
+	tx.Data = row.Data
+	tx.Nonce = fmt.Sprintf("0x%x", nonce)
+	var err error
+	if row.Value != "" {
+		if tx.Value, err = quantity(row.Value); err != nil {
+			return nil, fmt.Errorf("value: %w", err)
+		}
+	}
+	if row.Gas != "" {
+		gas = row.Gas
+	}
+	if tx.Gas, err = quantity(gas); err != nil {
+		return nil, fmt.Errorf("gas: %w", err)
+	}
+	if err := tx.Validate(); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// firstNonce returns the nonce given, or the pending nonce of account on
+// node
+func firstNonce(nonce, node, account string) (*big.Int, error) {
+	if nonce == "" && node == "" {
+		return nil, fmt.Errorf("one of -nonce and -node is required")
+	}
+	if nonce == "" {
+		nc := clefclient.NewNodeClient(node)
+		defer nc.Close()
+		var err error
+		if nonce, err = nc.PendingNonceAt(account); err != nil {
+			return nil, fmt.Errorf("failed to fetch nonce: %w", err)
+		}
+	}
+	n, ok := parseInteger(nonce)
+	if !ok {
+		return nil, fmt.Errorf("invalid nonce %q", nonce)
+	}
+	return n, nil
+}
+
+// quantity converts a decimal or 0x-prefixed hex integer to a hex quantity
+func quantity(s string) (string, error) {
+	n, ok := parseInteger(s)
+	if !ok {
+		return "", fmt.Errorf("invalid integer %q", s)
+	}
+	return fmt.Sprintf("0x%x", n), nil
+}
+
+// parseInteger parses a non-negative decimal or 0x-prefixed hex integer
+func parseInteger(s string) (*big.Int, bool) {
+	base := 10
+	if hex, ok := strings.CutPrefix(s, "0x"); ok {
+		s, base = hex, 16
+	}
+	n, ok := new(big.Int).SetString(s, base)
+	if !ok || n.Sign() < 0 {
+		return nil, false
+	}
+	return n, true
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	alice = "0x0000000000000000000000000000000000000001"
+	bob   = "0x0000000000000000000000000000000000000002"
+	carol = "0x0000000000000000000000000000000000000003"
+)
+
+// denyingSigner signs every transaction except those to denied
+type denyingSigner struct {
+	denied string
+}
+
+func (s *denyingSigner) ListAccounts() ([]string, error) {
+	return nil, nil
+}
+
+func (s *denyingSigner) SignTransaction(tx *clefclient.Transaction) (*clefclient.SignTxResponse, error) {
+	if strings.EqualFold(tx.To, s.denied) {
+		return nil, errors.New("Request denied")
+	}
+	signed := &clefclient.SignTxResponse{Raw: "0xraw" + tx.Nonce}
+	signed.Tx.Hash = "0xhash" + tx.Nonce
+	return signed, nil
+}
+
+func (s *denyingSigner) SignData(req *clefclient.SignDataRequest) (*clefclient.SignDataResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *denyingSigner) SignTypedData(req *clefclient.TypedDataRequest) (*clefclient.SignDataResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestSignRowsNonces(t *testing.T) {
+	template := clefclient.Transaction{From: alice, ChainID: "0x1", GasPrice: "0x1"}
+	for _, tc := range []struct {
+		name   string
+		rows   []batchRow
+		nonces []string
+		failed []int
+	}{
+		{
+			name:   "all signed",
+			rows:   []batchRow{{To: bob}, {To: bob, Value: "10"}},
+			nonces: []string{"0x5", "0x6"},
+		},
+		{
+			name:   "invalid row",
+			rows:   []batchRow{{To: bob}, {To: bob, Value: "ten"}, {To: bob}},
+			nonces: []string{"0x5", "0x6"},
+			failed: []int{2},
+		},
+		{
+			name:   "denied row",
+			rows:   []batchRow{{To: carol}, {To: bob}, {To: carol}, {To: bob}},
+			nonces: []string{"0x5", "0x6"},
+			failed: []int{1, 3},
+		},
+		{
+			name:   "gas below intrinsic gas",
+			rows:   []batchRow{{To: bob, Gas: "100"}, {To: bob}},
+			nonces: []string{"0x5"},
+			failed: []int{1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			next := big.NewInt(5)
+			summary := signRows(&denyingSigner{denied: carol}, tc.rows, template, "0x5208", next, json.NewEncoder(&out))
+
+			var nonces []string
+			dec := json.NewDecoder(&out)
+			for dec.More() {
+				var result batchResult
+				assert.NoError(t, dec.Decode(&result))
+				assert.Equal(t, "0xraw"+result.Nonce, result.Raw)
+				nonces = append(nonces, result.Nonce)
+			}
+			var failed []int
+			for _, e := range summary.Errors {
+				failed = append(failed, e.Row)
+			}
+			assert.Equal(t, tc.nonces, nonces)
+			assert.Equal(t, tc.failed, failed)
+			assert.Equal(t, len(tc.nonces), summary.Signed)
+			assert.Equal(t, len(tc.failed), summary.Failed)
+			assert.Equal(t, int64(5+len(tc.nonces)), next.Int64())
+		})
+	}
+}
+
+func TestReadBatchCSV(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		csv  string
+		rows []batchRow
+		err  string
+	}{
+		{
+			name: "all columns",
+			csv:  "to,value,data,gas\n" + bob + ",1,0x12,0x6000\n",
+			rows: []batchRow{{To: bob, Value: "1", Data: "0x12", Gas: "0x6000"}},
+		},
+		{
+			name: "reordered and padded columns",
+			csv:  " Data , TO ,Value\n0x12, " + bob + " ,0x1\n",
+			rows: []batchRow{{To: bob, Value: "0x1", Data: "0x12"}},
+		},
+		{
+			name: "only to",
+			csv:  "to\n" + bob + "\n" + carol + "\n",
+			rows: []batchRow{{To: bob}, {To: carol}},
+		},
+		{
+			name: "unknown columns",
+			csv:  "to,memo\n" + bob + ",rent\n",
+			rows: []batchRow{{To: bob}},
+		},
+		{
+			name: "header only",
+			csv:  "to,value\n",
+			rows: []batchRow{},
+		},
+		{
+			name: "empty",
+		},
+		{
+			name: "no to column",
+			csv:  "value,data\n1,0x\n",
+			err:  "csv header has no to column",
+		},
+		{
+			name: "ragged rows",
+			csv:  "to,value\n" + bob + "\n",
+			err:  "wrong number of fields",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rows, err := readBatchCSV(strings.NewReader(tc.csv))
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.rows, rows)
+		})
+	}
+}
+
+func TestParseInteger(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		quantity string
+	}{
+		{"0", "0x0"},
+		{"21000", "0x5208"},
+		{"0x5208", "0x5208"},
+		{"0x0", "0x0"},
+		{"115792089237316195423570985008687907853269984665640564039457584007913129639935", "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"},
+		{"", ""},
+		{"0x", ""},
+		{"-1", ""},
+		{"0x-1", ""},
+		{"1.5", ""},
+		{"0xzz", ""},
+		{"1e18", ""},
+	} {
+		n, ok := parseInteger(tc.in)
+		assert.Equal(t, tc.quantity != "", ok, tc.in)
+		q, err := quantity(tc.in)
+		if tc.quantity == "" {
+			assert.Nil(t, n, tc.in)
+			assert.ErrorContains(t, err, "invalid integer", tc.in)
+			continue
+		}
+		assert.NoError(t, err, tc.in)
+		assert.Equal(t, tc.quantity, q)
+		assert.Equal(t, tc.quantity, "0x"+n.Text(16))
+	}
+}
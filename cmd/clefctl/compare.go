@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// compare checks that a new Clef instance can replace an old one
+func compare(fs *flag.FlagSet, out *output) func() error {
+	oldEndpoint := fs.String("old", "", "IPC path or HTTP URL of the Clef being replaced")
+	newEndpoint := fs.String("new", "", "IPC path or HTTP URL of the replacing Clef")
+	checks := fs.String("checks", "", "JSONL file of {\"data\",\"sig\"} personal message signatures to recover, built-in checks if empty")
+	return func() error {
+		if *oldEndpoint == "" || *newEndpoint == "" {
+			fs.Usage()
+			return fmt.Errorf("-old and -new are required")
+		}
+		requests := clefclient.MigrationChecks()
+		if *checks != "" {
+			var err error
+			if requests, err = readChecks(*checks); err != nil {
+				return err
+			}
+		}
+
+		old, err := connect(*oldEndpoint)
+		if err != nil {
+			return err
+		}
+		defer old.Close()
+		replacement, err := connect(*newEndpoint)
+		if err != nil {
+			return err
+		}
+		defer replacement.Close()
+
+		report, err := clefclient.CompareSigners(old, replacement, requests)
+		if err != nil {
+			return err
+		}
+		if out.json() {
+			err = out.writeJSON(report)
+		} else {
+			writeComparison(report)
+		}
+		if err == nil && !report.OK() {
+			return errCheckFailed
+		}
+		return err
+	}
+}
+
+// readChecks reads ecRecover requests from a JSONL file
+func readChecks(path string) ([]*clefclient.EcRecoverRequest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var checks []*clefclient.EcRecoverRequest
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var check clefclient.EcRecoverRequest
+		if err := json.Unmarshal(scanner.Bytes(), &check); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		checks = append(checks, &check)
+	}
+	return checks, scanner.Err()
+}
+
+func writeComparison(r *clefclient.MigrationReport) {
+	fmt.Printf("version: %s -> %s\n", r.OldVersion, r.NewVersion)
+	fmt.Printf("accounts: %d on both, %d missing, %d added\n", len(r.Accounts), len(r.MissingAccounts), len(r.AddedAccounts))
+	fmt.Printf("ecRecover checks: %d\n", r.Checks)
+	if r.OK() {
+		fmt.Println("no discrepancies")
+		return
+	}
+	fmt.Printf("%d discrepancies:\n", len(r.Discrepancies))
+	for _, d := range r.Discrepancies {
+		fmt.Printf("  %s: %s\n", d.Check, d.Detail)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// completion prints a completion script for the shell named by its argument
+//
+//	source <(clefctl completion bash)
+//	clefctl completion zsh > "${fpath[1]}/_clefctl"
+//	clefctl completion fish > ~/.config/fish/completions/clefctl.fish
+func completion(fs *flag.FlagSet, out *output) func() error {
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: clefctl completion bash|zsh|fish")
+	}
+	return func() error {
+		switch shell := fs.Arg(0); shell {
+		case "bash":
+			return writeBashCompletion(os.Stdout)
+		case "zsh":
+			return writeZshCompletion(os.Stdout)
+		case "fish":
+			return writeFishCompletion(os.Stdout)
+		case "":
+			fs.Usage()
+			return fmt.Errorf("a shell is required")
+		default:
+			return fmt.Errorf("unsupported shell %q, use bash, zsh or fish", shell)
+		}
+	}
+}
+
+// completionFlags returns the flags of c, sorted by name
+func completionFlags(c command) []*flag.Flag {
+	fs, out := newFlagSet(c)
+	c.setup(fs, out)
+	var flags []*flag.Flag
+	fs.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, f)
+	})
+	return flags
+}
+
+func commandNames() string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return strings.Join(names, " ")
+}
+
+func writeBashCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# bash completion for clefctl\n_clefctl() {\n")
+	b.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(&b, "\tif [ \"$COMP_CWORD\" -eq 1 ]; then\n\t\tCOMPREPLY=($(compgen -W %q -- \"$cur\"))\n\t\treturn\n\tfi\n", commandNames())
+	b.WriteString("\tcase \"$prev\" in\n\t-output) COMPREPLY=($(compgen -W \"table json\" -- \"$cur\")); return ;;\n\tesac\n")
+	b.WriteString("\tlocal flags\n\tcase \"${COMP_WORDS[1]}\" in\n")
+	for _, c := range commands {
+		if c.name == "completion" {
+			fmt.Fprintf(&b, "\tcompletion) COMPREPLY=($(compgen -W \"bash zsh fish\" -- \"$cur\")); return ;;\n")
+			continue
+		}
+		var names []string
+		for _, f := range completionFlags(c) {
+			names = append(names, "-"+f.Name)
+		}
+		fmt.Fprintf(&b, "\t%s) flags=%q ;;\n", c.name, strings.Join(names, " "))
+	}
+	b.WriteString("\tesac\n\tif [[ \"$cur\" == -* ]]; then\n\t\tCOMPREPLY=($(compgen -W \"$flags\" -- \"$cur\"))\n\telse\n\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n\tfi\n}\n")
+	b.WriteString("complete -F _clefctl clefctl\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeZshCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("#compdef clefctl\n\n_clefctl() {\n\tlocal -a commands\n\tcommands=(\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "\t\t'%s:%s'\n", c.name, zshQuote(c.summary))
+	}
+	b.WriteString("\t)\n\tif (( CURRENT == 2 )); then\n\t\t_describe command commands\n\t\treturn\n\tfi\n")
+	b.WriteString("\tcase $words[2] in\n")
+	for _, c := range commands {
+		if c.name == "completion" {
+			b.WriteString("\tcompletion) _values shell bash zsh fish ;;\n")
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s)\n\t\t_arguments", c.name)
+		for _, f := range completionFlags(c) {
+			action := ":value:_files"
+			if f.Name == "output" {
+				action = ":format:(table json)"
+			}
+			fmt.Fprintf(&b, " \\\n\t\t\t'-%s[%s]%s'", f.Name, zshQuote(f.Usage), action)
+		}
+		b.WriteString("\n\t\t;;\n")
+	}
+	b.WriteString("\tesac\n}\n\n_clefctl \"$@\"\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeFishCompletion(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("# fish completion for clefctl\ncomplete -c clefctl -f\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "complete -c clefctl -n __fish_use_subcommand -a %s -d '%s'\n", c.name, fishQuote(c.summary))
+	}
+	for _, c := range commands {
+		cond := "__fish_seen_subcommand_from " + c.name
+		if c.name == "completion" {
+			fmt.Fprintf(&b, "complete -c clefctl -n '%s' -a 'bash zsh fish'\n", cond)
+			continue
+		}
+		for _, f := range completionFlags(c) {
+			// fish offers -name as an old style option
+			fmt.Fprintf(&b, "complete -c clefctl -n '%s' -o %s -d '%s'", cond, f.Name, fishQuote(f.Usage))
+			if f.Name == "output" {
+				b.WriteString(" -x -a 'table json'")
+			} else if !isBoolFlag(f) {
+				b.WriteString(" -r -F")
+			}
+			b.WriteString("\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func zshQuote(s string) string {
+	s = strings.ReplaceAll(s, "'", `'\''`)
+	s = strings.ReplaceAll(s, "[", `\[`)
+	s = strings.ReplaceAll(s, "]", `\]`)
+	return strings.ReplaceAll(s, ":", `\:`)
+}
+
+func fishQuote(s string) string {
+	return strings.ReplaceAll(s, "'", `\'`)
+}
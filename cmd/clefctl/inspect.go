@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// accountReport is everything known about an account
+type accountReport struct {
+	Address string `json:"address"`
+	// Managed is nil when Clef was not asked
+	Managed *bool `json:"managed,omitempty"`
+	// PendingNonce is the next nonce according to the node
+	PendingNonce string `json:"pendingNonce,omitempty"`
+	// Usage counts the requests of the audit log by method and outcome
+	Usage     map[string]map[string]int `json:"usage,omitempty"`
+	FirstSeen *time.Time                `json:"firstSeen,omitempty"`
+	LastSeen  *time.Time                `json:"lastSeen,omitempty"`
+	// Recent are the most recent transactions signed according to the
+	// audit log, newest first
+	Recent []signedTx `json:"recent,omitempty"`
+}
+
+// signedTx is a transaction signed for an account
+type signedTx struct {
+	Time    time.Time `json:"time"`
+	Hash    string    `json:"hash,omitempty"`
+	To      string    `json:"to"`
+	Value   string    `json:"value,omitempty"`
+	Nonce   string    `json:"nonce,omitempty"`
+	ChainID string    `json:"chainId,omitempty"`
+}
+
+// inspect reports what Clef, a node and the audit log know about an account
+func inspect(fs *flag.FlagSet, out *output) func() error {
+	endpoint := clefFlag(fs)
+	account := fs.String("account", "", "account to inspect")
+	audit := fs.String("audit", "", "audit log written by FileAuditStore, for usage and recent transactions")
+	node := fs.String("node", "", "node RPC URL, for the pending nonce")
+	recent := fs.Int("recent", 10, "number of recent transactions to show")
+	return func() error {
+		if *account == "" {
+			fs.Usage()
+			return fmt.Errorf("-account is required")
+		}
+		address, err := clefclient.ChecksumAddress(*account)
+		if err != nil {
+			return err
+		}
+
+		report := &accountReport{Address: address}
+		if *endpoint != "" {
+			client, err := connect(*endpoint)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			managed, err := client.HasAccount(address)
+			if err != nil {
+				return err
+			}
+			report.Managed = &managed
+		}
+		if *node != "" {
+			nc := clefclient.NewNodeClient(*node)
+			defer nc.Close()
+			if report.PendingNonce, err = nc.PendingNonceAt(address); err != nil {
+				return fmt.Errorf("failed to fetch nonce: %w", err)
+			}
+		}
+		if *audit != "" {
+			if err := report.readAudit(*audit, *recent); err != nil {
+				return err
+			}
+		}
+		if out.json() {
+			return out.writeJSON(report)
+		}
+		return report.write(os.Stdout)
+	}
+}
+
+// readAudit collects the usage and recent transactions of the account from
+// an audit log
+func (r *accountReport) readAudit(path string, recent int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		var entry clefclient.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if !strings.EqualFold(entry.Account, r.Address) {
+			continue
+		}
+
+		if r.Usage == nil {
+			r.Usage = map[string]map[string]int{}
+		}
+		if r.Usage[entry.Method] == nil {
+			r.Usage[entry.Method] = map[string]int{}
+		}
+		r.Usage[entry.Method][entry.Outcome]++
+		if r.FirstSeen == nil || entry.Time.Before(*r.FirstSeen) {
+			r.FirstSeen = &entry.Time
+		}
+		if r.LastSeen == nil || entry.Time.After(*r.LastSeen) {
+			r.LastSeen = &entry.Time
+		}
+
+		if entry.Method == "account_signTransaction" && entry.Outcome == clefclient.AuditOutcomeSuccess && entry.Tx != nil {
+			tx := signedTx{Time: entry.Time, To: entry.Tx.To, Value: entry.Tx.Value, Nonce: entry.Tx.Nonce, ChainID: entry.Tx.ChainID}
+			var resp clefclient.SignTxResponse
+			if json.Unmarshal(entry.Response, &resp) == nil {
+				tx.Hash = resp.Tx.Hash
+			}
+			r.Recent = append(r.Recent, tx)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	sort.SliceStable(r.Recent, func(i, j int) bool { return r.Recent[i].Time.After(r.Recent[j].Time) })
+	if len(r.Recent) > recent {
+		r.Recent = r.Recent[:recent]
+	}
+	return nil
+}
+
+// write writes the report as text
+func (r *accountReport) write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "address:\t%s\n", r.Address)
+	if r.Managed != nil {
+		fmt.Fprintf(tw, "managed by clef:\t%t\n", *r.Managed)
+	}
+	if r.PendingNonce != "" {
+		fmt.Fprintf(tw, "pending nonce:\t%s\n", r.PendingNonce)
+	}
+	if r.FirstSeen != nil {
+		fmt.Fprintf(tw, "first seen:\t%s\n", r.FirstSeen.Local().Format(time.RFC3339))
+		fmt.Fprintf(tw, "last seen:\t%s\n", r.LastSeen.Local().Format(time.RFC3339))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Usage) > 0 {
+		fmt.Fprintln(w, "\nusage:")
+		methods := make([]string, 0, len(r.Usage))
+		for method := range r.Usage {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			fmt.Fprintf(tw, "  %s\tsuccess %d\terror %d\n", method, r.Usage[method][clefclient.AuditOutcomeSuccess], r.Usage[method][clefclient.AuditOutcomeError])
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(r.Recent) > 0 {
+		fmt.Fprintln(w, "\nrecent transactions:")
+		fmt.Fprintln(tw, "  time\tnonce\tto\tvalue\tchain\thash")
+		for _, tx := range r.Recent {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\t%s\n", tx.Time.Local().Format(time.RFC3339), tx.Nonce, tx.To, tx.Value, tx.ChainID, tx.Hash)
+		}
+		return tw.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Command clefctl is a command line client for Clef, for operators and
+// support engineers.
+//
+//	clefctl sign-typed-data -clef ~/.clef/clef.ipc -account 0x... -file permit.json
+//	clefctl verify -message "hello" -sig 0x... -address 0x...
+//	clefctl sign-batch -account 0x... -in payouts.csv -out signed.jsonl -chain-id 1 -max-fee 30000000000 -node http://localhost:8545
+//	clefctl watch -file /var/log/clef-audit.jsonl
+//	clefctl inspect -account 0x... -audit /var/log/clef-audit.jsonl -node http://localhost:8545
+//	clefctl compare -old ~/.clef/clef.ipc -new http://clef-new:8550
+//	clefctl completion bash
+//
+// Every command takes -output json to write its results as JSON instead of
+// text; watch writes a JSON object per line.
+//
+// It exits with 1 when a check fails and with 2 on errors.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// command is a subcommand of clefctl
+type command struct {
+	name    string
+	summary string
+	// setup defines the flags of the command on fs and returns the function
+	// running it once they are parsed
+	setup func(fs *flag.FlagSet, out *output) func() error
+}
+
+var commands = []command{
+	{"sign-typed-data", "review and sign an EIP-712 typed data file", signTypedData},
+	{"verify", "recover the signer of a signature and compare it", verify},
+	{"sign-batch", "sign the transactions of a CSV or JSONL file", signBatch},
+	{"watch", "follow signer activity live", watch},
+	{"inspect", "show everything known about an account", inspect},
+	{"compare", "compare two Clef instances before a migration", compare},
+}
+
+func init() {
+	// completion lists the commands, so it cannot be part of their
+	// initialization
+	commands = append(commands, command{"completion", "print a bash, zsh or fish completion script", completion})
+}
+
+// errCheckFailed is returned by commands whose check failed, as opposed to
+// commands that could not run
+var errCheckFailed = errors.New("check failed")
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("clefctl: ")
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			fs, out := newFlagSet(c)
+			run := c.setup(fs, out)
+			fs.Parse(os.Args[2:])
+			err := out.check()
+			if err == nil {
+				err = run()
+			}
+			if errors.Is(err, errCheckFailed) {
+				os.Exit(1)
+			}
+			if err != nil {
+				log.Print(err)
+				os.Exit(2)
+			}
+			return
+		}
+	}
+	usage()
+	os.Exit(2)
+}
+
+// newFlagSet creates the flag set of c with the flags common to all commands
+func newFlagSet(c command) (*flag.FlagSet, *output) {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	out := &output{}
+	fs.StringVar(&out.format, "output", "table", "output format: table or json")
+	return fs, out
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: clefctl <command> [flags]\n\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", c.name, c.summary)
+	}
+}
+
+// output is how a command writes its results, selected with -output
+type output struct {
+	format string
+}
+
+func (o *output) check() error {
+	if o.format != "table" && o.format != "json" {
+		return fmt.Errorf("unknown output format %q, use table or json", o.format)
+	}
+	return nil
+}
+
+// json reports whether results are written as JSON
+func (o *output) json() bool {
+	return o.format == "json"
+}
+
+// writeJSON writes v as indented JSON to standard output
+func (o *output) writeJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeLine writes v as a line of JSON to standard output, for commands
+// producing a stream of results
+func (o *output) writeLine(v interface{}) error {
+	return json.NewEncoder(os.Stdout).Encode(v)
+}
+
+// clefFlag adds the flag selecting the Clef endpoint to fs
+func clefFlag(fs *flag.FlagSet) *string {
+	return fs.String("clef", os.Getenv("CLEF_ENDPOINT"), "Clef IPC path or HTTP URL, $CLEF_ENDPOINT if unset")
+}
+
+// connect creates a client for endpoint. Plaintext HTTP endpoints that are
+// not loopback are refused unless $CLEF_INSECURE_HTTP is set. The public
+// keys of $CLEF_PINNED_KEYS, separated by commas, are pinned.
+func connect(endpoint string) (*clefclient.ClefClient, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("-clef is required")
+	}
+	opts := []clefclient.Option{clefclient.WithClientID("clefctl")}
+	if os.Getenv("CLEF_INSECURE_HTTP") == "" {
+		opts = append(opts, clefclient.WithHTTPSOnly())
+	}
+	if pins := os.Getenv("CLEF_PINNED_KEYS"); pins != "" {
+		opts = append(opts, clefclient.WithPinnedPublicKeys(strings.Split(pins, ",")...))
+	}
+	return clefclient.NewClient(context.Background(), endpoint, opts...)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// signTypedData prints an EIP-712 document and its digest for review, has
+// Clef sign it and writes the signature
+func signTypedData(fs *flag.FlagSet, out *output) func() error {
+	endpoint := clefFlag(fs)
+	account := fs.String("account", "", "account to sign with")
+	file := fs.String("file", "", "EIP-712 JSON file, - for standard input")
+	format := fs.String("format", "hex", "signature format of table output: hex, rsv or base64")
+	return func() error {
+		if *account == "" || *file == "" {
+			fs.Usage()
+			return fmt.Errorf("-account and -file are required")
+		}
+		if !validSignatureFormat(*format) {
+			return fmt.Errorf("unknown format %q", *format)
+		}
+
+		td, err := readTypedData(*file)
+		if err != nil {
+			return err
+		}
+		digest, err := td.Hash()
+		if err != nil {
+			return fmt.Errorf("invalid typed data: %w", err)
+		}
+		if err := printReview(os.Stderr, td, digest); err != nil {
+			return err
+		}
+
+		client, err := connect(*endpoint)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		req, err := td.Request(*account)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "waiting for approval in Clef...")
+		resp, err := client.SignTypedData(req)
+		if err != nil {
+			return err
+		}
+
+		// Make sure Clef signed the digest that was reviewed
+		signer, err := clefclient.RecoverAddress(digest, resp.Signature)
+		if err != nil {
+			return fmt.Errorf("invalid signature from Clef: %w", err)
+		}
+		if !strings.EqualFold(signer, *account) {
+			return fmt.Errorf("signature was made by %s over a different digest or with another account", signer)
+		}
+		result, err := newSignatureResult(signer, digest, resp.Signature)
+		if err != nil {
+			return err
+		}
+		if out.json() {
+			return out.writeJSON(result)
+		}
+		return result.write(os.Stdout, *format)
+	}
+}
+
+// readTypedData reads an EIP-712 document, keeping numbers exact
+func readTypedData(path string) (*clefclient.TypedData, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var td clefclient.TypedData
+	if err := dec.Decode(&td); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &td, nil
+}
+
+// printReview writes the domain, message and digest of td
+func printReview(w io.Writer, td *clefclient.TypedData, digest []byte) error {
+	domain, err := json.MarshalIndent(td.Domain, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	message, err := json.MarshalIndent(td.Message, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "domain:\n  %s\n", domain)
+	fmt.Fprintf(w, "primary type: %s\n", td.PrimaryType)
+	fmt.Fprintf(w, "message:\n  %s\n", message)
+	fmt.Fprintf(w, "digest: 0x%x\n", digest)
+	return nil
+}
+
+func validSignatureFormat(format string) bool {
+	switch format {
+	case "hex", "rsv", "base64":
+		return true
+	}
+	return false
+}
+
+// signatureResult is a signature with its parts
+type signatureResult struct {
+	Signer    string `json:"signer"`
+	Digest    string `json:"digest"`
+	Signature string `json:"signature"`
+	R         string `json:"r"`
+	S         string `json:"s"`
+	V         byte   `json:"v"`
+
+	raw []byte
+}
+
+// newSignatureResult splits a 65 byte signature into its parts
+func newSignatureResult(signer string, digest []byte, signature string) (*signatureResult, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return nil, fmt.Errorf("invalid signature %q", signature)
+	}
+	return &signatureResult{
+		Signer:    signer,
+		Digest:    "0x" + hex.EncodeToString(digest),
+		Signature: signature,
+		R:         "0x" + hex.EncodeToString(sig[:32]),
+		S:         "0x" + hex.EncodeToString(sig[32:64]),
+		V:         sig[64],
+		raw:       sig,
+	}, nil
+}
+
+// write writes the signature in format
+func (r *signatureResult) write(w io.Writer, format string) error {
+	var err error
+	switch format {
+	case "rsv":
+		_, err = fmt.Fprintf(w, "r: %s\ns: %s\nv: %d\n", r.R, r.S, r.V)
+	case "base64":
+		_, err = fmt.Fprintln(w, base64.StdEncoding.EncodeToString(r.raw))
+	default:
+		_, err = fmt.Fprintln(w, r.Signature)
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"strings"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// verify recovers the signer of a signature over a message, hex data, typed
+// data or a digest, locally or with account_ecRecover, and compares it with
+// the expected address
+func verify(fs *flag.FlagSet, out *output) func() error {
+	endpoint := clefFlag(fs)
+	message := fs.String("message", "", "signed text, as a personal message")
+	data := fs.String("data", "", "signed 0x-prefixed hex data, as a personal message")
+	typedData := fs.String("typed-data", "", "signed EIP-712 JSON file")
+	digest := fs.String("digest", "", "signed 0x-prefixed 32 byte hash")
+	sig := fs.String("sig", "", "0x-prefixed 65 byte signature")
+	address := fs.String("address", "", "expected signer, exit with 1 if it differs")
+	remote := fs.Bool("remote", false, "recover with account_ecRecover of Clef instead of locally")
+	return func() error {
+		var inputs int
+		for _, s := range []string{*message, *data, *typedData, *digest} {
+			if s != "" {
+				inputs++
+			}
+		}
+		if inputs != 1 || *sig == "" {
+			fs.Usage()
+			return fmt.Errorf("-sig and one of -message, -data, -typed-data and -digest are required")
+		}
+
+		var signer string
+		var err error
+		if *remote {
+			signer, err = recoverRemote(*endpoint, *message, *data, *sig)
+		} else {
+			signer, err = recoverLocal(*message, *data, *typedData, *digest, *sig)
+		}
+		if err != nil {
+			return err
+		}
+		if signer, err = clefclient.ChecksumAddress(signer); err != nil {
+			return err
+		}
+		result := verifyResult{Signer: signer}
+		if *address != "" {
+			result.Expected = checksummed(*address)
+			match := strings.EqualFold(signer, *address)
+			result.Match = &match
+		}
+		if err := result.write(out); err != nil {
+			return err
+		}
+		if result.Match != nil && !*result.Match {
+			return errCheckFailed
+		}
+		return nil
+	}
+}
+
+// verifyResult is the outcome of verify
+type verifyResult struct {
+	Signer   string `json:"signer"`
+	Expected string `json:"expected,omitempty"`
+	Match    *bool  `json:"match,omitempty"`
+}
+
+func (r *verifyResult) write(out *output) error {
+	if out.json() {
+		return out.writeJSON(r)
+	}
+	fmt.Printf("signer: %s\n", r.Signer)
+	switch {
+	case r.Match == nil:
+	case *r.Match:
+		fmt.Println("match")
+	default:
+		fmt.Printf("mismatch: expected %s\n", r.Expected)
+	}
+	return nil
+}
+
+// recoverLocal recovers the signer without contacting Clef
+func recoverLocal(message, data, typedData, digest, sig string) (string, error) {
+	var hash []byte
+	switch {
+	case message != "":
+		hash = clefclient.TextHash([]byte(message))
+	case data != "":
+		b, err := decodeHex(data)
+		if err != nil {
+			return "", fmt.Errorf("-data: %w", err)
+		}
+		hash = clefclient.TextHash(b)
+	case typedData != "":
+		td, err := readTypedData(typedData)
+		if err != nil {
+			return "", err
+		}
+		if hash, err = td.Hash(); err != nil {
+			return "", fmt.Errorf("invalid typed data: %w", err)
+		}
+	default:
+		b, err := decodeHex(digest)
+		if err != nil || len(b) != 32 {
+			return "", fmt.Errorf("-digest must be 32 bytes of hex")
+		}
+		hash = b
+	}
+	return clefclient.RecoverAddress(hash, sig)
+}
+
+// recoverRemote recovers the signer of a personal message with Clef
+func recoverRemote(endpoint, message, data, sig string) (string, error) {
+	if message == "" && data == "" {
+		return "", fmt.Errorf("-remote only recovers personal messages, given with -message or -data")
+	}
+	if message != "" {
+		data = "0x" + hex.EncodeToString([]byte(message))
+	}
+	client, err := connect(endpoint)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	resp, err := client.EcRecover(&clefclient.EcRecoverRequest{Data: data, Signature: sig})
+	if err != nil {
+		return "", err
+	}
+	return resp.Address, nil
+}
+
+func decodeHex(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") {
+		return nil, fmt.Errorf("%q is missing the 0x prefix", s)
+	}
+	return hex.DecodeString(s[2:])
+}
+
+// checksummed returns the checksummed form of address, or address if it is
+// invalid
+func checksummed(address string) string {
+	if c, err := clefclient.ChecksumAddress(address); err == nil {
+		return c
+	}
+	return address
+}
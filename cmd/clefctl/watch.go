@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// activity is a line of an audit log written by a FileAuditStore, or a
+// signing event written by a BufferedSink
+type activity struct {
+	Time      time.Time             `json:"time"`
+	Requested time.Time             `json:"requested"`
+	Event     string                `json:"event"`
+	Method    string                `json:"method"`
+	Account   string                `json:"account"`
+	ClientID  string                `json:"clientId"`
+	Outcome   string                `json:"outcome"`
+	Error     string                `json:"error"`
+	Latency   time.Duration         `json:"latency"`
+	Duration  time.Duration         `json:"duration"`
+	Tx        *clefclient.TxSummary `json:"tx"`
+}
+
+// watch renders signer activity live, from an audit log or event file or by
+// polling Clef
+func watch(fs *flag.FlagSet, out *output) func() error {
+	endpoint := clefFlag(fs)
+	file := fs.String("file", "", "audit log or signing event JSONL file to follow, instead of polling Clef")
+	fromStart := fs.Bool("from-start", false, "render the existing lines of -file first")
+	interval := fs.Duration("interval", 5*time.Second, "how often Clef is polled")
+	return func() error {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if *file != "" {
+			return follow(ctx, *file, *fromStart, func(line []byte) {
+				var a activity
+				if err := json.Unmarshal(line, &a); err != nil {
+					fmt.Fprintf(os.Stderr, "skipping unreadable line: %v\n", err)
+					return
+				}
+				if out.json() {
+					out.writeLine(&a)
+					return
+				}
+				fmt.Println(a.render())
+			})
+		}
+
+		client, err := connect(*endpoint)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+		return poll(ctx, client, *interval, out)
+	}
+}
+
+// render formats the activity as a line
+func (a *activity) render() string {
+	at, took := a.Time, a.Latency
+	if at.IsZero() {
+		at, took = a.Requested, a.Duration
+	}
+	outcome := a.Outcome
+	if outcome == "" {
+		outcome = a.Event
+	}
+	parts := []string{at.Local().Format("15:04:05"), fmt.Sprintf("%-24s", a.Method), fmt.Sprintf("%-9s", outcome)}
+	if took > 0 {
+		parts = append(parts, took.Round(time.Millisecond).String())
+	}
+	if a.Account != "" {
+		parts = append(parts, "account="+a.Account)
+	}
+	if a.Tx != nil {
+		parts = append(parts, "to="+a.Tx.To)
+		if a.Tx.Value != "" {
+			parts = append(parts, "value="+a.Tx.Value)
+		}
+	}
+	if a.ClientID != "" {
+		parts = append(parts, "client="+a.ClientID)
+	}
+	if a.Error != "" {
+		parts = append(parts, "error="+a.Error)
+	}
+	return strings.Join(parts, " ")
+}
+
+// followInterval is how often a followed file is checked for new lines
+const followInterval = 250 * time.Millisecond
+
+// follow calls handle with the lines appended to path until ctx is done,
+// reopening the file when it is rotated or truncated
+func follow(ctx context.Context, path string, fromStart bool, handle func(line []byte)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	if !fromStart {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			return err
+		}
+	}
+
+	r := bufio.NewReader(f)
+	var partial []byte
+	for {
+		line, err := r.ReadBytes('\n')
+		partial = append(partial, line...)
+		if err == nil {
+			handle(partial)
+			partial = nil
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(followInterval):
+		}
+		rotated, err := rotatedOrTruncated(f, path)
+		if err != nil {
+			return err
+		}
+		if rotated {
+			f.Close()
+			if f, err = os.Open(path); err != nil {
+				return err
+			}
+			r.Reset(f)
+			partial = nil
+		}
+	}
+}
+
+// rotatedOrTruncated reports whether path no longer is the file f, or f got
+// shorter than the position read so far
+func rotatedOrTruncated(f *os.File, path string) (bool, error) {
+	current, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		// Not recreated yet after a rotation
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	opened, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !os.SameFile(opened, current) {
+		return true, nil
+	}
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+	return current.Size() < pos, nil
+}
+
+// pollStatus is the outcome of polling Clef once
+type pollStatus struct {
+	Time     time.Time     `json:"time"`
+	Up       bool          `json:"up"`
+	Error    string        `json:"error,omitempty"`
+	Latency  time.Duration `json:"latency,omitempty"`
+	Accounts int           `json:"accounts"`
+	Added    []string      `json:"added,omitempty"`
+	Removed  []string      `json:"removed,omitempty"`
+}
+
+// render formats the status as lines
+func (p *pollStatus) render() string {
+	now := p.Time.Format("15:04:05")
+	if !p.Up {
+		return fmt.Sprintf("%s unreachable: %s", now, p.Error)
+	}
+	lines := []string{fmt.Sprintf("%s up %s, %d accounts", now, p.Latency.Round(time.Millisecond), p.Accounts)}
+	for _, account := range p.Added {
+		lines = append(lines, fmt.Sprintf("%s added %s", now, account))
+	}
+	for _, account := range p.Removed {
+		lines = append(lines, fmt.Sprintf("%s removed %s", now, account))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// poll reports the reachability, latency and accounts of Clef every
+// interval, and the accounts added or removed
+func poll(ctx context.Context, client *clefclient.ClefClient, interval time.Duration, out *output) error {
+	var known map[string]bool
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		start := time.Now()
+		accounts, err := client.WithContext(ctx).ListAccounts()
+		if ctx.Err() != nil {
+			return nil
+		}
+		status := pollStatus{Time: time.Now(), Up: err == nil}
+		if err != nil {
+			status.Error = err.Error()
+		} else {
+			status.Latency = time.Since(start)
+			status.Accounts = len(accounts)
+			current := make(map[string]bool, len(accounts))
+			for _, account := range accounts {
+				current[account] = true
+			}
+			if known != nil {
+				status.Added, status.Removed = accountChanges(known, current)
+			}
+			known = current
+		}
+		if out.json() {
+			out.writeLine(&status)
+		} else {
+			fmt.Println(status.render())
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// accountChanges returns the accounts added and removed between two
+// listings
+func accountChanges(before, after map[string]bool) (added, removed []string) {
+	for account := range after {
+		if !before[account] {
+			added = append(added, account)
+		}
+	}
+	for account := range before {
+		if !after[account] {
+			removed = append(removed, account)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,40 @@
+// Command cleffixtures generates request and response fixtures with valid
+// addresses, signatures and raw transactions, for the fake Clef of
+// clefclienttest and golden tests. The same seed always generates the same
+// fixtures.
+//
+//	cleffixtures -out testdata/fixtures -chain-id 11155111 -types legacy,1559 -accounts 2 -count 3
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+
+	"github.com/AxLabs/clef-client/clefclienttest"
+)
+
+func main() {
+	out := flag.String("out", "testdata/fixtures", "directory to write the fixtures to")
+	chainID := flag.Uint64("chain-id", 1, "chain ID of the transactions and typed data")
+	types := flag.String("types", "legacy,1559", "comma separated transaction types")
+	accounts := flag.Int("accounts", 1, "number of accounts")
+	count := flag.Int("count", 1, "number of fixtures per account, transaction type and request kind")
+	seed := flag.String("seed", "", "seed of the keys and values")
+	flag.Parse()
+
+	fixtures, err := clefclienttest.GenerateFixtures(clefclienttest.FixtureConfig{
+		ChainID:  *chainID,
+		TxTypes:  strings.Split(*types, ","),
+		Accounts: *accounts,
+		Count:    *count,
+		Seed:     *seed,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := clefclienttest.WriteFixtures(*out, fixtures); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d fixtures to %s", len(fixtures), *out)
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// document is the subset of an OpenRPC document used by the generator
+type document struct {
+	Methods    []method `json:"methods"`
+	Components struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type param struct {
+	Name     string  `json:"name"`
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+type method struct {
+	Name        string  `json:"name"`
+	Summary     string  `json:"summary"`
+	GoName      string  `json:"x-go-name"`
+	Handwritten bool    `json:"x-go-handwritten"`
+	Params      []param `json:"params"`
+	Result      param   `json:"result"`
+}
+
+type schema struct {
+	Ref         string            `json:"$ref"`
+	Type        string            `json:"type"`
+	Format      string            `json:"format"`
+	Description string            `json:"description"`
+	Items       *schema           `json:"items"`
+	Properties  orderedProperties `json:"properties"`
+}
+
+// property is an object property, kept in document order
+type property struct {
+	name   string
+	schema *schema
+}
+
+// orderedProperties decodes a JSON object of schemas preserving key order,
+// so generated struct fields follow the document
+type orderedProperties []property
+
+func (p *orderedProperties) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("properties must be an object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var s schema
+		if err := dec.Decode(&s); err != nil {
+			return err
+		}
+		*p = append(*p, property{name: tok.(string), schema: &s})
+	}
+	return nil
+}
+
+func parseDocument(data []byte) (*document, error) {
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid OpenRPC document: %w", err)
+	}
+	return &doc, nil
+}
+
+// generate renders the Go source for the methods and schemas of doc
+func generate(doc *document, pkg, specPath string) ([]byte, error) {
+	var body bytes.Buffer
+	imports := map[string]bool{}
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := writeStruct(&body, name, doc.Components.Schemas[name], imports); err != nil {
+			return nil, err
+		}
+	}
+	for i := range doc.Methods {
+		if doc.Methods[i].Handwritten {
+			continue
+		}
+		if err := writeMethod(&body, &doc.Methods[i], imports); err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by clefgen from %s. DO NOT EDIT.\n\npackage %s\n\n", filepath.Base(specPath), pkg)
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		b.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.Write(body.Bytes())
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go: %w", err)
+	}
+	return src, nil
+}
+
+func writeStruct(b *bytes.Buffer, name string, s *schema, imports map[string]bool) error {
+	if s.Type != "object" {
+		return fmt.Errorf("schema %s: only object schemas are supported", name)
+	}
+	doc := s.Description
+	if doc == "" {
+		doc = "is the " + name + " object of the Clef API"
+	}
+	fmt.Fprintf(b, "// %s %s\ntype %s struct {\n", name, doc, name)
+	for _, p := range s.Properties {
+		typ, err := goType(p.schema, imports)
+		if err != nil {
+			return fmt.Errorf("schema %s: property %s: %w", name, p.name, err)
+		}
+		if p.schema.Description != "" {
+			fmt.Fprintf(b, "\t// %s is %s\n", exported(p.name), p.schema.Description)
+		}
+		fmt.Fprintf(b, "\t%s %s `json:\"%s\"`\n", exported(p.name), typ, p.name)
+	}
+	b.WriteString("}\n\n")
+	return nil
+}
+
+func writeMethod(b *bytes.Buffer, m *method, imports map[string]bool) error {
+	name := m.GoName
+	if name == "" {
+		name = exported(m.Name[strings.Index(m.Name, "_")+1:])
+	}
+	resultType, err := goType(m.Result.Schema, imports)
+	if err != nil {
+		return fmt.Errorf("method %s: result: %w", m.Name, err)
+	}
+
+	// Optional parameters are only sent when set. Once one is optional, all
+	// following ones are too, since params are positional.
+	var args, required, optional []string
+	for _, p := range m.Params {
+		typ, err := goType(p.Schema, imports)
+		if err != nil {
+			return fmt.Errorf("method %s: param %s: %w", m.Name, p.Name, err)
+		}
+		args = append(args, p.Name+" "+typ)
+		if p.Required && len(optional) == 0 {
+			required = append(required, p.Name)
+		} else {
+			optional = append(optional, fmt.Sprintf("\tif %s != %s {\n\t\tparams = append(params, %s)\n\t}\n", p.Name, zeroValue(typ), p.Name))
+		}
+	}
+
+	summary := m.Summary
+	if summary == "" {
+		summary = "calls " + m.Name
+	}
+	fmt.Fprintf(b, "// %s %s\n", name, summary)
+	fmt.Fprintf(b, "func (cc *ClefClient) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), resultType)
+	params := "nil"
+	switch {
+	case len(m.Params) == 1 && len(optional) == 0:
+		params = required[0]
+	case len(m.Params) > 0:
+		fmt.Fprintf(b, "\tparams := []interface{}{%s}\n%s", strings.Join(required, ", "), strings.Join(optional, ""))
+		params = "params"
+	}
+	zero := zeroValue(resultType)
+	fmt.Fprintf(b, "\tresp, err := cc.call(%q, %s)\n", m.Name, params)
+	fmt.Fprintf(b, "\tif err != nil {\n\t\treturn %s, err\n\t}\n\n", zero)
+	if strings.HasPrefix(resultType, "*") {
+		fmt.Fprintf(b, "\tvar result %s\n", resultType[1:])
+		fmt.Fprintf(b, "\tif err := decodeResult(%q, resp, &result); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &result, nil\n}\n\n", m.Name)
+	} else {
+		fmt.Fprintf(b, "\tvar result %s\n", resultType)
+		fmt.Fprintf(b, "\tif err := decodeResult(%q, resp, &result); err != nil {\n\t\treturn %s, err\n\t}\n\treturn result, nil\n}\n\n", m.Name, zero)
+	}
+	return nil
+}
+
+// goType maps a schema to a Go type, pointers for referenced structs
+func goType(s *schema, imports map[string]bool) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("missing schema")
+	}
+	if s.Ref != "" {
+		const prefix = "#/components/schemas/"
+		if !strings.HasPrefix(s.Ref, prefix) {
+			return "", fmt.Errorf("unsupported reference %s", s.Ref)
+		}
+		return "*" + strings.TrimPrefix(s.Ref, prefix), nil
+	}
+	switch s.Type {
+	case "string":
+		return "string", nil
+	case "boolean":
+		return "bool", nil
+	case "integer":
+		switch s.Format {
+		case "uint8", "uint16", "uint32", "uint64", "int32", "int64":
+			return s.Format, nil
+		}
+		imports["math/big"] = true
+		return "*big.Int", nil
+	case "array":
+		elem, err := goType(s.Items, imports)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "object":
+		imports["encoding/json"] = true
+		return "json.RawMessage", nil
+	}
+	return "", fmt.Errorf("unsupported schema type %q", s.Type)
+}
+
+func zeroValue(typ string) string {
+	switch {
+	case typ == "string":
+		return `""`
+	case typ == "bool":
+		return "false"
+	case strings.HasPrefix(typ, "*"), strings.HasPrefix(typ, "[]"), typ == "json.RawMessage":
+		return "nil"
+	}
+	return "0"
+}
+
+// exported converts a camelCase JSON name to an exported Go identifier,
+// keeping the ID initialism upper case
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	if strings.HasSuffix(name, "Id") {
+		name = strings.TrimSuffix(name, "Id") + "ID"
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,35 @@
+// Command clefgen generates typed ClefClient methods and structs from an
+// OpenRPC description of the Clef API. Methods marked x-go-handwritten are
+// skipped. It is run by go generate in the repository root.
+//
+//	clefgen -spec clef.openrpc.json -out clef_generated.go
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+)
+
+func main() {
+	spec := flag.String("spec", "clef.openrpc.json", "OpenRPC document")
+	out := flag.String("out", "clef_generated.go", "Go file to write")
+	pkg := flag.String("package", "clefclient", "package of the generated file")
+	flag.Parse()
+
+	data, err := os.ReadFile(*spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc, err := parseDocument(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	src, err := generate(doc, *pkg, *spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,52 @@
+// Command clefgrpc serves the Clef client API over gRPC, as defined in
+// grpcgateway/clef.proto. gRPC requires HTTP/2, so the gateway listens with TLS.
+//
+//	clefgrpc -clef ~/.clef/clef.ipc -listen :9443 -cert server.crt -key server.key -token-file token
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/AxLabs/clef-client/grpcgateway"
+	"github.com/AxLabs/clef-client/internal/gateway"
+)
+
+func main() {
+	endpoint := flag.String("clef", "", "Clef IPC path or HTTP URL")
+	listen := flag.String("listen", "localhost:9443", "address to listen on")
+	cert := flag.String("cert", "", "TLS certificate file")
+	key := flag.String("key", "", "TLS key file")
+	tokenFile := flag.String("token-file", "", "file holding the bearer token clients must present")
+	auditFile := flag.String("audit", "", "file to append the audit log to")
+	flag.Parse()
+	if *endpoint == "" || *cert == "" || *key == "" {
+		flag.Usage()
+		log.Fatal("-clef, -cert and -key are required")
+	}
+
+	client, err := gateway.Dial(*endpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	if *auditFile != "" {
+		store, err := gateway.EnableAudit(client, *auditFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+	}
+
+	config := grpcgateway.Config{Client: client}
+	if *tokenFile != "" {
+		if config.Authenticate, err = gateway.BearerAuth(*tokenFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	log.Printf("serving Clef gRPC gateway on %s", *listen)
+	log.Fatal(http.ListenAndServeTLS(*listen, *cert, *key, grpcgateway.NewServer(config)))
+}
@@ -0,0 +1,52 @@
+// Command clefoffline executes an offline signing bundle against a local Clef
+// on an air-gapped machine and writes the results for import elsewhere.
+//
+//	clefoffline -clef ~/.clef/clef.ipc -in requests.json -out results.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+func main() {
+	endpoint := flag.String("clef", "", "Clef IPC path or HTTP URL")
+	in := flag.String("in", "", "request bundle to execute")
+	out := flag.String("out", "", "file to write the results to")
+	flag.Parse()
+	if *endpoint == "" || *in == "" || *out == "" {
+		flag.Usage()
+		log.Fatal("-clef, -in and -out are required")
+	}
+
+	bundle, err := clefclient.ReadOfflineBundle(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var client *clefclient.ClefClient
+	if strings.HasPrefix(*endpoint, "http://") || strings.HasPrefix(*endpoint, "https://") {
+		client = clefclient.NewHTTPClient(*endpoint)
+	} else if client, err = clefclient.NewIPCClient(*endpoint); err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	results := client.ExecuteOffline(bundle)
+	if err := results.WriteFile(*out); err != nil {
+		log.Fatal(err)
+	}
+
+	var failed int
+	for _, r := range results.Results {
+		if r.Error != "" {
+			failed++
+			log.Printf("request %s (%s) failed: %s", r.ID, r.Method, r.Error)
+		}
+	}
+	fmt.Printf("executed %d requests, %d failed\n", len(results.Results), failed)
+}
@@ -0,0 +1,56 @@
+// Command clefrest serves Clef account and signing methods as a REST+JSON API,
+// as described in restgateway/openapi.yaml.
+//
+//	clefrest -clef ~/.clef/clef.ipc -listen :8443 -cert server.crt -key server.key -token-file token
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/AxLabs/clef-client/internal/gateway"
+	"github.com/AxLabs/clef-client/restgateway"
+)
+
+func main() {
+	endpoint := flag.String("clef", "", "Clef IPC path or HTTP URL")
+	listen := flag.String("listen", "localhost:8443", "address to listen on")
+	cert := flag.String("cert", "", "TLS certificate file, plain HTTP if empty")
+	key := flag.String("key", "", "TLS key file")
+	tokenFile := flag.String("token-file", "", "file holding the bearer token clients must present")
+	auditFile := flag.String("audit", "", "file to append the audit log to")
+	flag.Parse()
+	if *endpoint == "" {
+		flag.Usage()
+		log.Fatal("-clef is required")
+	}
+
+	client, err := gateway.Dial(*endpoint)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	if *auditFile != "" {
+		store, err := gateway.EnableAudit(client, *auditFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+	}
+
+	config := restgateway.Config{Signer: client}
+	if *tokenFile != "" {
+		if config.Authenticate, err = gateway.BearerAuth(*tokenFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	server := restgateway.NewServer(config)
+	log.Printf("serving Clef REST gateway on %s", *listen)
+	if *cert != "" {
+		log.Fatal(http.ListenAndServeTLS(*listen, *cert, *key, server))
+	}
+	log.Fatal(http.ListenAndServe(*listen, server))
+}
@@ -0,0 +1,158 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// unsignedTxFormatVersion is the version of the unsigned transaction format
+const unsignedTxFormatVersion = 1
+
+// ErrDigestMismatch is returned when an unsigned transaction does not match
+// its digest or the digest approved by a reviewer
+var ErrDigestMismatch = errors.New("unsigned transaction digest mismatch")
+
+// UnsignedTx is a fully specified transaction exported for review before it
+// is signed, possibly in another environment. Digest identifies the exact
+// transaction, so a reviewer can approve it out of band.
+type UnsignedTx struct {
+	Version int          `json:"version"`
+	Tx      *Transaction `json:"tx"`
+	Digest  string       `json:"digest"`
+	Created time.Time    `json:"created"`
+	// Note is free text for reviewers, not covered by the digest
+	Note string `json:"note,omitempty"`
+}
+
+// ExportUnsignedTx normalizes tx and computes its digest. The nonce, gas,
+// chain ID and fees must be set, so that what is reviewed is what is signed.
+func ExportUnsignedTx(tx *Transaction) (*UnsignedTx, error) {
+	canonical, err := canonicalTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := txDigest(canonical)
+	if err != nil {
+		return nil, err
+	}
+	return &UnsignedTx{
+		Version: unsignedTxFormatVersion,
+		Tx:      canonical,
+		Digest:  digest,
+		Created: time.Now().UTC(),
+	}, nil
+}
+
+// Verify checks that the transaction is canonical and matches the digest
+func (u *UnsignedTx) Verify() error {
+	if u.Version != unsignedTxFormatVersion {
+		return fmt.Errorf("unsupported unsigned transaction version %d", u.Version)
+	}
+	if u.Tx == nil {
+		return fmt.Errorf("unsigned transaction has no tx")
+	}
+	canonical, err := canonicalTx(u.Tx)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(canonical, u.Tx) {
+		return fmt.Errorf("%w: transaction is not in canonical form", ErrDigestMismatch)
+	}
+	digest, err := txDigest(canonical)
+	if err != nil {
+		return err
+	}
+	if digest != u.Digest {
+		return fmt.Errorf("%w: computed %s, recorded %s", ErrDigestMismatch, digest, u.Digest)
+	}
+	return nil
+}
+
+// WriteFile writes the unsigned transaction as JSON
+func (u *UnsignedTx) WriteFile(path string) error {
+	return writeJSONFile(path, u)
+}
+
+// ReadUnsignedTx reads and verifies an unsigned transaction written by WriteFile
+func ReadUnsignedTx(path string) (*UnsignedTx, error) {
+	var u UnsignedTx
+	if err := readJSONFile(path, &u); err != nil {
+		return nil, err
+	}
+	if err := u.Verify(); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// SignUnsignedTx verifies an imported unsigned transaction against the digest
+// approved by the reviewer and signs it
+func (cc *ClefClient) SignUnsignedTx(u *UnsignedTx, approvedDigest string) (*SignTxResponse, error) {
+	if u == nil {
+		return nil, nilRequestError("unsigned transaction")
+	}
+	if err := u.Verify(); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(u.Digest, approvedDigest) {
+		return nil, fmt.Errorf("%w: approved %s, got %s", ErrDigestMismatch, approvedDigest, u.Digest)
+	}
+	tx := *u.Tx
+	return cc.SignTransaction(&tx)
+}
+
+// canonicalTx returns a copy of tx with checksummed addresses, minimal
+// quantities and lowercase data
+func canonicalTx(tx *Transaction) (*Transaction, error) {
+	if err := checkTransaction(tx); err != nil {
+		return nil, err
+	}
+	for name, value := range map[string]string{"nonce": tx.Nonce, "gas": tx.Gas, "chainId": tx.ChainID} {
+		if value == "" {
+			return nil, fmt.Errorf("transaction: %s is required", name)
+		}
+	}
+	if tx.GasPrice == "" && tx.MaxFeePerGas == "" {
+		return nil, fmt.Errorf("transaction: gasPrice or maxFeePerGas is required")
+	}
+
+	canonical := *tx
+	canonical.From, _ = ChecksumAddress(tx.From)
+	if tx.To != "" {
+		canonical.To, _ = ChecksumAddress(tx.To)
+	}
+	for _, field := range []*string{
+		&canonical.Gas, &canonical.GasPrice, &canonical.MaxFeePerGas, &canonical.MaxPriorityFeePerGas,
+		&canonical.Value, &canonical.Nonce, &canonical.ChainID, &canonical.MaxFeePerBlobGas,
+	} {
+		if *field != "" {
+			v, _ := decodeQuantity(*field)
+			*field = encodeQuantity(v)
+		}
+	}
+	if tx.Data != "" {
+		data, _ := decodeHexData(tx.Data)
+		canonical.Data = encodeHexData(data)
+	}
+	if tx.BlobVersionedHashes != nil {
+		canonical.BlobVersionedHashes = make([]string, len(tx.BlobVersionedHashes))
+		for i, hash := range tx.BlobVersionedHashes {
+			b, _ := decodeHexData(hash)
+			canonical.BlobVersionedHashes[i] = encodeHexData(b)
+		}
+	}
+	return &canonical, nil
+}
+
+// txDigest is the keccak256 hash of the JSON encoding of a canonical transaction
+func txDigest(tx *Transaction) (string, error) {
+	data, err := json.Marshal(tx)
+	if err != nil {
+		return "", err
+	}
+	return encodeHexData(keccak256(data)), nil
+}
@@ -0,0 +1,81 @@
+package clefclient
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testColdTx() *Transaction {
+	return &Transaction{
+		From:     "0x7e5f4552091a69125d5dfcb7b8c2659029395bdf",
+		To:       "0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF",
+		Gas:      "0x05208",
+		GasPrice: "0x3B9ACA00",
+		Value:    "0x0de0b6b3a7640000",
+		Nonce:    "0x0",
+		ChainID:  "0x1",
+		Data:     "0xABCD",
+	}
+}
+
+func TestExportUnsignedTx(t *testing.T) {
+	u, err := ExportUnsignedTx(testColdTx())
+	assert.NoError(t, err)
+	assert.Equal(t, &Transaction{
+		From:     "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf",
+		To:       "0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF",
+		Gas:      "0x5208",
+		GasPrice: "0x3b9aca00",
+		Value:    "0xde0b6b3a7640000",
+		Nonce:    "0x0",
+		ChainID:  "0x1",
+		Data:     "0xabcd",
+	}, u.Tx)
+	assert.NoError(t, u.Verify())
+
+	// equivalent spellings of a transaction share the digest
+	tx := testColdTx()
+	tx.Gas = "0x5208"
+	other, err := ExportUnsignedTx(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, u.Digest, other.Digest)
+
+	tx.Nonce = ""
+	_, err = ExportUnsignedTx(tx)
+	assert.ErrorContains(t, err, "nonce is required")
+}
+
+func TestUnsignedTxRoundTrip(t *testing.T) {
+	u, err := ExportUnsignedTx(testColdTx())
+	assert.NoError(t, err)
+	u.Note = "monthly payout"
+
+	path := filepath.Join(t.TempDir(), "tx.json")
+	assert.NoError(t, u.WriteFile(path))
+	imported, err := ReadUnsignedTx(path)
+	assert.NoError(t, err)
+	assert.Equal(t, u.Digest, imported.Digest)
+	assert.Equal(t, u.Tx, imported.Tx)
+
+	imported.Tx.Value = "0x1"
+	assert.ErrorIs(t, imported.Verify(), ErrDigestMismatch)
+	imported.Tx.Value = "0x01"
+	assert.ErrorContains(t, imported.Verify(), "canonical")
+}
+
+func TestSignUnsignedTx(t *testing.T) {
+	client, server := setupHTTPTestServer(t, "account_signTransaction", SignTxResponse{Raw: "0xraw"})
+	defer server.Close()
+
+	u, err := ExportUnsignedTx(testColdTx())
+	assert.NoError(t, err)
+
+	_, err = client.SignUnsignedTx(u, "0x1234")
+	assert.ErrorIs(t, err, ErrDigestMismatch)
+
+	signed, err := client.SignUnsignedTx(u, u.Digest)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xraw", signed.Raw)
+}
@@ -0,0 +1,130 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrUnsupportedByVersion is returned when a request cannot be expressed in
+// the external API of the Clef server it is sent to
+var ErrUnsupportedByVersion = errors.New("not supported by the clef api version")
+
+// EnableCompat adapts requests and responses to the external API version of
+// the Clef server, so one client works against servers of different
+// releases. The version is queried with account_version on the first call.
+//
+// Servers before 5.0.0 sign text with account_sign instead of
+// account_signData, servers before 6.0.0 answer account_new with the whole
+// account instead of its address. Newer versions are used as they are.
+func (cc *ClefClient) EnableCompat() {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &compatTransport{next: cc.transport}
+}
+
+// compatTransport is a transport decorator translating calls for older
+// versions of the external API
+type compatTransport struct {
+	next transport
+
+	mu       sync.Mutex
+	detected bool
+	major    int
+}
+
+func (t *compatTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if method == "account_version" {
+		return t.next.call(ctx, method, params)
+	}
+	major, err := t.version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case method == "account_signData" && major < 5:
+		req, ok := params.(*SignDataRequest)
+		if !ok {
+			break
+		}
+		if req.ContentType != "" && req.ContentType != ContentTypeTextPlain {
+			return nil, fmt.Errorf("%w: content type %s needs version 5.0.0, server has %d", ErrUnsupportedByVersion, req.ContentType, major)
+		}
+		return t.next.call(ctx, "account_sign", []interface{}{req.Address, req.Data})
+	case method == "account_new" && major < 6:
+		resp, err := t.next.call(ctx, method, params)
+		if err != nil || resp.Error != nil {
+			return resp, err
+		}
+		var account struct {
+			Address string `json:"address"`
+		}
+		if err := json.Unmarshal(resp.Result, &account); err == nil && account.Address != "" {
+			resp.Result, _ = json.Marshal(account.Address)
+		}
+		return resp, nil
+	}
+	return t.next.call(ctx, method, params)
+}
+
+// callBatch only carries transaction signing, which needs no translation
+func (t *compatTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	return t.next.callBatch(ctx, method, params)
+}
+
+func (t *compatTransport) close() error {
+	return t.next.close()
+}
+
+// version returns the major version of the external API, querying it once.
+// Failures to reach Clef are returned and retried on the next call, versions
+// that cannot be parsed are treated as current.
+func (t *compatTransport) version(ctx context.Context) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.detected {
+		return t.major, nil
+	}
+
+	resp, err := t.next.call(ctx, "account_version", nil)
+	if err != nil {
+		return 0, &CallError{Method: "account_version", Err: err}
+	}
+	t.detected = true
+	t.major = compatCurrent
+	if resp.Error == nil {
+		if major, ok := parseMajorVersion(resp.Result); ok {
+			t.major = major
+		}
+	}
+	return t.major, nil
+}
+
+// compatCurrent is the major version of the external API the client speaks
+// natively
+const compatCurrent = 6
+
+// parseMajorVersion extracts the major version from an account_version
+// result, either a bare version string or an object with a version member
+func parseMajorVersion(result json.RawMessage) (int, bool) {
+	var version string
+	if err := json.Unmarshal(result, &version); err != nil {
+		var obj VersionResponse
+		if err := json.Unmarshal(result, &obj); err != nil {
+			return 0, false
+		}
+		version = obj.Version
+	}
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	n, err := strconv.Atoi(major)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
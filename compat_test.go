@@ -0,0 +1,118 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// versionedTransport answers like a Clef server of the given version
+type versionedTransport struct {
+	version string
+	methods []string
+	params  []interface{}
+}
+
+func (t *versionedTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	t.methods = append(t.methods, method)
+	t.params = append(t.params, params)
+	switch method {
+	case "account_version":
+		if t.version == "" {
+			return nil, errors.New("connection refused")
+		}
+		result, _ := json.Marshal(t.version)
+		return &rpcResponse{Result: result}, nil
+	case "account_new":
+		if t.version < "6" {
+			return &rpcResponse{Result: json.RawMessage(`{"address":"0x0000000000000000000000000000000000000001","url":"keystore:///tmp/key"}`)}, nil
+		}
+		return &rpcResponse{Result: json.RawMessage(`"0x0000000000000000000000000000000000000001"`)}, nil
+	}
+	result, _ := json.Marshal(SignDataResponse{Signature: testSignature(1)})
+	return &rpcResponse{Result: result}, nil
+}
+
+func (t *versionedTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (t *versionedTransport) close() error {
+	return nil
+}
+
+func TestCompatSignDataBeforeV5(t *testing.T) {
+	next := &versionedTransport{version: "4.0.0"}
+	cc := &ClefClient{transport: next}
+	cc.EnableCompat()
+
+	_, err := cc.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000001", Data: "0x01"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"account_version", "account_sign"}, next.methods)
+	assert.Equal(t, []interface{}{"0x0000000000000000000000000000000000000001", "0x01"}, next.params[1])
+
+	_, err = cc.SignData(&SignDataRequest{ContentType: ContentTypeCliqueHeader, Address: "0x0000000000000000000000000000000000000001", Data: "0x01"})
+	assert.ErrorIs(t, err, ErrUnsupportedByVersion)
+	assert.Len(t, next.methods, 2)
+}
+
+func TestCompatNewAccountBeforeV6(t *testing.T) {
+	for _, version := range []string{"5.0.0", "6.1.0"} {
+		cc := &ClefClient{transport: &versionedTransport{version: version}}
+		cc.EnableCompat()
+
+		address, err := cc.NewAccount()
+		assert.NoError(t, err, version)
+		assert.Equal(t, "0x0000000000000000000000000000000000000001", address, version)
+	}
+}
+
+func TestCompatCurrentVersionUnchanged(t *testing.T) {
+	next := &versionedTransport{version: "6.1.0"}
+	cc := &ClefClient{transport: next}
+	cc.EnableCompat()
+
+	req := &SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000001", Data: "0x01"}
+	_, err := cc.SignData(req)
+	assert.NoError(t, err)
+	_, err = cc.SignData(req)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"account_version", "account_signData", "account_signData"}, next.methods)
+	assert.Same(t, req, next.params[1])
+}
+
+func TestCompatVersionDetectionRetried(t *testing.T) {
+	next := &versionedTransport{}
+	cc := &ClefClient{transport: next}
+	cc.EnableCompat()
+
+	_, err := cc.NewAccount()
+	assert.EqualError(t, err, "account_new: account_version: connection refused")
+
+	next.version = "5.0.0"
+	address, err := cc.NewAccount()
+	assert.NoError(t, err)
+	assert.Equal(t, "0x0000000000000000000000000000000000000001", address)
+}
+
+func TestParseMajorVersion(t *testing.T) {
+	tests := []struct {
+		result string
+		major  int
+		ok     bool
+	}{
+		{`"6.1.0"`, 6, true},
+		{`"v5.0.0"`, 5, true},
+		{`{"version":"4.0.0"}`, 4, true},
+		{`"unknown"`, 0, false},
+		{`42`, 0, false},
+	}
+	for _, tt := range tests {
+		major, ok := parseMajorVersion(json.RawMessage(tt.result))
+		assert.Equal(t, tt.major, major, tt.result)
+		assert.Equal(t, tt.ok, ok, tt.result)
+	}
+}
@@ -0,0 +1,417 @@
+package clefclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes a client: where Clef is, how calls are made, which
+// requests are allowed and what is reported about them. It is usually loaded
+// from a file with LoadConfig.
+//
+//	endpoint: https://clef.internal:8550
+//	timeout: 2m
+//	headers:
+//	  Authorization: Bearer ${CLEF_TOKEN}
+//	client_id: payouts
+//	pinned_public_keys: ["8Rw90Ej3Ttt8RRkrg+WYDS9n7IS03bk5bjP/UXPtaY8="]
+//	retry:
+//	  max_attempts: 5
+//	  backoff: 200ms
+//	default_account: "0x..."
+//	compat: true
+//	min_version: 6.0.0
+//	attestation_account: "0x..."
+//	verify_signers: true
+//	policies:
+//	  chain_id: 11155111
+//	  allow_destinations:
+//	    - address: "0x..."
+//	      selectors: ["0xa9059cbb"]
+//	  deny_selectors: ["0x095ea7b3"]
+//	  spending_limits:
+//	    - max: "10"
+//	      window: 1h
+//	  spending_state: /var/lib/payouts/spending.json
+//	telemetry:
+//	  log_level: info
+//	  expvar: clef
+//	  slow_approval: 30s
+type Config struct {
+	// Endpoint is an endpoint accepted by NewClient
+	Endpoint string `yaml:"endpoint"`
+	// Timeout bounds every call, see WithTimeout
+	Timeout time.Duration `yaml:"timeout"`
+	// Headers are added to the requests of HTTP endpoints. Environment
+	// variables in their values are expanded, so that secrets need not be
+	// stored in the file.
+	Headers map[string]string `yaml:"headers"`
+	// ClientID identifies the service in requests and audit entries, see
+	// WithClientID
+	ClientID string `yaml:"client_id"`
+	// UserAgent replaces DefaultUserAgent in HTTP requests
+	UserAgent string `yaml:"user_agent"`
+	// InsecureHTTP allows plaintext HTTP endpoints that are not on the
+	// loopback interface, which are refused otherwise, see WithHTTPSOnly
+	InsecureHTTP bool `yaml:"insecure_http"`
+	// PinnedPublicKeys and PinnedCertificates restrict the certificates
+	// accepted from HTTPS endpoints, see WithPinnedPublicKeys and
+	// WithPinnedCertificates
+	PinnedPublicKeys   []string `yaml:"pinned_public_keys"`
+	PinnedCertificates []string `yaml:"pinned_certificates"`
+	// RequestSigning signs HTTP requests for a gateway in front of Clef, see
+	// WithRequestSigning
+	RequestSigning *RequestSigningConfig `yaml:"request_signing"`
+	// Retry enables retries of failed calls
+	Retry *RetryConfig `yaml:"retry"`
+	// DefaultAccount signs requests that name no account, see
+	// WithDefaultAccount
+	DefaultAccount string `yaml:"default_account"`
+	// Compat adapts requests to the version of Clef, see EnableCompat
+	Compat bool `yaml:"compat"`
+	// VerifySigners recovers the signer of data signatures locally, see
+	// EnableSignerVerification
+	VerifySigners bool `yaml:"verify_signers"`
+	// MinVersion and BlockedVersions make connecting fail for external API
+	// versions of Clef they refuse, see WithVersionPolicy
+	MinVersion      string   `yaml:"min_version"`
+	BlockedVersions []string `yaml:"blocked_versions"`
+	// AttestationAccount must sign a nonce when connecting, proving the
+	// identity of Clef, see WithSignerIdentity
+	AttestationAccount string          `yaml:"attestation_account"`
+	Policies           PolicyConfig    `yaml:"policies"`
+	Telemetry          TelemetryConfig `yaml:"telemetry"`
+}
+
+// RequestSigningConfig describes the shared secret of WithRequestSigning.
+// Environment variables in Secret are expanded, so that it need not be
+// stored in the file.
+type RequestSigningConfig struct {
+	KeyID  string `yaml:"key_id"`
+	Secret string `yaml:"secret"`
+}
+
+// RetryConfig describes a RetryPolicy
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	Backoff     time.Duration `yaml:"backoff"`
+}
+
+// PolicyConfig describes the client-side policies applied to signing
+// requests, see EnablePolicies
+type PolicyConfig struct {
+	// ChainID, decimal or 0x-prefixed hex, is required of transactions,
+	// typed data domains and signed transactions, see EnableChainGuard
+	ChainID string `yaml:"chain_id"`
+	// AllowDestinations are the only destinations of transactions, see
+	// AllowDestinations
+	AllowDestinations []DestinationConfig `yaml:"allow_destinations"`
+	// DenyDestinations are addresses transactions may not be sent to
+	DenyDestinations []string `yaml:"deny_destinations"`
+	// DenySelectors are function selectors transactions may not call
+	DenySelectors []string `yaml:"deny_selectors"`
+	// SpendingLimits cap the value signed per account, see
+	// EnableSpendingLimits
+	SpendingLimits []SpendingLimitConfig `yaml:"spending_limits"`
+	// SpendingState is the file keeping track of the value signed, so that
+	// limits survive restarts. It is kept in memory if empty.
+	SpendingState string `yaml:"spending_state"`
+}
+
+// SpendingLimitConfig describes a SpendingLimit
+type SpendingLimitConfig struct {
+	Account string `yaml:"account"`
+	// Max is a decimal amount of ether, e.g. "0.5"
+	Max    string        `yaml:"max"`
+	Window time.Duration `yaml:"window"`
+}
+
+// DestinationConfig describes a Destination
+type DestinationConfig struct {
+	Address   string   `yaml:"address"`
+	Selectors []string `yaml:"selectors"`
+}
+
+// TelemetryConfig describes what a client reports about its calls. Metrics,
+// tracing, hooks and sinks need code and are enabled on the client.
+type TelemetryConfig struct {
+	// LogLevel logs calls to stderr at debug, info, warn or error level,
+	// see WithLogger. Calls are not logged if empty.
+	LogLevel string `yaml:"log_level"`
+	// Expvar publishes call counters under this name, see EnableExpvar
+	Expvar string `yaml:"expvar"`
+	// WireDump writes the exchanged messages, redacted, to stderr, see
+	// EnableWireDump
+	WireDump bool `yaml:"wire_dump"`
+	// SlowApproval logs a warning for signing requests pending longer
+	SlowApproval time.Duration `yaml:"slow_approval"`
+}
+
+// LoadConfig reads a Config from a YAML file, or a JSON file as JSON is
+// YAML. Unknown fields are rejected so that typos do not go unnoticed. TOML
+// is not supported.
+func LoadConfig(path string) (*Config, error) {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return nil, fmt.Errorf("config %s: TOML is not supported, use YAML", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	defer f.Close()
+
+	var config Config
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&config); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+func (c *Config) validate() error {
+	if c.Endpoint == "" {
+		return fmt.Errorf("endpoint is required")
+	}
+	if c.Policies.ChainID != "" {
+		if _, err := c.chainID(); err != nil {
+			return err
+		}
+	}
+	if c.Telemetry.LogLevel != "" {
+		if _, err := c.logLevel(); err != nil {
+			return err
+		}
+	}
+	for _, pin := range c.PinnedPublicKeys {
+		if b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256//")); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("invalid public key pin %q, want a base64 SHA-256 hash", pin)
+		}
+	}
+	for _, pin := range c.PinnedCertificates {
+		if b, err := hex.DecodeString(strings.ReplaceAll(pin, ":", "")); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("invalid certificate pin %q, want a hex SHA-256 fingerprint", pin)
+		}
+	}
+	if c.RequestSigning != nil && (c.RequestSigning.KeyID == "" || c.RequestSigning.Secret == "") {
+		return fmt.Errorf("request_signing requires key_id and secret")
+	}
+	if c.AttestationAccount != "" {
+		if _, err := ChecksumAddress(c.AttestationAccount); err != nil {
+			return fmt.Errorf("invalid attestation_account %q", c.AttestationAccount)
+		}
+	}
+	for _, version := range append([]string{c.MinVersion}, c.BlockedVersions...) {
+		if _, ok := parseVersion(version); version != "" && !ok {
+			return fmt.Errorf("invalid version %q", version)
+		}
+	}
+	return c.Policies.validate()
+}
+
+func (p *PolicyConfig) validate() error {
+	addresses := append([]string(nil), p.DenyDestinations...)
+	selectors := append([]string(nil), p.DenySelectors...)
+	for _, d := range p.AllowDestinations {
+		addresses = append(addresses, d.Address)
+		selectors = append(selectors, d.Selectors...)
+	}
+	for _, address := range addresses {
+		if _, err := ChecksumAddress(address); err != nil {
+			return fmt.Errorf("policies: invalid address %q", address)
+		}
+	}
+	for _, selector := range selectors {
+		if b, err := decodeHexData(selector); err != nil || len(b) != 4 {
+			return fmt.Errorf("policies: invalid selector %q", selector)
+		}
+	}
+	_, err := p.spendingLimits()
+	return err
+}
+
+func (p *PolicyConfig) spendingLimits() ([]SpendingLimit, error) {
+	limits := make([]SpendingLimit, 0, len(p.SpendingLimits))
+	for _, l := range p.SpendingLimits {
+		if l.Account != "" {
+			if _, err := ChecksumAddress(l.Account); err != nil {
+				return nil, fmt.Errorf("policies: invalid address %q", l.Account)
+			}
+		}
+		limit, err := ParseUnits(l.Max, 18)
+		if err != nil {
+			return nil, fmt.Errorf("policies: invalid spending limit: %w", err)
+		}
+		if l.Window <= 0 {
+			return nil, fmt.Errorf("policies: spending limit window is required")
+		}
+		limits = append(limits, SpendingLimit{Account: l.Account, Max: limit, Window: l.Window})
+	}
+	return limits, nil
+}
+
+// policies returns the policies described by p, other than the chain ID
+// which is enforced by a chain guard
+func (p *PolicyConfig) policies() []DryRunPolicy {
+	var policies []DryRunPolicy
+	if len(p.AllowDestinations) > 0 {
+		destinations := make([]Destination, len(p.AllowDestinations))
+		for i, d := range p.AllowDestinations {
+			destinations[i] = Destination{Address: d.Address, Selectors: d.Selectors}
+		}
+		policies = append(policies, AllowDestinations(destinations...))
+	}
+	if len(p.DenyDestinations) > 0 {
+		policies = append(policies, DenyDestinations(p.DenyDestinations...))
+	}
+	if len(p.DenySelectors) > 0 {
+		policies = append(policies, DenySelectors(p.DenySelectors...))
+	}
+	return policies
+}
+
+func (c *Config) chainID() (*big.Int, error) {
+	chainID, ok := new(big.Int).SetString(c.Policies.ChainID, 0)
+	if !ok {
+		return nil, fmt.Errorf("policies: invalid chain_id %q", c.Policies.ChainID)
+	}
+	return chainID, nil
+}
+
+func (c *Config) logLevel() (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Telemetry.LogLevel)); err != nil {
+		return 0, fmt.Errorf("telemetry: invalid log_level %q", c.Telemetry.LogLevel)
+	}
+	return level, nil
+}
+
+// Client creates a client as described by c. opts are applied after the
+// options of c, e.g. to provide an http.Client; ctx bounds connecting. The
+// default account, if any, is checked with Clef. Plaintext HTTP endpoints
+// are refused unless loopback or InsecureHTTP is set.
+func (c *Config) Client(ctx context.Context, opts ...Option) (*ClefClient, error) {
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+
+	var logger *slog.Logger
+	var configured []Option
+	if !c.InsecureHTTP {
+		configured = append(configured, WithHTTPSOnly())
+	}
+	if len(c.PinnedPublicKeys) > 0 {
+		configured = append(configured, WithPinnedPublicKeys(c.PinnedPublicKeys...))
+	}
+	if len(c.PinnedCertificates) > 0 {
+		configured = append(configured, WithPinnedCertificates(c.PinnedCertificates...))
+	}
+	if c.RequestSigning != nil {
+		secret := os.ExpandEnv(c.RequestSigning.Secret)
+		if secret == "" {
+			return nil, fmt.Errorf("request_signing secret %q expands to nothing", c.RequestSigning.Secret)
+		}
+		configured = append(configured, WithRequestSigning(c.RequestSigning.KeyID, []byte(secret)))
+	}
+	if c.Timeout > 0 {
+		configured = append(configured, WithTimeout(c.Timeout))
+	}
+	if len(c.Headers) > 0 {
+		header := http.Header{}
+		for name, value := range c.Headers {
+			header.Set(name, os.ExpandEnv(value))
+		}
+		configured = append(configured, WithHeaders(header))
+	}
+	if c.ClientID != "" {
+		configured = append(configured, WithClientID(c.ClientID))
+	}
+	if c.UserAgent != "" {
+		configured = append(configured, WithUserAgent(c.UserAgent))
+	}
+	if c.MinVersion != "" || len(c.BlockedVersions) > 0 {
+		configured = append(configured, WithVersionPolicy(VersionPolicy{Min: c.MinVersion, Blocked: c.BlockedVersions}))
+	}
+	if c.AttestationAccount != "" {
+		configured = append(configured, WithSignerIdentity(c.AttestationAccount))
+	}
+	if c.Retry != nil {
+		configured = append(configured, WithRetry(RetryPolicy{MaxAttempts: c.Retry.MaxAttempts, Backoff: c.Retry.Backoff}))
+	}
+	if c.Telemetry.LogLevel != "" {
+		level, _ := c.logLevel()
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+		configured = append(configured, WithLogger(logger))
+	}
+
+	cc, err := NewClient(ctx, c.Endpoint, append(configured, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	if c.Compat {
+		cc.EnableCompat()
+	}
+	if c.VerifySigners {
+		cc.EnableSignerVerification()
+	}
+	if c.Telemetry.WireDump {
+		cc.EnableWireDump(NewWireDump(WireDumpConfig{Writer: os.Stderr}))
+	}
+	if c.Telemetry.Expvar != "" {
+		cc.EnableExpvar(c.Telemetry.Expvar)
+	}
+	if c.Telemetry.SlowApproval > 0 {
+		if logger == nil {
+			logger = slog.Default()
+		}
+		cc.EnableHooks(Hooks{
+			SlowApproval: c.Telemetry.SlowApproval,
+			OnSlowApproval: func(e *SignEvent) {
+				logger.Warn("clef signing request pending", "method", e.Method, "account", e.Account, "pending", e.Duration)
+			},
+		})
+	}
+	// Policies come last so that rejected requests are not sent or counted
+	if len(c.Policies.SpendingLimits) > 0 {
+		var store SpendingStore = NewMemorySpendingStore()
+		if c.Policies.SpendingState != "" {
+			if store, err = NewFileSpendingStore(c.Policies.SpendingState); err != nil {
+				cc.Close()
+				return nil, err
+			}
+		}
+		limits, _ := c.Policies.spendingLimits()
+		cc.EnableSpendingLimits(store, limits...)
+	}
+	if policies := c.Policies.policies(); len(policies) > 0 {
+		cc.EnablePolicies(policies...)
+	}
+	if c.Policies.ChainID != "" {
+		chainID, _ := c.chainID()
+		cc.EnableChainGuard(chainID)
+	}
+	if c.DefaultAccount != "" {
+		derived, err := cc.WithDefaultAccount(c.DefaultAccount)
+		if err != nil {
+			cc.Close()
+			return nil, err
+		}
+		cc = derived
+	}
+	return cc, nil
+}
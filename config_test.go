@@ -0,0 +1,123 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		_, err := VerifyRequestSignature(r.Header, body, func(string) ([]byte, bool) { return []byte("hmac-secret"), true }, time.Minute)
+		assert.NoError(t, err)
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: json.RawMessage(`["0x0000000000000000000000000000000000000001"]`)})
+	}))
+	defer server.Close()
+	t.Setenv("CLEF_TOKEN", "secret")
+	t.Setenv("CLEF_HMAC_SECRET", "hmac-secret")
+
+	path := writeConfig(t, "clef.yaml", `
+endpoint: `+server.URL+`
+timeout: 2m
+headers:
+  Authorization: Bearer ${CLEF_TOKEN}
+request_signing:
+  key_id: payouts
+  secret: ${CLEF_HMAC_SECRET}
+retry:
+  max_attempts: 5
+  backoff: 200ms
+policies:
+  chain_id: 11155111
+  deny_selectors: ["0x095ea7b3"]
+  spending_limits:
+    - max: "0.5"
+      window: 1h
+telemetry:
+  expvar: clef_config_test
+`)
+	config, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, config.Timeout)
+	assert.Equal(t, &RetryConfig{MaxAttempts: 5, Backoff: 200 * time.Millisecond}, config.Retry)
+	assert.Equal(t, "11155111", config.Policies.ChainID)
+
+	cc, err := config.Client(context.Background())
+	assert.NoError(t, err)
+	defer cc.Close()
+	assert.Equal(t, 2*time.Minute, cc.timeout)
+
+	accounts, err := cc.ListAccounts()
+	assert.NoError(t, err)
+	assert.Len(t, accounts, 1)
+
+	_, err = cc.SignTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001", ChainID: "0x1"})
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	_, err = cc.SignTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001", ChainID: "0xaa36a7", Value: "0xde0b6b3a7640000"})
+	assert.ErrorIs(t, err, ErrSpendingLimit)
+	assert.Equal(t, 1, requests)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	_, err := LoadConfig(writeConfig(t, "clef.toml", `endpoint = "http://localhost:8550"`))
+	assert.ErrorContains(t, err, "TOML is not supported, use YAML")
+
+	_, err = LoadConfig(writeConfig(t, "clef.yaml", "endpoint: http://localhost:8550\ntimout: 1s\n"))
+	assert.ErrorContains(t, err, "field timout not found")
+
+	_, err = LoadConfig(writeConfig(t, "clef.yaml", "timeout: 1s\n"))
+	assert.ErrorContains(t, err, "endpoint is required")
+
+	_, err = LoadConfig(writeConfig(t, "clef.yaml", "endpoint: http://localhost:8550\npolicies:\n  chain_id: mainnet\n"))
+	assert.ErrorContains(t, err, `policies: invalid chain_id "mainnet"`)
+
+	_, err = LoadConfig(writeConfig(t, "clef.yaml", "endpoint: http://localhost:8550\npolicies:\n  allow_destinations:\n    - address: 0x1234\n"))
+	assert.ErrorContains(t, err, `policies: invalid address "0x1234"`)
+
+	_, err = LoadConfig(writeConfig(t, "clef.yaml", "endpoint: http://localhost:8550\npolicies:\n  deny_selectors: [transfer]\n"))
+	assert.ErrorContains(t, err, `policies: invalid selector "transfer"`)
+
+	_, err = LoadConfig(writeConfig(t, "clef.yaml", "endpoint: http://localhost:8550\npolicies:\n  spending_limits:\n    - max: \"10\"\n"))
+	assert.ErrorContains(t, err, "policies: spending limit window is required")
+
+	_, err = LoadConfig(writeConfig(t, "clef.yaml", "endpoint: http://localhost:8550\npinned_public_keys: [abc]\n"))
+	assert.ErrorContains(t, err, `invalid public key pin "abc"`)
+
+	_, err = LoadConfig(writeConfig(t, "clef.yaml", "endpoint: http://localhost:8550\nrequest_signing:\n  key_id: payouts\n"))
+	assert.ErrorContains(t, err, "request_signing requires key_id and secret")
+
+	_, err = LoadConfig(writeConfig(t, "clef.yaml", "endpoint: http://localhost:8550\nmin_version: latest\n"))
+	assert.ErrorContains(t, err, `invalid version "latest"`)
+
+	_, err = LoadConfig(writeConfig(t, "clef.json", `{"endpoint": "http://localhost:8550", "telemetry": {"log_level": "loud"}}`))
+	assert.ErrorContains(t, err, `telemetry: invalid log_level "loud"`)
+}
+
+func TestConfigHTTPSOnly(t *testing.T) {
+	config := &Config{Endpoint: "http://clef.internal:8550"}
+	_, err := config.Client(context.Background())
+	assert.ErrorIs(t, err, ErrInsecureEndpoint)
+
+	config.InsecureHTTP = true
+	cc, err := config.Client(context.Background())
+	assert.NoError(t, err)
+	cc.Close()
+}
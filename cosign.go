@@ -0,0 +1,207 @@
+package clefclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInsufficientSignatures is returned when fewer signers than the threshold
+// produced a signature
+var ErrInsufficientSignatures = errors.New("insufficient signatures collected")
+
+// Cosigner is one owner taking part in a multi-party signature
+type Cosigner struct {
+	Client  Signer
+	Address string
+	// Timeout overrides the collector's per-signer timeout if non-zero
+	Timeout time.Duration
+}
+
+// CollectedSignature is the outcome of asking one cosigner for a signature
+type CollectedSignature struct {
+	Address   string
+	Signature string
+	// ContentType is the content type of the signed data, empty for typed
+	// data and signatures collected through Collect
+	ContentType string
+	Err         error
+}
+
+// CollectedSignatures are the outcomes of a collection in signature order
+type CollectedSignatures []CollectedSignature
+
+// Packed returns the successful signatures concatenated into one 0x-prefixed
+// blob, the format expected by Safe's execTransaction. The signatures are
+// ordered by ascending signer address, as Safe requires, whatever the order
+// of cs. Typed data signatures get v 0 and 1 mapped to 27 and 28; signatures
+// of text/plain data additionally get v increased by 4, which makes Safe
+// verify them as eth_sign signatures. Other content types cannot be
+// verified by Safe.
+func (cs CollectedSignatures) Packed() (string, error) {
+	sorted := make(CollectedSignatures, 0, len(cs))
+	for _, s := range cs {
+		if s.Err == nil {
+			sorted = append(sorted, s)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return addressLess(sorted[i].Address, sorted[j].Address)
+	})
+
+	var packed []byte
+	for _, s := range sorted {
+		sig, err := decodeHexData(s.Signature)
+		if err != nil {
+			return "", fmt.Errorf("signature of %s: %w", s.Address, err)
+		}
+		if len(sig) != 65 {
+			return "", fmt.Errorf("signature of %s: expected 65 bytes, got %d", s.Address, len(sig))
+		}
+		if sig[64] < 27 {
+			sig[64] += 27
+		}
+		switch s.ContentType {
+		case "":
+		case ContentTypeTextPlain:
+			sig[64] += 4
+		default:
+			return "", fmt.Errorf("signature of %s: %s signatures cannot be packed for Safe", s.Address, s.ContentType)
+		}
+		packed = append(packed, sig...)
+	}
+	return encodeHexData(packed), nil
+}
+
+// SignatureCollectorConfig configures a SignatureCollector
+type SignatureCollectorConfig struct {
+	// Threshold is the number of signatures required, all signers if zero
+	Threshold int
+	// Timeout bounds how long each signer may take, no limit if zero
+	Timeout time.Duration
+	// SortByAddress orders the collected signatures by ascending signer
+	// address, otherwise they keep the order of the signers. Packed sorts
+	// them either way.
+	SortByAddress bool
+}
+
+// SignatureCollector fans the same payload out to several Clef instances and
+// collects their owners' signatures
+type SignatureCollector struct {
+	signers []Cosigner
+	config  SignatureCollectorConfig
+}
+
+// NewSignatureCollector creates a collector over the given signers
+func NewSignatureCollector(signers []Cosigner, config SignatureCollectorConfig) *SignatureCollector {
+	return &SignatureCollector{signers: signers, config: config}
+}
+
+// CollectData asks every signer to sign data with the given content type
+func (sc *SignatureCollector) CollectData(ctx context.Context, contentType, data string) (CollectedSignatures, error) {
+	sigs, err := sc.Collect(ctx, func(s Cosigner) (*SignDataResponse, error) {
+		return s.Client.SignData(&SignDataRequest{ContentType: contentType, Address: s.Address, Data: data})
+	})
+	for i := range sigs {
+		sigs[i].ContentType = contentType
+	}
+	return sigs, err
+}
+
+// CollectTypedData asks every signer to sign the EIP-712 typed data
+func (sc *SignatureCollector) CollectTypedData(ctx context.Context, td *TypedData) (CollectedSignatures, error) {
+	return sc.Collect(ctx, func(s Cosigner) (*SignDataResponse, error) {
+		req, err := td.Request(s.Address)
+		if err != nil {
+			return nil, err
+		}
+		return s.Client.SignTypedData(req)
+	})
+}
+
+// Collect runs sign for every signer concurrently and waits until each has
+// answered or timed out. The outcomes of all signers are returned, together
+// with ErrInsufficientSignatures if fewer than the threshold succeeded. A
+// signer that times out keeps its request pending in Clef; its answer is
+// discarded.
+func (sc *SignatureCollector) Collect(ctx context.Context, sign func(Cosigner) (*SignDataResponse, error)) (CollectedSignatures, error) {
+	results := make(CollectedSignatures, len(sc.signers))
+	done := make(chan struct{}, len(sc.signers))
+	for i, s := range sc.signers {
+		go func() {
+			results[i] = sc.collectOne(ctx, s, sign)
+			done <- struct{}{}
+		}()
+	}
+	for range sc.signers {
+		<-done
+	}
+
+	if sc.config.SortByAddress {
+		sort.SliceStable(results, func(i, j int) bool {
+			return addressLess(results[i].Address, results[j].Address)
+		})
+	}
+
+	threshold := sc.config.Threshold
+	if threshold <= 0 {
+		threshold = len(sc.signers)
+	}
+	var collected int
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Address, r.Err))
+			continue
+		}
+		collected++
+	}
+	if collected < threshold {
+		errs = append([]error{fmt.Errorf("%w: %d of %d", ErrInsufficientSignatures, collected, threshold)}, errs...)
+		return results, errors.Join(errs...)
+	}
+	return results, nil
+}
+
+func (sc *SignatureCollector) collectOne(ctx context.Context, s Cosigner, sign func(Cosigner) (*SignDataResponse, error)) CollectedSignature {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = sc.config.Timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result := make(chan CollectedSignature, 1)
+	go func() {
+		resp, err := sign(s)
+		if err != nil {
+			result <- CollectedSignature{Address: s.Address, Err: err}
+			return
+		}
+		result <- CollectedSignature{Address: s.Address, Signature: resp.Signature}
+	}()
+
+	select {
+	case r := <-result:
+		return r
+	case <-ctx.Done():
+		return CollectedSignature{Address: s.Address, Err: contextError(ctx.Err())}
+	}
+}
+
+// addressLess compares two hex addresses numerically
+func addressLess(a, b string) bool {
+	ab, aerr := decodeHexData(a)
+	bb, berr := decodeHexData(b)
+	if aerr != nil || berr != nil {
+		return strings.ToLower(a) < strings.ToLower(b)
+	}
+	return bytes.Compare(ab, bb) < 0
+}
@@ -0,0 +1,101 @@
+package clefclient
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureCollector(t *testing.T) {
+	a, aServer := setupSignerServer(t, nil, testSignature(0xaa))
+	defer aServer.Close()
+	b, bServer := setupSignerServer(t, nil, testSignature(0xbb))
+	defer bServer.Close()
+
+	collector := NewSignatureCollector([]Cosigner{
+		{Client: a, Address: "0x00000000000000000000000000000000000000ff"},
+		{Client: b, Address: "0x0000000000000000000000000000000000000001"},
+	}, SignatureCollectorConfig{SortByAddress: true})
+
+	sigs, err := collector.CollectData(context.Background(), ContentTypeTextPlain, "0x1234")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x0000000000000000000000000000000000000001", sigs[0].Address)
+	assert.Equal(t, testSignature(0xbb), sigs[0].Signature)
+	assert.Equal(t, testSignature(0xaa), sigs[1].Signature)
+
+	// Safe verifies text/plain signatures as eth_sign ones, with v+4
+	packed, err := sigs.Packed()
+	assert.NoError(t, err)
+	assert.Equal(t, ethSignSignature(0xbb)+ethSignSignature(0xaa)[2:], packed)
+
+	// Typed data signatures are packed unchanged
+	td, err := testPermit().TypedData()
+	assert.NoError(t, err)
+	stub := &stubSigner{signature: testSignature(0xcc)}
+	sigs, err = NewSignatureCollector([]Cosigner{{Client: stub, Address: "0x0000000000000000000000000000000000000001"}}, SignatureCollectorConfig{}).
+		CollectTypedData(context.Background(), td)
+	assert.NoError(t, err)
+	packed, err = sigs.Packed()
+	assert.NoError(t, err)
+	assert.Equal(t, testSignature(0xcc), packed)
+
+	// Safe requires ascending owners and v of 27 or 28 whatever the input
+	low := encodeHexData(append(bytes.Repeat([]byte{0xdd}, 64), 1))
+	packed, err = CollectedSignatures{
+		{Address: "0x00000000000000000000000000000000000000ff", Signature: testSignature(0xcc)},
+		{Address: "0x0000000000000000000000000000000000000002", Err: ErrSigningTimeout},
+		{Address: "0x0000000000000000000000000000000000000001", Signature: low},
+	}.Packed()
+	assert.NoError(t, err)
+	assert.Equal(t, encodeHexData(append(bytes.Repeat([]byte{0xdd}, 64), 28))+testSignature(0xcc)[2:], packed)
+
+	_, err = CollectedSignatures{{Address: "0x0000000000000000000000000000000000000001", Signature: "0x1234"}}.Packed()
+	assert.ErrorContains(t, err, "expected 65 bytes, got 2")
+
+	sigs, err = collector.CollectData(context.Background(), ContentTypeDataValidator, "0x1234")
+	assert.NoError(t, err)
+	_, err = sigs.Packed()
+	assert.ErrorContains(t, err, "data/validator signatures cannot be packed for Safe")
+}
+
+// ethSignSignature is testSignature with the v Safe expects for eth_sign
+func ethSignSignature(b byte) string {
+	return encodeHexData(append(bytes.Repeat([]byte{b}, 64), 31))
+}
+
+func TestSignatureCollectorTimeout(t *testing.T) {
+	fast, fastServer := setupSignerServer(t, nil, testSignature(0xaa))
+	defer fastServer.Close()
+	release := make(chan struct{})
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slowServer.Close()
+	defer close(release)
+
+	signers := []Cosigner{
+		{Client: fast, Address: "0x01"},
+		{Client: NewHTTPClient(slowServer.URL), Address: "0x02", Timeout: 50 * time.Millisecond},
+	}
+
+	sigs, err := NewSignatureCollector(signers, SignatureCollectorConfig{Threshold: 1}).
+		CollectData(context.Background(), ContentTypeTextPlain, "0x1234")
+	assert.NoError(t, err)
+	assert.Equal(t, testSignature(0xaa), sigs[0].Signature)
+	assert.ErrorIs(t, sigs[1].Err, context.DeadlineExceeded)
+	assert.ErrorIs(t, sigs[1].Err, ErrSigningTimeout)
+
+	packed, err := sigs.Packed()
+	assert.NoError(t, err)
+	assert.Equal(t, ethSignSignature(0xaa), packed)
+
+	_, err = NewSignatureCollector(signers, SignatureCollectorConfig{}).
+		CollectData(context.Background(), ContentTypeTextPlain, "0x1234")
+	assert.ErrorIs(t, err, ErrInsufficientSignatures)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,72 @@
+package clefclient
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// keccak256 returns the Keccak-256 hash of the concatenated data
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// TextHash returns the EIP-191 personal message hash of data, the hash clef
+// signs for text/plain requests
+func TextHash(data []byte) []byte {
+	return keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(data))), data)
+}
+
+// RecoverAddress returns the checksummed address of the account that produced
+// the 0x-prefixed 65 byte signature over hash. Both 0/1 and 27/28 recovery ids
+// are accepted.
+func RecoverAddress(hash []byte, signature string) (string, error) {
+	sig, err := decodeHexData(signature)
+	if err != nil {
+		return "", err
+	}
+	if len(hash) != 32 || len(sig) != 65 {
+		return "", errInvalidSignature
+	}
+	sig = append([]byte(nil), sig...)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	pub, err := ecRecover(hash, sig)
+	if err != nil {
+		return "", err
+	}
+	return checksumAddress(pubkeyAddress(pub)), nil
+}
+
+// ChecksumAddress returns the EIP-55 mixed-case form of a hex address
+func ChecksumAddress(address string) (string, error) {
+	b, err := decodeHexData(address)
+	if err != nil {
+		return "", err
+	}
+	if len(b) != 20 {
+		return "", fmt.Errorf("address %q must be 20 bytes", address)
+	}
+	return checksumAddress(b), nil
+}
+
+func checksumAddress(b []byte) string {
+	lower := hex.EncodeToString(b)
+	hash := hex.EncodeToString(keccak256([]byte(lower)))
+	var sb strings.Builder
+	sb.WriteString("0x")
+	for i, c := range lower {
+		if c >= 'a' && hash[i] >= '8' {
+			c -= 'a' - 'A'
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
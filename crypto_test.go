@@ -0,0 +1,69 @@
+package clefclient
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeccak256(t *testing.T) {
+	assert.Equal(t, "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", encodeHexData(keccak256()))
+	assert.Equal(t, "0xa9059cbb", encodeHexData(keccak256([]byte("transfer("), []byte("address,uint256)"))[:4]))
+}
+
+// testSign signs hash with key using the nonce k, returning a 65 byte
+// [R || S || V] signature with V being 27 or 28
+func testSign(t *testing.T, key, k int64, hash []byte) string {
+	d, nonce := big.NewInt(key), big.NewInt(k)
+	R := ecBaseMul(nonce)
+	r := new(big.Int).Mod(R.x, secp256k1N)
+	s := new(big.Int).Mul(r, d)
+	s.Add(s, new(big.Int).SetBytes(hash))
+	s.Mul(s, new(big.Int).ModInverse(nonce, secp256k1N)).Mod(s, secp256k1N)
+	if r.Sign() == 0 || s.Sign() == 0 {
+		t.Fatal("degenerate test signature")
+	}
+	sig := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	return encodeHexData(append(sig, byte(27+R.y.Bit(0))))
+}
+
+func TestRecoverAddress(t *testing.T) {
+	// EIP-155 example transaction signed by 0x4646...46
+	hash, _ := decodeHexData("0xdaf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53")
+	sig := "0x28ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276" +
+		"67cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83" + "00"
+	address, err := RecoverAddress(hash, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", address)
+
+	hash = TextHash([]byte("hello"))
+	address, err = RecoverAddress(hash, testSign(t, 1, 12345, hash))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", address)
+
+	address, err = RecoverAddress(hash, testSign(t, 2, 67890, hash))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF", address)
+
+	_, err = RecoverAddress(hash, "0x1234")
+	assert.Error(t, err)
+	_, err = RecoverAddress(hash, encodeHexData(make([]byte, 65)))
+	assert.Error(t, err)
+}
+
+func TestChecksumAddress(t *testing.T) {
+	for _, expected := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		address, err := ChecksumAddress(strings.ToLower(expected))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, address)
+	}
+	_, err := ChecksumAddress("0x1234")
+	assert.Error(t, err)
+}
@@ -0,0 +1,286 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+// DryRunPolicy is a local check applied to every dry-run request. It receives
+// the method and the request params and rejects the request with an error.
+type DryRunPolicy func(method string, params interface{}) error
+
+// DryRunConfig configures a DryRunClient
+type DryRunConfig struct {
+	// ChainID, if set, must match the chain ID of transactions and of typed
+	// data domains
+	ChainID  *big.Int
+	Policies []DryRunPolicy
+}
+
+// DryRunResult is a validated request as it would be sent to Clef
+type DryRunResult struct {
+	Method string
+	// Payload is the exact JSON-RPC request body
+	Payload json.RawMessage
+}
+
+// DryRunClient validates and serializes signing requests without contacting
+// Clef, e.g. for checking transaction pipelines in CI
+type DryRunClient struct {
+	config DryRunConfig
+	client *ClefClient
+}
+
+// DryRun returns a client that validates requests and returns their wire
+// payloads instead of sending them. Requests are prepared and checked as the
+// client would: its default account and network are filled in, and the
+// policies of EnablePolicies, EnableChainGuard and its network apply along
+// with those of config.
+func (cc *ClefClient) DryRun(config DryRunConfig) *DryRunClient {
+	return &DryRunClient{config: config, client: cc}
+}
+
+// SignTransaction validates tx and returns its account_signTransaction payload
+func (d *DryRunClient) SignTransaction(tx *Transaction) (*DryRunResult, error) {
+	params, err := d.prepare("account_signTransaction", tx)
+	if err != nil {
+		return nil, err
+	}
+	tx = params.(*Transaction)
+	if d.config.ChainID != nil {
+		if tx.ChainID == "" {
+			return nil, fmt.Errorf("transaction: chainId is required")
+		}
+		if err := d.checkChainID(tx.ChainID); err != nil {
+			return nil, fmt.Errorf("transaction: %w", err)
+		}
+	}
+	return d.payload("account_signTransaction", tx)
+}
+
+// SignData validates req and returns its account_signData payload
+func (d *DryRunClient) SignData(req *SignDataRequest) (*DryRunResult, error) {
+	params, err := d.prepare("account_signData", req)
+	if err != nil {
+		return nil, err
+	}
+	req = params.(*SignDataRequest)
+	if _, err := encodeAddressWord(req.Address); err != nil {
+		return nil, fmt.Errorf("sign data: address: %w", err)
+	}
+	if _, err := decodeHexData(req.Data); err != nil {
+		return nil, fmt.Errorf("sign data: %w", err)
+	}
+	switch req.ContentType {
+	case "", ContentTypeTextPlain, ContentTypeDataTyped, ContentTypeCliqueHeader, ContentTypeDataValidator:
+	default:
+		return nil, fmt.Errorf("sign data: unknown content type %q", req.ContentType)
+	}
+	return d.payload("account_signData", req)
+}
+
+// SignTypedData validates req and returns its account_signTypedData payload
+func (d *DryRunClient) SignTypedData(req *TypedDataRequest) (*DryRunResult, error) {
+	params, err := d.prepare("account_signTypedData", req)
+	if err != nil {
+		return nil, err
+	}
+	req = params.(*TypedDataRequest)
+	if _, err := encodeAddressWord(req.Address); err != nil {
+		return nil, fmt.Errorf("typed data: address: %w", err)
+	}
+	var td TypedData
+	if err := json.Unmarshal(req.TypedData, &td); err != nil {
+		return nil, fmt.Errorf("typed data: %w", err)
+	}
+	if _, ok := td.Types[td.PrimaryType]; !ok {
+		return nil, fmt.Errorf("typed data: primary type %q is not defined", td.PrimaryType)
+	}
+	if _, ok := td.Types["EIP712Domain"]; !ok {
+		return nil, fmt.Errorf("typed data: EIP712Domain type is not defined")
+	}
+	if d.config.ChainID != nil {
+		if chainID, ok := td.Domain["chainId"]; ok {
+			if err := d.checkChainID(fmt.Sprint(chainID)); err != nil {
+				return nil, fmt.Errorf("typed data: %w", err)
+			}
+		}
+	}
+	return d.payload("account_signTypedData", req)
+}
+
+func (d *DryRunClient) checkChainID(s string) error {
+	return checkChainID(s, d.config.ChainID)
+}
+
+// checkChainID verifies that the chain ID s, decimal or 0x-prefixed hex, is
+// the expected one
+func checkChainID(s string, expected *big.Int) error {
+	chainID, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return fmt.Errorf("invalid chainId %q", s)
+	}
+	if chainID.Cmp(expected) != 0 {
+		return fmt.Errorf("chainId %s does not match expected %s", chainID, expected)
+	}
+	return nil
+}
+
+// prepare fills in params as the client would and validates them as its
+// transport does
+func (d *DryRunClient) prepare(method string, params interface{}) (interface{}, error) {
+	if v := reflect.ValueOf(params); v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, nilRequestError(method)
+	}
+	if d.client != nil {
+		var err error
+		if params, err = d.client.fillNetwork(d.client.fillAccount(params)); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkRequest(method, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+func (d *DryRunClient) payload(method string, params interface{}) (*DryRunResult, error) {
+	for _, policy := range d.config.Policies {
+		if err := policy(method, params); err != nil {
+			return nil, err
+		}
+	}
+	if d.client != nil {
+		checks := d.client.checks
+		if d.client.network != nil {
+			network := &policyTransport{policies: d.client.network.policies()}
+			checks = append([]DryRunPolicy{network.check}, checks...)
+		}
+		for _, check := range checks {
+			if err := check(method, params); err != nil {
+				return nil, err
+			}
+		}
+	}
+	payload, err := json.Marshal(rpcRequest{Jsonrpc: "2.0", Method: method, Params: params, ID: 1})
+	if err != nil {
+		return nil, err
+	}
+	return &DryRunResult{Method: method, Payload: payload}, nil
+}
+
+// checkTransactionHex verifies that the hex quantity and data fields of a
+// transaction are formatted as Clef requires. It is stricter than
+// checkTransaction, which accepts any spelling of a value.
+func checkTransactionHex(tx *Transaction) error {
+	quantities := []struct{ name, value string }{
+		{"gas", tx.Gas},
+		{"gasPrice", tx.GasPrice},
+		{"maxFeePerGas", tx.MaxFeePerGas},
+		{"maxPriorityFeePerGas", tx.MaxPriorityFeePerGas},
+		{"value", tx.Value},
+		{"nonce", tx.Nonce},
+		{"chainId", tx.ChainID},
+		{"maxFeePerBlobGas", tx.MaxFeePerBlobGas},
+	}
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+		if err := checkHexQuantity(q.value); err != nil {
+			return fmt.Errorf("transaction: %s: %w", q.name, err)
+		}
+	}
+	if tx.Data != "" {
+		if err := checkHexData(tx.Data); err != nil {
+			return fmt.Errorf("transaction: data: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkTransaction verifies the addresses and hex fields of a transaction
+func checkTransaction(tx *Transaction) error {
+	if _, err := encodeAddressWord(tx.From); err != nil {
+		return fmt.Errorf("transaction: from: %w", err)
+	}
+	if tx.To != "" {
+		if _, err := encodeAddressWord(tx.To); err != nil {
+			return fmt.Errorf("transaction: to: %w", err)
+		}
+	}
+	quantities := []struct{ name, value string }{
+		{"gas", tx.Gas},
+		{"gasPrice", tx.GasPrice},
+		{"maxFeePerGas", tx.MaxFeePerGas},
+		{"maxPriorityFeePerGas", tx.MaxPriorityFeePerGas},
+		{"value", tx.Value},
+		{"nonce", tx.Nonce},
+		{"chainId", tx.ChainID},
+		{"maxFeePerBlobGas", tx.MaxFeePerBlobGas},
+	}
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+		if _, err := decodeQuantity(q.value); err != nil {
+			return fmt.Errorf("transaction: %s: %w", q.name, err)
+		}
+	}
+	if tx.Data != "" {
+		if _, err := decodeHexData(tx.Data); err != nil {
+			return fmt.Errorf("transaction: data: %w", err)
+		}
+	}
+	for i, hash := range tx.BlobVersionedHashes {
+		if b, err := decodeHexData(hash); err != nil || len(b) != 32 {
+			return fmt.Errorf("transaction: blobVersionedHashes[%d]: invalid hash %q", i, hash)
+		}
+	}
+	if (tx.MaxFeePerBlobGas != "" || len(tx.BlobVersionedHashes) > 0) && (tx.GasPrice != "" || tx.To == "") {
+		return fmt.Errorf("transaction: blob transactions need EIP-1559 fees and a recipient")
+	}
+	if tx.GasPrice != "" && (tx.MaxFeePerGas != "" || tx.MaxPriorityFeePerGas != "") {
+		return fmt.Errorf("transaction: gasPrice cannot be combined with EIP-1559 fee fields")
+	}
+	if tx.MaxFeePerGas != "" && tx.MaxPriorityFeePerGas != "" {
+		maxFee, _ := decodeQuantity(tx.MaxFeePerGas)
+		tip, _ := decodeQuantity(tx.MaxPriorityFeePerGas)
+		if tip.Cmp(maxFee) > 0 {
+			return fmt.Errorf("transaction: maxPriorityFeePerGas exceeds maxFeePerGas")
+		}
+	}
+	return nil
+}
+
+// txGas is the intrinsic gas of a transaction, the least gas any
+// transaction needs
+const txGas = 21000
+
+// Validate checks tx before it is sent to Clef: From is required, addresses
+// must be 20 bytes, hex fields must be formatted as Clef requires, gas must
+// cover the intrinsic gas of a transaction and gasPrice cannot be combined
+// with EIP-1559 fees. SignTransaction validates transactions automatically.
+func (tx *Transaction) Validate() error {
+	if tx == nil {
+		return nilRequestError("transaction")
+	}
+	if tx.From == "" {
+		return fmt.Errorf("transaction: from is required")
+	}
+	if err := checkTransactionHex(tx); err != nil {
+		return err
+	}
+	if err := checkTransaction(tx); err != nil {
+		return err
+	}
+	if tx.Gas != "" {
+		gas, _ := decodeQuantity(tx.Gas)
+		if gas.Cmp(big.NewInt(txGas)) < 0 {
+			return fmt.Errorf("transaction: gas %s is below the intrinsic gas of %d", gas, txGas)
+		}
+	}
+	return nil
+}
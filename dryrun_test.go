@@ -0,0 +1,147 @@
+package clefclient
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunSignTransaction(t *testing.T) {
+	dry := NewHTTPClient("http://127.0.0.1:0").DryRun(DryRunConfig{ChainID: big.NewInt(1)})
+	tx := &Transaction{
+		From:    "0x0000000000000000000000000000000000000001",
+		To:      "0x0000000000000000000000000000000000000002",
+		Gas:     "0x5208",
+		Value:   "0x1",
+		Nonce:   "0x0",
+		ChainID: "0x1",
+	}
+
+	result, err := dry.SignTransaction(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, "account_signTransaction", result.Method)
+	assert.JSONEq(t, `{
+		"jsonrpc": "2.0",
+		"method": "account_signTransaction",
+		"params": {
+			"from": "0x0000000000000000000000000000000000000001",
+			"to": "0x0000000000000000000000000000000000000002",
+			"gas": "0x5208",
+			"value": "0x1",
+			"nonce": "0x0",
+			"chainId": "0x1"
+		},
+		"id": 1
+	}`, string(result.Payload))
+
+	invalid := *tx
+	invalid.ChainID = "0x5"
+	_, err = dry.SignTransaction(&invalid)
+	assert.ErrorContains(t, err, "does not match")
+
+	invalid = *tx
+	invalid.Value = "10"
+	_, err = dry.SignTransaction(&invalid)
+	assert.ErrorContains(t, err, "value")
+
+	invalid = *tx
+	invalid.GasPrice, invalid.MaxFeePerGas = "0x1", "0x2"
+	_, err = dry.SignTransaction(&invalid)
+	assert.ErrorContains(t, err, "gasPrice")
+}
+
+func TestDryRunAppliesClient(t *testing.T) {
+	alice := "0x0000000000000000000000000000000000000001"
+	bob := "0x0000000000000000000000000000000000000002"
+
+	// Requests the client would refuse are refused
+	client := NewHTTPClient("http://127.0.0.1:0")
+	_, err := client.DryRun(DryRunConfig{}).SignTransaction(&Transaction{From: alice, To: bob, Value: "0x01", Gas: "0x10"})
+	assert.ErrorContains(t, err, "leading zeros")
+	_, err = client.DryRun(DryRunConfig{}).SignTransaction(&Transaction{From: alice, To: bob, Gas: "0x10"})
+	assert.ErrorContains(t, err, "below the intrinsic gas")
+	_, err = client.DryRun(DryRunConfig{}).SignTransaction(nil)
+	assert.ErrorIs(t, err, ErrNilRequest)
+
+	// The default account and network are filled in
+	client = NewHTTPClient("http://127.0.0.1:0", WithNetwork(&Network{Name: "sepolia", ChainID: big.NewInt(11155111)}))
+	client.defaultAccount = alice
+	result, err := client.DryRun(DryRunConfig{}).SignTransaction(&Transaction{To: bob, Gas: "0x5208"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","method":"account_signTransaction","params":{"from":"`+alice+`","to":"`+bob+`","gas":"0x5208","chainId":"0xaa36a7"},"id":1}`, string(result.Payload))
+
+	// The policies of the client and its network apply
+	_, err = client.DryRun(DryRunConfig{}).SignTransaction(&Transaction{To: bob, Gas: "0x5208", ChainID: "0x1"})
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	client.EnableChainGuard(big.NewInt(1))
+	_, err = client.DryRun(DryRunConfig{}).SignTransaction(&Transaction{To: bob, Gas: "0x5208"})
+	assert.ErrorIs(t, err, ErrWrongChain)
+	_, err = client.DryRun(DryRunConfig{}).SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Data: "0x00"})
+	assert.NoError(t, err)
+
+	client = NewHTTPClient("http://127.0.0.1:0")
+	client.EnablePolicies(DenyDestinations(bob))
+	_, err = client.DryRun(DryRunConfig{}).SignTransaction(&Transaction{From: alice, To: bob, Gas: "0x5208"})
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	_, err = client.DryRun(DryRunConfig{}).SignTransaction(&Transaction{From: alice, To: alice, Gas: "0x5208"})
+	assert.NoError(t, err)
+}
+
+func TestDryRunPolicies(t *testing.T) {
+	errDenied := errors.New("denied")
+	dry := NewHTTPClient("http://127.0.0.1:0").DryRun(DryRunConfig{
+		Policies: []DryRunPolicy{func(method string, params interface{}) error {
+			if method == "account_signData" {
+				return errDenied
+			}
+			return nil
+		}},
+	})
+
+	_, err := dry.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000001", Data: "0x00"})
+	assert.ErrorIs(t, err, errDenied)
+
+	req, err := testPermit().Request()
+	assert.NoError(t, err)
+	result, err := dry.SignTypedData(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "account_signTypedData", result.Method)
+
+	req.TypedData = []byte(`{"types": {}, "primaryType": "Permit"}`)
+	_, err = dry.SignTypedData(req)
+	assert.ErrorContains(t, err, "primary type")
+}
+
+func TestTransactionValidate(t *testing.T) {
+	valid := func() *Transaction {
+		return &Transaction{
+			From:     "0x0000000000000000000000000000000000000001",
+			To:       "0x0000000000000000000000000000000000000002",
+			Gas:      "0x5208",
+			GasPrice: "0x3b9aca00",
+			Nonce:    "0x0",
+			Data:     "0xabcd",
+		}
+	}
+	assert.NoError(t, valid().Validate())
+
+	tests := []struct {
+		modify func(tx *Transaction)
+		err    string
+	}{
+		{func(tx *Transaction) { tx.From = "" }, "transaction: from is required"},
+		{func(tx *Transaction) { tx.From = "0x01" }, `transaction: from: invalid address "0x01"`},
+		{func(tx *Transaction) { tx.To = "0x02" }, `transaction: to: invalid address "0x02"`},
+		{func(tx *Transaction) { tx.Gas = "0x5207" }, "transaction: gas 20999 is below the intrinsic gas of 21000"},
+		{func(tx *Transaction) { tx.MaxFeePerGas = "0x1" }, "transaction: gasPrice cannot be combined with EIP-1559 fee fields"},
+		{func(tx *Transaction) { tx.Nonce = "0x00" }, `transaction: nonce: hex quantity "0x00" has leading zeros`},
+		{func(tx *Transaction) { tx.Data = "0xabc" }, `transaction: data: hex data "0xabc" has odd length`},
+	}
+	for _, tt := range tests {
+		tx := valid()
+		tt.modify(tx)
+		assert.EqualError(t, tx.Validate(), tt.err)
+	}
+}
@@ -0,0 +1,131 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedEndpoint is returned by NewClient for endpoints it cannot
+// connect to
+var ErrUnsupportedEndpoint = errors.New("unsupported clef endpoint")
+
+// ErrInsecureEndpoint is returned by clients created with WithHTTPSOnly for
+// plaintext HTTP endpoints that are not on the loopback interface
+var ErrInsecureEndpoint = errors.New("plaintext HTTP endpoint refused")
+
+// StdioEndpoint is the endpoint of a Clef reached over the standard input
+// and output of the process, e.g. when a tool is run through ssh
+const StdioEndpoint = "stdio:"
+
+// NewClient creates a client for the endpoint, inferring the transport from
+// its form:
+//
+//   - http:// and https:// URLs use HTTP
+//   - "stdio:" exchanges requests over the standard input and output
+//   - anything else without a scheme is the path of Clef's IPC socket
+//
+// Clef does not serve WebSocket, so ws:// and wss:// URLs fail with
+// ErrUnsupportedEndpoint, as do other schemes. ctx bounds connecting,
+// including the checks of WithVersionPolicy and WithSignerIdentity.
+func NewClient(ctx context.Context, endpoint string, opts ...Option) (*ClefClient, error) {
+	cc, err := newEndpointClient(ctx, endpoint, opts)
+	if err != nil {
+		return nil, err
+	}
+	o := collectOptions(opts)
+	if o.versionPolicy != nil {
+		if _, err := cc.WithContext(ctx).CheckVersion(*o.versionPolicy); err != nil {
+			cc.Close()
+			return nil, err
+		}
+	}
+	if o.identity != "" {
+		if err := cc.WithContext(ctx).VerifyIdentity(o.identity); err != nil {
+			cc.Close()
+			return nil, err
+		}
+	}
+	return cc, nil
+}
+
+// newEndpointClient creates the client of NewClient
+func newEndpointClient(ctx context.Context, endpoint string, opts []Option) (*ClefClient, error) {
+	scheme, _, hasScheme := strings.Cut(endpoint, "://")
+	switch {
+	case endpoint == "":
+		return nil, fmt.Errorf("%w: empty endpoint", ErrUnsupportedEndpoint)
+	case endpoint == StdioEndpoint:
+		return newClient(newStreamTransport(stdioConn{}), endpoint, opts), nil
+	case hasScheme && (strings.EqualFold(scheme, "http") || strings.EqualFold(scheme, "https")):
+		if o := collectOptions(opts); o.httpsOnly || len(o.pins) > 0 {
+			if err := checkSecureURL(endpoint); err != nil {
+				return nil, err
+			}
+		}
+		return newClient(newHTTPTransport(endpoint), endpoint, opts), nil
+	case hasScheme && (strings.EqualFold(scheme, "ws") || strings.EqualFold(scheme, "wss")):
+		return nil, fmt.Errorf("%w: %s, clef serves HTTP and IPC only", ErrUnsupportedEndpoint, redactEndpoint(endpoint))
+	case hasScheme:
+		return nil, fmt.Errorf("%w: unknown scheme %s", ErrUnsupportedEndpoint, scheme)
+	}
+
+	transport, err := newIPCTransport(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create IPC transport: %w", err)
+	}
+	return newClient(transport, endpoint, opts), nil
+}
+
+// checkSecureURL returns ErrInsecureEndpoint unless rawURL is an https://
+// URL or a plaintext URL of a loopback host
+func checkSecureURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsupportedEndpoint, err)
+	}
+	if strings.EqualFold(u.Scheme, "https") || isLoopback(u.Hostname()) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s, use https:// or a loopback address", ErrInsecureEndpoint, redactEndpoint(rawURL))
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// stdioConn is a net.Conn over the standard input and output. Deadlines only
+// take effect if they are pipes or terminals.
+type stdioConn struct{}
+
+func (stdioConn) Read(b []byte) (int, error)  { return os.Stdin.Read(b) }
+func (stdioConn) Write(b []byte) (int, error) { return os.Stdout.Write(b) }
+
+func (stdioConn) Close() error {
+	return errors.Join(os.Stdin.Close(), os.Stdout.Close())
+}
+
+func (stdioConn) LocalAddr() net.Addr  { return stdioAddr{} }
+func (stdioConn) RemoteAddr() net.Addr { return stdioAddr{} }
+
+func (stdioConn) SetDeadline(t time.Time) error {
+	return errors.Join(os.Stdin.SetReadDeadline(t), os.Stdout.SetWriteDeadline(t))
+}
+
+func (stdioConn) SetReadDeadline(t time.Time) error  { return os.Stdin.SetReadDeadline(t) }
+func (stdioConn) SetWriteDeadline(t time.Time) error { return os.Stdout.SetWriteDeadline(t) }
+
+// stdioAddr is the address of both ends of a stdioConn
+type stdioAddr struct{}
+
+func (stdioAddr) Network() string { return "stdio" }
+func (stdioAddr) String() string  { return StdioEndpoint }
@@ -0,0 +1,103 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientHTTP(t *testing.T) {
+	_, server := setupHTTPTestServer(t, "account_list", []string{"0x0000000000000000000000000000000000000001"})
+	defer server.Close()
+
+	client, err := NewClient(context.Background(), server.URL)
+	assert.NoError(t, err)
+	accounts, err := client.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x0000000000000000000000000000000000000001"}, accounts)
+}
+
+func TestNewClientIPC(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "clef-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	socketPath := filepath.Join(tmpDir, "clef.ipc")
+	listener, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var req rpcRequest
+		assert.NoError(t, json.NewDecoder(conn).Decode(&req))
+		json.NewEncoder(conn).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: json.RawMessage(`["0x0000000000000000000000000000000000000001"]`)})
+	}()
+
+	client, err := NewClient(context.Background(), socketPath)
+	assert.NoError(t, err)
+	defer client.Close()
+	accounts, err := client.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x0000000000000000000000000000000000000001"}, accounts)
+}
+
+func TestNewClientStdio(t *testing.T) {
+	client, err := NewClient(context.Background(), StdioEndpoint)
+	assert.NoError(t, err)
+	assert.IsType(t, &ipcTransport{}, client.transport)
+	assert.Equal(t, StdioEndpoint, client.endpoint)
+}
+
+func TestNewClientUnsupported(t *testing.T) {
+	for _, endpoint := range []string{"", "ws://localhost:8546", "wss://user:pw@clef.example", "ftp://clef.example"} {
+		_, err := NewClient(context.Background(), endpoint)
+		assert.ErrorIs(t, err, ErrUnsupportedEndpoint, endpoint)
+	}
+	_, err := NewClient(context.Background(), "wss://user:pw@clef.example")
+	assert.NotContains(t, err.Error(), "pw")
+
+	_, err = NewClient(context.Background(), "/nonexistent/clef.ipc")
+	assert.ErrorIs(t, err, ErrConnection)
+}
+
+func TestWithHTTPSOnly(t *testing.T) {
+	for _, endpoint := range []string{"http://clef.internal:8550", "http://10.0.0.5:8550", "http://user:pw@clef.example"} {
+		_, err := NewClient(context.Background(), endpoint, WithHTTPSOnly())
+		assert.ErrorIs(t, err, ErrInsecureEndpoint, endpoint)
+		assert.NotContains(t, err.Error(), "pw")
+
+		_, err = NewHTTPClient(endpoint, WithHTTPSOnly()).ListAccounts()
+		assert.ErrorIs(t, err, ErrInsecureEndpoint, endpoint)
+	}
+	for _, endpoint := range []string{"https://clef.internal:8550", "http://localhost:8550", "http://127.0.0.1:8550", "http://[::1]:8550"} {
+		_, err := NewClient(context.Background(), endpoint, WithHTTPSOnly())
+		assert.NoError(t, err, endpoint)
+	}
+
+	// httptest servers listen on loopback
+	_, server := setupHTTPTestServer(t, "account_list", []string{})
+	defer server.Close()
+	client, err := NewClient(context.Background(), server.URL, WithHTTPSOnly())
+	assert.NoError(t, err)
+	_, err = client.ListAccounts()
+	assert.NoError(t, err)
+
+	redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://clef.internal:8550", http.StatusPermanentRedirect)
+	}))
+	defer redirect.Close()
+	_, err = NewHTTPClient(redirect.URL, WithHTTPSOnly()).ListAccounts()
+	assert.ErrorIs(t, err, ErrInsecureEndpoint)
+	assert.NotErrorIs(t, err, ErrConnection)
+}
@@ -0,0 +1,232 @@
+package clefclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ENSRegistryAddress is the address of the ENS registry on mainnet and most
+// testnets
+const ENSRegistryAddress = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+
+// ErrENSNotFound is returned when an ENS name has no resolver or address
+var ErrENSNotFound = errors.New("ens name not found")
+
+var (
+	ensResolverSelector = keccak256([]byte("resolver(bytes32)"))[:4]
+	ensAddrSelector     = keccak256([]byte("addr(bytes32)"))[:4]
+)
+
+// ENSBackend is the node access needed to resolve ENS names
+type ENSBackend interface {
+	BlockNumber() (string, error)
+	// CallContract executes a call against the state at block
+	CallContract(call *Transaction, block string) (string, error)
+}
+
+// ENSResolution records how a name was resolved, so that the resolution can
+// be verified against the chain later
+type ENSResolution struct {
+	Name        string
+	Node        string
+	Resolver    string
+	Address     string
+	BlockNumber string
+	Time        time.Time
+}
+
+// ENSConfig configures an ENSResolver
+type ENSConfig struct {
+	Backend ENSBackend
+	// Registry is the ENS registry, ENSRegistryAddress if empty
+	Registry string
+	// CacheTTL is how long resolutions are reused, no caching if zero
+	CacheTTL time.Duration
+	// OnResolve is called with every resolution made against the chain
+	OnResolve func(r *ENSResolution)
+}
+
+// ENSResolver resolves ENS names to addresses
+type ENSResolver struct {
+	config ENSConfig
+
+	mu    sync.Mutex
+	cache map[string]*ENSResolution
+}
+
+// NewENSResolver creates a new ENSResolver
+func NewENSResolver(config ENSConfig) *ENSResolver {
+	if config.Registry == "" {
+		config.Registry = ENSRegistryAddress
+	}
+	return &ENSResolver{config: config, cache: map[string]*ENSResolution{}}
+}
+
+// IsENSName reports whether s looks like an ENS name rather than a hex address
+func IsENSName(s string) bool {
+	return strings.Contains(s, ".") && !strings.HasPrefix(s, "0x")
+}
+
+// Namehash returns the EIP-137 namehash of an ENS name. Names are lowercased
+// but otherwise not normalized.
+func Namehash(name string) []byte {
+	node := make([]byte, 32)
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = keccak256(node, keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+// Resolve returns the address of name
+func (r *ENSResolver) Resolve(name string) (*ENSResolution, error) {
+	name = strings.ToLower(name)
+	if cached := r.cached(name); cached != nil {
+		return cached, nil
+	}
+
+	block, err := r.config.Backend.BlockNumber()
+	if err != nil {
+		return nil, err
+	}
+	node := Namehash(name)
+	resolver, err := r.callAddress(r.config.Registry, ensResolverSelector, node, block)
+	if err != nil {
+		return nil, fmt.Errorf("ens registry lookup for %s: %w", name, err)
+	}
+	if resolver == nil {
+		return nil, fmt.Errorf("%w: %s has no resolver", ErrENSNotFound, name)
+	}
+	address, err := r.callAddress(encodeHexData(resolver), ensAddrSelector, node, block)
+	if err != nil {
+		return nil, fmt.Errorf("ens resolver lookup for %s: %w", name, err)
+	}
+	if address == nil {
+		return nil, fmt.Errorf("%w: %s has no address", ErrENSNotFound, name)
+	}
+
+	resolution := &ENSResolution{
+		Name:        name,
+		Node:        encodeHexData(node),
+		Resolver:    checksumAddress(resolver),
+		Address:     checksumAddress(address),
+		BlockNumber: block,
+		Time:        time.Now().UTC(),
+	}
+	if r.config.OnResolve != nil {
+		r.config.OnResolve(resolution)
+	}
+	if r.config.CacheTTL > 0 {
+		r.mu.Lock()
+		r.cache[name] = resolution
+		r.mu.Unlock()
+	}
+	return resolution, nil
+}
+
+func (r *ENSResolver) cached(name string) *ENSResolution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cached, ok := r.cache[name]
+	if !ok {
+		return nil
+	}
+	if time.Since(cached.Time) > r.config.CacheTTL {
+		delete(r.cache, name)
+		return nil
+	}
+	return cached
+}
+
+// callAddress calls an (bytes32) -> address view function, returning nil for
+// the zero address
+func (r *ENSResolver) callAddress(contract string, selector, node []byte, block string) ([]byte, error) {
+	data := append(append([]byte{}, selector...), node...)
+	output, err := r.config.Backend.CallContract(&Transaction{To: contract, Data: encodeHexData(data)}, block)
+	if err != nil {
+		return nil, err
+	}
+	word, err := decodeHexData(output)
+	if err != nil {
+		return nil, err
+	}
+	if len(word) == 0 {
+		return nil, nil
+	}
+	if len(word) != abiWordSize {
+		return nil, fmt.Errorf("unexpected output %s", output)
+	}
+	address := word[12:]
+	if bytes.Equal(address, make([]byte, 20)) {
+		return nil, nil
+	}
+	return address, nil
+}
+
+// CallContract executes a call against the state at block without creating a
+// transaction
+func (nc *NodeClient) CallContract(call *Transaction, block string) (string, error) {
+	return nc.callString("eth_call", []interface{}{call, block})
+}
+
+// EnableENS resolves ENS names in the To field of transactions before they are
+// sent to Clef. Enable it after EnableAudit so that the audit log records the
+// resolved addresses.
+func (cc *ClefClient) EnableENS(resolver *ENSResolver) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &ensTransport{next: cc.transport, resolver: resolver}
+}
+
+// ensTransport is a transport decorator resolving ENS names in transactions
+type ensTransport struct {
+	next     transport
+	resolver *ENSResolver
+}
+
+func (t *ensTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	params, err := t.resolve(params)
+	if err != nil {
+		return nil, err
+	}
+	return t.next.call(ctx, method, params)
+}
+
+func (t *ensTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	resolved := make([]interface{}, len(params))
+	for i, p := range params {
+		var err error
+		if resolved[i], err = t.resolve(p); err != nil {
+			return nil, err
+		}
+	}
+	return t.next.callBatch(ctx, method, resolved)
+}
+
+func (t *ensTransport) close() error {
+	return t.next.close()
+}
+
+// resolve returns a copy of a transaction with its ENS destination resolved
+func (t *ensTransport) resolve(params interface{}) (interface{}, error) {
+	tx, ok := params.(*Transaction)
+	if !ok || !IsENSName(tx.To) {
+		return params, nil
+	}
+	resolution, err := t.resolver.Resolve(tx.To)
+	if err != nil {
+		return nil, err
+	}
+	resolved := *tx
+	resolved.To = resolution.Address
+	return &resolved, nil
+}
@@ -0,0 +1,97 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeENSBackend resolves names from a map of namehash to address
+type fakeENSBackend struct {
+	resolver  string
+	addresses map[string]string
+	calls     atomic.Int32
+}
+
+func (b *fakeENSBackend) BlockNumber() (string, error) {
+	return "0x10", nil
+}
+
+func (b *fakeENSBackend) CallContract(call *Transaction, block string) (string, error) {
+	b.calls.Add(1)
+	selector, node := call.Data[:10], "0x"+call.Data[10:]
+	address, ok := b.addresses[node]
+	if !ok {
+		return "0x" + word("0"), nil
+	}
+	if selector == encodeHexData(ensResolverSelector) {
+		return "0x" + word(b.resolver[2:]), nil
+	}
+	return "0x" + word(strings.ToLower(address[2:])), nil
+}
+
+func TestNamehash(t *testing.T) {
+	assert.Equal(t, "0x"+word("0"), encodeHexData(Namehash("")))
+	assert.Equal(t, "0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae", encodeHexData(Namehash("eth")))
+	assert.Equal(t, "0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f", encodeHexData(Namehash("foo.eth")))
+}
+
+func TestENSResolver(t *testing.T) {
+	backend := &fakeENSBackend{
+		resolver:  "0x4976fb03C32e5B8cfe2b6cCB31c09Ba78EBaBa41",
+		addresses: map[string]string{encodeHexData(Namehash("vitalik.eth")): "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"},
+	}
+	var logged []*ENSResolution
+	resolver := NewENSResolver(ENSConfig{
+		Backend:   backend,
+		CacheTTL:  time.Minute,
+		OnResolve: func(r *ENSResolution) { logged = append(logged, r) },
+	})
+
+	resolution, err := resolver.Resolve("Vitalik.eth")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045", resolution.Address)
+	assert.Equal(t, "0x4976fb03C32e5B8cfe2b6cCB31c09Ba78EBaBa41", resolution.Resolver)
+	assert.Equal(t, "0x10", resolution.BlockNumber)
+
+	_, err = resolver.Resolve("vitalik.eth")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), backend.calls.Load())
+	assert.Len(t, logged, 1)
+
+	_, err = resolver.Resolve("unknown.eth")
+	assert.ErrorIs(t, err, ErrENSNotFound)
+}
+
+func TestEnableENS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params Transaction `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		result, _ := json.Marshal(SignTxResponse{Raw: req.Params.To})
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: result})
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL)
+	client.EnableENS(NewENSResolver(ENSConfig{Backend: &fakeENSBackend{
+		resolver:  "0x4976fb03C32e5B8cfe2b6cCB31c09Ba78EBaBa41",
+		addresses: map[string]string{encodeHexData(Namehash("vitalik.eth")): "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045"},
+	}}))
+
+	tx := &Transaction{From: "0x0000000000000000000000000000000000000001", To: "vitalik.eth"}
+	signed, err := client.SignTransaction(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045", signed.Raw)
+	assert.Equal(t, "vitalik.eth", tx.To)
+
+	_, err = client.SignTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001", To: "missing.eth"})
+	assert.ErrorIs(t, err, ErrENSNotFound)
+}
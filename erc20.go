@@ -0,0 +1,88 @@
+package clefclient
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ERC20ABI is the JSON ABI of the ERC-20 functions used by the builders
+const ERC20ABI = `[
+	{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}]},
+	{"type": "function", "name": "approve", "inputs": [{"name": "spender", "type": "address"}, {"name": "value", "type": "uint256"}]},
+	{"type": "function", "name": "transferFrom", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}]},
+	{"type": "function", "name": "balanceOf", "inputs": [{"name": "owner", "type": "address"}]},
+	{"type": "function", "name": "allowance", "inputs": [{"name": "owner", "type": "address"}, {"name": "spender", "type": "address"}]}
+]`
+
+// ERC20Token builds transactions for an ERC-20 token
+type ERC20Token struct {
+	Address string
+	// Decimals is the token's decimals(), used to convert decimal amounts
+	Decimals uint8
+}
+
+// Transfer builds a transfer of amount tokens, a decimal string such as
+// "12.5", from the from account to recipient
+func (t ERC20Token) Transfer(from, recipient, amount string) (*Transaction, error) {
+	value, err := ParseUnits(amount, t.Decimals)
+	if err != nil {
+		return nil, err
+	}
+	return buildTokenCall(t.Address, ERC20ABI, from, "transfer", recipient, value)
+}
+
+// Approve builds an approval allowing spender to transfer amount tokens of
+// the from account
+func (t ERC20Token) Approve(from, spender, amount string) (*Transaction, error) {
+	value, err := ParseUnits(amount, t.Decimals)
+	if err != nil {
+		return nil, err
+	}
+	return buildTokenCall(t.Address, ERC20ABI, from, "approve", spender, value)
+}
+
+// TransferFrom builds a transfer of amount tokens from owner to recipient,
+// sent by the from account using its allowance
+func (t ERC20Token) TransferFrom(from, owner, recipient, amount string) (*Transaction, error) {
+	value, err := ParseUnits(amount, t.Decimals)
+	if err != nil {
+		return nil, err
+	}
+	return buildTokenCall(t.Address, ERC20ABI, from, "transferFrom", owner, recipient, value)
+}
+
+// ParseUnits converts a decimal amount such as "1.5" to its integer value in
+// the smallest unit of a token with the given decimals
+func ParseUnits(amount string, decimals uint8) (*big.Int, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(amount), ".")
+	if whole == "" && fraction == "" {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(fraction) > int(decimals) {
+		return nil, fmt.Errorf("amount %q has more than %d decimals", amount, decimals)
+	}
+	digits := whole + fraction + strings.Repeat("0", int(decimals)-len(fraction))
+	if strings.Trim(digits, "0123456789") != "" {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	v, _ := new(big.Int).SetString(digits, 10)
+	return v, nil
+}
+
+// FormatUnits converts an integer value in the smallest unit of a token with
+// the given decimals to a decimal string, the inverse of ParseUnits
+func FormatUnits(value *big.Int, decimals uint8) string {
+	s := new(big.Int).Abs(value).String()
+	if len(s) <= int(decimals) {
+		s = strings.Repeat("0", int(decimals)-len(s)+1) + s
+	}
+	whole, fraction := s[:len(s)-int(decimals)], strings.TrimRight(s[len(s)-int(decimals):], "0")
+	if value.Sign() < 0 {
+		whole = "-" + whole
+	}
+	if fraction == "" {
+		return whole
+	}
+	return whole + "." + fraction
+}
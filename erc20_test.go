@@ -0,0 +1,62 @@
+package clefclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUnits(t *testing.T) {
+	for amount, expected := range map[string]string{
+		"1":        "1000000",
+		"1.5":      "1500000",
+		".000001":  "1",
+		"12.3456":  "12345600",
+		"0":        "0",
+		" 100.00 ": "100000000",
+	} {
+		v, err := ParseUnits(amount, 6)
+		assert.NoError(t, err, amount)
+		assert.Equal(t, expected, v.String(), amount)
+	}
+	for _, amount := range []string{"", ".", "1.0000001", "-1", "1e6", "1,5", "0x10"} {
+		_, err := ParseUnits(amount, 6)
+		assert.Error(t, err, amount)
+	}
+
+	v, err := ParseUnits("7", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), v.Int64())
+}
+
+func TestFormatUnits(t *testing.T) {
+	assert.Equal(t, "1.5", FormatUnits(big.NewInt(1500000), 6))
+	assert.Equal(t, "0.000001", FormatUnits(big.NewInt(1), 6))
+	assert.Equal(t, "0", FormatUnits(big.NewInt(0), 6))
+	assert.Equal(t, "-2", FormatUnits(big.NewInt(-2000000), 6))
+	assert.Equal(t, "42", FormatUnits(big.NewInt(42), 0))
+}
+
+func TestERC20Token(t *testing.T) {
+	token := ERC20Token{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Decimals: 6}
+	from := "0x0000000000000000000000000000000000000001"
+	to := "0x0000000000000000000000000000000000000002"
+
+	tx, err := token.Transfer(from, to, "2.5")
+	assert.NoError(t, err)
+	assert.Equal(t, &Transaction{From: from, To: token.Address, Data: "0xa9059cbb" + word("2") + word("2625a0")}, tx)
+
+	tx, err = token.Approve(from, to, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x095ea7b3"+word("2")+word("f4240"), tx.Data)
+
+	tx, err = token.TransferFrom(to, from, to, "0.000001")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x23b872dd"+word("1")+word("2")+word("1"), tx.Data)
+
+	_, err = token.Transfer(from, to, "0.0000001")
+	assert.ErrorContains(t, err, "more than 6 decimals")
+	_, err = ERC20Token{Address: "usdc"}.Transfer(from, to, "1")
+	assert.ErrorContains(t, err, "token")
+}
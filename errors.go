@@ -0,0 +1,239 @@
+package clefclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// Classes of the errors returned by the client, so retry logic and alerting
+// can tell Clef rejecting a request apart from a flaky network. Errors match
+// at most one class with errors.Is.
+var (
+	// ErrConnection is matched by failures to reach Clef or to read its answer
+	ErrConnection = errors.New("connection error")
+	// ErrMalformedResponse is matched by answers that are not valid JSON-RPC
+	ErrMalformedResponse = errors.New("malformed response")
+	// ErrSigner is matched by the errors returned by Clef, see RPCError
+	ErrSigner = errors.New("signer error")
+)
+
+// CallError is the error of a call made by the client. It carries the method
+// and a summary of the request, so logged errors can be traced to the
+// request without leaking its payload: the summary holds the accounts and
+// the value only, never data or signatures.
+type CallError struct {
+	Method string
+	// Request is the redacted summary of the request, empty if there is
+	// nothing to summarize
+	Request string
+	Err     error
+}
+
+func (e *CallError) Error() string {
+	if e.Request == "" {
+		return fmt.Sprintf("%s: %v", e.Method, e.Err)
+	}
+	return fmt.Sprintf("%s (%s): %v", e.Method, e.Request, e.Err)
+}
+
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+// summarizeRequest returns the redacted summary of the params of a call
+func summarizeRequest(params interface{}) string {
+	var fields [][2]string
+	switch p := params.(type) {
+	case *Transaction:
+		fields = [][2]string{{"from", p.From}, {"to", p.To}, {"value", p.Value}}
+	case *SignDataRequest:
+		fields = [][2]string{{"address", p.Address}, {"content_type", p.ContentType}}
+	case *TypedDataRequest:
+		fields = [][2]string{{"address", p.Address}}
+	}
+	var parts []string
+	for _, f := range fields {
+		if f[1] != "" {
+			parts = append(parts, f[0]+"="+f[1])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Errors returned when a context aborts a signing request. They keep the
+// context's error, so errors.Is also matches context.DeadlineExceeded and
+// context.Canceled.
+var (
+	// ErrSigningTimeout is returned when the deadline of a signing request
+	// passes, typically because the approval took too long
+	ErrSigningTimeout = errors.New("signing timed out")
+	// ErrCanceled is returned when a signing request is canceled
+	ErrCanceled = errors.New("signing canceled")
+	// ErrRequestExpired is returned when a signing request waited longer
+	// than its maximum age and was not sent to Clef
+	ErrRequestExpired = errors.New("signing request expired")
+)
+
+// contextError classifies the error of a context that aborted a signing
+// request
+func contextError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return &classifiedError{class: ErrSigningTimeout, err: err}
+	case errors.Is(err, context.Canceled):
+		return &classifiedError{class: ErrCanceled, err: err}
+	}
+	return err
+}
+
+// Errors returned instead of panicking when the client is misconfigured
+var (
+	// ErrNilClient is returned by the methods of a nil ClefClient or of one
+	// that was not created by a constructor
+	ErrNilClient = errors.New("clef client is nil or not initialized")
+	// ErrNilRequest is returned when a request argument is nil
+	ErrNilRequest = errors.New("nil request")
+)
+
+// nilRequestError reports that the named request argument is nil
+func nilRequestError(name string) error {
+	return fmt.Errorf("%s: %w", name, ErrNilRequest)
+}
+
+// ErrEmptyResult is returned when Clef answers a call without an error but
+// with a null or missing result. It is classified as ErrMalformedResponse.
+var ErrEmptyResult = errors.New("clef returned an empty result")
+
+// classifiedError is an error matched to one of the error classes. It keeps
+// the message of the wrapped error.
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() []error {
+	return []error{e.class, e.err}
+}
+
+// connectionError classifies err as a connection failure
+func connectionError(err error) error {
+	return &classifiedError{class: ErrConnection, err: err}
+}
+
+// decodeError classifies an error decoding an answer: a connection failure
+// if the answer was cut short, a malformed response otherwise
+func decodeError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr) {
+		return connectionError(err)
+	}
+	return malformedError(err)
+}
+
+// malformedError classifies err as a malformed response
+func malformedError(err error) error {
+	return &classifiedError{class: ErrMalformedResponse, err: err}
+}
+
+// Errors matching the well-known rejections of Clef. The *RPCError returned
+// by the client keeps Clef's message and wraps the matching sentinel, so
+// callers can test for them with errors.Is.
+var (
+	// ErrRequestDenied is returned when the user or the rules reject a request
+	ErrRequestDenied = errors.New("request denied")
+	// ErrUserDeniedTransaction is returned when a transaction signing request
+	// is rejected. It is returned together with ErrRequestDenied.
+	ErrUserDeniedTransaction = errors.New("user denied transaction")
+	// ErrAccountNotFound is returned for accounts Clef does not manage
+	ErrAccountNotFound = errors.New("account not found")
+	// ErrPasswordRequired is returned when an account is locked and no
+	// password was provided or stored
+	ErrPasswordRequired = errors.New("password required")
+)
+
+// signerErrorPatterns map fragments of Clef's error messages, lower cased,
+// to sentinels
+var signerErrorPatterns = []struct {
+	fragment string
+	err      error
+}{
+	{"request denied", ErrRequestDenied},
+	{"denied transaction", ErrRequestDenied},
+	{"unknown account", ErrAccountNotFound},
+	{"account not found", ErrAccountNotFound},
+	{"no such account", ErrAccountNotFound},
+	{"authentication needed", ErrPasswordRequired},
+	{"password required", ErrPasswordRequired},
+}
+
+// RPCError is a JSON-RPC error returned by Clef. Use errors.As to retrieve
+// it from the errors returned by the client. It matches ErrSigner. Its
+// message includes the data member, if any, so logs show the details.
+type RPCError struct {
+	Code    int
+	Message string
+	// Data is the optional data member of the error, nil if absent
+	Data json.RawMessage
+	// Result is the result a malformed answer carried along with the error,
+	// which is ignored. It is nil for well-formed answers.
+	Result json.RawMessage
+
+	sentinels []error
+}
+
+func (e *RPCError) Error() string {
+	if len(e.Data) == 0 || string(e.Data) == "null" {
+		return e.Message
+	}
+	var text string
+	if json.Unmarshal(e.Data, &text) == nil {
+		return fmt.Sprintf("%s: %s", e.Message, text)
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact, e.Data) != nil {
+		return fmt.Sprintf("%s: %s", e.Message, e.Data)
+	}
+	return fmt.Sprintf("%s: %s", e.Message, compact.String())
+}
+
+// DecodeData unmarshals the data member of the error into v, for Clef's
+// structured details such as validation messages
+func (e *RPCError) DecodeData(v interface{}) error {
+	if len(e.Data) == 0 {
+		return fmt.Errorf("rpc error has no data")
+	}
+	return json.Unmarshal(e.Data, v)
+}
+
+// Unwrap returns ErrSigner and the sentinels matching the error
+func (e *RPCError) Unwrap() []error {
+	return append([]error{ErrSigner}, e.sentinels...)
+}
+
+// newCallError converts the error response of a call of method to an
+// *RPCError, matched to the sentinels of Clef's well-known rejections
+func newCallError(method string, e *rpcError) error {
+	err := &RPCError{Code: e.Code, Message: e.Message, Data: e.Data, Result: e.result}
+	lower := strings.ToLower(e.Message)
+	for _, p := range signerErrorPatterns {
+		if !strings.Contains(lower, p.fragment) {
+			continue
+		}
+		err.sentinels = []error{p.err}
+		if p.err == ErrRequestDenied && method == "account_signTransaction" {
+			err.sentinels = append(err.sentinels, ErrUserDeniedTransaction)
+		}
+		break
+	}
+	return err
+}
@@ -0,0 +1,209 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupErrorTestServer returns a client whose calls all fail with message
+func setupErrorTestServer(t *testing.T, message string) (*ClefClient, *httptest.Server) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"` + message + `"}}`))
+	}))
+	return NewHTTPClient(server.URL), server
+}
+
+func TestSignerErrorSentinels(t *testing.T) {
+	tests := []struct {
+		message string
+		call    func(cc *ClefClient) error
+		is      []error
+		isNot   []error
+	}{
+		{
+			message: "Request denied",
+			call: func(cc *ClefClient) error {
+				_, err := cc.SignTransaction(&Transaction{From: "0x1234567890123456789012345678901234567890"})
+				return err
+			},
+			is: []error{ErrRequestDenied, ErrUserDeniedTransaction},
+		},
+		{
+			message: "Request denied",
+			call: func(cc *ClefClient) error {
+				_, err := cc.SignData(&SignDataRequest{Address: "0x1234567890123456789012345678901234567890", Data: "0x00"})
+				return err
+			},
+			is:    []error{ErrRequestDenied},
+			isNot: []error{ErrUserDeniedTransaction},
+		},
+		{
+			message: "unknown account",
+			call: func(cc *ClefClient) error {
+				_, err := cc.SignData(&SignDataRequest{Address: "0x1234567890123456789012345678901234567890", Data: "0x00"})
+				return err
+			},
+			is: []error{ErrAccountNotFound},
+		},
+		{
+			message: "authentication needed: password or unlock",
+			call: func(cc *ClefClient) error {
+				_, err := cc.SignTransaction(&Transaction{From: "0x1234567890123456789012345678901234567890"})
+				return err
+			},
+			is:    []error{ErrPasswordRequired},
+			isNot: []error{ErrRequestDenied},
+		},
+		{
+			message: "something else",
+			call: func(cc *ClefClient) error {
+				_, err := cc.ListAccounts()
+				return err
+			},
+			isNot: []error{ErrRequestDenied, ErrAccountNotFound, ErrPasswordRequired},
+		},
+	}
+	for _, tt := range tests {
+		client, server := setupErrorTestServer(t, tt.message)
+		err := tt.call(client)
+		server.Close()
+
+		var rpcErr *RPCError
+		if assert.True(t, errors.As(err, &rpcErr)) {
+			assert.Equal(t, tt.message, rpcErr.Message)
+		}
+		for _, target := range tt.is {
+			assert.True(t, errors.Is(err, target), "%q should be %v", tt.message, target)
+		}
+		for _, target := range tt.isNot {
+			assert.False(t, errors.Is(err, target), "%q should not be %v", tt.message, target)
+		}
+	}
+}
+
+func TestBatchSignerErrorSentinels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"Request denied"}}]`))
+	}))
+	defer server.Close()
+
+	results, err := NewHTTPClient(server.URL).SignTransactions([]*Transaction{{From: "0x1234567890123456789012345678901234567890"}})
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(results[0].Err, ErrUserDeniedTransaction))
+}
+
+func TestRPCError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"Request denied","data":{"reason":"rule"}}}`))
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPClient(server.URL).SignTransaction(&Transaction{From: "0x1234567890123456789012345678901234567890"})
+	var rpcErr *RPCError
+	if assert.True(t, errors.As(err, &rpcErr)) {
+		assert.Equal(t, -32000, rpcErr.Code)
+		assert.Equal(t, "Request denied", rpcErr.Message)
+		assert.JSONEq(t, `{"reason":"rule"}`, string(rpcErr.Data))
+	}
+	assert.True(t, errors.Is(err, ErrUserDeniedTransaction))
+}
+
+func TestErrorClasses(t *testing.T) {
+	classes := []error{ErrConnection, ErrMalformedResponse, ErrSigner}
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	malformed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":[}`))
+	}))
+	defer malformed.Close()
+	signer, server := setupErrorTestServer(t, "Request denied")
+	defer server.Close()
+
+	tests := []struct {
+		client *ClefClient
+		class  error
+	}{
+		{NewHTTPClient(closed.URL), ErrConnection},
+		{NewHTTPClient(malformed.URL), ErrMalformedResponse},
+		{signer, ErrSigner},
+	}
+	for _, tt := range tests {
+		_, err := tt.client.ListAccounts()
+		for _, class := range classes {
+			assert.Equal(t, class == tt.class, errors.Is(err, class), "%v should match %v: %v", err, tt.class, class)
+		}
+	}
+}
+
+func TestCallErrorRedactsRequest(t *testing.T) {
+	client, server := setupErrorTestServer(t, "Request denied")
+	defer server.Close()
+
+	_, err := client.SignTransaction(&Transaction{
+		From:  "0x1234567890123456789012345678901234567890",
+		To:    "0x0000000000000000000000000000000000000002",
+		Value: "0x1",
+		Data:  "0xa9059cbb",
+	})
+	assert.EqualError(t, err, "account_signTransaction (from=0x1234567890123456789012345678901234567890, to=0x0000000000000000000000000000000000000002, value=0x1): Request denied")
+	var callErr *CallError
+	if assert.True(t, errors.As(err, &callErr)) {
+		assert.Equal(t, "account_signTransaction", callErr.Method)
+	}
+	assert.True(t, errors.Is(err, ErrUserDeniedTransaction))
+
+	_, err = client.EcRecover(&EcRecoverRequest{Data: "0x68656c6c6f", Signature: "0x1234"})
+	assert.EqualError(t, err, "account_ecRecover: Request denied")
+}
+
+func TestResultAndErrorInResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":["0x1234567890123456789012345678901234567890"],"error":{"code":-32000,"message":"Request denied"}}`))
+	}))
+	defer server.Close()
+
+	accounts, err := NewHTTPClient(server.URL).ListAccounts()
+	assert.Nil(t, accounts)
+	assert.EqualError(t, err, "account_list: Request denied")
+	var rpcErr *RPCError
+	if assert.True(t, errors.As(err, &rpcErr)) {
+		assert.JSONEq(t, `["0x1234567890123456789012345678901234567890"]`, string(rpcErr.Result))
+	}
+
+	resp := &rpcResponse{Result: []byte("null"), Error: &rpcError{Message: "Request denied"}}
+	settleResponse(resp)
+	assert.Nil(t, resp.Result)
+	assert.Nil(t, resp.Error.result)
+}
+
+func TestRPCErrorData(t *testing.T) {
+	tests := []struct {
+		data string
+		msg  string
+	}{
+		{``, "Request denied"},
+		{`null`, "Request denied"},
+		{`"gas too low"`, "Request denied: gas too low"},
+		{`{ "field": "gas" }`, `Request denied: {"field":"gas"}`},
+	}
+	for _, tt := range tests {
+		err := &RPCError{Message: "Request denied", Data: json.RawMessage(tt.data)}
+		assert.EqualError(t, err, tt.msg)
+	}
+
+	var details struct {
+		Field string `json:"field"`
+	}
+	err := &RPCError{Message: "invalid transaction", Data: json.RawMessage(`{"field":"gas"}`)}
+	assert.NoError(t, err.DecodeData(&details))
+	assert.Equal(t, "gas", details.Field)
+	assert.Error(t, (&RPCError{}).DecodeData(&details))
+}
@@ -0,0 +1,38 @@
+package clefclient
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// requestDeadlineKey is the context key of the time after which requests
+// are no longer sent to Clef, see WithRequestDeadline
+type requestDeadlineKey struct{}
+
+// WithRequestDeadline returns a context carrying a deadline after which the
+// requests of a client using it, see ClefClient.WithContext, fail with
+// ErrRequestExpired instead of being sent to Clef. Unlike a context
+// deadline it does not abort requests already sent, which may be waiting
+// for approval. It is checked before every attempt of retried calls and
+// when a TimeLock releases a request. The earlier of nested deadlines
+// applies.
+func WithRequestDeadline(ctx context.Context, deadline time.Time) context.Context {
+	if prev, ok := ctx.Value(requestDeadlineKey{}).(time.Time); ok && prev.Before(deadline) {
+		return ctx
+	}
+	return context.WithValue(ctx, requestDeadlineKey{}, deadline)
+}
+
+// checkRequestDeadline fails with ErrRequestExpired if the deadline carried
+// by ctx has passed
+func checkRequestDeadline(ctx context.Context) error {
+	deadline, ok := ctx.Value(requestDeadlineKey{}).(time.Time)
+	if !ok {
+		return nil
+	}
+	if late := time.Since(deadline); late > 0 {
+		return fmt.Errorf("%w: deadline passed %s ago", ErrRequestExpired, late.Round(time.Millisecond))
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package clefclient
+
+import (
+	"context"
+	"expvar"
+)
+
+// EnableExpvar counts every subsequent request made by the client in the
+// expvar map published under name, creating it if needed, so that several
+// clients can share one map. The map holds the counters calls, errors,
+// bytes_sent and bytes_received; bytes are counted on the wire of the HTTP
+// and IPC transports.
+func (cc *ClefClient) EnableExpvar(name string) *expvar.Map {
+	vars, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		vars = expvar.NewMap(name)
+	}
+	if !cc.ready() {
+		return vars
+	}
+	cc.transport = &expvarTransport{next: cc.transport, vars: vars}
+	return vars
+}
+
+// expvarTransport is a transport decorator counting calls in an expvar map
+type expvarTransport struct {
+	next transport
+	vars *expvar.Map
+}
+
+func (t *expvarTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	resp, err := t.next.call(withByteObserver(ctx, t.observeBytes), method, params)
+	t.vars.Add("calls", 1)
+	if err != nil || resp.Error != nil {
+		t.vars.Add("errors", 1)
+	}
+	return resp, err
+}
+
+func (t *expvarTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	resps, err := t.next.callBatch(withByteObserver(ctx, t.observeBytes), method, params)
+	t.vars.Add("calls", int64(len(params)))
+	for i := range params {
+		if err != nil || resps[i].Error != nil {
+			t.vars.Add("errors", 1)
+		}
+	}
+	return resps, err
+}
+
+func (t *expvarTransport) close() error {
+	return t.next.close()
+}
+
+func (t *expvarTransport) observeBytes(sent, received int64) {
+	t.vars.Add("bytes_sent", sent)
+	t.vars.Add("bytes_received", received)
+}
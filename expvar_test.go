@@ -0,0 +1,59 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"expvar"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpvarHTTP(t *testing.T) {
+	client, server := setupHTTPTestServer(t, "account_list", []string{"0x0000000000000000000000000000000000000001"})
+	defer server.Close()
+
+	vars := client.EnableExpvar("clef_test_http")
+	_, err := client.ListAccounts()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "1", vars.Get("calls").String())
+	assert.Nil(t, vars.Get("errors"))
+	sent, _ := json.Marshal(rpcRequest{Jsonrpc: "2.0", Method: "account_list", ID: 1})
+	received, _ := json.Marshal(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: json.RawMessage(`["0x0000000000000000000000000000000000000001"]`)})
+	assert.Equal(t, strconv.Itoa(len(sent)), vars.Get("bytes_sent").String())
+	assert.Equal(t, strconv.Itoa(len(received)+1), vars.Get("bytes_received").String())
+	assert.Same(t, vars, expvar.Get("clef_test_http"))
+}
+
+func TestExpvarIPC(t *testing.T) {
+	client, listener, tmpDir := setupIPCTestServer(t, "account_list", []string{"0x0000000000000000000000000000000000000001"})
+	defer listener.Close()
+	defer os.RemoveAll(tmpDir)
+	defer client.Close()
+
+	vars := client.EnableExpvar("clef_test_ipc")
+	_, err := client.ListAccounts()
+	assert.NoError(t, err)
+
+	sent, _ := json.Marshal(rpcRequest{Jsonrpc: "2.0", Method: "account_list", ID: 1})
+	received, _ := json.Marshal(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: json.RawMessage(`["0x0000000000000000000000000000000000000001"]`)})
+	assert.Equal(t, strconv.Itoa(len(sent)+1), vars.Get("bytes_sent").String())
+	assert.Equal(t, strconv.Itoa(len(received)), vars.Get("bytes_received").String())
+}
+
+func TestExpvarShared(t *testing.T) {
+	cc := &ClefClient{transport: &countingTransport{}}
+	vars := cc.EnableExpvar("clef_test_shared")
+	other := &ClefClient{transport: &failingTransport{err: ErrAuditFailed}}
+	assert.Same(t, vars, other.EnableExpvar("clef_test_shared"))
+
+	_, err := cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001"}, {From: "0x0000000000000000000000000000000000000001"}})
+	assert.NoError(t, err)
+	_, err = other.ListAccounts()
+	assert.Error(t, err)
+
+	assert.Equal(t, "3", vars.Get("calls").String())
+	assert.Equal(t, "1", vars.Get("errors").String())
+}
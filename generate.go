@@ -0,0 +1,3 @@
+package clefclient
+
+//go:generate go run ./cmd/clefgen -spec clef.openrpc.json -out clef_generated.go
@@ -0,0 +1,127 @@
+package clefclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden wire-format fixtures")
+
+const goldenAccount = "0x1234567890123456789012345678901234567890"
+
+// goldenTypedData is a minimal EIP-712 document used by the typed data fixtures
+const goldenTypedData = `{"types":{"EIP712Domain":[{"name":"name","type":"string"},{"name":"chainId","type":"uint256"}],` +
+	`"Mail":[{"name":"contents","type":"string"}]},"primaryType":"Mail",` +
+	`"domain":{"name":"Ether Mail","chainId":1},"message":{"contents":"Hello, Bob!"}}`
+
+// captureRequest returns the exact body the client sends for call
+func captureRequest(t *testing.T, call func(cc *ClefClient)) []byte {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if bytes.HasPrefix(body, []byte("[")) {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+	}))
+	defer server.Close()
+	call(NewHTTPClient(server.URL))
+	return body
+}
+
+// TestGoldenWireFormat asserts the exact JSON sent for each request type.
+// Run with -update after an intended wire format change.
+func TestGoldenWireFormat(t *testing.T) {
+	legacy := &Transaction{
+		From:     goldenAccount,
+		To:       "0x3535353535353535353535353535353535353535",
+		Gas:      "0x5208",
+		GasPrice: "0x4a817c800",
+		Value:    "0xde0b6b3a7640000",
+		Nonce:    "0x9",
+		ChainID:  "0x1",
+	}
+	dynamicFee := &Transaction{
+		From:                 goldenAccount,
+		To:                   "0x3535353535353535353535353535353535353535",
+		Gas:                  "0x5208",
+		MaxFeePerGas:         "0x77359400",
+		MaxPriorityFeePerGas: "0x3b9aca00",
+		Value:                "0x1",
+		Nonce:                "0x0",
+		Data:                 "0xa9059cbb",
+		ChainID:              "0x1",
+	}
+	blob := *dynamicFee
+	blob.MaxFeePerBlobGas = "0x3b9aca00"
+	blob.BlobVersionedHashes = []string{"0x01b0a4cdd5f55589f5c5b4d46c76704bb6ce95c0a8c09f77f197a57808dded28"}
+
+	cases := map[string]func(cc *ClefClient){
+		"account_list":                   func(cc *ClefClient) { cc.ListAccounts() },
+		"account_new":                    func(cc *ClefClient) { cc.NewAccount() },
+		"account_version":                func(cc *ClefClient) { cc.Version() },
+		"account_signTransaction_legacy": func(cc *ClefClient) { cc.SignTransaction(legacy) },
+		"account_signTransaction_1559":   func(cc *ClefClient) { cc.SignTransaction(dynamicFee) },
+		"account_signTransaction_blob":   func(cc *ClefClient) { cc.SignTransaction(&blob) },
+		"account_signTransaction_batch": func(cc *ClefClient) {
+			cc.SignTransactions([]*Transaction{legacy, dynamicFee})
+		},
+		"account_signData_text_plain": func(cc *ClefClient) {
+			cc.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: goldenAccount, Data: "0x68656c6c6f"})
+		},
+		"account_signData_data_typed": func(cc *ClefClient) {
+			cc.SignData(&SignDataRequest{ContentType: ContentTypeDataTyped, Address: goldenAccount, Data: goldenTypedData})
+		},
+		"account_signData_clique_header": func(cc *ClefClient) {
+			cc.SignData(&SignDataRequest{ContentType: ContentTypeCliqueHeader, Address: goldenAccount, Data: "0xf901f9a0"})
+		},
+		"account_signData_data_validator": func(cc *ClefClient) {
+			cc.SignData(&SignDataRequest{ContentType: ContentTypeDataValidator, Address: goldenAccount, Data: "0x68656c6c6f"})
+		},
+		"account_signTypedData_v3": func(cc *ClefClient) {
+			cc.SignTypedData(&TypedDataRequest{Address: goldenAccount, TypedData: json.RawMessage(goldenTypedData), RawVersion: "V3"})
+		},
+		"account_signTypedData_v4": func(cc *ClefClient) {
+			cc.SignTypedData(&TypedDataRequest{Address: goldenAccount, TypedData: json.RawMessage(goldenTypedData), RawVersion: "V4"})
+		},
+		"account_ecRecover": func(cc *ClefClient) {
+			cc.EcRecover(&EcRecoverRequest{Data: "0x68656c6c6f", Signature: "0x11"})
+		},
+		"account_signGnosisSafeTx": func(cc *ClefClient) {
+			cc.SignGnosisSafeTx(goldenAccount, &GnosisSafeTx{
+				Safe:     "0x2222222222222222222222222222222222222222",
+				To:       "0x3333333333333333333333333333333333333333",
+				Value:    "0",
+				GasPrice: "0",
+				Data:     "0x",
+				ChainID:  "0x1",
+			}, "")
+		},
+	}
+
+	for name, call := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := captureRequest(t, call)
+			path := filepath.Join("testdata", "golden", name+".json")
+			if *updateGolden {
+				assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+				assert.NoError(t, os.WriteFile(path, append(got, '\n'), 0644))
+				return
+			}
+			want, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.Equal(t, string(bytes.TrimSuffix(want, []byte("\n"))), string(got))
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package grpcgateway serves the Clef client API over gRPC, so that services
+// written in other languages can sign through one gateway. The service is
+// defined in clef.proto. gRPC requires HTTP/2, which net/http negotiates for
+// TLS listeners.
+package grpcgateway
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// servicePath is the path prefix of the Clef service methods
+const servicePath = "/clef.v1.Clef/"
+
+// maxMessageSize bounds the size of request messages
+const maxMessageSize = 4 << 20
+
+// gRPC status codes returned by the gateway
+const (
+	codeOK               = 0
+	codeUnknown          = 2
+	codeInvalidArgument  = 3
+	codePermissionDenied = 7
+	codeUnimplemented    = 12
+	codeUnauthenticated  = 16
+)
+
+// Client is the Clef API served by the gateway, implemented by ClefClient
+type Client interface {
+	clefclient.Signer
+	NewAccount() (string, error)
+	EcRecover(req *clefclient.EcRecoverRequest) (*clefclient.EcRecoverResponse, error)
+	Version() (*clefclient.VersionResponse, error)
+}
+
+// Config configures a Server
+type Config struct {
+	Client Client
+	// Authenticate, if set, is called for every request and rejects it
+	// with UNAUTHENTICATED on error
+	Authenticate func(r *http.Request) error
+	// Policies are applied to signing requests before they reach Clef and
+	// reject them with PERMISSION_DENIED
+	Policies []clefclient.DryRunPolicy
+}
+
+// Server is an http.Handler serving the Clef gRPC service
+type Server struct {
+	config Config
+}
+
+// NewServer creates a new Server
+func NewServer(config Config) *Server {
+	return &Server{config: config}
+}
+
+// statusError is an error carrying a gRPC status code
+type statusError struct {
+	code int
+	err  error
+}
+
+func (e *statusError) Error() string { return e.err.Error() }
+
+// ServeHTTP handles a unary gRPC call
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "expected a gRPC request", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc+proto")
+
+	response, err := s.handle(r)
+	if err == nil {
+		frame := make([]byte, 5, 5+len(response))
+		binary.BigEndian.PutUint32(frame[1:], uint32(len(response)))
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.WriteHeader(http.StatusOK)
+		w.Write(append(frame, response...))
+		w.Header().Set("Grpc-Status", fmt.Sprint(codeOK))
+		return
+	}
+
+	code := codeUnknown
+	var se *statusError
+	if errors.As(err, &se) {
+		code = se.code
+	}
+	w.Header().Set("Grpc-Status", fmt.Sprint(code))
+	w.Header().Set("Grpc-Message", encodeGrpcMessage(err.Error()))
+	w.WriteHeader(http.StatusOK)
+}
+
+// handle authenticates the call, reads its request message and dispatches it
+func (s *Server) handle(r *http.Request) ([]byte, error) {
+	if s.config.Authenticate != nil {
+		if err := s.config.Authenticate(r); err != nil {
+			return nil, &statusError{codeUnauthenticated, err}
+		}
+	}
+	body, err := readFrame(r.Body)
+	if err != nil {
+		return nil, &statusError{codeInvalidArgument, err}
+	}
+	req, err := decodeMessage(body)
+	if err != nil {
+		return nil, &statusError{codeInvalidArgument, err}
+	}
+	return s.dispatch(strings.TrimPrefix(r.URL.Path, servicePath), req)
+}
+
+// readFrame reads a single uncompressed length-prefixed message
+func readFrame(body io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(body, header[:]); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	if header[0] != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds limit", size)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(body, msg); err != nil {
+		return nil, fmt.Errorf("failed to read message: %w", err)
+	}
+	return msg, nil
+}
+
+func (s *Server) dispatch(method string, req protoMessage) ([]byte, error) {
+	var out protoBuilder
+	switch method {
+	case "ListAccounts":
+		accounts, err := s.config.Client.ListAccounts()
+		if err != nil {
+			return nil, err
+		}
+		out.strs(1, accounts)
+
+	case "NewAccount":
+		address, err := s.config.Client.NewAccount()
+		if err != nil {
+			return nil, err
+		}
+		out.str(1, address)
+
+	case "SignTransaction":
+		txMsg, err := decodeMessage([]byte(req.str(1)))
+		if err != nil {
+			return nil, &statusError{codeInvalidArgument, err}
+		}
+		tx := &clefclient.Transaction{
+			From: txMsg.str(1), To: txMsg.str(2), Gas: txMsg.str(3), GasPrice: txMsg.str(4),
+			MaxFeePerGas: txMsg.str(5), MaxPriorityFeePerGas: txMsg.str(6), Value: txMsg.str(7),
+			Nonce: txMsg.str(8), Data: txMsg.str(9), ChainID: txMsg.str(10),
+		}
+		if err := s.checkPolicies("account_signTransaction", tx); err != nil {
+			return nil, err
+		}
+		signed, err := s.config.Client.SignTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		var signedTx protoBuilder
+		signedTx.strFields(signed.Tx.Nonce, signed.Tx.GasPrice, signed.Tx.Gas, signed.Tx.To, signed.Tx.Value,
+			signed.Tx.Input, signed.Tx.V, signed.Tx.R, signed.Tx.S, signed.Tx.Hash)
+		out.str(1, signed.Raw)
+		out.bytes(2, signedTx.buf)
+
+	case "SignData":
+		sdr := &clefclient.SignDataRequest{ContentType: req.str(1), Address: req.str(2), Data: req.str(3)}
+		if err := s.checkPolicies("account_signData", sdr); err != nil {
+			return nil, err
+		}
+		sig, err := s.config.Client.SignData(sdr)
+		if err != nil {
+			return nil, err
+		}
+		out.str(1, sig.Signature)
+
+	case "SignTypedData":
+		typedData := req.str(2)
+		if !json.Valid([]byte(typedData)) {
+			return nil, &statusError{codeInvalidArgument, errors.New("typed_data is not valid JSON")}
+		}
+		tdr := &clefclient.TypedDataRequest{Address: req.str(1), TypedData: json.RawMessage(typedData), RawVersion: "V4"}
+		if err := s.checkPolicies("account_signTypedData", tdr); err != nil {
+			return nil, err
+		}
+		sig, err := s.config.Client.SignTypedData(tdr)
+		if err != nil {
+			return nil, err
+		}
+		out.str(1, sig.Signature)
+
+	case "EcRecover":
+		recovered, err := s.config.Client.EcRecover(&clefclient.EcRecoverRequest{Data: req.str(1), Signature: req.str(2)})
+		if err != nil {
+			return nil, err
+		}
+		out.str(1, recovered.Address)
+
+	case "Version":
+		version, err := s.config.Client.Version()
+		if err != nil {
+			return nil, err
+		}
+		out.str(1, version.Version)
+
+	default:
+		return nil, &statusError{codeUnimplemented, fmt.Errorf("unknown method %s", method)}
+	}
+	return out.buf, nil
+}
+
+func (s *Server) checkPolicies(method string, params interface{}) error {
+	for _, policy := range s.config.Policies {
+		if err := policy(method, params); err != nil {
+			return &statusError{codePermissionDenied, err}
+		}
+	}
+	return nil
+}
+
+// encodeGrpcMessage percent-encodes a status message as gRPC requires
+func encodeGrpcMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= 0x20 && c <= 0x7e && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package grpcgateway
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubClient answers every method with fixed values
+type stubClient struct {
+	signedTx *clefclient.Transaction
+}
+
+func (c *stubClient) ListAccounts() ([]string, error) {
+	return []string{"0x01", "0x02"}, nil
+}
+
+func (c *stubClient) NewAccount() (string, error) {
+	return "0x03", nil
+}
+
+func (c *stubClient) SignTransaction(tx *clefclient.Transaction) (*clefclient.SignTxResponse, error) {
+	c.signedTx = tx
+	resp := &clefclient.SignTxResponse{Raw: "0xraw"}
+	resp.Tx.Hash = "0xhash"
+	return resp, nil
+}
+
+func (c *stubClient) SignData(req *clefclient.SignDataRequest) (*clefclient.SignDataResponse, error) {
+	return nil, errors.New("Request denied")
+}
+
+func (c *stubClient) SignTypedData(req *clefclient.TypedDataRequest) (*clefclient.SignDataResponse, error) {
+	return &clefclient.SignDataResponse{Signature: "0xtyped"}, nil
+}
+
+func (c *stubClient) EcRecover(req *clefclient.EcRecoverRequest) (*clefclient.EcRecoverResponse, error) {
+	return &clefclient.EcRecoverResponse{Address: "0x04"}, nil
+}
+
+func (c *stubClient) Version() (*clefclient.VersionResponse, error) {
+	return &clefclient.VersionResponse{Version: "6.1.0"}, nil
+}
+
+func setupGateway(t *testing.T, config Config) *httptest.Server {
+	server := httptest.NewUnstartedServer(NewServer(config))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	return server
+}
+
+// invoke makes a unary gRPC call and returns the response message and status
+func invoke(t *testing.T, server *httptest.Server, method string, msg []byte) (protoMessage, string, string) {
+	frame := make([]byte, 5)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	req, _ := http.NewRequest(http.MethodPost, server.URL+servicePath+method, bytes.NewReader(append(frame, msg...)))
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := server.Client().Do(req)
+	if !assert.NoError(t, err) {
+		return nil, "", ""
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, 2, resp.ProtoMajor)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	status, message := resp.Trailer.Get("Grpc-Status"), resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, message = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	if len(body) == 0 {
+		return nil, status, message
+	}
+	out, err := decodeMessage(body[5:])
+	assert.NoError(t, err)
+	return out, status, message
+}
+
+func TestGateway(t *testing.T) {
+	client := &stubClient{}
+	server := setupGateway(t, Config{Client: client})
+	defer server.Close()
+
+	out, status, _ := invoke(t, server, "ListAccounts", nil)
+	assert.Equal(t, "0", status)
+	assert.Equal(t, [][]byte{[]byte("0x01"), []byte("0x02")}, out[1])
+
+	var tx protoBuilder
+	tx.strFields("0x01", "0x02", "0x5208")
+	tx.str(10, "0x1")
+	var req protoBuilder
+	req.bytes(1, tx.buf)
+	out, status, _ = invoke(t, server, "SignTransaction", req.buf)
+	assert.Equal(t, "0", status)
+	assert.Equal(t, "0xraw", out.str(1))
+	signed, err := decodeMessage(out[2][0])
+	assert.NoError(t, err)
+	assert.Equal(t, "0xhash", signed.str(10))
+	assert.Equal(t, &clefclient.Transaction{From: "0x01", To: "0x02", Gas: "0x5208", ChainID: "0x1"}, client.signedTx)
+
+	out, status, _ = invoke(t, server, "Version", nil)
+	assert.Equal(t, "0", status)
+	assert.Equal(t, "6.1.0", out.str(1))
+
+	_, status, message := invoke(t, server, "SignData", nil)
+	assert.Equal(t, "2", status)
+	assert.Equal(t, "Request denied", message)
+
+	_, status, _ = invoke(t, server, "Shutdown", nil)
+	assert.Equal(t, "12", status)
+}
+
+func TestGatewayAuthAndPolicies(t *testing.T) {
+	server := setupGateway(t, Config{
+		Client: &stubClient{},
+		Authenticate: func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				return errors.New("invalid token")
+			}
+			return nil
+		},
+		Policies: []clefclient.DryRunPolicy{func(method string, params interface{}) error {
+			return errors.New("typed data not allowed: 100%")
+		}},
+	})
+	defer server.Close()
+
+	_, status, _ := invoke(t, server, "NewAccount", nil)
+	assert.Equal(t, "0", status)
+
+	var req protoBuilder
+	req.strFields("0x01", `{"types":{}}`)
+	_, status, message := invoke(t, server, "SignTypedData", req.buf)
+	assert.Equal(t, "7", status)
+	assert.Equal(t, "typed data not allowed: 100%25", message)
+}
+
+func TestGatewayRejectsUnauthenticated(t *testing.T) {
+	server := setupGateway(t, Config{Client: &stubClient{}, Authenticate: func(r *http.Request) error {
+		return errors.New("invalid token")
+	}})
+	defer server.Close()
+
+	_, status, message := invoke(t, server, "NewAccount", nil)
+	assert.Equal(t, "16", status)
+	assert.Equal(t, "invalid token", message)
+}
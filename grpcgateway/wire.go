@@ -0,0 +1,104 @@
+package grpcgateway
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated protobuf message")
+
+// protoMessage is a decoded protobuf message: the last value of every
+// length-delimited field, and all values of repeated ones
+type protoMessage map[int][][]byte
+
+// str returns the string field num, empty if absent
+func (m protoMessage) str(num int) string {
+	values := m[num]
+	if len(values) == 0 {
+		return ""
+	}
+	return string(values[len(values)-1])
+}
+
+// decodeMessage decodes the length-delimited fields of a protobuf message.
+// The messages of the Clef service only have string and message fields, so
+// numeric fields are skipped.
+func decodeMessage(b []byte) (protoMessage, error) {
+	m := protoMessage{}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		b = b[n:]
+		num, wire := int(key>>3), int(key&7)
+		switch wire {
+		case wireVarint:
+			if _, n = binary.Uvarint(b); n <= 0 {
+				return nil, errTruncated
+			}
+			b = b[n:]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if wire == wireFixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return nil, errTruncated
+			}
+			b = b[size:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			if n <= 0 || length > uint64(len(b)-n) {
+				return nil, errTruncated
+			}
+			m[num] = append(m[num], b[n:n+int(length)])
+			b = b[n+int(length):]
+		default:
+			return nil, fmt.Errorf("unsupported protobuf wire type %d", wire)
+		}
+	}
+	return m, nil
+}
+
+// protoBuilder encodes a protobuf message
+type protoBuilder struct {
+	buf []byte
+}
+
+// bytes appends a length-delimited field
+func (p *protoBuilder) bytes(num int, b []byte) {
+	p.buf = binary.AppendUvarint(p.buf, uint64(num)<<3|wireBytes)
+	p.buf = binary.AppendUvarint(p.buf, uint64(len(b)))
+	p.buf = append(p.buf, b...)
+}
+
+// str appends a string field, omitted when empty as in proto3
+func (p *protoBuilder) str(num int, s string) {
+	if s != "" {
+		p.bytes(num, []byte(s))
+	}
+}
+
+// strs appends a repeated string field
+func (p *protoBuilder) strs(num int, ss []string) {
+	for _, s := range ss {
+		p.bytes(num, []byte(s))
+	}
+}
+
+// strFields appends string fields numbered from 1 in order
+func (p *protoBuilder) strFields(fields ...string) {
+	for i, s := range fields {
+		p.str(i+1, s)
+	}
+}
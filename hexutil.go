@@ -0,0 +1,123 @@
+package clefclient
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+)
+
+// decodeQuantity parses a 0x-prefixed hex quantity into a big.Int
+func decodeQuantity(s string) (*big.Int, error) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return nil, fmt.Errorf("hex quantity %q is missing 0x prefix", s)
+	}
+	v, ok := new(big.Int).SetString(s[2:], 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex quantity %q", s)
+	}
+	return v, nil
+}
+
+// checkHexQuantity verifies that s is a hex quantity as Clef accepts it:
+// 0x-prefixed, at least one digit and no leading zeros
+func checkHexQuantity(s string) error {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return fmt.Errorf("hex quantity %q is missing 0x prefix", s)
+	}
+	digits := s[2:]
+	if digits == "" {
+		return fmt.Errorf("hex quantity %q has no digits", s)
+	}
+	if len(digits) > 1 && digits[0] == '0' {
+		return fmt.Errorf("hex quantity %q has leading zeros", s)
+	}
+	if i := strings.IndexFunc(digits, func(r rune) bool { return !isHexDigit(r) }); i >= 0 {
+		return fmt.Errorf("hex quantity %q has invalid character %q", s, digits[i])
+	}
+	return nil
+}
+
+// checkHexData verifies that s is hex data as Clef accepts it: 0x-prefixed
+// with an even number of digits
+func checkHexData(s string) error {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return fmt.Errorf("hex data %q is missing 0x prefix", s)
+	}
+	digits := s[2:]
+	if len(digits)%2 != 0 {
+		return fmt.Errorf("hex data %q has odd length", s)
+	}
+	if i := strings.IndexFunc(digits, func(r rune) bool { return !isHexDigit(r) }); i >= 0 {
+		return fmt.Errorf("hex data %q has invalid character %q", s, digits[i])
+	}
+	return nil
+}
+
+func isHexDigit(r rune) bool {
+	return '0' <= r && r <= '9' || 'a' <= r && r <= 'f' || 'A' <= r && r <= 'F'
+}
+
+// encodeQuantity formats a big.Int as a 0x-prefixed hex quantity
+func encodeQuantity(v *big.Int) string {
+	return "0x" + v.Text(16)
+}
+
+// decodeHexData parses 0x-prefixed hex data into bytes
+func decodeHexData(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return nil, fmt.Errorf("hex data %q is missing 0x prefix", s)
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex data %q: %w", s, err)
+	}
+	return b, nil
+}
+
+// encodeHexData formats bytes as 0x-prefixed hex data
+func encodeHexData(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
+
+// checkRequest verifies the params of a call of method before they are
+// sent, so malformed values fail with a precise error instead of an opaque
+// rejection by Clef. Transactions to sign are fully validated, other
+// requests have their hex quantity and data fields checked.
+func checkRequest(method string, params interface{}) error {
+	if v := reflect.ValueOf(params); params != nil && v.Kind() == reflect.Ptr && v.IsNil() {
+		return nilRequestError(method)
+	}
+	switch req := params.(type) {
+	case *Transaction:
+		if method == "account_signTransaction" {
+			return req.Validate()
+		}
+		return checkTransactionHex(req)
+	case *SignDataRequest:
+		if req.ContentType != ContentTypeDataTyped {
+			if err := checkHexData(req.Data); err != nil {
+				return fmt.Errorf("sign data: data: %w", err)
+			}
+		}
+	case *EcRecoverRequest:
+		if err := checkHexData(req.Data); err != nil {
+			return fmt.Errorf("ecrecover: data: %w", err)
+		}
+		if err := checkHexData(req.Signature); err != nil {
+			return fmt.Errorf("ecrecover: signature: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkBatchRequest verifies the params of every entry of a batch
+func checkBatchRequest(method string, params []interface{}) error {
+	for i, p := range params {
+		if err := checkRequest(method, p); err != nil {
+			return fmt.Errorf("batch entry %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package clefclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckHexQuantity(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"0x0", ""},
+		{"0x5208", ""},
+		{"0X3B9ACA00", ""},
+		{"5208", `hex quantity "5208" is missing 0x prefix`},
+		{"0x", `hex quantity "0x" has no digits`},
+		{"0x05208", `hex quantity "0x05208" has leading zeros`},
+		{"0x00", `hex quantity "0x00" has leading zeros`},
+		{"0x52g8", `hex quantity "0x52g8" has invalid character 'g'`},
+	}
+	for _, tt := range tests {
+		err := checkHexQuantity(tt.value)
+		if tt.err == "" {
+			assert.NoError(t, err, tt.value)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestCheckHexData(t *testing.T) {
+	tests := []struct {
+		value string
+		err   string
+	}{
+		{"0x", ""},
+		{"0x00ff", ""},
+		{"0xABCD", ""},
+		{"", `hex data "" is missing 0x prefix`},
+		{"abcd", `hex data "abcd" is missing 0x prefix`},
+		{"0xabc", `hex data "0xabc" has odd length`},
+		{"0xzz", `hex data "0xzz" has invalid character 'z'`},
+	}
+	for _, tt := range tests {
+		err := checkHexData(tt.value)
+		if tt.err == "" {
+			assert.NoError(t, err, tt.value)
+		} else {
+			assert.EqualError(t, err, tt.err)
+		}
+	}
+}
+
+func TestInvalidHexNotSent(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer server.Close()
+	client := NewHTTPClient(server.URL)
+
+	_, err := client.SignTransaction(&Transaction{From: "0x01", Gas: "0x05208"})
+	assert.EqualError(t, err, `account_signTransaction (from=0x01): transaction: gas: hex quantity "0x05208" has leading zeros`)
+	_, err = client.SignData(&SignDataRequest{Address: "0x01", Data: "0x123"})
+	assert.EqualError(t, err, `account_signData (address=0x01): sign data: data: hex data "0x123" has odd length`)
+	_, err = client.EcRecover(&EcRecoverRequest{Data: "0x00", Signature: "abcd"})
+	assert.EqualError(t, err, `account_ecRecover: ecrecover: signature: hex data "abcd" is missing 0x prefix`)
+	from := "0x0000000000000000000000000000000000000001"
+	_, err = client.SignTransactions([]*Transaction{{From: from}, {From: from, Value: "1"}})
+	assert.EqualError(t, err, `batch entry 2: transaction: value: hex quantity "1" is missing 0x prefix`)
+	assert.Equal(t, 0, calls)
+}
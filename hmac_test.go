@@ -0,0 +1,64 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRequestSigning(t *testing.T) {
+	secrets := map[string][]byte{"payouts": []byte("s3cret")}
+	lookup := func(keyID string) ([]byte, bool) {
+		secret, ok := secrets[keyID]
+		return secret, ok
+	}
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var keyID string
+		keyID, verifyErr = VerifyRequestSignature(r.Header, body, lookup, time.Minute)
+		if verifyErr == nil {
+			assert.Equal(t, "payouts", keyID)
+		}
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: json.RawMessage(`[]`)})
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPClient(server.URL, WithRequestSigning("payouts", []byte("s3cret"))).ListAccounts()
+	assert.NoError(t, err)
+	assert.NoError(t, verifyErr)
+
+	NewHTTPClient(server.URL).ListAccounts()
+	assert.ErrorIs(t, verifyErr, ErrInvalidRequestSignature)
+	assert.EqualError(t, verifyErr, "invalid request signature: request is not signed")
+
+	NewHTTPClient(server.URL, WithRequestSigning("payouts", []byte("guess"))).ListAccounts()
+	assert.EqualError(t, verifyErr, `invalid request signature: signature mismatch for key "payouts"`)
+
+	NewHTTPClient(server.URL, WithRequestSigning("billing", []byte("s3cret"))).ListAccounts()
+	assert.EqualError(t, verifyErr, `invalid request signature: unknown key "billing"`)
+
+	// Replayed later
+	stale := NewHTTPClient(server.URL, WithRequestSigning("payouts", []byte("s3cret")))
+	stale.transport.(*httpTransport).signer.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	stale.ListAccounts()
+	assert.ErrorIs(t, verifyErr, ErrInvalidRequestSignature)
+	assert.ErrorContains(t, verifyErr, "timestamp is 1h0m")
+}
+
+func TestVerifyRequestSignatureBody(t *testing.T) {
+	signer := &requestSigner{keyID: "payouts", secret: []byte("s3cret"), now: time.Now}
+	header := http.Header{}
+	signer.sign(header, []byte(`{"method":"account_list"}`))
+	lookup := func(string) ([]byte, bool) { return []byte("s3cret"), true }
+
+	_, err := VerifyRequestSignature(header, []byte(`{"method":"account_list"}`), lookup, time.Minute)
+	assert.NoError(t, err)
+	_, err = VerifyRequestSignature(header, []byte(`{"method":"account_signTransaction"}`), lookup, time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidRequestSignature)
+}
@@ -0,0 +1,178 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+)
+
+// signingMethods are the methods whose calls raise signing events
+var signingMethods = map[string]bool{
+	"account_signTransaction":  true,
+	"account_signData":         true,
+	"account_signTypedData":    true,
+	"account_sign":             true,
+	"account_signGnosisSafeTx": true,
+}
+
+// SignEvent describes a signing request made to Clef
+type SignEvent struct {
+	Method string
+	// Account is the account asked to sign
+	Account string
+	// Tx summarizes the transaction of account_signTransaction requests
+	Tx *TxSummary
+	// Requested is when the request was sent
+	Requested time.Time
+	// Duration is how long Clef took to answer, zero for OnSignRequested
+	Duration time.Duration
+	// Err is the error of denied and failed requests
+	Err error
+}
+
+// Hooks are called with the signing requests made through a client. Hooks
+// run on the goroutine of the call and delay it, so they should not block.
+type Hooks struct {
+	// OnSignRequested is called before a signing request is sent
+	OnSignRequested func(e *SignEvent)
+	// OnSignCompleted is called when Clef signed
+	OnSignCompleted func(e *SignEvent)
+	// OnSignDenied is called when Clef or its user rejected the request
+	OnSignDenied func(e *SignEvent)
+	// OnSignFailed is called when the request failed for another reason,
+	// e.g. because Clef could not be reached
+	OnSignFailed func(e *SignEvent)
+
+	// SlowApproval is how long a signing request may be pending before
+	// OnSlowApproval is called, never if zero
+	SlowApproval time.Duration
+	// OnSlowApproval is called, from a separate goroutine, for every signing
+	// request still pending after SlowApproval, so that forgotten approval
+	// prompts get noticed. Duration is the time pending so far.
+	OnSlowApproval func(e *SignEvent)
+}
+
+// EnableHooks calls hooks for every subsequent signing request made by the
+// client
+func (cc *ClefClient) EnableHooks(hooks Hooks) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &hooksTransport{next: cc.transport, hooks: hooks}
+}
+
+// hooksTransport is a transport decorator calling Hooks
+type hooksTransport struct {
+	next  transport
+	hooks Hooks
+}
+
+func (t *hooksTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if !signingMethods[method] {
+		return t.next.call(ctx, method, params)
+	}
+	e := t.requested(method, params)
+	stop := t.watch([]*SignEvent{e})
+	resp, err := t.next.call(ctx, method, params)
+	stop()
+	if err == nil && resp.Error != nil {
+		t.answered(e, newCallError(method, resp.Error))
+	} else {
+		t.answered(e, err)
+	}
+	return resp, err
+}
+
+func (t *hooksTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	if !signingMethods[method] {
+		return t.next.callBatch(ctx, method, params)
+	}
+	events := make([]*SignEvent, len(params))
+	for i, p := range params {
+		events[i] = t.requested(method, p)
+	}
+	stop := t.watch(events)
+	resps, err := t.next.callBatch(ctx, method, params)
+	stop()
+	for i, e := range events {
+		callErr := err
+		if err == nil && resps[i].Error != nil {
+			callErr = newCallError(method, resps[i].Error)
+		}
+		t.answered(e, callErr)
+	}
+	return resps, err
+}
+
+func (t *hooksTransport) close() error {
+	return t.next.close()
+}
+
+// requested builds the event of a signing request and reports it
+func (t *hooksTransport) requested(method string, params interface{}) *SignEvent {
+	e := newSignEvent(method, params, time.Now())
+	if t.hooks.OnSignRequested != nil {
+		t.hooks.OnSignRequested(e)
+	}
+	return e
+}
+
+// newSignEvent builds the event of a signing request
+func newSignEvent(method string, params interface{}, requested time.Time) *SignEvent {
+	e := &SignEvent{Method: method, Requested: requested}
+	switch p := params.(type) {
+	case *Transaction:
+		e.Account = p.From
+		e.Tx = &TxSummary{From: p.From, To: p.To, Value: p.Value, Nonce: p.Nonce, ChainID: p.ChainID}
+	case *SignDataRequest:
+		e.Account = p.Address
+	case *TypedDataRequest:
+		e.Account = p.Address
+	case []interface{}:
+		if len(p) > 0 {
+			e.Account, _ = p[0].(string)
+		}
+		if safeTx := gnosisSafeTx(p); safeTx != nil {
+			e.Tx = &TxSummary{From: safeTx.Safe, To: safeTx.To, ChainID: safeTx.ChainID}
+			if value, ok := new(big.Int).SetString(safeTx.Value, 10); ok {
+				e.Tx.Value = encodeQuantity(value)
+			}
+		}
+	}
+	return e
+}
+
+// watch calls OnSlowApproval for the events if their requests are still
+// pending after SlowApproval. The returned function stops watching.
+func (t *hooksTransport) watch(events []*SignEvent) func() {
+	if t.hooks.SlowApproval <= 0 || t.hooks.OnSlowApproval == nil {
+		return func() {}
+	}
+	timer := time.AfterFunc(t.hooks.SlowApproval, func() {
+		for _, requested := range events {
+			e := *requested
+			e.Duration = time.Since(e.Requested)
+			t.hooks.OnSlowApproval(&e)
+		}
+	})
+	return func() { timer.Stop() }
+}
+
+// answered reports the outcome of a signing request on a copy of its event
+func (t *hooksTransport) answered(requested *SignEvent, err error) {
+	e := *requested
+	e.Duration = time.Since(e.Requested)
+	e.Err = err
+
+	hook := t.hooks.OnSignCompleted
+	switch {
+	case err != nil && errors.Is(err, ErrSigner):
+		hook = t.hooks.OnSignDenied
+	case err != nil:
+		hook = t.hooks.OnSignFailed
+	}
+	if hook != nil {
+		hook(&e)
+	}
+}
@@ -0,0 +1,102 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooks(t *testing.T) {
+	var requested, completed, denied, failed []*SignEvent
+	hooks := Hooks{
+		OnSignRequested: func(e *SignEvent) { requested = append(requested, e) },
+		OnSignCompleted: func(e *SignEvent) { completed = append(completed, e) },
+		OnSignDenied:    func(e *SignEvent) { denied = append(denied, e) },
+		OnSignFailed:    func(e *SignEvent) { failed = append(failed, e) },
+	}
+
+	next := &batchErrorTransport{failingTransport{err: errors.New("unavailable")}}
+	cc := &ClefClient{transport: next}
+	cc.EnableHooks(hooks)
+
+	_, err := cc.ListAccounts()
+	assert.Error(t, err)
+	assert.Empty(t, requested)
+
+	_, err = cc.SignTransactions([]*Transaction{
+		{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002", Value: "0x1"},
+		{From: "0x0000000000000000000000000000000000000003"},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, requested, 2) && assert.Len(t, completed, 1) && assert.Len(t, denied, 1) {
+		assert.Equal(t, "account_signTransaction", completed[0].Method)
+		assert.Equal(t, "0x0000000000000000000000000000000000000001", completed[0].Account)
+		assert.Equal(t, &TxSummary{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002", Value: "0x1"}, completed[0].Tx)
+		assert.NoError(t, completed[0].Err)
+		assert.Equal(t, "0x0000000000000000000000000000000000000003", denied[0].Account)
+		assert.ErrorIs(t, denied[0].Err, ErrRequestDenied)
+		assert.Equal(t, requested[1].Requested, denied[0].Requested)
+	}
+
+	next.err = connectionError(errors.New("connection refused"))
+	_, err = cc.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000001", Data: "0x01"})
+	assert.Error(t, err)
+	if assert.Len(t, failed, 1) {
+		assert.Equal(t, "account_signData", failed[0].Method)
+		assert.Equal(t, "0x0000000000000000000000000000000000000001", failed[0].Account)
+		assert.ErrorIs(t, failed[0].Err, ErrConnection)
+	}
+}
+
+func TestHooksOptional(t *testing.T) {
+	cc := &ClefClient{transport: &batchErrorTransport{}}
+	cc.EnableHooks(Hooks{})
+
+	results, err := cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001"}})
+	assert.NoError(t, err)
+	assert.NoError(t, results[0].Err)
+}
+
+// slowTransport answers after a delay
+type slowTransport struct {
+	countingTransport
+	delay time.Duration
+}
+
+func (t *slowTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	time.Sleep(t.delay)
+	return &rpcResponse{Result: json.RawMessage(`{"signature":"` + testSignature(1) + `"}`)}, nil
+}
+
+func TestHooksSlowApproval(t *testing.T) {
+	slow := make(chan *SignEvent, 2)
+	hooks := Hooks{
+		SlowApproval:   10 * time.Millisecond,
+		OnSlowApproval: func(e *SignEvent) { slow <- e },
+	}
+	req := &SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000001", Data: "0x01"}
+
+	cc := &ClefClient{transport: &slowTransport{delay: 50 * time.Millisecond}}
+	cc.EnableHooks(hooks)
+	_, err := cc.SignData(req)
+	assert.NoError(t, err)
+	select {
+	case e := <-slow:
+		assert.Equal(t, "account_signData", e.Method)
+		assert.Equal(t, "0x0000000000000000000000000000000000000001", e.Account)
+		assert.GreaterOrEqual(t, e.Duration, 10*time.Millisecond)
+	default:
+		t.Fatal("no slow approval reported")
+	}
+
+	cc = &ClefClient{transport: &slowTransport{}}
+	cc.EnableHooks(hooks)
+	_, err = cc.SignData(req)
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, slow)
+}
@@ -0,0 +1,52 @@
+package clefclient
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrIdentityMismatch is returned when the endpoint fails to prove that it
+// holds the key of the attestation account
+var ErrIdentityMismatch = errors.New("signer identity could not be verified")
+
+// identityPrefix starts the messages signed to prove the identity of Clef,
+// so that they cannot be mistaken for anything else
+const identityPrefix = "clef-client identity check"
+
+// WithSignerIdentity makes NewClient verify that the endpoint is the
+// expected signer and not an impostor: Clef is asked to sign a random nonce
+// as a personal message with the attestation account, and the recovered
+// signer must be that account, or NewClient fails with ErrIdentityMismatch.
+// Clef's rules should approve these requests automatically for the
+// attestation account, which should hold no funds. It has no effect on the
+// other constructors and on clients derived with With; use VerifyIdentity
+// with those.
+func WithSignerIdentity(account string) Option {
+	return func(o *clientOptions) {
+		o.identity = account
+	}
+}
+
+// VerifyIdentity proves that Clef holds the key of the attestation account
+// by having it sign a random nonce, which is verified locally
+func (cc *ClefClient) VerifyIdentity(account string) error {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	message := []byte(fmt.Sprintf("%s\nnonce: %s", identityPrefix, encodeHexData(nonce)))
+	signed, err := cc.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: account, Data: encodeHexData(message)})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrIdentityMismatch, err)
+	}
+	recovered, err := RecoverAddress(TextHash(message), signed.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrIdentityMismatch, err)
+	}
+	if !strings.EqualFold(recovered, account) {
+		return fmt.Errorf("%w: nonce signed by %s instead of %s", ErrIdentityMismatch, recovered, account)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// signerServer serves account_signData with the keys of signer
+func signerServer(t *testing.T, signer *MemorySigner) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string           `json:"method"`
+			Params *SignDataRequest `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "account_signData", req.Method)
+		data, _ := decodeHexData(req.Params.Data)
+		assert.True(t, strings.HasPrefix(string(data), identityPrefix+"\nnonce: 0x"))
+
+		resp := rpcResponse{Jsonrpc: "2.0", ID: 1}
+		signed, err := signer.SignData(req.Params)
+		if err != nil {
+			resp.Error = &rpcError{Code: -32000, Message: err.Error()}
+		} else {
+			resp.Result, _ = json.Marshal(signed)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestWithSignerIdentity(t *testing.T) {
+	signer := NewMemorySigner(1)
+	attestation, _ := signer.NewAccount()
+	server := signerServer(t, signer)
+	defer server.Close()
+
+	cc, err := NewClient(context.Background(), server.URL, WithSignerIdentity(attestation))
+	assert.NoError(t, err)
+	assert.NoError(t, cc.VerifyIdentity(attestation))
+
+	// An impostor does not hold the key
+	impostor := NewMemorySigner(1)
+	impostor.NewAccount()
+	server = signerServer(t, impostor)
+	defer server.Close()
+	_, err = NewClient(context.Background(), server.URL, WithSignerIdentity(attestation))
+	assert.ErrorIs(t, err, ErrIdentityMismatch)
+
+	// Nor does one answering with a signature of another key
+	cc = &ClefClient{transport: &signatureTransport{signature: "0x" + strings.Repeat("11", 64) + "1b"}}
+	assert.ErrorIs(t, cc.VerifyIdentity(attestation), ErrIdentityMismatch)
+}
@@ -0,0 +1,60 @@
+// Package gateway holds the setup shared by the clefgrpc and clefrest
+// commands: connecting to Clef, the audit log and bearer token checks.
+package gateway
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// Dial connects to Clef over HTTP if the endpoint is a URL and over IPC
+// otherwise
+func Dial(endpoint string) (*clefclient.ClefClient, error) {
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return clefclient.NewHTTPClient(endpoint), nil
+	}
+	return clefclient.NewIPCClient(endpoint)
+}
+
+// EnableAudit appends every request of the client to the audit log in file,
+// redacted with DefaultRedaction. The returned store must be closed after
+// the client.
+func EnableAudit(client *clefclient.ClefClient, file string) (io.Closer, error) {
+	store, err := clefclient.NewFileAuditStore(file)
+	if err != nil {
+		return nil, err
+	}
+	client.EnableAudit(clefclient.NewAuditLog(clefclient.AuditConfig{
+		Store:      store,
+		Redactions: []clefclient.RedactionRule{clefclient.DefaultRedaction},
+	}))
+	return store, nil
+}
+
+// BearerAuth reads the token in file and returns an Authenticate hook
+// rejecting requests without it. An empty token file is an error, since it
+// would accept every request with an empty bearer token.
+func BearerAuth(file string) (func(r *http.Request) error, error) {
+	token, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := strings.TrimSpace(string(token))
+	if trimmed == "" {
+		return nil, fmt.Errorf("token file %s is empty", file)
+	}
+	expected := []byte("Bearer " + trimmed)
+	return func(r *http.Request) error {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			return errors.New("invalid bearer token")
+		}
+		return nil
+	}, nil
+}
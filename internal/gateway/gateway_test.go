@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBearerAuth(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token")
+	assert.NoError(t, os.WriteFile(file, []byte("s3cret\n"), 0o600))
+
+	auth, err := BearerAuth(file)
+	assert.NoError(t, err)
+	for header, ok := range map[string]bool{
+		"Bearer s3cret":  true,
+		"Bearer s3cre":   false,
+		"Bearer s3cret ": false,
+		"Bearer ":        false,
+		"":               false,
+	} {
+		r, _ := http.NewRequest(http.MethodPost, "/", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		if ok {
+			assert.NoError(t, auth(r), header)
+		} else {
+			assert.EqualError(t, auth(r), "invalid bearer token", header)
+		}
+	}
+
+	empty := filepath.Join(dir, "empty")
+	assert.NoError(t, os.WriteFile(empty, []byte(" \n"), 0o600))
+	_, err = BearerAuth(empty)
+	assert.ErrorContains(t, err, "is empty")
+
+	_, err = BearerAuth(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestEnableAudit(t *testing.T) {
+	client, err := Dial("http://localhost:1")
+	assert.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	store, err := EnableAudit(client, file)
+	assert.NoError(t, err)
+
+	_, err = client.ListAccounts()
+	assert.Error(t, err)
+	assert.NoError(t, store.Close())
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"method":"account_list"`)
+}
@@ -0,0 +1,37 @@
+// Package secret holds key material and passwords in memory that is locked
+// into RAM where supported, so that it is not swapped to disk, and wiped
+// after use.
+package secret
+
+// Wipe overwrites b with zeros
+func Wipe(b []byte) {
+	clear(b)
+}
+
+// Buffer is memory for a secret, locked where the platform and the memlock
+// limit allow it
+type Buffer struct {
+	b    []byte
+	free func([]byte)
+}
+
+// New allocates a zeroed Buffer of size bytes
+func New(size int) *Buffer {
+	b, free := alloc(size)
+	return &Buffer{b: b, free: free}
+}
+
+// Bytes returns the memory of the buffer, which must not be used after
+// Destroy
+func (b *Buffer) Bytes() []byte {
+	return b.b
+}
+
+// Destroy wipes and releases the buffer
+func (b *Buffer) Destroy() {
+	Wipe(b.b)
+	if b.free != nil {
+		b.free(b.b)
+	}
+	b.b, b.free = nil, nil
+}
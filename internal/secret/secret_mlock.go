@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package secret
+
+import "syscall"
+
+// alloc maps anonymous memory and locks it, falling back to the heap if
+// mapping fails. Locking fails beyond RLIMIT_MEMLOCK, in which case the
+// memory is still wiped on release.
+func alloc(size int) ([]byte, func([]byte)) {
+	if size <= 0 {
+		return nil, nil
+	}
+	b, err := syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+	if err != nil {
+		return make([]byte, size), nil
+	}
+	locked := syscall.Mlock(b) == nil
+	return b, func(b []byte) {
+		if locked {
+			syscall.Munlock(b)
+		}
+		syscall.Munmap(b)
+	}
+}
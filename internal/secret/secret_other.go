@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package secret
+
+// alloc allocates heap memory, which is only wiped on release
+func alloc(size int) ([]byte, func([]byte)) {
+	if size <= 0 {
+		return nil, nil
+	}
+	return make([]byte, size), nil
+}
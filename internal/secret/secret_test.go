@@ -0,0 +1,23 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer(t *testing.T) {
+	b := New(16)
+	assert.Equal(t, make([]byte, 16), b.Bytes())
+	copy(b.Bytes(), "correct horse")
+	b.Destroy()
+	assert.Nil(t, b.Bytes())
+
+	assert.Nil(t, New(0).Bytes())
+}
+
+func TestWipe(t *testing.T) {
+	b := []byte("password")
+	Wipe(b)
+	assert.Equal(t, make([]byte, 8), b)
+}
@@ -0,0 +1,147 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrHighS is returned for signatures whose s value is in the upper half of
+// the curve order, which some contracts and chains reject as malleable
+var ErrHighS = errors.New("signature is not in canonical low-s form")
+
+// secp256k1HalfN is the largest s value of a low-s signature
+var secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+
+// LowSMode is how EnableLowS treats high-s signatures
+type LowSMode int
+
+const (
+	// LowSReject fails calls returning a high-s signature with ErrHighS
+	LowSReject LowSMode = iota
+	// LowSNormalize replaces high-s data signatures by their low-s
+	// equivalent. Signed transactions cannot be normalized without
+	// re-encoding them and are rejected.
+	LowSNormalize
+)
+
+// EnableLowS checks that the signatures returned by subsequent signing
+// requests use the canonical low-s form, as required by EIP-2 for
+// transactions and by contracts such as OpenZeppelin's ECDSA library.
+// Clef itself signs with low s; the check guards against signers and
+// proxies that do not.
+func (cc *ClefClient) EnableLowS(mode LowSMode) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &lowSTransport{next: cc.transport, mode: mode}
+}
+
+// IsLowS reports whether a 65 byte hex signature has a low s value
+func IsLowS(signature string) (bool, error) {
+	sig, err := decodeHexData(signature)
+	if err != nil || len(sig) != 65 {
+		return false, fmt.Errorf("invalid signature %q", signature)
+	}
+	return new(big.Int).SetBytes(sig[32:64]).Cmp(secp256k1HalfN) <= 0, nil
+}
+
+// NormalizeSignature returns the low-s equivalent of a 65 byte hex
+// signature, which recovers the same signer: s is replaced by N - s and the
+// recovery id flipped. Low-s signatures are returned unchanged.
+func NormalizeSignature(signature string) (string, error) {
+	low, err := IsLowS(signature)
+	if err != nil || low {
+		return signature, err
+	}
+	sig, _ := decodeHexData(signature)
+	s := new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(sig[32:64]))
+	s.FillBytes(sig[32:64])
+	if v := sig[64]; v >= 27 {
+		sig[64] = 27 + ((v - 27) ^ 1)
+	} else {
+		sig[64] = v ^ 1
+	}
+	return encodeHexData(sig), nil
+}
+
+// lowSTransport is a transport decorator checking the s value of returned
+// signatures
+type lowSTransport struct {
+	next transport
+	mode LowSMode
+}
+
+func (t *lowSTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	resp, err := t.next.call(ctx, method, params)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	if err := t.check(method, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// callBatch fails the whole batch if one of its signatures is high-s, like
+// the policies of EnablePolicies
+func (t *lowSTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	resps, err := t.next.callBatch(ctx, method, params)
+	if err != nil {
+		return resps, err
+	}
+	for i, resp := range resps {
+		if resp == nil || resp.Error != nil {
+			continue
+		}
+		if err := t.check(method, resp); err != nil {
+			return nil, fmt.Errorf("batch entry %d: %w", i+1, err)
+		}
+	}
+	return resps, nil
+}
+
+func (t *lowSTransport) close() error {
+	return t.next.close()
+}
+
+// check rejects, or normalizes in place, high-s signatures in the result
+// of a signing method
+func (t *lowSTransport) check(method string, resp *rpcResponse) error {
+	if len(resp.Result) == 0 || string(resp.Result) == "null" {
+		return nil
+	}
+	switch method {
+	case "account_signTransaction":
+		var result SignTxResponse
+		if json.Unmarshal(resp.Result, &result) != nil || result.Tx.S == "" {
+			return nil
+		}
+		s, err := decodeQuantity(result.Tx.S)
+		if err == nil && s.Cmp(secp256k1HalfN) > 0 {
+			return fmt.Errorf("%s: %w", method, ErrHighS)
+		}
+	case "account_signData", "account_signTypedData":
+		var result SignDataResponse
+		if json.Unmarshal(resp.Result, &result) != nil {
+			return nil
+		}
+		low, err := IsLowS(result.Signature)
+		if err != nil || low {
+			// Malformed signatures are reported by the method
+			return nil
+		}
+		if t.mode != LowSNormalize {
+			return fmt.Errorf("%s: %w", method, ErrHighS)
+		}
+		result.Signature, _ = NormalizeSignature(result.Signature)
+		normalized, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = normalized
+	}
+	return nil
+}
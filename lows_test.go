@@ -0,0 +1,103 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// malleate returns the high-s twin of a low-s signature
+func malleate(signature string) string {
+	sig, _ := decodeHexData(signature)
+	new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(sig[32:64])).FillBytes(sig[32:64])
+	sig[64] = 27 + ((sig[64] - 27) ^ 1)
+	return encodeHexData(sig)
+}
+
+// signatureTransport answers signing methods with fixed results
+type signatureTransport struct {
+	signature string
+	s         string
+}
+
+func (t *signatureTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	var result interface{} = SignDataResponse{Signature: t.signature}
+	if method == "account_signTransaction" {
+		var resp SignTxResponse
+		resp.Raw = "0x01"
+		resp.Tx.S = t.s
+		result = resp
+	}
+	raw, _ := json.Marshal(result)
+	return &rpcResponse{Result: raw}, nil
+}
+
+func (t *signatureTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (t *signatureTransport) close() error {
+	return nil
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	signer := NewMemorySigner(1)
+	account, _ := signer.NewAccount()
+	signed, err := signer.SignData(&SignDataRequest{Address: account, Data: "0x68656c6c6f"})
+	assert.NoError(t, err)
+	high := malleate(signed.Signature)
+
+	low, err := IsLowS(signed.Signature)
+	assert.NoError(t, err)
+	assert.True(t, low)
+	low, err = IsLowS(high)
+	assert.NoError(t, err)
+	assert.False(t, low)
+
+	// Both forms recover the signer
+	recovered, err := RecoverAddress(TextHash([]byte("hello")), high)
+	assert.NoError(t, err)
+	assert.Equal(t, account, recovered)
+
+	normalized, err := NormalizeSignature(high)
+	assert.NoError(t, err)
+	assert.Equal(t, signed.Signature, normalized)
+	normalized, err = NormalizeSignature(signed.Signature)
+	assert.NoError(t, err)
+	assert.Equal(t, signed.Signature, normalized)
+
+	_, err = NormalizeSignature("0x1234")
+	assert.Error(t, err)
+}
+
+func TestEnableLowS(t *testing.T) {
+	signer := NewMemorySigner(1)
+	account, _ := signer.NewAccount()
+	signed, _ := signer.SignData(&SignDataRequest{Address: account, Data: "0x68656c6c6f"})
+	req := &SignDataRequest{Address: account, Data: "0x68656c6c6f"}
+
+	cc := &ClefClient{transport: &signatureTransport{signature: malleate(signed.Signature)}}
+	cc.EnableLowS(LowSReject)
+	_, err := cc.SignData(req)
+	assert.ErrorIs(t, err, ErrHighS)
+
+	cc = &ClefClient{transport: &signatureTransport{signature: malleate(signed.Signature), s: encodeQuantity(new(big.Int).Sub(secp256k1N, big.NewInt(1)))}}
+	cc.EnableLowS(LowSNormalize)
+	resp, err := cc.SignData(req)
+	assert.NoError(t, err)
+	assert.Equal(t, signed.Signature, resp.Signature)
+	_, err = cc.SignTransaction(&Transaction{From: account})
+	assert.ErrorIs(t, err, ErrHighS)
+
+	cc = &ClefClient{transport: &signatureTransport{signature: signed.Signature, s: "0x1"}}
+	cc.EnableLowS(LowSReject)
+	resp, err = cc.SignData(req)
+	assert.NoError(t, err)
+	assert.Equal(t, signed.Signature, resp.Signature)
+	_, err = cc.SignTransaction(&Transaction{From: account})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,236 @@
+package clefclient
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/AxLabs/clef-client/internal/secret"
+)
+
+// MemorySigner is a Signer holding secp256k1 keys in memory, for end-to-end
+// tests that need valid signatures without running Clef. It signs like Clef
+// does: EIP-155 legacy and EIP-1559 transactions, EIP-191 personal messages
+// and EIP-712 typed data, with deterministic RFC 6979 signatures. Its keys
+// are not protected in any way, so it must only be used in tests.
+type MemorySigner struct {
+	chainID *big.Int
+
+	mu       sync.Mutex
+	keys     map[string]*big.Int
+	accounts []string
+}
+
+var _ Signer = (*MemorySigner)(nil)
+
+// NewMemorySigner creates a MemorySigner without accounts that signs
+// transactions for chainID, like Clef started with --chainid
+func NewMemorySigner(chainID uint64) *MemorySigner {
+	return &MemorySigner{
+		chainID: new(big.Int).SetUint64(chainID),
+		keys:    make(map[string]*big.Int),
+	}
+}
+
+// NewAccount adds an account with a random key and returns its address
+func (m *MemorySigner) NewAccount() (string, error) {
+	b := make([]byte, 32)
+	defer secret.Wipe(b)
+	for {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		if d := new(big.Int).SetBytes(b); d.Sign() > 0 && d.Cmp(secp256k1N) < 0 {
+			return m.addKey(d), nil
+		}
+	}
+}
+
+// ImportKey adds an account with the given 0x-prefixed 32 byte private key
+// and returns its address, so tests can use well-known accounts
+func (m *MemorySigner) ImportKey(key string) (string, error) {
+	b, err := decodeHexData(key)
+	if err != nil {
+		return "", err
+	}
+	defer secret.Wipe(b)
+	d := new(big.Int).SetBytes(b)
+	if len(b) != 32 || d.Sign() == 0 || d.Cmp(secp256k1N) >= 0 {
+		wipeInt(d)
+		return "", fmt.Errorf("invalid private key")
+	}
+	return m.addKey(d), nil
+}
+
+// Wipe zeroes the keys of the signer and forgets its accounts
+func (m *MemorySigner) Wipe() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range m.keys {
+		wipeInt(d)
+	}
+	m.keys = make(map[string]*big.Int)
+	m.accounts = nil
+}
+
+func (m *MemorySigner) addKey(d *big.Int) string {
+	address := checksumAddress(pubkeyAddress(ecBaseMul(d)))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[strings.ToLower(address)]; !ok {
+		m.keys[strings.ToLower(address)] = d
+		m.accounts = append(m.accounts, address)
+	} else {
+		wipeInt(d)
+	}
+	return address
+}
+
+func (m *MemorySigner) key(address string) (*big.Int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.keys[strings.ToLower(address)]
+	if !ok {
+		return nil, fmt.Errorf("unknown account %s", address)
+	}
+	return d, nil
+}
+
+// ListAccounts returns the addresses of the accounts in the order they were added
+func (m *MemorySigner) ListAccounts() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.accounts...), nil
+}
+
+// SignTransaction signs tx with the key of tx.From. Transactions with
+// maxFeePerGas are signed as EIP-1559 transactions, others as EIP-155 legacy
+// transactions.
+func (m *MemorySigner) SignTransaction(tx *Transaction) (*SignTxResponse, error) {
+	if err := checkTransaction(tx); err != nil {
+		return nil, err
+	}
+	d, err := m.key(tx.From)
+	if err != nil {
+		return nil, err
+	}
+	if tx.ChainID != "" {
+		chainID, _ := decodeQuantity(tx.ChainID)
+		if chainID.Cmp(m.chainID) != 0 {
+			return nil, fmt.Errorf("requested chainid %d does not match the configuration of the signer", chainID)
+		}
+	}
+	if tx.MaxFeePerBlobGas != "" || len(tx.BlobVersionedHashes) > 0 {
+		return nil, fmt.Errorf("blob transactions are not supported by the memory signer")
+	}
+	if tx.Gas == "" || tx.Nonce == "" {
+		return nil, fmt.Errorf("transaction: gas and nonce are required")
+	}
+
+	quantity := func(s string) *big.Int {
+		if s == "" {
+			return new(big.Int)
+		}
+		v, _ := decodeQuantity(s)
+		return v
+	}
+	var to, data []byte
+	if tx.To != "" {
+		to, _ = decodeHexData(tx.To)
+	}
+	if tx.Data != "" {
+		data, _ = decodeHexData(tx.Data)
+	}
+	nonce, gas, value := quantity(tx.Nonce), quantity(tx.Gas), quantity(tx.Value)
+
+	var raw []byte
+	var v *big.Int
+	var sig []byte
+	if tx.MaxFeePerGas != "" {
+		fields := [][]byte{
+			rlpInt(m.chainID), rlpInt(nonce), rlpInt(quantity(tx.MaxPriorityFeePerGas)),
+			rlpInt(quantity(tx.MaxFeePerGas)), rlpInt(gas), rlpBytes(to), rlpInt(value),
+			rlpBytes(data), rlpList(),
+		}
+		sig = ecSign(keccak256([]byte{0x02}, rlpList(fields...)), d)
+		v = big.NewInt(int64(sig[64]))
+		fields = append(fields, rlpInt(v), rlpInt(new(big.Int).SetBytes(sig[:32])), rlpInt(new(big.Int).SetBytes(sig[32:64])))
+		raw = append([]byte{0x02}, rlpList(fields...)...)
+	} else {
+		fields := [][]byte{
+			rlpInt(nonce), rlpInt(quantity(tx.GasPrice)), rlpInt(gas), rlpBytes(to),
+			rlpInt(value), rlpBytes(data),
+		}
+		sig = ecSign(keccak256(rlpList(append(fields, rlpInt(m.chainID), rlpInt(new(big.Int)), rlpInt(new(big.Int)))...)), d)
+		v = new(big.Int).Lsh(m.chainID, 1)
+		v.Add(v, big.NewInt(35+int64(sig[64])))
+		raw = rlpList(append(fields, rlpInt(v), rlpInt(new(big.Int).SetBytes(sig[:32])), rlpInt(new(big.Int).SetBytes(sig[32:64])))...)
+	}
+
+	resp := &SignTxResponse{Raw: encodeHexData(raw)}
+	resp.Tx.Nonce = encodeQuantity(nonce)
+	resp.Tx.GasPrice = encodeQuantity(quantity(tx.GasPrice))
+	resp.Tx.Gas = encodeQuantity(gas)
+	resp.Tx.To = tx.To
+	resp.Tx.Value = encodeQuantity(value)
+	resp.Tx.Input = encodeHexData(data)
+	resp.Tx.V = encodeQuantity(v)
+	resp.Tx.R = encodeQuantity(new(big.Int).SetBytes(sig[:32]))
+	resp.Tx.S = encodeQuantity(new(big.Int).SetBytes(sig[32:64]))
+	resp.Tx.Hash = encodeHexData(keccak256(raw))
+	return resp, nil
+}
+
+// SignData signs text/plain data as an EIP-191 personal message and
+// data/typed data as EIP-712 typed data
+func (m *MemorySigner) SignData(req *SignDataRequest) (*SignDataResponse, error) {
+	var hash []byte
+	switch req.ContentType {
+	case "", ContentTypeTextPlain:
+		data, err := decodeHexData(req.Data)
+		if err != nil {
+			data = []byte(req.Data)
+		}
+		hash = TextHash(data)
+	case ContentTypeDataTyped:
+		var td TypedData
+		if err := json.Unmarshal([]byte(req.Data), &td); err != nil {
+			return nil, fmt.Errorf("invalid typed data: %w", err)
+		}
+		var err error
+		if hash, err = td.Hash(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("content type %s is not supported by the memory signer", req.ContentType)
+	}
+	return m.signHash(req.Address, hash)
+}
+
+// SignTypedData signs EIP-712 typed data
+func (m *MemorySigner) SignTypedData(req *TypedDataRequest) (*SignDataResponse, error) {
+	var td TypedData
+	if err := json.Unmarshal(req.TypedData, &td); err != nil {
+		return nil, fmt.Errorf("invalid typed data: %w", err)
+	}
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return m.signHash(req.Address, hash)
+}
+
+// signHash signs hash with the key of address, with V being 27 or 28 like
+// the data signatures of Clef
+func (m *MemorySigner) signHash(address string, hash []byte) (*SignDataResponse, error) {
+	d, err := m.key(address)
+	if err != nil {
+		return nil, err
+	}
+	sig := ecSign(hash, d)
+	sig[64] += 27
+	return &SignDataResponse{Signature: encodeHexData(sig)}, nil
+}
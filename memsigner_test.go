@@ -0,0 +1,117 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySignerLegacyTransaction(t *testing.T) {
+	// EIP-155 example transaction
+	signer := NewMemorySigner(1)
+	from, err := signer.ImportKey("0x" + strings.Repeat("46", 32))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F", from)
+
+	resp, err := signer.SignTransaction(&Transaction{
+		From:     from,
+		To:       "0x3535353535353535353535353535353535353535",
+		Nonce:    "0x9",
+		GasPrice: "0x4a817c800",
+		Gas:      "0x5208",
+		Value:    "0xde0b6b3a7640000",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025"+
+		"a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276"+
+		"a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83", resp.Raw)
+	assert.Equal(t, "0x25", resp.Tx.V)
+}
+
+func TestMemorySignerDynamicFeeTransaction(t *testing.T) {
+	signer := NewMemorySigner(5)
+	from, err := signer.NewAccount()
+	assert.NoError(t, err)
+
+	tx := &Transaction{
+		From:                 from,
+		To:                   "0x3535353535353535353535353535353535353535",
+		Nonce:                "0x0",
+		MaxFeePerGas:         "0x77359400",
+		MaxPriorityFeePerGas: "0x3b9aca00",
+		Gas:                  "0x5208",
+		Value:                "0x1",
+		ChainID:              "0x5",
+	}
+	resp, err := signer.SignTransaction(tx)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.Raw, "0x02"))
+	assert.Contains(t, []string{"0x0", "0x1"}, resp.Tx.V)
+
+	tx.ChainID = "0x1"
+	_, err = signer.SignTransaction(tx)
+	assert.ErrorContains(t, err, "chainid")
+
+	tx.From = "0x1234567890123456789012345678901234567890"
+	tx.ChainID = ""
+	_, err = signer.SignTransaction(tx)
+	assert.ErrorContains(t, err, "unknown account")
+}
+
+func TestMemorySignerData(t *testing.T) {
+	signer := NewMemorySigner(1)
+	from, err := signer.ImportKey("0x" + strings.Repeat("00", 31) + "01")
+	assert.NoError(t, err)
+	accounts, err := signer.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{from}, accounts)
+
+	resp, err := signer.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: from, Data: "0x68656c6c6f"})
+	assert.NoError(t, err)
+	recovered, err := RecoverAddress(TextHash([]byte("hello")), resp.Signature)
+	assert.NoError(t, err)
+	assert.Equal(t, from, recovered)
+
+	td := &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": {{Name: "name", Type: "string"}},
+			"Mail":         {{Name: "contents", Type: "string"}},
+		},
+		PrimaryType: "Mail",
+		Domain:      map[string]interface{}{"name": "Test"},
+		Message:     map[string]interface{}{"contents": "Hello"},
+	}
+	req, err := td.Request(from)
+	assert.NoError(t, err)
+	resp, err = signer.SignTypedData(req)
+	assert.NoError(t, err)
+	hash, err := td.Hash()
+	assert.NoError(t, err)
+	recovered, err = RecoverAddress(hash, resp.Signature)
+	assert.NoError(t, err)
+	assert.Equal(t, from, recovered)
+
+	data, _ := json.Marshal(td)
+	viaSignData, err := signer.SignData(&SignDataRequest{ContentType: ContentTypeDataTyped, Address: from, Data: string(data)})
+	assert.NoError(t, err)
+	assert.Equal(t, resp.Signature, viaSignData.Signature)
+
+	_, err = signer.SignData(&SignDataRequest{ContentType: ContentTypeCliqueHeader, Address: from, Data: "0x"})
+	assert.Error(t, err)
+}
+
+func TestMemorySignerWipe(t *testing.T) {
+	signer := NewMemorySigner(1)
+	from, err := signer.ImportKey("0x4646464646464646464646464646464646464646464646464646464646464646")
+	assert.NoError(t, err)
+	d, _ := signer.key(from)
+
+	signer.Wipe()
+	assert.Equal(t, 0, d.Sign())
+	accounts, _ := signer.ListAccounts()
+	assert.Empty(t, accounts)
+	_, err = signer.SignData(&SignDataRequest{Address: from, Data: "0x68656c6c6f"})
+	assert.ErrorContains(t, err, "unknown account")
+}
@@ -0,0 +1,218 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcomes of calls counted by Metrics
+const (
+	MetricsOutcomeSuccess    = "success"
+	MetricsOutcomeRejected   = "rejected"
+	MetricsOutcomeConnection = "connection_error"
+	MetricsOutcomeMalformed  = "malformed_response"
+	MetricsOutcomeError      = "error"
+)
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency
+// histogram. They reach into minutes since signing waits for the user.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300}
+
+// MetricsConfig configures Metrics
+type MetricsConfig struct {
+	// Namespace prefixes the metric names, clef_client if empty
+	Namespace string
+	// Buckets are the latency histogram buckets, DefaultLatencyBuckets if
+	// empty
+	Buckets []float64
+}
+
+// Metrics collects request counts by method and outcome, latency histograms
+// by method and the number of requests in flight. It is an http.Handler
+// serving the metrics in the Prometheus text exposition format.
+type Metrics struct {
+	namespace string
+	buckets   []float64
+
+	mu        sync.Mutex
+	requests  map[metricsKey]uint64
+	latencies map[string]*histogram
+	inFlight  int64
+}
+
+type metricsKey struct {
+	method  string
+	outcome string
+}
+
+// histogram is a cumulative latency histogram
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewMetrics creates new Metrics
+func NewMetrics(config MetricsConfig) *Metrics {
+	if config.Namespace == "" {
+		config.Namespace = "clef_client"
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = DefaultLatencyBuckets
+	}
+	buckets := append([]float64(nil), config.Buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		namespace: config.Namespace,
+		buckets:   buckets,
+		requests:  map[metricsKey]uint64{},
+		latencies: map[string]*histogram{},
+	}
+}
+
+// EnableMetrics records every subsequent request made by the client in m.
+// Entries of a batch are counted as requests of their own and observed with
+// the latency of the whole batch.
+func (cc *ClefClient) EnableMetrics(m *Metrics) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &metricsTransport{next: cc.transport, metrics: m}
+}
+
+// metricsTransport is a transport decorator recording calls in Metrics
+type metricsTransport struct {
+	next    transport
+	metrics *Metrics
+}
+
+func (t *metricsTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	t.metrics.start(1)
+	start := time.Now()
+	resp, err := t.next.call(ctx, method, params)
+	t.metrics.observe(method, time.Since(start), metricsOutcome(resp, err))
+	return resp, err
+}
+
+func (t *metricsTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	t.metrics.start(len(params))
+	start := time.Now()
+	resps, err := t.next.callBatch(ctx, method, params)
+	latency := time.Since(start)
+	for i := range params {
+		var resp *rpcResponse
+		if err == nil && i < len(resps) {
+			resp = resps[i]
+		}
+		t.metrics.observe(method, latency, metricsOutcome(resp, err))
+	}
+	return resps, err
+}
+
+func (t *metricsTransport) close() error {
+	return t.next.close()
+}
+
+// metricsOutcome classifies the result of a call
+func metricsOutcome(resp *rpcResponse, err error) string {
+	switch {
+	case err == nil && resp != nil && resp.Error != nil:
+		return MetricsOutcomeRejected
+	case err == nil:
+		return MetricsOutcomeSuccess
+	case errors.Is(err, ErrSigner):
+		return MetricsOutcomeRejected
+	case errors.Is(err, ErrConnection):
+		return MetricsOutcomeConnection
+	case errors.Is(err, ErrMalformedResponse):
+		return MetricsOutcomeMalformed
+	}
+	return MetricsOutcomeError
+}
+
+func (m *Metrics) start(n int) {
+	m.mu.Lock()
+	m.inFlight += int64(n)
+	m.mu.Unlock()
+}
+
+func (m *Metrics) observe(method string, latency time.Duration, outcome string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.inFlight--
+	m.requests[metricsKey{method, outcome}]++
+
+	h := m.latencies[method]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.latencies[method] = h
+	}
+	seconds := latency.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	name := m.namespace + "_requests_total"
+	fmt.Fprintf(&b, "# HELP %s Requests made to Clef by method and outcome.\n# TYPE %s counter\n", name, name)
+	keys := make([]metricsKey, 0, len(m.requests))
+	for key := range m.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s{method=%q,outcome=%q} %d\n", name, key.method, key.outcome, m.requests[key])
+	}
+
+	name = m.namespace + "_request_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s Latency of requests made to Clef, including user approval.\n# TYPE %s histogram\n", name, name)
+	methods := make([]string, 0, len(m.latencies))
+	for method := range m.latencies {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		h := m.latencies[method]
+		for i, bound := range m.buckets {
+			fmt.Fprintf(&b, "%s_bucket{method=%q,le=%q} %d\n", name, method, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{method=%q,le=\"+Inf\"} %d\n", name, method, h.count)
+		fmt.Fprintf(&b, "%s_sum{method=%q} %s\n", name, method, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "%s_count{method=%q} %d\n", name, method, h.count)
+	}
+
+	name = m.namespace + "_requests_in_flight"
+	fmt.Fprintf(&b, "# HELP %s Requests waiting for an answer from Clef.\n# TYPE %s gauge\n%s %d\n", name, name, name, m.inFlight)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,70 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingTransport fails every call with err
+type failingTransport struct {
+	err error
+}
+
+func (t *failingTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	return nil, t.err
+}
+
+func (t *failingTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	return nil, t.err
+}
+
+func (t *failingTransport) close() error {
+	return nil
+}
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics(MetricsConfig{Buckets: []float64{1, 0.5}})
+
+	cc := &ClefClient{transport: &countingTransport{}}
+	cc.EnableMetrics(m)
+	_, err := cc.ListAccounts()
+	assert.NoError(t, err)
+	_, err = cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001"}, {From: "0x0000000000000000000000000000000000000001"}})
+	assert.NoError(t, err)
+
+	cc = &ClefClient{transport: &failingTransport{err: connectionError(errors.New("connection refused"))}}
+	cc.EnableMetrics(m)
+	_, err = cc.ListAccounts()
+	assert.ErrorIs(t, err, ErrConnection)
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE clef_client_requests_total counter",
+		`clef_client_requests_total{method="account_list",outcome="connection_error"} 1`,
+		`clef_client_requests_total{method="account_list",outcome="success"} 1`,
+		`clef_client_requests_total{method="account_signTransaction",outcome="success"} 2`,
+		"# TYPE clef_client_request_duration_seconds histogram",
+		`clef_client_request_duration_seconds_bucket{method="account_list",le="0.5"} 2`,
+		`clef_client_request_duration_seconds_bucket{method="account_list",le="1"} 2`,
+		`clef_client_request_duration_seconds_bucket{method="account_list",le="+Inf"} 2`,
+		`clef_client_request_duration_seconds_count{method="account_signTransaction"} 2`,
+		"clef_client_requests_in_flight 0",
+	} {
+		assert.Contains(t, strings.Split(body, "\n"), line)
+	}
+}
+
+func TestMetricsOutcome(t *testing.T) {
+	assert.Equal(t, MetricsOutcomeSuccess, metricsOutcome(&rpcResponse{}, nil))
+	assert.Equal(t, MetricsOutcomeRejected, metricsOutcome(&rpcResponse{Error: &rpcError{Message: "Request denied"}}, nil))
+	assert.Equal(t, MetricsOutcomeMalformed, metricsOutcome(nil, malformedError(errors.New("bad json"))))
+	assert.Equal(t, MetricsOutcomeError, metricsOutcome(nil, ErrAuditFailed))
+}
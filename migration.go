@@ -0,0 +1,146 @@
+package clefclient
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MigrationDiscrepancy is a difference between two Clef instances that
+// should be resolved before cutting over from one to the other
+type MigrationDiscrepancy struct {
+	// Check is the check that found it: version, accounts or ecRecover
+	Check  string `json:"check"`
+	Detail string `json:"detail"`
+}
+
+// MigrationReport compares an old and a new Clef instance
+type MigrationReport struct {
+	OldVersion string `json:"oldVersion"`
+	NewVersion string `json:"newVersion"`
+	// Accounts are the accounts both instances manage
+	Accounts []string `json:"accounts"`
+	// MissingAccounts are managed by the old instance only
+	MissingAccounts []string `json:"missingAccounts,omitempty"`
+	// AddedAccounts are managed by the new instance only
+	AddedAccounts []string `json:"addedAccounts,omitempty"`
+	// Checks is the number of ecRecover checks replayed
+	Checks        int                    `json:"checks"`
+	Discrepancies []MigrationDiscrepancy `json:"discrepancies,omitempty"`
+}
+
+// OK reports whether no discrepancies were found
+func (r *MigrationReport) OK() bool {
+	return len(r.Discrepancies) == 0
+}
+
+func (r *MigrationReport) add(check, format string, args ...interface{}) {
+	r.Discrepancies = append(r.Discrepancies, MigrationDiscrepancy{Check: check, Detail: fmt.Sprintf(format, args...)})
+}
+
+// MigrationChecks returns ecRecover checks of personal messages signed with
+// a well-known test key, for CompareSigners when no recorded signatures are
+// at hand. The key is public and must not hold funds.
+func MigrationChecks() []*EcRecoverRequest {
+	signer := NewMemorySigner(1)
+	account, _ := signer.ImportKey("0x4646464646464646464646464646464646464646464646464646464646464646")
+	messages := [][]byte{
+		[]byte("clef migration check"),
+		{},
+		{0x00, 0xff, 0x19, 0x01},
+		[]byte(strings.Repeat("long message ", 100)),
+	}
+	checks := make([]*EcRecoverRequest, 0, len(messages))
+	for _, message := range messages {
+		data := "0x" + hex.EncodeToString(message)
+		signed, _ := signer.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: account, Data: data})
+		checks = append(checks, &EcRecoverRequest{Data: data, Signature: signed.Signature})
+	}
+	return checks
+}
+
+// CompareSigners compares the versions and accounts of an old and a new
+// Clef instance and replays the ecRecover checks against both, reporting
+// discrepancies before cutting over. Only read-only methods are called, so
+// nothing needs to be approved. Errors are returned if an instance cannot
+// be queried at all.
+func CompareSigners(old, new *ClefClient, checks []*EcRecoverRequest) (*MigrationReport, error) {
+	report := &MigrationReport{Checks: len(checks)}
+
+	oldVersion, err := old.Version()
+	if err != nil {
+		return nil, fmt.Errorf("old signer: %w", err)
+	}
+	newVersion, err := new.Version()
+	if err != nil {
+		return nil, fmt.Errorf("new signer: %w", err)
+	}
+	report.OldVersion, report.NewVersion = oldVersion.Version, newVersion.Version
+	oldMajor, _ := parseMajorVersion([]byte(fmt.Sprintf("%q", oldVersion.Version)))
+	newMajor, _ := parseMajorVersion([]byte(fmt.Sprintf("%q", newVersion.Version)))
+	if oldMajor != newMajor {
+		report.add("version", "external API changes from %s to %s", oldVersion.Version, newVersion.Version)
+	}
+
+	oldAccounts, err := old.ListAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("old signer: %w", err)
+	}
+	newAccounts, err := new.ListAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("new signer: %w", err)
+	}
+	managed := make(map[string]bool, len(newAccounts))
+	for _, account := range newAccounts {
+		managed[strings.ToLower(account)] = true
+	}
+	for _, account := range oldAccounts {
+		if managed[strings.ToLower(account)] {
+			report.Accounts = append(report.Accounts, account)
+			delete(managed, strings.ToLower(account))
+		} else {
+			report.MissingAccounts = append(report.MissingAccounts, account)
+		}
+	}
+	for _, account := range newAccounts {
+		if managed[strings.ToLower(account)] {
+			report.AddedAccounts = append(report.AddedAccounts, account)
+		}
+	}
+	sort.Strings(report.Accounts)
+	sort.Strings(report.MissingAccounts)
+	sort.Strings(report.AddedAccounts)
+	if len(report.MissingAccounts) > 0 {
+		report.add("accounts", "%d accounts are missing on the new signer: %s", len(report.MissingAccounts), strings.Join(report.MissingAccounts, ", "))
+	}
+
+	for i, check := range checks {
+		compareRecovery(report, i+1, check, old, new)
+	}
+	return report, nil
+}
+
+// compareRecovery replays an ecRecover check against both signers and
+// compares the results with the signer recovered locally
+func compareRecovery(report *MigrationReport, n int, check *EcRecoverRequest, old, new *ClefClient) {
+	var expected string
+	if data, err := decodeHexData(check.Data); err == nil {
+		expected, _ = RecoverAddress(TextHash(data), check.Signature)
+	}
+	recovered := func(cc *ClefClient) string {
+		resp, err := cc.EcRecover(check)
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return resp.Address
+	}
+	oldResult, newResult := recovered(old), recovered(new)
+
+	switch {
+	case !strings.EqualFold(oldResult, newResult):
+		report.add("ecRecover", "check %d: old signer recovered %s, new signer %s", n, oldResult, newResult)
+	case expected != "" && !strings.EqualFold(newResult, expected):
+		report.add("ecRecover", "check %d: both signers recovered %s instead of %s", n, newResult, expected)
+	}
+}
@@ -0,0 +1,77 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// migrationTransport answers the read-only methods like a Clef instance
+type migrationTransport struct {
+	version  string
+	accounts []string
+	// recovered, if set, is returned by account_ecRecover instead of the
+	// actual signer
+	recovered string
+}
+
+func (t *migrationTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	var result interface{}
+	switch method {
+	case "account_version":
+		result = VersionResponse{Version: t.version}
+	case "account_list":
+		result = t.accounts
+	case "account_ecRecover":
+		req := params.(*EcRecoverRequest)
+		data, _ := decodeHexData(req.Data)
+		address, _ := RecoverAddress(TextHash(data), req.Signature)
+		if t.recovered != "" {
+			address = t.recovered
+		}
+		result = EcRecoverResponse{Address: address}
+	default:
+		return nil, errors.New("unexpected method " + method)
+	}
+	raw, _ := json.Marshal(result)
+	return &rpcResponse{Result: raw}, nil
+}
+
+func (t *migrationTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (t *migrationTransport) close() error {
+	return nil
+}
+
+func TestCompareSigners(t *testing.T) {
+	a, b, c := "0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", "0x0000000000000000000000000000000000000003"
+	old := &ClefClient{transport: &migrationTransport{version: "6.1.0", accounts: []string{b, a}}}
+	same := &ClefClient{transport: &migrationTransport{version: "6.2.0", accounts: []string{a, b, c}}}
+
+	report, err := CompareSigners(old, same, MigrationChecks())
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), "%v", report.Discrepancies)
+	assert.Equal(t, "6.1.0", report.OldVersion)
+	assert.Equal(t, "6.2.0", report.NewVersion)
+	assert.Equal(t, []string{a, b}, report.Accounts)
+	assert.Equal(t, []string{c}, report.AddedAccounts)
+	assert.Equal(t, 4, report.Checks)
+
+	broken := &ClefClient{transport: &migrationTransport{version: "7.0.0", accounts: []string{a}, recovered: c}}
+	report, err = CompareSigners(old, broken, MigrationChecks()[:1])
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, []string{b}, report.MissingAccounts)
+	assert.Equal(t, []string{"version", "accounts", "ecRecover"}, []string{
+		report.Discrepancies[0].Check, report.Discrepancies[1].Check, report.Discrepancies[2].Check,
+	})
+	assert.Contains(t, report.Discrepancies[2].Detail, "new signer "+c)
+
+	_, err = CompareSigners(old, &ClefClient{transport: &failingTransport{err: errors.New("connection refused")}}, nil)
+	assert.ErrorContains(t, err, "new signer")
+}
@@ -0,0 +1,135 @@
+package clefclient
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownAccount is returned when no signer manages the requested account
+var ErrUnknownAccount = errors.New("account is not managed by any signer")
+
+// MultiClient routes requests by account across several Clef instances
+type MultiClient struct {
+	clients []*ClefClient
+
+	mu     sync.RWMutex
+	routes map[string]*ClefClient
+	order  []string
+}
+
+// NewMultiClient creates a MultiClient over the given clients. If several
+// clients manage the same account, the first one wins.
+func NewMultiClient(clients ...*ClefClient) *MultiClient {
+	return &MultiClient{clients: clients, routes: map[string]*ClefClient{}}
+}
+
+// Refresh rebuilds the address to signer map from the account lists of all
+// clients. Accounts of clients that fail to answer are dropped and their
+// errors returned, while the remaining routes stay usable.
+func (m *MultiClient) Refresh() error {
+	routes := map[string]*ClefClient{}
+	var order []string
+	var errs []error
+	for i, client := range m.clients {
+		accounts, err := client.ListAccounts()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("signer %d: %w", i, err))
+			continue
+		}
+		for _, account := range accounts {
+			key := strings.ToLower(account)
+			if _, ok := routes[key]; ok {
+				continue
+			}
+			routes[key] = client
+			order = append(order, account)
+		}
+	}
+
+	m.mu.Lock()
+	m.routes = routes
+	m.order = order
+	m.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// ListAccounts refreshes the routes and returns the merged account list
+func (m *MultiClient) ListAccounts() ([]string, error) {
+	err := m.Refresh()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.order...), err
+}
+
+// Route returns the client managing the account, refreshing the routes once
+// if the account is not known yet
+func (m *MultiClient) Route(address string) (*ClefClient, error) {
+	if client := m.lookup(address); client != nil {
+		return client, nil
+	}
+	// Unreachable signers must not hide the ones that do manage the account
+	refreshErr := m.Refresh()
+	if client := m.lookup(address); client != nil {
+		return client, nil
+	}
+	if refreshErr != nil {
+		return nil, fmt.Errorf("%w: %s (%v)", ErrUnknownAccount, address, refreshErr)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, address)
+}
+
+func (m *MultiClient) lookup(address string) *ClefClient {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.routes[strings.ToLower(address)]
+}
+
+// SignTransaction signs the transaction with the signer managing tx.From
+func (m *MultiClient) SignTransaction(tx *Transaction) (*SignTxResponse, error) {
+	if tx == nil {
+		return nil, nilRequestError("account_signTransaction")
+	}
+	client, err := m.Route(tx.From)
+	if err != nil {
+		return nil, err
+	}
+	return client.SignTransaction(tx)
+}
+
+// SignData signs the data with the signer managing req.Address
+func (m *MultiClient) SignData(req *SignDataRequest) (*SignDataResponse, error) {
+	if req == nil {
+		return nil, nilRequestError("account_signData")
+	}
+	client, err := m.Route(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	return client.SignData(req)
+}
+
+// SignTypedData signs the typed data with the signer managing req.Address
+func (m *MultiClient) SignTypedData(req *TypedDataRequest) (*SignDataResponse, error) {
+	if req == nil {
+		return nil, nilRequestError("account_signTypedData")
+	}
+	client, err := m.Route(req.Address)
+	if err != nil {
+		return nil, err
+	}
+	return client.SignTypedData(req)
+}
+
+// Close closes all underlying clients
+func (m *MultiClient) Close() error {
+	var errs []error
+	for _, client := range m.clients {
+		if err := client.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,87 @@
+package clefclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testSignature returns a well-formed signature filled with b
+func testSignature(b byte) string {
+	return encodeHexData(append(bytes.Repeat([]byte{b}, 64), 27))
+}
+
+// setupSignerServer serves account_list with the given accounts and signs
+// data with the given signature
+func setupSignerServer(t *testing.T, accounts []string, signature string) (*ClefClient, *httptest.Server) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var result interface{}
+		switch req.Method {
+		case "account_list":
+			result = accounts
+		case "account_signData":
+			result = SignDataResponse{Signature: signature}
+		default:
+			t.Errorf("unexpected method %s", req.Method)
+		}
+		resultBytes, _ := json.Marshal(result)
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: resultBytes})
+	}))
+	return NewHTTPClient(server.URL), server
+}
+
+func TestMultiClientRouting(t *testing.T) {
+	hot, hotServer := setupSignerServer(t, []string{"0xAA", "0xBB"}, testSignature(0x01))
+	defer hotServer.Close()
+	treasury, treasuryServer := setupSignerServer(t, []string{"0xCC", "0xaa"}, testSignature(0x02))
+	defer treasuryServer.Close()
+
+	multi := NewMultiClient(hot, treasury)
+
+	accounts, err := multi.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xAA", "0xBB", "0xCC"}, accounts)
+
+	sig, err := multi.SignData(&SignDataRequest{Address: "0xcc", Data: "0x"})
+	assert.NoError(t, err)
+	assert.Equal(t, testSignature(0x02), sig.Signature)
+
+	// Duplicate accounts are routed to the first signer
+	sig, err = multi.SignData(&SignDataRequest{Address: "0xaa", Data: "0x"})
+	assert.NoError(t, err)
+	assert.Equal(t, testSignature(0x01), sig.Signature)
+
+	_, err = multi.SignData(&SignDataRequest{Address: "0xdd", Data: "0x"})
+	assert.ErrorIs(t, err, ErrUnknownAccount)
+
+	_, err = multi.SignTransaction(nil)
+	assert.EqualError(t, err, "account_signTransaction: nil request")
+	_, err = multi.SignData(nil)
+	assert.ErrorIs(t, err, ErrNilRequest)
+	_, err = multi.SignTypedData(nil)
+	assert.ErrorIs(t, err, ErrNilRequest)
+}
+
+func TestMultiClientPartialFailure(t *testing.T) {
+	hot, hotServer := setupSignerServer(t, []string{"0xAA"}, testSignature(0x01))
+	defer hotServer.Close()
+	down, downServer := setupSignerServer(t, nil, "")
+	downServer.Close()
+
+	multi := NewMultiClient(down, hot)
+	accounts, err := multi.ListAccounts()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"0xAA"}, accounts)
+
+	// Routing still works for the reachable signer
+	sig, err := multi.SignData(&SignDataRequest{Address: "0xAA", Data: "0x"})
+	assert.NoError(t, err)
+	assert.Equal(t, testSignature(0x01), sig.Signature)
+}
@@ -0,0 +1,109 @@
+package clefclient
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrUnknownNetwork is returned when a network profile is not defined
+var ErrUnknownNetwork = errors.New("unknown network")
+
+// FeeStrategy sets the fees of a transaction that has none
+type FeeStrategy func(tx *Transaction) error
+
+// StaticFees is a FeeStrategy setting fixed EIP-1559 fee caps
+func StaticFees(maxFeePerGas, maxPriorityFeePerGas *big.Int) FeeStrategy {
+	return func(tx *Transaction) error {
+		tx.MaxFeePerGas = encodeQuantity(maxFeePerGas)
+		tx.MaxPriorityFeePerGas = encodeQuantity(maxPriorityFeePerGas)
+		return nil
+	}
+}
+
+// NodeGasPrice is a FeeStrategy setting the legacy gas price suggested by
+// the node
+func NodeGasPrice(nc *NodeClient) FeeStrategy {
+	return func(tx *Transaction) error {
+		gasPrice, err := nc.GasPrice()
+		if err != nil {
+			return fmt.Errorf("failed to fetch gas price: %w", err)
+		}
+		tx.GasPrice = gasPrice
+		return nil
+	}
+}
+
+// Network is the profile of a network signed for through Clef
+type Network struct {
+	Name    string
+	ChainID *big.Int
+	// Node is a node of the network, e.g. for a TxManager. The client does
+	// not use it.
+	Node Backend
+	// Fees sets the fees of transactions that set neither gasPrice nor
+	// maxFeePerGas, if not nil
+	Fees FeeStrategy
+	// Policies are applied to signing requests in addition to
+	// RequireChainID(ChainID)
+	Policies []DryRunPolicy
+}
+
+// Networks are network profiles by name
+type Networks map[string]*Network
+
+// Get returns the profile of the network called name
+func (n Networks) Get(name string) (*Network, error) {
+	network, ok := n[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNetwork, name)
+	}
+	return network, nil
+}
+
+// WithNetwork signs for network: transactions without a chain ID get the
+// chain ID of the network and transactions without fees get fees from its
+// FeeStrategy, then the policies of the network are applied to signing
+// requests as by EnablePolicies. Requests for other chains fail with
+// ErrPolicyViolation.
+func WithNetwork(network *Network) Option {
+	return func(o *clientOptions) {
+		o.network = network
+	}
+}
+
+// CallNetwork signs the calls for network, replacing the network of the
+// client, see WithNetwork
+func CallNetwork(network *Network) CallOption {
+	return func(cc *ClefClient) {
+		cc.network = network
+	}
+}
+
+// policies returns the policies applied to the signing requests for n
+func (n *Network) policies() []DryRunPolicy {
+	policies := make([]DryRunPolicy, 0, len(n.Policies)+1)
+	if n.ChainID != nil {
+		policies = append(policies, RequireChainID(n.ChainID))
+	}
+	return append(policies, n.Policies...)
+}
+
+// fillNetwork returns params with the chain ID and fees of the network of
+// the client filled in, copying transactions instead of modifying them
+func (cc *ClefClient) fillNetwork(params interface{}) (interface{}, error) {
+	tx, ok := params.(*Transaction)
+	if cc.network == nil || !ok {
+		return params, nil
+	}
+	filled := *tx
+	if filled.ChainID == "" && cc.network.ChainID != nil {
+		filled.ChainID = encodeQuantity(cc.network.ChainID)
+	}
+	if cc.network.Fees != nil && filled.GasPrice == "" && filled.MaxFeePerGas == "" {
+		if err := cc.network.Fees(&filled); err != nil {
+			return nil, fmt.Errorf("network %s: %w", cc.network.Name, err)
+		}
+	}
+	return &filled, nil
+}
@@ -0,0 +1,61 @@
+package clefclient
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworks(t *testing.T) {
+	networks := Networks{
+		"mainnet": {Name: "mainnet", ChainID: big.NewInt(1)},
+		"sepolia": {Name: "sepolia", ChainID: big.NewInt(11155111), Fees: StaticFees(big.NewInt(2000000000), big.NewInt(1000000000))},
+	}
+	sepolia, err := networks.Get("sepolia")
+	assert.NoError(t, err)
+	_, err = networks.Get("holesky")
+	assert.ErrorIs(t, err, ErrUnknownNetwork)
+
+	next := &versionedTransport{version: "6.0.0"}
+	cc := newClient(next, "", []Option{WithNetwork(sepolia)})
+
+	tx := &Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002"}
+	_, err = cc.SignTransaction(tx)
+	assert.NoError(t, err)
+	if assert.Len(t, next.params, 1) {
+		sent := next.params[0].(*Transaction)
+		assert.Equal(t, "0xaa36a7", sent.ChainID)
+		assert.Equal(t, "0x77359400", sent.MaxFeePerGas)
+		assert.Equal(t, "0x3b9aca00", sent.MaxPriorityFeePerGas)
+	}
+	assert.Empty(t, tx.ChainID)
+
+	// Fees that are set are kept
+	_, err = cc.SignTransaction(&Transaction{From: tx.From, To: tx.To, GasPrice: "0x1"})
+	assert.NoError(t, err)
+	assert.Empty(t, next.params[1].(*Transaction).MaxFeePerGas)
+
+	_, err = cc.SignTransaction(&Transaction{From: tx.From, To: tx.To, ChainID: "0x1"})
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.Len(t, next.params, 2)
+
+	mainnet, _ := networks.Get("mainnet")
+	_, err = cc.WithCallOptions(CallNetwork(mainnet)).SignTransaction(&Transaction{From: tx.From, To: tx.To})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x1", next.params[2].(*Transaction).ChainID)
+	assert.Empty(t, next.params[2].(*Transaction).MaxFeePerGas)
+
+	results, err := cc.With(WithNetwork(mainnet)).SignTransactions([]*Transaction{{From: tx.From, To: tx.To, ChainID: "0xaa36a7"}})
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.Empty(t, results)
+}
+
+func TestNetworkFeeStrategyError(t *testing.T) {
+	network := &Network{Name: "local", Fees: func(tx *Transaction) error { return errors.New("node unavailable") }}
+	cc := newClient(&versionedTransport{version: "6.0.0"}, "", []Option{WithNetwork(network)})
+
+	_, err := cc.SignTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001"})
+	assert.EqualError(t, err, "account_signTransaction (from=0x0000000000000000000000000000000000000001): network local: node unavailable")
+}
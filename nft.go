@@ -0,0 +1,79 @@
+package clefclient
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// ERC721ABI is the JSON ABI of the ERC-721 transfer functions
+const ERC721ABI = `[
+	{"type": "function", "name": "safeTransferFrom", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "tokenId", "type": "uint256"}]},
+	{"type": "function", "name": "safeTransferFrom", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "tokenId", "type": "uint256"}, {"name": "data", "type": "bytes"}]},
+	{"type": "function", "name": "transferFrom", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "tokenId", "type": "uint256"}]}
+]`
+
+// ERC1155ABI is the JSON ABI of the ERC-1155 transfer functions
+const ERC1155ABI = `[
+	{"type": "function", "name": "safeTransferFrom", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "id", "type": "uint256"}, {"name": "value", "type": "uint256"}, {"name": "data", "type": "bytes"}]},
+	{"type": "function", "name": "safeBatchTransferFrom", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "ids", "type": "uint256[]"}, {"name": "values", "type": "uint256[]"}, {"name": "data", "type": "bytes"}]}
+]`
+
+// ERC721Token builds transactions for an ERC-721 collection
+type ERC721Token struct {
+	Address string
+}
+
+// SafeTransferFrom builds a transfer of tokenID from the from account to
+// recipient. The data payload is passed to the recipient's onERC721Received
+// and omitted if nil.
+func (t ERC721Token) SafeTransferFrom(from, recipient string, tokenID *big.Int, data []byte) (*Transaction, error) {
+	if data == nil {
+		return buildTokenCall(t.Address, ERC721ABI, from, "safeTransferFrom(address,address,uint256)", from, recipient, tokenID)
+	}
+	return buildTokenCall(t.Address, ERC721ABI, from, "safeTransferFrom(address,address,uint256,bytes)", from, recipient, tokenID, data)
+}
+
+// ERC1155Token builds transactions for an ERC-1155 contract
+type ERC1155Token struct {
+	Address string
+}
+
+// SafeTransferFrom builds a transfer of amount tokens of id from the from
+// account to recipient, with a data payload for onERC1155Received
+func (t ERC1155Token) SafeTransferFrom(from, recipient string, id, amount *big.Int, data []byte) (*Transaction, error) {
+	return buildTokenCall(t.Address, ERC1155ABI, from, "safeTransferFrom", from, recipient, id, amount, nilToEmpty(data))
+}
+
+// SafeBatchTransferFrom builds a transfer of amounts[i] tokens of ids[i] from
+// the from account to recipient, with a data payload for
+// onERC1155BatchReceived
+func (t ERC1155Token) SafeBatchTransferFrom(from, recipient string, ids, amounts []*big.Int, data []byte) (*Transaction, error) {
+	if len(ids) != len(amounts) {
+		return nil, fmt.Errorf("got %d ids but %d amounts", len(ids), len(amounts))
+	}
+	return buildTokenCall(t.Address, ERC1155ABI, from, "safeBatchTransferFrom", from, recipient, ids, amounts, nilToEmpty(data))
+}
+
+// buildTokenCall builds a transaction calling method of the token contract
+func buildTokenCall(token, abiJSON, from, method string, args ...interface{}) (*Transaction, error) {
+	if _, err := encodeAddressWord(token); err != nil {
+		return nil, fmt.Errorf("token: %w", err)
+	}
+	for i, arg := range args {
+		if v, ok := arg.(*big.Int); ok && v == nil {
+			return nil, fmt.Errorf("argument %d of %s is nil", i, method)
+		}
+	}
+	data, err := BuildCallData(abiJSON, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{From: from, To: token, Data: data}, nil
+}
+
+func nilToEmpty(data []byte) []byte {
+	if data == nil {
+		return []byte{}
+	}
+	return data
+}
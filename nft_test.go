@@ -0,0 +1,45 @@
+package clefclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestERC721SafeTransferFrom(t *testing.T) {
+	nft := ERC721Token{Address: "0xBC4CA0EdA7647A8aB7C2061c2E118A18a936f13D"}
+	from := "0x0000000000000000000000000000000000000001"
+	to := "0x0000000000000000000000000000000000000002"
+
+	tx, err := nft.SafeTransferFrom(from, to, big.NewInt(7), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &Transaction{From: from, To: nft.Address, Data: "0x42842e0e" + word("1") + word("2") + word("7")}, tx)
+
+	tx, err = nft.SafeTransferFrom(from, to, big.NewInt(7), []byte{0xab})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xb88d4fde"+word("1")+word("2")+word("7")+word("80")+word("1")+"ab"+word("")[2:], tx.Data)
+
+	_, err = nft.SafeTransferFrom(from, to, nil, nil)
+	assert.ErrorContains(t, err, "nil")
+}
+
+func TestERC1155SafeTransferFrom(t *testing.T) {
+	token := ERC1155Token{Address: "0x76BE3b62873462d2142405439777e971754E8E77"}
+	from := "0x0000000000000000000000000000000000000001"
+	to := "0x0000000000000000000000000000000000000002"
+
+	tx, err := token.SafeTransferFrom(from, to, big.NewInt(3), big.NewInt(10), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xf242432a"+word("1")+word("2")+word("3")+word("a")+word("a0")+word("0"), tx.Data)
+
+	tx, err = token.SafeBatchTransferFrom(from, to, []*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(10), big.NewInt(20)}, []byte{0x01, 0x02})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2eb2c2d6"+word("1")+word("2")+word("a0")+word("100")+word("160")+
+		word("2")+word("1")+word("2")+
+		word("2")+word("a")+word("14")+
+		word("2")+"0102"+word("")[4:], tx.Data)
+
+	_, err = token.SafeBatchTransferFrom(from, to, []*big.Int{big.NewInt(1)}, nil, nil)
+	assert.ErrorContains(t, err, "1 ids but 0 amounts")
+}
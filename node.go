@@ -0,0 +1,134 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Backend defines the node operations needed to broadcast and track
+// transactions signed through Clef
+type Backend interface {
+	// PendingNonceAt returns the next nonce for the account, including pending transactions
+	PendingNonceAt(account string) (string, error)
+	// SendRawTransaction broadcasts a signed transaction and returns its hash
+	SendRawTransaction(raw string) (string, error)
+	// TransactionReceipt returns the receipt of a mined transaction, or nil if it is still pending
+	TransactionReceipt(hash string) (*Receipt, error)
+	// BlockNumber returns the number of the most recent block
+	BlockNumber() (string, error)
+}
+
+// Receipt represents the receipt of a mined transaction
+type Receipt struct {
+	TransactionHash   string `json:"transactionHash"`
+	BlockHash         string `json:"blockHash"`
+	BlockNumber       string `json:"blockNumber"`
+	Status            string `json:"status"`
+	GasUsed           string `json:"gasUsed"`
+	EffectiveGasPrice string `json:"effectiveGasPrice,omitempty"`
+	ContractAddress   string `json:"contractAddress,omitempty"`
+}
+
+// Reverted reports whether the transaction failed during execution
+func (r *Receipt) Reverted() bool {
+	return r.Status == "0x0"
+}
+
+// NodeClient is a minimal Ethereum node JSON-RPC client implementing Backend
+type NodeClient struct {
+	transport transport
+}
+
+// NewNodeClient creates a new NodeClient using HTTP transport
+func NewNodeClient(url string) *NodeClient {
+	return &NodeClient{transport: newHTTPTransport(url)}
+}
+
+// Close closes the underlying transport
+func (nc *NodeClient) Close() error {
+	return nc.transport.close()
+}
+
+// PendingNonceAt returns the next nonce for the account, including pending transactions
+func (nc *NodeClient) PendingNonceAt(account string) (string, error) {
+	resp, err := nc.transport.call(context.Background(), "eth_getTransactionCount", []interface{}{account, "pending"})
+	if err != nil {
+		return "", err
+	}
+
+	var nonce string
+	if err := json.Unmarshal(resp.Result, &nonce); err != nil {
+		return "", err
+	}
+	return nonce, nil
+}
+
+// SendRawTransaction broadcasts a signed transaction and returns its hash
+func (nc *NodeClient) SendRawTransaction(raw string) (string, error) {
+	resp, err := nc.transport.call(context.Background(), "eth_sendRawTransaction", []interface{}{raw})
+	if err != nil {
+		return "", err
+	}
+
+	var hash string
+	if err := json.Unmarshal(resp.Result, &hash); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// TransactionReceipt returns the receipt of a mined transaction, or nil if it is still pending
+func (nc *NodeClient) TransactionReceipt(hash string) (*Receipt, error) {
+	resp, err := nc.transport.call(context.Background(), "eth_getTransactionReceipt", []interface{}{hash})
+	if err != nil {
+		return nil, err
+	}
+
+	var receipt *Receipt
+	if err := json.Unmarshal(resp.Result, &receipt); err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
+
+// BlockNumber returns the number of the most recent block
+func (nc *NodeClient) BlockNumber() (string, error) {
+	resp, err := nc.transport.call(context.Background(), "eth_blockNumber", []interface{}{})
+	if err != nil {
+		return "", err
+	}
+
+	var number string
+	if err := json.Unmarshal(resp.Result, &number); err != nil {
+		return "", err
+	}
+	return number, nil
+}
+
+// ChainID returns the chain ID of the node's network
+func (nc *NodeClient) ChainID() (string, error) {
+	return nc.callString("eth_chainId", []interface{}{})
+}
+
+// GasPrice returns the node's suggested legacy gas price
+func (nc *NodeClient) GasPrice() (string, error) {
+	return nc.callString("eth_gasPrice", []interface{}{})
+}
+
+// EstimateGas returns the node's gas estimate for the transaction
+func (nc *NodeClient) EstimateGas(tx *Transaction) (string, error) {
+	return nc.callString("eth_estimateGas", []interface{}{tx})
+}
+
+func (nc *NodeClient) callString(method string, params []interface{}) (string, error) {
+	resp, err := nc.transport.call(context.Background(), method, params)
+	if err != nil {
+		return "", err
+	}
+
+	var result string
+	if err := json.Unmarshal(resp.Result, &result); err != nil {
+		return "", err
+	}
+	return result, nil
+}
@@ -0,0 +1,237 @@
+package clefclient
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// offlineFormatVersion is the version of the offline bundle file format
+const offlineFormatVersion = 1
+
+var (
+	// ErrBundleMismatch is returned when importing results produced for a
+	// different request bundle
+	ErrBundleMismatch = errors.New("offline results belong to a different bundle")
+	// ErrNoOfflineResult is returned when a bundle's results lack a request
+	ErrNoOfflineResult = errors.New("no result for offline request")
+)
+
+// offlineMethods are the methods that may be carried in an offline bundle
+var offlineMethods = map[string]bool{
+	"account_signTransaction": true,
+	"account_signData":        true,
+	"account_signTypedData":   true,
+}
+
+// OfflineRequest is a signing request carried to an air-gapped Clef
+type OfflineRequest struct {
+	ID     string          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// OfflineBundle is a set of signing requests serialized for transfer to an
+// isolated machine
+type OfflineBundle struct {
+	Version  int              `json:"version"`
+	ID       string           `json:"id"`
+	Created  time.Time        `json:"created"`
+	Requests []OfflineRequest `json:"requests"`
+}
+
+// OfflineResult is the outcome of one request executed on the isolated machine
+type OfflineResult struct {
+	ID     string          `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// OfflineResults are the outcomes of executing an OfflineBundle
+type OfflineResults struct {
+	Version  int             `json:"version"`
+	BundleID string          `json:"bundleId"`
+	Executed time.Time       `json:"executed"`
+	Results  []OfflineResult `json:"results"`
+}
+
+// NewOfflineBundle creates an empty bundle with a random ID
+func NewOfflineBundle() (*OfflineBundle, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	return &OfflineBundle{Version: offlineFormatVersion, ID: id, Created: time.Now().UTC()}, nil
+}
+
+// AddTransaction adds a transaction signing request and returns its ID
+func (b *OfflineBundle) AddTransaction(tx *Transaction) (string, error) {
+	return b.add("account_signTransaction", tx)
+}
+
+// AddData adds a data signing request and returns its ID
+func (b *OfflineBundle) AddData(req *SignDataRequest) (string, error) {
+	return b.add("account_signData", req)
+}
+
+// AddTypedData adds a typed data signing request and returns its ID
+func (b *OfflineBundle) AddTypedData(req *TypedDataRequest) (string, error) {
+	return b.add("account_signTypedData", req)
+}
+
+func (b *OfflineBundle) add(method string, params interface{}) (string, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	id := fmt.Sprintf("%d", len(b.Requests)+1)
+	b.Requests = append(b.Requests, OfflineRequest{ID: id, Method: method, Params: raw})
+	return id, nil
+}
+
+// WriteFile writes the bundle as JSON to path
+func (b *OfflineBundle) WriteFile(path string) error {
+	return writeJSONFile(path, b)
+}
+
+// ReadOfflineBundle reads a bundle written by WriteFile
+func ReadOfflineBundle(path string) (*OfflineBundle, error) {
+	var b OfflineBundle
+	if err := readJSONFile(path, &b); err != nil {
+		return nil, err
+	}
+	if b.Version != offlineFormatVersion {
+		return nil, fmt.Errorf("unsupported offline bundle version %d", b.Version)
+	}
+	for _, req := range b.Requests {
+		if !offlineMethods[req.Method] {
+			return nil, fmt.Errorf("offline request %s: method %s is not allowed", req.ID, req.Method)
+		}
+	}
+	return &b, nil
+}
+
+// ExecuteOffline runs every request of the bundle against this client, which
+// is expected to talk to the isolated Clef. Requests are decoded and checked
+// like those of SignTransaction, SignData and SignTypedData, so the
+// policies of the client apply. Failed requests are recorded in the results
+// rather than aborting the run.
+func (cc *ClefClient) ExecuteOffline(b *OfflineBundle) *OfflineResults {
+	results := &OfflineResults{Version: offlineFormatVersion, BundleID: b.ID}
+	for _, req := range b.Requests {
+		result := OfflineResult{ID: req.ID, Method: req.Method}
+		if !offlineMethods[req.Method] {
+			result.Error = fmt.Sprintf("method %s is not allowed", req.Method)
+		} else if params, err := req.params(); err != nil {
+			result.Error = err.Error()
+		} else if resp, err := cc.call(req.Method, params); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Result = resp.Result
+		}
+		results.Results = append(results.Results, result)
+	}
+	results.Executed = time.Now().UTC()
+	return results
+}
+
+// params decodes the params of the request into the request type of its
+// method, so that they can be checked before they are sent
+func (r OfflineRequest) params() (interface{}, error) {
+	var params interface{}
+	switch r.Method {
+	case "account_signTransaction":
+		params = &Transaction{}
+	case "account_signData":
+		params = &SignDataRequest{}
+	case "account_signTypedData":
+		params = &TypedDataRequest{}
+	default:
+		return nil, fmt.Errorf("method %s is not allowed", r.Method)
+	}
+	if err := json.Unmarshal(r.Params, params); err != nil {
+		return nil, fmt.Errorf("%s: invalid params: %w", r.Method, err)
+	}
+	return params, nil
+}
+
+// WriteFile writes the results as JSON to path
+func (r *OfflineResults) WriteFile(path string) error {
+	return writeJSONFile(path, r)
+}
+
+// ImportOfflineResults reads results written by WriteFile and checks that
+// they were produced for the given bundle
+func ImportOfflineResults(path string, b *OfflineBundle) (*OfflineResults, error) {
+	var r OfflineResults
+	if err := readJSONFile(path, &r); err != nil {
+		return nil, err
+	}
+	if r.Version != offlineFormatVersion {
+		return nil, fmt.Errorf("unsupported offline results version %d", r.Version)
+	}
+	if r.BundleID != b.ID {
+		return nil, ErrBundleMismatch
+	}
+	return &r, nil
+}
+
+// SignedTransaction returns the signed transaction for the request ID
+func (r *OfflineResults) SignedTransaction(id string) (*SignTxResponse, error) {
+	var result SignTxResponse
+	if err := r.decode(id, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Signature returns the data or typed data signature for the request ID
+func (r *OfflineResults) Signature(id string) (*SignDataResponse, error) {
+	var result SignDataResponse
+	if err := r.decode(id, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *OfflineResults) decode(id string, v interface{}) error {
+	for _, result := range r.Results {
+		if result.ID != id {
+			continue
+		}
+		if result.Error != "" {
+			return errors.New(result.Error)
+		}
+		return json.Unmarshal(result.Result, v)
+	}
+	return fmt.Errorf("%w %s", ErrNoOfflineResult, id)
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,95 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfflineRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	bundle, err := NewOfflineBundle()
+	assert.NoError(t, err)
+	txID, err := bundle.AddTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002", Gas: "0x5208", Nonce: "0x0"})
+	assert.NoError(t, err)
+	dataID, err := bundle.AddData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000001", Data: "0x1234"})
+	assert.NoError(t, err)
+	assert.NoError(t, bundle.WriteFile(filepath.Join(dir, "requests.json")))
+
+	// On the isolated machine
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		resp := rpcResponse{Jsonrpc: "2.0", ID: req.ID}
+		if req.Method == "account_signData" {
+			resp.Result, _ = json.Marshal(SignDataResponse{Signature: "0xsig"})
+		} else {
+			resp.Error = &rpcError{Code: -32000, Message: "Request denied"}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+	client := NewHTTPClient(server.URL)
+	carried, err := ReadOfflineBundle(filepath.Join(dir, "requests.json"))
+	assert.NoError(t, err)
+	results := client.ExecuteOffline(carried)
+	assert.NoError(t, results.WriteFile(filepath.Join(dir, "results.json")))
+
+	imported, err := ImportOfflineResults(filepath.Join(dir, "results.json"), bundle)
+	assert.NoError(t, err)
+	sig, err := imported.Signature(dataID)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xsig", sig.Signature)
+
+	_, err = imported.SignedTransaction(txID)
+	assert.EqualError(t, err, "account_signTransaction (from=0x0000000000000000000000000000000000000001, to=0x0000000000000000000000000000000000000002): Request denied")
+	_, err = imported.Signature("99")
+	assert.ErrorIs(t, err, ErrNoOfflineResult)
+
+	other, _ := NewOfflineBundle()
+	_, err = ImportOfflineResults(filepath.Join(dir, "results.json"), other)
+	assert.ErrorIs(t, err, ErrBundleMismatch)
+}
+
+func TestReadOfflineBundleRejectsMethods(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.json")
+	data, _ := json.Marshal(OfflineBundle{Version: 1, ID: "x", Requests: []OfflineRequest{{ID: "1", Method: "account_new"}}})
+	assert.NoError(t, os.WriteFile(path, data, 0600))
+
+	_, err := ReadOfflineBundle(path)
+	assert.ErrorContains(t, err, "not allowed")
+}
+
+func TestExecuteOfflinePolicies(t *testing.T) {
+	alice := "0x0000000000000000000000000000000000000001"
+	bob := "0x0000000000000000000000000000000000000002"
+	mallory := "0x0000000000000000000000000000000000000003"
+	bundle, _ := NewOfflineBundle()
+	allowed, _ := bundle.AddTransaction(&Transaction{From: alice, To: bob, Value: "0x2"})
+	denied, _ := bundle.AddTransaction(&Transaction{From: alice, To: mallory, Value: "0x1"})
+	overLimit, _ := bundle.AddTransaction(&Transaction{From: alice, To: bob, Value: "0x9"})
+	bundle.Requests = append(bundle.Requests, OfflineRequest{ID: "4", Method: "account_signTransaction", Params: json.RawMessage(`[]`)})
+
+	next := &approvingTransport{}
+	cc := &ClefClient{transport: next}
+	cc.EnableSpendingLimits(NewMemorySpendingStore(), SpendingLimit{Max: big.NewInt(10), Window: time.Hour})
+	cc.EnablePolicies(AllowDestinations(Destination{Address: bob}))
+	results := cc.ExecuteOffline(bundle)
+
+	_, err := results.SignedTransaction(allowed)
+	assert.NoError(t, err)
+	_, err = results.SignedTransaction(denied)
+	assert.ErrorContains(t, err, "rejected by client policy")
+	_, err = results.SignedTransaction(overLimit)
+	assert.ErrorContains(t, err, "spending limit exceeded")
+	_, err = results.SignedTransaction("4")
+	assert.ErrorContains(t, err, "invalid params")
+	assert.Equal(t, 1, next.calls)
+}
@@ -0,0 +1,316 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Option configures a ClefClient created by NewHTTPClient or NewIPCClient
+type Option func(*clientOptions)
+
+// clientOptions are the settings collected from the options of a constructor
+type clientOptions struct {
+	timeout    time.Duration
+	httpClient *http.Client
+	header     http.Header
+	retry      *RetryPolicy
+	logger     *slog.Logger
+	network    *Network
+	clientID   string
+	httpsOnly  bool
+	pins       []tlsPin
+	// requestSigner signs HTTP requests, see WithRequestSigning
+	requestSigner *requestSigner
+	// versionPolicy and identity are checked by NewClient
+	versionPolicy *VersionPolicy
+	identity      string
+}
+
+// WithTimeout bounds the time every call may take, including the time the
+// user takes to approve signing requests. Calls that take longer fail with
+// ErrSigningTimeout. An IPC connection is closed once a call timed out.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithHTTPClient sends the requests of an HTTP client with c instead of
+// http.DefaultClient, e.g. for custom TLS settings or proxies. It has no
+// effect on IPC clients.
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = c
+	}
+}
+
+// WithHeaders adds header to every request of an HTTP client, e.g. for an
+// authenticating proxy in front of Clef. It has no effect on IPC clients.
+func WithHeaders(header http.Header) Option {
+	return func(o *clientOptions) {
+		if o.header == nil {
+			o.header = http.Header{}
+		}
+		for name, values := range header {
+			name = http.CanonicalHeaderKey(name)
+			if name == "User-Agent" {
+				o.header[name] = values
+				continue
+			}
+			o.header[name] = append(o.header[name], values...)
+		}
+	}
+}
+
+// DefaultUserAgent is the User-Agent of HTTP requests, unless set with
+// WithUserAgent
+const DefaultUserAgent = "clef-client-go"
+
+// WithUserAgent sends ua as the User-Agent of HTTP requests
+func WithUserAgent(ua string) Option {
+	return WithHeaders(http.Header{"User-Agent": {ua}})
+}
+
+// WithClientID identifies the service making requests, so that they can be
+// attributed in the logs of Clef or of a proxy in front of it: id is sent in
+// the X-Client-Id header of HTTP requests and recorded as the ClientID of
+// audit entries.
+func WithClientID(id string) Option {
+	return func(o *clientOptions) {
+		o.clientID = id
+	}
+}
+
+// WithHTTPSOnly refuses to send requests over plaintext HTTP, except to
+// loopback addresses, so that signing traffic is not exposed to the network
+// by a mistyped endpoint: NewClient fails with ErrInsecureEndpoint for such
+// endpoints, as do the calls of clients created by NewHTTPClient and
+// redirects to such URLs. It has no effect on IPC clients or on clients
+// derived with With.
+func WithHTTPSOnly() Option {
+	return func(o *clientOptions) {
+		o.httpsOnly = true
+	}
+}
+
+// WithRetry retries failed calls according to policy
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *clientOptions) {
+		o.retry = &policy
+	}
+}
+
+// WithLogger logs every call, including retried attempts, to logger: calls
+// that succeed at debug level, calls that fail at warn level. Requests and
+// results are not logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
+// collectOptions returns the settings of opts
+func collectOptions(opts []Option) clientOptions {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// newClient creates a client over base configured with opts
+func newClient(base transport, endpoint string, opts []Option) *ClefClient {
+	o := collectOptions(opts)
+	if t, ok := base.(*httpTransport); ok {
+		if o.httpClient != nil {
+			t.client = o.httpClient
+		}
+		t.header = o.header
+		t.signer = o.requestSigner
+		if o.httpsOnly {
+			t.requireHTTPS()
+		}
+		if len(o.pins) > 0 {
+			t.pin(o.pins)
+		}
+	}
+
+	cc := &ClefClient{transport: base, endpoint: endpoint, timeout: o.timeout, network: o.network, clientID: o.clientID}
+	if o.logger != nil {
+		cc.transport = &logTransport{next: cc.transport, logger: o.logger}
+	}
+	if o.retry != nil {
+		cc.transport = &retryTransport{next: cc.transport, policy: o.retry.withDefaults()}
+	}
+	return cc
+}
+
+// With returns a client derived from cc with opts applied on top of its
+// defaults, e.g. for a class of requests of one tenant or priority. The
+// derived client shares the transport, and so the connection, of cc:
+// WithHTTPClient has no effect, and closing either client closes both.
+// Headers of WithHeaders replace headers of the same name.
+func (cc *ClefClient) With(opts ...Option) *ClefClient {
+	if cc == nil {
+		return nil
+	}
+	o := collectOptions(opts)
+
+	c := *cc
+	if o.timeout > 0 {
+		c.timeout = o.timeout
+	}
+	if o.header != nil {
+		CallHeaders(o.header)(&c)
+	}
+	if o.logger != nil {
+		c.transport = &logTransport{next: c.transport, logger: o.logger}
+	}
+	if o.retry != nil {
+		CallRetry(*o.retry)(&c)
+	}
+	if o.network != nil {
+		c.network = o.network
+	}
+	if o.clientID != "" {
+		c.clientID = o.clientID
+	}
+	return &c
+}
+
+// RetryPolicy configures the retries of failed calls
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts of a call, including the
+	// first, 3 if zero
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled for every
+	// further retry, 100ms if zero
+	Backoff time.Duration
+	// Retryable reports whether a failed call is retried. If nil, calls
+	// failing with ErrConnection are retried, unless they are signing
+	// requests: Clef may have received those and would prompt the user
+	// again.
+	Retryable func(method string, err error) bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 100 * time.Millisecond
+	}
+	if p.Retryable == nil {
+		p.Retryable = func(method string, err error) bool {
+			return !signingMethods[method] && errors.Is(err, ErrConnection)
+		}
+	}
+	return p
+}
+
+// retryTransport is a transport decorator retrying failed calls
+type retryTransport struct {
+	next   transport
+	policy RetryPolicy
+	// override is set for the retries of CallRetry, which replace the
+	// retries of the decorators it wraps
+	override bool
+}
+
+// retryOverrideKey is the context key marking calls whose retries are
+// overridden by CallRetry
+type retryOverrideKey struct{}
+
+func (t *retryTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	var resp *rpcResponse
+	err := t.retry(ctx, method, func(ctx context.Context) error {
+		var err error
+		resp, err = t.next.call(ctx, method, params)
+		return err
+	})
+	return resp, err
+}
+
+// callBatch retries whole batches, which fail only when they could not be
+// exchanged
+func (t *retryTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	var resps []*rpcResponse
+	err := t.retry(ctx, method, func(ctx context.Context) error {
+		var err error
+		resps, err = t.next.callBatch(ctx, method, params)
+		return err
+	})
+	return resps, err
+}
+
+func (t *retryTransport) close() error {
+	return t.next.close()
+}
+
+// retry runs attempt until it succeeds, fails with an error that is not
+// retryable or the attempts are exhausted. Calls overridden by CallRetry are
+// attempted once. No attempt is made past the deadline of WithRequestDeadline.
+func (t *retryTransport) retry(ctx context.Context, method string, attempt func(ctx context.Context) error) error {
+	if t.override {
+		ctx = context.WithValue(ctx, retryOverrideKey{}, true)
+	} else if ctx.Value(retryOverrideKey{}) != nil {
+		return attempt(ctx)
+	}
+	backoff := t.policy.Backoff
+	for i := 1; ; i++ {
+		if err := checkRequestDeadline(ctx); err != nil {
+			return err
+		}
+		err := attempt(ctx)
+		if err == nil || i >= t.policy.MaxAttempts || !t.policy.Retryable(method, err) {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// logTransport is a transport decorator logging calls
+type logTransport struct {
+	next   transport
+	logger *slog.Logger
+}
+
+func (t *logTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	start := time.Now()
+	resp, err := t.next.call(ctx, method, params)
+	t.log(ctx, method, 1, time.Since(start), err)
+	return resp, err
+}
+
+func (t *logTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	start := time.Now()
+	resps, err := t.next.callBatch(ctx, method, params)
+	t.log(ctx, method, len(params), time.Since(start), err)
+	return resps, err
+}
+
+func (t *logTransport) close() error {
+	return t.next.close()
+}
+
+func (t *logTransport) log(ctx context.Context, method string, requests int, duration time.Duration, err error) {
+	attrs := []slog.Attr{slog.String("method", method), slog.Duration("duration", duration)}
+	if requests > 1 {
+		attrs = append(attrs, slog.Int("requests", requests))
+	}
+	if err != nil {
+		t.logger.LogAttrs(ctx, slog.LevelWarn, "clef call failed", append(attrs, slog.Any("error", err))...)
+		return
+	}
+	t.logger.LogAttrs(ctx, slog.LevelDebug, "clef call", attrs...)
+}
@@ -0,0 +1,236 @@
+package clefclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyTransport fails the first calls with a connection error
+type flakyTransport struct {
+	countingTransport
+	failures int
+}
+
+func (t *flakyTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if t.failures > 0 {
+		t.failures--
+		t.calls++
+		return nil, connectionError(errors.New("connection reset"))
+	}
+	return t.countingTransport.call(ctx, method, params)
+}
+
+// roundTripperFunc adapts a function to http.RoundTripper
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestWithHeadersAndHTTPClient(t *testing.T) {
+	var sent *http.Request
+	httpClient := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		sent = r
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":[]}`)),
+		}, nil
+	})}
+	client := NewHTTPClient("http://clef.invalid:8550", WithHTTPClient(httpClient), WithHeaders(http.Header{"Authorization": {"Bearer token"}}))
+
+	_, err := client.ListAccounts()
+	assert.NoError(t, err)
+	if assert.NotNil(t, sent) {
+		assert.Equal(t, "Bearer token", sent.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", sent.Header.Get("Content-Type"))
+	}
+}
+
+func TestWithTimeoutHTTP(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewHTTPClient(server.URL, WithTimeout(20*time.Millisecond))
+	_, err := client.ListAccounts()
+	assert.ErrorIs(t, err, ErrSigningTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWithTimeoutIPC(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "clef-test")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	listener, err := net.Listen("unix", filepath.Join(tmpDir, "clef.ipc"))
+	assert.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// Read the request but never answer
+		var req rpcRequest
+		json.NewDecoder(conn).Decode(&req)
+		time.Sleep(time.Second)
+	}()
+
+	client, err := NewIPCClient(filepath.Join(tmpDir, "clef.ipc"), WithTimeout(20*time.Millisecond))
+	assert.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.ListAccounts()
+	assert.ErrorIs(t, err, ErrSigningTimeout)
+
+	// The late answer must not be taken for the answer to the next call
+	_, err = client.ListAccounts()
+	assert.ErrorIs(t, err, ErrConnection)
+}
+
+func TestWithRetry(t *testing.T) {
+	next := &flakyTransport{failures: 2}
+	cc := newClient(next, "", []Option{WithRetry(RetryPolicy{Backoff: time.Millisecond})})
+	_, err := cc.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, next.calls)
+
+	next = &flakyTransport{failures: 3}
+	cc = newClient(next, "", []Option{WithRetry(RetryPolicy{Backoff: time.Millisecond})})
+	_, err = cc.ListAccounts()
+	assert.ErrorIs(t, err, ErrConnection)
+	assert.Equal(t, 3, next.calls)
+}
+
+func TestWithRetryRequestDeadline(t *testing.T) {
+	next := &flakyTransport{failures: 2}
+	cc := newClient(next, "", []Option{WithRetry(RetryPolicy{Backoff: 20 * time.Millisecond})})
+	ctx := WithRequestDeadline(context.Background(), time.Now().Add(10*time.Millisecond))
+	_, err := cc.WithContext(ctx).ListAccounts()
+	assert.ErrorIs(t, err, ErrRequestExpired)
+	assert.Equal(t, 1, next.calls)
+
+	// The earlier deadline applies
+	ctx = WithRequestDeadline(WithRequestDeadline(context.Background(), time.Now().Add(-time.Second)), time.Now().Add(time.Hour))
+	_, err = cc.WithContext(ctx).ListAccounts()
+	assert.ErrorIs(t, err, ErrRequestExpired)
+	assert.Equal(t, 1, next.calls)
+}
+
+func TestWithRetrySkipsSigning(t *testing.T) {
+	next := &flakyTransport{failures: 1}
+	cc := newClient(next, "", []Option{WithRetry(RetryPolicy{Backoff: time.Millisecond})})
+	_, err := cc.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000001", Data: "0x01"})
+	assert.ErrorIs(t, err, ErrConnection)
+	assert.Equal(t, 1, next.calls)
+
+	next = &flakyTransport{failures: 1}
+	cc = newClient(next, "", []Option{WithRetry(RetryPolicy{
+		Backoff:   time.Millisecond,
+		Retryable: func(method string, err error) bool { return true },
+	})})
+	cc.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000001", Data: "0x01"})
+	assert.Equal(t, 2, next.calls)
+}
+
+func TestWithLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	next := &flakyTransport{failures: 1}
+	cc := newClient(next, "", []Option{WithLogger(logger), WithRetry(RetryPolicy{Backoff: time.Millisecond})})
+	_, err := cc.ListAccounts()
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], `level=WARN msg="clef call failed" method=account_list`)
+		assert.Contains(t, lines[0], `error="connection reset"`)
+		assert.Contains(t, lines[1], `level=DEBUG msg="clef call" method=account_list`)
+	}
+}
+
+func TestWith(t *testing.T) {
+	var sent []*http.Request
+	httpClient := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		sent = append(sent, r)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":[]}`)),
+		}, nil
+	})}
+	client := NewHTTPClient("http://clef.invalid:8550", WithHTTPClient(httpClient), WithTimeout(time.Minute), WithHeaders(http.Header{"X-Tenant": {"default"}}))
+
+	var out bytes.Buffer
+	tenant := client.With(WithTimeout(time.Second), WithHeaders(http.Header{"X-Tenant": {"acme"}}), WithLogger(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	assert.Equal(t, time.Second, tenant.timeout)
+	assert.Equal(t, time.Minute, client.timeout)
+
+	_, err := tenant.ListAccounts()
+	assert.NoError(t, err)
+	_, err = client.ListAccounts()
+	assert.NoError(t, err)
+
+	if assert.Len(t, sent, 2) {
+		assert.Equal(t, "acme", sent[0].Header.Get("X-Tenant"))
+		assert.Equal(t, "default", sent[1].Header.Get("X-Tenant"))
+	}
+	assert.Equal(t, 1, strings.Count(out.String(), "clef call"))
+}
+
+func TestWithClientID(t *testing.T) {
+	var sent []*http.Request
+	httpClient := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		sent = append(sent, r)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":[]}`)),
+		}, nil
+	})}
+	store := &MemoryAuditStore{}
+	client := NewHTTPClient("http://clef.invalid:8550", WithHTTPClient(httpClient), WithClientID("payouts"))
+	client.EnableAudit(NewAuditLog(AuditConfig{Store: store}))
+
+	_, err := client.ListAccounts()
+	assert.NoError(t, err)
+	_, err = client.With(WithClientID("refunds"), WithUserAgent("refunds/1.2")).ListAccounts()
+	assert.NoError(t, err)
+	_, err = NewHTTPClient("http://clef.invalid:8550", WithHTTPClient(httpClient)).ListAccounts()
+	assert.NoError(t, err)
+
+	if assert.Len(t, sent, 3) {
+		assert.Equal(t, "payouts", sent[0].Header.Get("X-Client-Id"))
+		assert.Equal(t, DefaultUserAgent, sent[0].Header.Get("User-Agent"))
+		assert.Equal(t, "refunds", sent[1].Header.Get("X-Client-Id"))
+		assert.Equal(t, []string{"refunds/1.2"}, sent[1].Header.Values("User-Agent"))
+		assert.Empty(t, sent[2].Header.Get("X-Client-Id"))
+	}
+	entries := store.Entries()
+	if assert.Len(t, entries, 4) {
+		assert.Equal(t, "payouts", entries[0].ClientID)
+		assert.Equal(t, "payouts", entries[1].ClientID)
+		assert.Equal(t, "refunds", entries[2].ClientID)
+		assert.Equal(t, "refunds", entries[3].ClientID)
+	}
+}
@@ -0,0 +1,250 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDuplicateNonce is returned when a transaction is signed for a nonce that
+// already has an in-flight transaction and the tracker refuses duplicates
+var ErrDuplicateNonce = errors.New("nonce already has a pending transaction")
+
+// InFlightTx is a transaction signed through the client and not yet known to
+// be mined
+type InFlightTx struct {
+	Account string
+	Nonce   uint64
+	To      string
+	// Hash is the hash of the signed transaction
+	Hash   string
+	Signed time.Time
+}
+
+// PendingTrackerConfig configures a PendingTracker
+type PendingTrackerConfig struct {
+	// Refuse makes signing fail with ErrDuplicateNonce for nonces with an
+	// in-flight transaction, instead of only reporting them
+	Refuse bool
+	// OnDuplicate is called with the in-flight transaction when tx reuses its nonce
+	OnDuplicate func(existing *InFlightTx, tx *Transaction)
+	// TTL is how long transactions are tracked, until pruned if zero
+	TTL time.Duration
+}
+
+// PendingTracker tracks signed transactions per account and nonce to detect
+// accidental replacements
+type PendingTracker struct {
+	config PendingTrackerConfig
+
+	mu      sync.Mutex
+	pending map[string]map[uint64]*InFlightTx
+	// reserved are the nonces of transactions being signed, so that
+	// concurrent requests for the same nonce are duplicates too
+	reserved map[string]map[uint64]*InFlightTx
+}
+
+// NewPendingTracker creates a new PendingTracker
+func NewPendingTracker(config PendingTrackerConfig) *PendingTracker {
+	return &PendingTracker{config: config, pending: map[string]map[uint64]*InFlightTx{}, reserved: map[string]map[uint64]*InFlightTx{}}
+}
+
+// Pending returns the in-flight transactions of account ordered by nonce
+func (pt *PendingTracker) Pending(account string) []*InFlightTx {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	var txs []*InFlightTx
+	for _, tx := range pt.pending[strings.ToLower(account)] {
+		if !pt.expired(tx) {
+			copied := *tx
+			txs = append(txs, &copied)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].Nonce < txs[j].Nonce })
+	return txs
+}
+
+// Forget stops tracking the transaction of account at nonce, e.g. before an
+// intentional replacement
+func (pt *PendingTracker) Forget(account string, nonce uint64) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	delete(pt.pending[strings.ToLower(account)], nonce)
+}
+
+// Prune stops tracking the transactions of account below nextNonce, the
+// account's nonce in the latest block
+func (pt *PendingTracker) Prune(account string, nextNonce uint64) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	for nonce := range pt.pending[strings.ToLower(account)] {
+		if nonce < nextNonce {
+			delete(pt.pending[strings.ToLower(account)], nonce)
+		}
+	}
+}
+
+// reserve reports a duplicate nonce of tx, returning an error if refused.
+// Otherwise the nonce is reserved until the returned function is called,
+// after tracking the transaction if it was signed.
+func (pt *PendingTracker) reserve(tx *Transaction) (func(), error) {
+	if tx.Nonce == "" {
+		return func() {}, nil
+	}
+	nonce, err := decodeQuantity(tx.Nonce)
+	if err != nil || !nonce.IsUint64() {
+		return func() {}, nil
+	}
+	account := strings.ToLower(tx.From)
+	pt.mu.Lock()
+	existing, ok := pt.pending[account][nonce.Uint64()]
+	if ok && pt.expired(existing) {
+		ok = false
+	}
+	if !ok {
+		// Being signed, without hash yet
+		existing, ok = pt.reserved[account][nonce.Uint64()]
+	}
+	var copied InFlightTx
+	if ok {
+		copied = *existing
+	}
+	release := func() {}
+	if !ok || !pt.config.Refuse {
+		release = pt.reserveLocked(account, &InFlightTx{Account: tx.From, Nonce: nonce.Uint64(), To: tx.To, Signed: time.Now()})
+	}
+	pt.mu.Unlock()
+	if !ok {
+		return release, nil
+	}
+
+	if pt.config.OnDuplicate != nil {
+		pt.config.OnDuplicate(&copied, tx)
+	}
+	if pt.config.Refuse {
+		return nil, fmt.Errorf("%w: nonce %d of %s (%s)", ErrDuplicateNonce, copied.Nonce, tx.From, copied.Hash)
+	}
+	return release, nil
+}
+
+// reserveLocked reserves the nonce of tx and returns the function releasing
+// it. pt.mu must be held.
+func (pt *PendingTracker) reserveLocked(account string, tx *InFlightTx) func() {
+	if pt.reserved[account] == nil {
+		pt.reserved[account] = map[uint64]*InFlightTx{}
+	}
+	pt.reserved[account][tx.Nonce] = tx
+	return func() {
+		pt.mu.Lock()
+		defer pt.mu.Unlock()
+		if pt.reserved[account][tx.Nonce] == tx {
+			delete(pt.reserved[account], tx.Nonce)
+		}
+	}
+}
+
+// track records a signed transaction. The nonce is taken from the response
+// since Clef fills it in when missing.
+func (pt *PendingTracker) track(tx *Transaction, result json.RawMessage) {
+	var signed SignTxResponse
+	if err := json.Unmarshal(result, &signed); err != nil {
+		return
+	}
+	nonceHex := signed.Tx.Nonce
+	if nonceHex == "" {
+		nonceHex = tx.Nonce
+	}
+	nonce, err := decodeQuantity(nonceHex)
+	if err != nil || !nonce.IsUint64() {
+		return
+	}
+	pending := &InFlightTx{Account: tx.From, Nonce: nonce.Uint64(), To: tx.To, Signed: time.Now()}
+	if raw, err := decodeHexData(signed.Raw); err == nil && len(raw) > 0 {
+		pending.Hash = encodeHexData(keccak256(raw))
+	}
+
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	account := strings.ToLower(tx.From)
+	if pt.pending[account] == nil {
+		pt.pending[account] = map[uint64]*InFlightTx{}
+	}
+	pt.pending[account][pending.Nonce] = pending
+}
+
+func (pt *PendingTracker) expired(tx *InFlightTx) bool {
+	return pt.config.TTL > 0 && time.Since(tx.Signed) > pt.config.TTL
+}
+
+// EnablePendingTracker tracks transactions signed through the client and
+// checks every new transaction against the in-flight ones. Intentional
+// replacements such as SpeedUpTx are checked too, so Forget their nonce first
+// when duplicates are refused.
+func (cc *ClefClient) EnablePendingTracker(tracker *PendingTracker) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &pendingTransport{next: cc.transport, tracker: tracker}
+}
+
+// pendingTransport is a transport decorator feeding a PendingTracker
+type pendingTransport struct {
+	next    transport
+	tracker *PendingTracker
+}
+
+func (t *pendingTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	tx, ok := params.(*Transaction)
+	if !ok {
+		return t.next.call(ctx, method, params)
+	}
+	release, err := t.tracker.reserve(tx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := t.next.call(ctx, method, params)
+	if err == nil && resp != nil && resp.Error == nil {
+		t.tracker.track(tx, resp.Result)
+	}
+	return resp, err
+}
+
+// callBatch checks the entries of the batch against each other too, and
+// rejects the whole batch if one of them is refused
+func (t *pendingTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	var releases []func()
+	defer func() {
+		for _, release := range releases {
+			release()
+		}
+	}()
+	for i, p := range params {
+		if tx, ok := p.(*Transaction); ok {
+			release, err := t.tracker.reserve(tx)
+			if err != nil {
+				return nil, fmt.Errorf("batch entry %d: %w", i+1, err)
+			}
+			releases = append(releases, release)
+		}
+	}
+	resps, err := t.next.callBatch(ctx, method, params)
+	if err != nil {
+		return resps, err
+	}
+	for i, p := range params {
+		if tx, ok := p.(*Transaction); ok && resps[i].Error == nil {
+			t.tracker.track(tx, resps[i].Result)
+		}
+	}
+	return resps, nil
+}
+
+func (t *pendingTransport) close() error {
+	return t.next.close()
+}
@@ -0,0 +1,136 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupNonceServer signs transactions, filling in nonce 0x5 when missing
+func setupNonceServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params Transaction `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var signed SignTxResponse
+		signed.Raw = "0xf86b"
+		signed.Tx.Nonce = req.Params.Nonce
+		if signed.Tx.Nonce == "" {
+			signed.Tx.Nonce = "0x5"
+		}
+		result, _ := json.Marshal(signed)
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: result})
+	}))
+}
+
+func TestPendingTrackerWarns(t *testing.T) {
+	server := setupNonceServer(t)
+	defer server.Close()
+
+	var duplicates []*InFlightTx
+	tracker := NewPendingTracker(PendingTrackerConfig{
+		OnDuplicate: func(existing *InFlightTx, tx *Transaction) { duplicates = append(duplicates, existing) },
+	})
+	client := NewHTTPClient(server.URL)
+	client.EnablePendingTracker(tracker)
+
+	_, err := client.SignTransaction(&Transaction{From: "0x00000000000000000000000000000000000000AB", To: "0x0000000000000000000000000000000000000002"})
+	assert.NoError(t, err)
+	pending := tracker.Pending("0x00000000000000000000000000000000000000ab")
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, uint64(5), pending[0].Nonce)
+		assert.Equal(t, encodeHexData(keccak256([]byte{0xf8, 0x6b})), pending[0].Hash)
+	}
+
+	_, err = client.SignTransaction(&Transaction{From: "0x00000000000000000000000000000000000000ab", To: "0x0000000000000000000000000000000000000003", Nonce: "0x5"})
+	assert.NoError(t, err)
+	assert.Len(t, duplicates, 1)
+
+	_, err = client.SignTransaction(&Transaction{From: "0x00000000000000000000000000000000000000ab", To: "0x0000000000000000000000000000000000000003", Nonce: "0x6"})
+	assert.NoError(t, err)
+	assert.Len(t, duplicates, 1)
+	assert.Len(t, tracker.Pending("0x00000000000000000000000000000000000000ab"), 2)
+
+	tracker.Prune("0x00000000000000000000000000000000000000ab", 6)
+	pending = tracker.Pending("0x00000000000000000000000000000000000000ab")
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, uint64(6), pending[0].Nonce)
+	}
+}
+
+func TestPendingTrackerRefuses(t *testing.T) {
+	server := setupNonceServer(t)
+	defer server.Close()
+
+	tracker := NewPendingTracker(PendingTrackerConfig{Refuse: true})
+	client := NewHTTPClient(server.URL)
+	client.EnablePendingTracker(tracker)
+
+	_, err := client.SignTransaction(&Transaction{From: "0x00000000000000000000000000000000000000ab", To: "0x0000000000000000000000000000000000000002", Nonce: "0x1"})
+	assert.NoError(t, err)
+	_, err = client.SignTransaction(&Transaction{From: "0x00000000000000000000000000000000000000AB", To: "0x0000000000000000000000000000000000000002", Nonce: "0x1", GasPrice: "0x2"})
+	assert.ErrorIs(t, err, ErrDuplicateNonce)
+
+	tracker.Forget("0x00000000000000000000000000000000000000ab", 1)
+	_, err = client.SignTransaction(&Transaction{From: "0x00000000000000000000000000000000000000ab", To: "0x0000000000000000000000000000000000000002", Nonce: "0x1", GasPrice: "0x2"})
+	assert.NoError(t, err)
+}
+
+// gatedTransport signs transactions once release is closed
+type gatedTransport struct {
+	approvingTransport
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *gatedTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	t.started <- struct{}{}
+	<-t.release
+	return t.approvingTransport.call(ctx, method, params)
+}
+
+func TestPendingTrackerRefusesConcurrent(t *testing.T) {
+	tx := &Transaction{From: "0x00000000000000000000000000000000000000ab", To: "0x0000000000000000000000000000000000000002", Nonce: "0x1"}
+	next := &gatedTransport{started: make(chan struct{}, 1), release: make(chan struct{})}
+	tracker := NewPendingTracker(PendingTrackerConfig{Refuse: true})
+	client := &ClefClient{transport: next}
+	client.EnablePendingTracker(tracker)
+
+	// A request for a nonce being signed is a duplicate
+	done := make(chan error)
+	go func() {
+		_, err := client.SignTransaction(tx)
+		done <- err
+	}()
+	<-next.started
+	_, err := client.SignTransaction(tx)
+	assert.ErrorIs(t, err, ErrDuplicateNonce)
+	close(next.release)
+	assert.NoError(t, <-done)
+
+	// The nonce of a failed request is released
+	next.rejected = map[string]bool{"0x1": true}
+	failing := &Transaction{From: tx.From, To: tx.To, Nonce: "0x2", Value: "0x1"}
+	_, err = client.SignTransaction(failing)
+	assert.Error(t, err)
+	<-next.started
+	_, err = client.SignTransaction(failing)
+	assert.NotErrorIs(t, err, ErrDuplicateNonce)
+	<-next.started
+
+	// Entries of a batch are checked against each other
+	calls := next.calls
+	_, err = client.SignTransactions([]*Transaction{
+		{From: tx.From, To: tx.To, Nonce: "0x3"},
+		{From: tx.From, To: tx.To, Nonce: "0x3"},
+	})
+	assert.ErrorIs(t, err, ErrDuplicateNonce)
+	assert.ErrorContains(t, err, "batch entry 2")
+	assert.Equal(t, calls, next.calls)
+	assert.Len(t, tracker.Pending(tx.From), 1)
+}
@@ -0,0 +1,96 @@
+package clefclient
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Permit describes an EIP-2612 permit allowing spender to transfer value
+// tokens on behalf of owner until deadline
+type Permit struct {
+	// TokenName is the name in the token's EIP-712 domain, usually its name()
+	TokenName string
+	// TokenVersion is the version in the token's EIP-712 domain, "1" if empty
+	TokenVersion string
+	ChainID      *big.Int
+	// Token is the token contract address, the domain's verifying contract
+	Token string
+
+	Owner    string
+	Spender  string
+	Value    *big.Int
+	Nonce    *big.Int
+	Deadline *big.Int
+}
+
+// TypedData validates the permit and builds its EIP-712 typed data
+func (p *Permit) TypedData() (*TypedData, error) {
+	if p.TokenName == "" {
+		return nil, fmt.Errorf("permit: token name is required")
+	}
+	for name, address := range map[string]string{"token": p.Token, "owner": p.Owner, "spender": p.Spender} {
+		if _, err := encodeAddressWord(address); err != nil {
+			return nil, fmt.Errorf("permit: %s: %w", name, err)
+		}
+	}
+	for name, v := range map[string]*big.Int{"chain ID": p.ChainID, "value": p.Value, "nonce": p.Nonce, "deadline": p.Deadline} {
+		if v == nil {
+			return nil, fmt.Errorf("permit: %s is required", name)
+		}
+		if _, err := encodeUintWord(v); err != nil {
+			return nil, fmt.Errorf("permit: %s: %w", name, err)
+		}
+	}
+
+	version := p.TokenVersion
+	if version == "" {
+		version = "1"
+	}
+	return &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": eip712DomainFields,
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: map[string]interface{}{
+			"name":              p.TokenName,
+			"version":           version,
+			"chainId":           p.ChainID.String(),
+			"verifyingContract": p.Token,
+		},
+		Message: map[string]interface{}{
+			"owner":    p.Owner,
+			"spender":  p.Spender,
+			"value":    p.Value.String(),
+			"nonce":    p.Nonce.String(),
+			"deadline": p.Deadline.String(),
+		},
+	}, nil
+}
+
+// Request builds the request for signing the permit with the owner account
+func (p *Permit) Request() (*TypedDataRequest, error) {
+	td, err := p.TypedData()
+	if err != nil {
+		return nil, err
+	}
+	return td.Request(p.Owner)
+}
+
+// SignPermit signs the permit with the owner account
+func (cc *ClefClient) SignPermit(p *Permit) (*SignDataResponse, error) {
+	if p == nil {
+		return nil, nilRequestError("permit")
+	}
+	req, err := p.Request()
+	if err != nil {
+		return nil, err
+	}
+	return cc.SignTypedData(req)
+}
@@ -0,0 +1,85 @@
+package clefclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPermit() *Permit {
+	return &Permit{
+		TokenName: "USD Coin",
+		ChainID:   big.NewInt(1),
+		Token:     "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+		Owner:     "0x0000000000000000000000000000000000000001",
+		Spender:   "0x0000000000000000000000000000000000000002",
+		Value:     big.NewInt(1000000),
+		Nonce:     big.NewInt(0),
+		Deadline:  big.NewInt(1700000000),
+	}
+}
+
+func TestPermitRequest(t *testing.T) {
+	req, err := testPermit().Request()
+	assert.NoError(t, err)
+	assert.Equal(t, "0x0000000000000000000000000000000000000001", req.Address)
+	assert.Equal(t, "V4", req.RawVersion)
+	assert.JSONEq(t, `{
+		"types": {
+			"EIP712Domain": [
+				{"name": "name", "type": "string"},
+				{"name": "version", "type": "string"},
+				{"name": "chainId", "type": "uint256"},
+				{"name": "verifyingContract", "type": "address"}
+			],
+			"Permit": [
+				{"name": "owner", "type": "address"},
+				{"name": "spender", "type": "address"},
+				{"name": "value", "type": "uint256"},
+				{"name": "nonce", "type": "uint256"},
+				{"name": "deadline", "type": "uint256"}
+			]
+		},
+		"primaryType": "Permit",
+		"domain": {
+			"name": "USD Coin",
+			"version": "1",
+			"chainId": "1",
+			"verifyingContract": "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+		},
+		"message": {
+			"owner": "0x0000000000000000000000000000000000000001",
+			"spender": "0x0000000000000000000000000000000000000002",
+			"value": "1000000",
+			"nonce": "0",
+			"deadline": "1700000000"
+		}
+	}`, string(req.TypedData))
+}
+
+func TestPermitValidation(t *testing.T) {
+	p := testPermit()
+	p.Spender = "0x1234"
+	_, err := p.Request()
+	assert.ErrorContains(t, err, "spender")
+
+	p = testPermit()
+	p.Deadline = nil
+	_, err = p.Request()
+	assert.ErrorContains(t, err, "deadline is required")
+
+	p = testPermit()
+	p.Value = big.NewInt(-1)
+	_, err = p.Request()
+	assert.ErrorContains(t, err, "value")
+}
+
+func TestSignPermit(t *testing.T) {
+	client, server := setupHTTPTestServer(t, "account_signTypedData", SignDataResponse{Signature: testSignature(0x01)})
+	defer server.Close()
+
+	sig, err := client.SignPermit(testPermit())
+	assert.NoError(t, err)
+	assert.Equal(t, testSignature(0x01), sig.Signature)
+}
@@ -0,0 +1,117 @@
+package clefclient
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrPinMismatch is returned when the certificate chain of the Clef
+// endpoint matches none of the pins of WithPinnedPublicKeys and
+// WithPinnedCertificates
+var ErrPinMismatch = errors.New("certificate does not match any pin")
+
+// WithPinnedPublicKeys accepts the TLS connections of an HTTP client only
+// if a certificate of the verified chain of the endpoint has one of the
+// given public keys, in addition to the usual verification, so that a
+// compromised CA cannot silently intercept signing traffic. Keys are pinned
+// by the base64 SHA-256 hash of their DER encoded SubjectPublicKeyInfo, with
+// an optional "sha256//" prefix as used by curl's --pinnedpubkey:
+//
+//	openssl x509 -in clef.pem -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64
+//
+// Pinned clients refuse plaintext HTTP like WithHTTPSOnly. Calls fail with
+// ErrPinMismatch if no pin matches. The transport of WithHTTPClient must be
+// an *http.Transport. It has no effect on IPC clients or on clients derived
+// with With.
+func WithPinnedPublicKeys(hashes ...string) Option {
+	return func(o *clientOptions) {
+		for _, hash := range hashes {
+			o.pins = append(o.pins, tlsPin{spki: true, hash: strings.TrimPrefix(hash, "sha256//")})
+		}
+	}
+}
+
+// WithPinnedCertificates is like WithPinnedPublicKeys, but pins whole
+// certificates by their hex SHA-256 fingerprint, with or without colons as
+// printed by openssl x509 -fingerprint -sha256. Certificate pins must be
+// updated whenever the certificate is renewed, even with the same key.
+func WithPinnedCertificates(fingerprints ...string) Option {
+	return func(o *clientOptions) {
+		for _, fingerprint := range fingerprints {
+			o.pins = append(o.pins, tlsPin{hash: strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))})
+		}
+	}
+}
+
+// tlsPin is a pinned public key or certificate
+type tlsPin struct {
+	// spki pins the public key by its base64 hash, the certificate by its
+	// hex fingerprint otherwise
+	spki bool
+	hash string
+}
+
+func (p tlsPin) matches(cert *x509.Certificate) bool {
+	if p.spki {
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		return base64.StdEncoding.EncodeToString(sum[:]) == p.hash
+	}
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:]) == p.hash
+}
+
+// checkPins returns ErrPinMismatch unless a certificate of a verified chain
+// matches one of the pins. Only verified chains count: certificates the
+// endpoint merely sends along could be copied from the real Clef by anyone.
+func checkPins(pins []tlsPin, chains [][]*x509.Certificate) error {
+	if len(chains) == 0 {
+		return fmt.Errorf("%w: no verified certificate chain", ErrPinMismatch)
+	}
+	for _, chain := range chains {
+		for _, cert := range chain {
+			for _, pin := range pins {
+				if pin.matches(cert) {
+					return nil
+				}
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrPinMismatch, chains[0][0].Subject.String())
+}
+
+// pin verifies the TLS connections of t against pins. The client and its
+// transport are copied, as they may be shared.
+func (t *httpTransport) pin(pins []tlsPin) {
+	t.requireHTTPS()
+	base := t.client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	rt, ok := base.(*http.Transport)
+	if !ok {
+		t.pinErr = fmt.Errorf("cannot pin certificates with a %T, use an *http.Transport", base)
+		return
+	}
+	rt = rt.Clone()
+	if rt.TLSClientConfig == nil {
+		rt.TLSClientConfig = &tls.Config{}
+	}
+	verify := rt.TLSClientConfig.VerifyConnection
+	rt.TLSClientConfig.VerifyConnection = func(cs tls.ConnectionState) error {
+		if err := checkPins(pins, cs.VerifiedChains); err != nil {
+			return err
+		}
+		if verify != nil {
+			return verify(cs)
+		}
+		return nil
+	}
+	t.client.Transport = rt
+}
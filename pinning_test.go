@@ -0,0 +1,127 @@
+package clefclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTLSPinning(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: json.RawMessage(`[]`)})
+	}))
+	defer server.Close()
+
+	spki := sha256.Sum256(server.Certificate().RawSubjectPublicKeyInfo)
+	keyPin := base64.StdEncoding.EncodeToString(spki[:])
+	cert := sha256.Sum256(server.Certificate().Raw)
+	var fingerprint []string
+	for _, b := range cert {
+		fingerprint = append(fingerprint, strings.ToUpper(hex.EncodeToString([]byte{b})))
+	}
+	otherPin := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	for _, opt := range []Option{
+		WithPinnedPublicKeys(otherPin, keyPin),
+		WithPinnedPublicKeys("sha256//" + keyPin),
+		WithPinnedCertificates(strings.Join(fingerprint, ":")),
+	} {
+		_, err := NewHTTPClient(server.URL, WithHTTPClient(server.Client()), opt).ListAccounts()
+		assert.NoError(t, err)
+	}
+
+	_, err := NewHTTPClient(server.URL, WithHTTPClient(server.Client()), WithPinnedPublicKeys(otherPin)).ListAccounts()
+	assert.ErrorIs(t, err, ErrPinMismatch)
+	assert.NotErrorIs(t, err, ErrConnection)
+
+	// The shared client is left alone
+	_, err = NewHTTPClient(server.URL, WithHTTPClient(server.Client())).ListAccounts()
+	assert.NoError(t, err)
+
+	// Pinned clients refuse plaintext
+	_, err = NewHTTPClient("http://clef.internal:8550", WithPinnedPublicKeys(keyPin)).ListAccounts()
+	assert.ErrorIs(t, err, ErrInsecureEndpoint)
+
+	custom := &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}
+	_, err = NewHTTPClient(server.URL, WithHTTPClient(custom), WithPinnedPublicKeys(keyPin)).ListAccounts()
+	assert.ErrorContains(t, err, "cannot pin certificates")
+}
+
+// testCertificate creates a certificate for 127.0.0.1, self-signed if
+// parent is nil
+func testCertificate(t *testing.T, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return cert, key
+}
+
+func TestTLSPinningVerifiedChain(t *testing.T) {
+	// The real Clef certificate, pinned, and a leaf from another trusted CA
+	// presenting it as an extra chain entry
+	clef, _ := testCertificate(t, "clef", true, nil, nil)
+	otherCA, otherKey := testCertificate(t, "other CA", true, nil, nil)
+	leaf, leafKey := testCertificate(t, "attacker", false, otherCA, otherKey)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: json.RawMessage(`[]`)})
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{leaf.Raw, clef.Raw}, PrivateKey: leafKey}}}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(clef)
+	roots.AddCert(otherCA)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	pin := func(cert *x509.Certificate) string {
+		sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		return base64.StdEncoding.EncodeToString(sum[:])
+	}
+
+	_, err := NewHTTPClient(server.URL, WithHTTPClient(client), WithPinnedPublicKeys(pin(clef))).ListAccounts()
+	assert.ErrorIs(t, err, ErrPinMismatch)
+
+	// The CA of the verified chain can be pinned
+	_, err = NewHTTPClient(server.URL, WithHTTPClient(client), WithPinnedPublicKeys(pin(otherCA))).ListAccounts()
+	assert.NoError(t, err)
+
+	// Without verification there is no chain to match
+	insecure := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	_, err = NewHTTPClient(server.URL, WithHTTPClient(insecure), WithPinnedPublicKeys(pin(leaf))).ListAccounts()
+	assert.ErrorIs(t, err, ErrPinMismatch)
+	assert.ErrorContains(t, err, "no verified certificate chain")
+}
@@ -0,0 +1,219 @@
+package clefclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// ErrPolicyViolation is returned when a client-side policy rejects a request
+// before it reaches Clef
+var ErrPolicyViolation = errors.New("rejected by client policy")
+
+// EnablePolicies applies policies to every subsequent signing request made
+// by the client, as defense in depth alongside Clef's own rules. Rejected
+// requests fail with ErrPolicyViolation and are not sent.
+func (cc *ClefClient) EnablePolicies(policies ...DryRunPolicy) {
+	if !cc.ready() {
+		return
+	}
+	t := &policyTransport{next: cc.transport, policies: policies}
+	cc.transport = t
+	cc.checks = append(cc.checks, t.check)
+}
+
+// policyTransport is a transport decorator applying policies to signing
+// requests
+type policyTransport struct {
+	next     transport
+	policies []DryRunPolicy
+}
+
+func (t *policyTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if err := t.check(method, params); err != nil {
+		return nil, err
+	}
+	return t.next.call(ctx, method, params)
+}
+
+// callBatch rejects the whole batch if one of its requests is rejected
+func (t *policyTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	for i, p := range params {
+		if err := t.check(method, p); err != nil {
+			return nil, fmt.Errorf("batch entry %d: %w", i+1, err)
+		}
+	}
+	return t.next.callBatch(ctx, method, params)
+}
+
+func (t *policyTransport) close() error {
+	return t.next.close()
+}
+
+func (t *policyTransport) check(method string, params interface{}) error {
+	if !signingMethods[method] {
+		return nil
+	}
+	for _, policy := range t.policies {
+		if err := policy(method, params); err != nil {
+			return fmt.Errorf("%w: %w", ErrPolicyViolation, err)
+		}
+	}
+	return nil
+}
+
+// RequireChainID is a DryRunPolicy requiring transactions to carry the
+// given chain ID, and typed data domains that declare a chain ID to match it
+func RequireChainID(chainID *big.Int) DryRunPolicy {
+	return func(method string, params interface{}) error {
+		switch req := params.(type) {
+		case *Transaction:
+			if req.ChainID == "" {
+				return fmt.Errorf("transaction: chainId is required")
+			}
+			if err := checkChainID(req.ChainID, chainID); err != nil {
+				return fmt.Errorf("transaction: %w", err)
+			}
+		case *TypedDataRequest:
+			var td struct {
+				Domain map[string]interface{} `json:"domain"`
+			}
+			dec := json.NewDecoder(bytes.NewReader(req.TypedData))
+			dec.UseNumber()
+			if err := dec.Decode(&td); err != nil {
+				return fmt.Errorf("typed data: %w", err)
+			}
+			if id, ok := td.Domain["chainId"]; ok {
+				if err := checkChainID(fmt.Sprint(id), chainID); err != nil {
+					return fmt.Errorf("typed data: %w", err)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Destination is a transaction destination allowed by AllowDestinations
+type Destination struct {
+	Address string
+	// Selectors are the 0x-prefixed 4 byte function selectors that may be
+	// called, any if empty. Transactions without call data are always
+	// allowed.
+	Selectors []string
+}
+
+// AllowDestinations is a DryRunPolicy allowing transactions, and Gnosis
+// Safe transactions, only to the given destinations. Contract creations are
+// rejected.
+func AllowDestinations(destinations ...Destination) DryRunPolicy {
+	allowed := make(map[string][]string, len(destinations))
+	for _, d := range destinations {
+		selectors := make([]string, len(d.Selectors))
+		for i, s := range d.Selectors {
+			selectors[i] = normalizeSelector(s)
+		}
+		allowed[strings.ToLower(d.Address)] = selectors
+	}
+	return func(method string, params interface{}) error {
+		c, ok := callOf(params)
+		if !ok {
+			return nil
+		}
+		if c.to == "" {
+			return fmt.Errorf("%s: contract creation is not allowed", c.kind)
+		}
+		selectors, ok := allowed[strings.ToLower(c.to)]
+		if !ok {
+			return fmt.Errorf("%s: destination %s is not allowed", c.kind, c.to)
+		}
+		selector := dataSelector(c.data)
+		if selector == "" || len(selectors) == 0 || slices.Contains(selectors, selector) {
+			return nil
+		}
+		return fmt.Errorf("%s: call of %s on %s is not allowed", c.kind, selector, c.to)
+	}
+}
+
+// DenyDestinations is a DryRunPolicy rejecting transactions and Gnosis Safe
+// transactions to the given addresses
+func DenyDestinations(addresses ...string) DryRunPolicy {
+	denied := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		denied[strings.ToLower(address)] = true
+	}
+	return func(method string, params interface{}) error {
+		if c, ok := callOf(params); ok && c.to != "" && denied[strings.ToLower(c.to)] {
+			return fmt.Errorf("%s: destination %s is denied", c.kind, c.to)
+		}
+		return nil
+	}
+}
+
+// DenySelectors is a DryRunPolicy rejecting transactions, and Gnosis Safe
+// transactions, calling the given 0x-prefixed 4 byte function selectors on
+// any contract, e.g. 0x095ea7b3 for ERC-20 approvals
+func DenySelectors(selectors ...string) DryRunPolicy {
+	denied := make(map[string]bool, len(selectors))
+	for _, s := range selectors {
+		denied[normalizeSelector(s)] = true
+	}
+	return func(method string, params interface{}) error {
+		if c, ok := callOf(params); ok {
+			if selector := dataSelector(c.data); denied[selector] {
+				return fmt.Errorf("%s: call of %s is denied", c.kind, selector)
+			}
+		}
+		return nil
+	}
+}
+
+// contractCall is the destination and call data of a transaction or of a
+// Gnosis Safe transaction, which the Safe executes on its own behalf
+type contractCall struct {
+	kind string
+	to   string
+	data string
+}
+
+// callOf returns the call made by the params of a signing request, false if
+// they make none
+func callOf(params interface{}) (contractCall, bool) {
+	switch p := params.(type) {
+	case *Transaction:
+		return contractCall{kind: "transaction", to: p.To, data: p.Data}, true
+	case []interface{}:
+		if safeTx := gnosisSafeTx(p); safeTx != nil {
+			return contractCall{kind: "safe transaction", to: safeTx.To, data: safeTx.Data}, true
+		}
+	}
+	return contractCall{}, false
+}
+
+// gnosisSafeTx returns the Safe transaction of account_signGnosisSafeTx
+// params, nil for other params
+func gnosisSafeTx(params []interface{}) *GnosisSafeTx {
+	if len(params) < 2 {
+		return nil
+	}
+	safeTx, _ := params[1].(*GnosisSafeTx)
+	return safeTx
+}
+
+// dataSelector returns the function selector of call data, empty if it is
+// shorter than a selector
+func dataSelector(data string) string {
+	b, err := decodeHexData(data)
+	if err != nil || len(b) < 4 {
+		return ""
+	}
+	return encodeHexData(b[:4])
+}
+
+func normalizeSelector(selector string) string {
+	return "0x" + strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(selector, "0x"), "0X"))
+}
@@ -0,0 +1,95 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnablePolicies(t *testing.T) {
+	next := &countingTransport{}
+	cc := &ClefClient{transport: next}
+	cc.EnablePolicies(func(method string, params interface{}) error {
+		if tx, ok := params.(*Transaction); ok && tx.Value != "" {
+			return errors.New("value transfers are not allowed")
+		}
+		return nil
+	})
+
+	_, err := cc.SignTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001", Value: "0x1"})
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.EqualError(t, err, "account_signTransaction (from=0x0000000000000000000000000000000000000001, value=0x1): rejected by client policy: value transfers are not allowed")
+
+	_, err = cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001"}, {From: "0x0000000000000000000000000000000000000001", Value: "0x1"}})
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.Equal(t, 0, next.calls)
+
+	// Other methods are not subject to policies
+	_, err = cc.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, next.calls)
+}
+
+func TestRequireChainID(t *testing.T) {
+	policy := RequireChainID(big.NewInt(11155111))
+
+	assert.NoError(t, policy("account_signTransaction", &Transaction{ChainID: "0xaa36a7"}))
+	assert.EqualError(t, policy("account_signTransaction", &Transaction{}), "transaction: chainId is required")
+	assert.EqualError(t, policy("account_signTransaction", &Transaction{ChainID: "0x1"}), "transaction: chainId 1 does not match expected 11155111")
+
+	assert.NoError(t, policy("account_signTypedData", &TypedDataRequest{TypedData: json.RawMessage(`{"domain":{"chainId":11155111}}`)}))
+	assert.NoError(t, policy("account_signTypedData", &TypedDataRequest{TypedData: json.RawMessage(`{"domain":{"name":"app"}}`)}))
+	assert.EqualError(t, policy("account_signTypedData", &TypedDataRequest{TypedData: json.RawMessage(`{"domain":{"chainId":"0x1"}}`)}), "typed data: chainId 1 does not match expected 11155111")
+}
+
+func TestDestinationPolicies(t *testing.T) {
+	token := "0x1111111111111111111111111111111111111111"
+	vault := "0x2222222222222222222222222222222222222222"
+	transfer := "0xa9059cbb" + strings.Repeat("00", 64)
+	approve := "0x095ea7b3" + strings.Repeat("00", 64)
+
+	allow := AllowDestinations(
+		Destination{Address: "0x1111111111111111111111111111111111111111", Selectors: []string{"0xA9059CBB"}},
+		Destination{Address: vault},
+	)
+	assert.NoError(t, allow("account_signTransaction", &Transaction{To: token, Data: transfer}))
+	assert.NoError(t, allow("account_signTransaction", &Transaction{To: token, Value: "0x1"}))
+	assert.NoError(t, allow("account_signTransaction", &Transaction{To: strings.ToUpper(vault[:2]) + vault[2:], Data: approve}))
+	assert.EqualError(t, allow("account_signTransaction", &Transaction{To: token, Data: approve}), "transaction: call of 0x095ea7b3 on "+token+" is not allowed")
+	assert.EqualError(t, allow("account_signTransaction", &Transaction{To: "0x3333333333333333333333333333333333333333"}), "transaction: destination 0x3333333333333333333333333333333333333333 is not allowed")
+	assert.EqualError(t, allow("account_signTransaction", &Transaction{Data: "0x6080"}), "transaction: contract creation is not allowed")
+	assert.NoError(t, allow("account_signData", &SignDataRequest{Data: "0x00"}))
+
+	deny := DenyDestinations(vault)
+	assert.NoError(t, deny("account_signTransaction", &Transaction{To: token}))
+	assert.NoError(t, deny("account_signTransaction", &Transaction{Data: "0x6080"}))
+	assert.EqualError(t, deny("account_signTransaction", &Transaction{To: vault}), "transaction: destination "+vault+" is denied")
+
+	denySelectors := DenySelectors("095ea7b3")
+	assert.NoError(t, denySelectors("account_signTransaction", &Transaction{To: token, Data: transfer}))
+	assert.NoError(t, denySelectors("account_signTransaction", &Transaction{To: token, Data: "0x09"}))
+	assert.EqualError(t, denySelectors("account_signTransaction", &Transaction{To: token, Data: approve}), "transaction: call of 0x095ea7b3 is denied")
+
+	next := &countingTransport{}
+	cc := &ClefClient{transport: next}
+	cc.EnablePolicies(allow, denySelectors)
+	_, err := cc.SignTransaction(&Transaction{From: vault, To: token, Data: approve})
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.Equal(t, 0, next.calls)
+
+	// Safe transactions are checked like transactions
+	safe := func(to, data string) []interface{} {
+		return []interface{}{vault, &GnosisSafeTx{Safe: vault, To: to, Data: data}}
+	}
+	assert.NoError(t, allow("account_signGnosisSafeTx", safe(token, transfer)))
+	assert.EqualError(t, allow("account_signGnosisSafeTx", safe("0x3333333333333333333333333333333333333333", "")), "safe transaction: destination 0x3333333333333333333333333333333333333333 is not allowed")
+	assert.EqualError(t, deny("account_signGnosisSafeTx", safe(vault, "")), "safe transaction: destination "+vault+" is denied")
+	assert.EqualError(t, denySelectors("account_signGnosisSafeTx", safe(token, approve)), "safe transaction: call of 0x095ea7b3 is denied")
+	_, err = cc.SignGnosisSafeTx(vault, &GnosisSafeTx{Safe: vault, To: token, Data: approve}, "")
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.Equal(t, 0, next.calls)
+}
@@ -0,0 +1,156 @@
+package process
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ErrNoFourByteDBPath is returned when a 4byte database is installed without Config.FourByteDBPath
+var ErrNoFourByteDBPath = errors.New("no 4byte database path configured")
+
+// signaturePattern matches canonical method signatures such as transfer(address,uint256)
+var signaturePattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*\(.*\)$`)
+
+// FourByteDB maps hex encoded 4 byte method selectors (without 0x) to
+// canonical method signatures, in the format of Clef's 4byte.json
+type FourByteDB map[string]string
+
+// ParseFourByte decodes and validates a 4byte database
+func ParseFourByte(data []byte) (FourByteDB, error) {
+	var db FourByteDB
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("failed to decode 4byte database: %w", err)
+	}
+	if err := db.Validate(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// FetchFourByte downloads and validates a 4byte database
+func FetchFourByte(ctx context.Context, url string) (FourByteDB, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch 4byte database: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch 4byte database: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch 4byte database: %w", err)
+	}
+	return ParseFourByte(data)
+}
+
+// Validate checks that every entry is a well-formed signature whose
+// selector matches its key
+func (db FourByteDB) Validate() error {
+	for selector, signature := range db {
+		if !signaturePattern.MatchString(signature) {
+			return fmt.Errorf("invalid signature %q for selector %s", signature, selector)
+		}
+		if want := Selector(signature); selector != want {
+			return fmt.Errorf("selector %s does not match signature %q, expected %s", selector, signature, want)
+		}
+	}
+	return nil
+}
+
+// Merge adds all entries of other to db
+func (db FourByteDB) Merge(other FourByteDB) {
+	for selector, signature := range other {
+		db[selector] = signature
+	}
+}
+
+// abiArgument is a function input of a contract ABI
+type abiArgument struct {
+	Type       string        `json:"type"`
+	Components []abiArgument `json:"components"`
+}
+
+// abiEntry is an entry of a contract ABI
+type abiEntry struct {
+	Type   string        `json:"type"`
+	Name   string        `json:"name"`
+	Inputs []abiArgument `json:"inputs"`
+}
+
+// AddABI adds the signatures of all functions of a contract ABI
+func (db FourByteDB) AddABI(abiJSON []byte) error {
+	var entries []abiEntry
+	if err := json.Unmarshal(abiJSON, &entries); err != nil {
+		return fmt.Errorf("failed to decode ABI: %w", err)
+	}
+	for _, entry := range entries {
+		// Entries without a type are functions, as in solc output
+		if entry.Type != "function" && entry.Type != "" {
+			continue
+		}
+		signature := entry.Name + "(" + canonicalTypes(entry.Inputs) + ")"
+		db[Selector(signature)] = signature
+	}
+	return nil
+}
+
+// canonicalTypes joins argument types as used in method signatures,
+// expanding tuples into their component types
+func canonicalTypes(args []abiArgument) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		if strings.HasPrefix(arg.Type, "tuple") {
+			types[i] = "(" + canonicalTypes(arg.Components) + ")" + strings.TrimPrefix(arg.Type, "tuple")
+		} else {
+			types[i] = arg.Type
+		}
+	}
+	return strings.Join(types, ",")
+}
+
+// Selector returns the hex encoded 4 byte selector of a method signature
+func Selector(signature string) string {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(signature))
+	return hex.EncodeToString(h.Sum(nil)[:4])
+}
+
+// InstallFourByte validates the database and writes it to Config.FourByteDBPath
+func (c *Config) InstallFourByte(db FourByteDB) error {
+	if c.FourByteDBPath == "" {
+		return ErrNoFourByteDBPath
+	}
+	if err := db.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.FourByteDBPath), 0o700); err != nil {
+		return fmt.Errorf("failed to create 4byte database directory: %w", err)
+	}
+	tmp := c.FourByteDBPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write 4byte database: %w", err)
+	}
+	return os.Rename(tmp, c.FourByteDBPath)
+}
@@ -0,0 +1,65 @@
+package process
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelector(t *testing.T) {
+	assert.Equal(t, "a9059cbb", Selector("transfer(address,uint256)"))
+	assert.Equal(t, "095ea7b3", Selector("approve(address,uint256)"))
+}
+
+func TestParseFourByte(t *testing.T) {
+	db, err := ParseFourByte([]byte(`{"a9059cbb":"transfer(address,uint256)"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "transfer(address,uint256)", db["a9059cbb"])
+
+	_, err = ParseFourByte([]byte(`{"deadbeef":"transfer(address,uint256)"}`))
+	assert.ErrorContains(t, err, "does not match")
+
+	_, err = ParseFourByte([]byte(`{"a9059cbb":"not a signature"}`))
+	assert.ErrorContains(t, err, "invalid signature")
+}
+
+func TestFetchFourByte(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"095ea7b3":"approve(address,uint256)"}`))
+	}))
+	defer server.Close()
+
+	db, err := FetchFourByte(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Len(t, db, 1)
+}
+
+func TestAddABIAndInstall(t *testing.T) {
+	db := FourByteDB{}
+	err := db.AddABI([]byte(`[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}]},
+		{"type":"function","name":"submit","inputs":[{"name":"orders","type":"tuple[]","components":[{"type":"address"},{"type":"uint256"}]}]},
+		{"type":"event","name":"Transfer","inputs":[{"type":"address"}]}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, FourByteDB{
+		"a9059cbb":                              "transfer(address,uint256)",
+		Selector("submit((address,uint256)[])"): "submit((address,uint256)[])",
+	}, db)
+
+	config := &Config{FourByteDBPath: filepath.Join(t.TempDir(), "4byte-custom.json")}
+	assert.NoError(t, config.InstallFourByte(db))
+
+	data, err := os.ReadFile(config.FourByteDBPath)
+	assert.NoError(t, err)
+	installed, err := ParseFourByte(data)
+	assert.NoError(t, err)
+	assert.Equal(t, db, installed)
+
+	assert.ErrorIs(t, (&Config{}).InstallFourByte(db), ErrNoFourByteDBPath)
+}
@@ -0,0 +1,70 @@
+// Package process runs the Clef binary for administrative tasks that are not
+// exposed over its JSON-RPC interface, such as ruleset attestation.
+package process
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// DefaultBinary is the Clef executable used when Config.Binary is empty
+const DefaultBinary = "clef"
+
+// Config describes a Clef installation
+type Config struct {
+	// Binary is the path to the clef executable
+	Binary string
+	// ConfigDir is Clef's --configdir, holding the master seed and attestations
+	ConfigDir string
+	// Keystore is Clef's --keystore directory
+	Keystore string
+	// ChainID is Clef's --chainid, 0 leaves Clef's default
+	ChainID uint64
+	// RulesPath is where the active rules.js is installed, passed as --rules
+	RulesPath string
+	// FourByteDBPath is Clef's --4bytedb-custom file of additional method signatures
+	FourByteDBPath string
+}
+
+// binary returns the configured executable or DefaultBinary
+func (c *Config) binary() string {
+	if c.Binary == "" {
+		return DefaultBinary
+	}
+	return c.Binary
+}
+
+// globalArgs returns the command line flags shared by all Clef invocations
+func (c *Config) globalArgs() []string {
+	args := []string{"--suppress-bootwarn"}
+	if c.ConfigDir != "" {
+		args = append(args, "--configdir", c.ConfigDir)
+	}
+	if c.Keystore != "" {
+		args = append(args, "--keystore", c.Keystore)
+	}
+	if c.ChainID != 0 {
+		args = append(args, "--chainid", fmt.Sprint(c.ChainID))
+	}
+	if c.FourByteDBPath != "" {
+		args = append(args, "--4bytedb-custom", c.FourByteDBPath)
+	}
+	return args
+}
+
+// run executes a Clef subcommand, feeding stdin, and returns its combined output
+func (c *Config) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, c.binary(), append(c.globalArgs(), args...)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return out.Bytes(), fmt.Errorf("clef %s failed: %w: %s", args[0], err, strings.TrimSpace(out.String()))
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,278 @@
+package process
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// DefaultReleaseURL is where Geth publishes its release archives, which
+// include Clef
+const DefaultReleaseURL = "https://gethstore.blob.core.windows.net/builds"
+
+// DefaultMaxReleaseSize bounds the size of release downloads and of the
+// extracted binary unless Release.MaxSize is set
+const DefaultMaxReleaseSize = 512 << 20
+
+// ErrUnverifiedRelease is returned when a release is installed with neither
+// a checksum nor signing keys to verify it with
+var ErrUnverifiedRelease = errors.New("release has no checksum or signing keys")
+
+// Release identifies a Geth "alltools" release archive and how to verify it
+type Release struct {
+	// Version is the release version, e.g. 1.14.11
+	Version string
+	// Commit is the short commit hash that is part of the archive names,
+	// e.g. f3c696fa
+	Commit string
+	// OS and Arch select the platform, runtime.GOOS and runtime.GOARCH if
+	// empty. Geth names 32 bit ARM builds arm5, arm6 and arm7.
+	OS   string
+	Arch string
+	// BaseURL is where archives are downloaded from, DefaultReleaseURL if
+	// empty
+	BaseURL string
+	// SHA256 is the expected hex encoded checksum of the archive
+	SHA256 string
+	// SigningKeys is an armored OpenPGP keyring. If set, the archive must
+	// carry a detached signature, at its URL with .asc appended, by one of
+	// its keys.
+	SigningKeys []byte
+	// HTTPClient downloads the archive, http.DefaultClient if nil
+	HTTPClient *http.Client
+	// MaxSize is the largest archive, signature and extracted binary
+	// accepted, in bytes, DefaultMaxReleaseSize if zero
+	MaxSize int64
+}
+
+// Archive returns the file name of the release archive
+func (r *Release) Archive() string {
+	ext := ".tar.gz"
+	if r.os() == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("geth-alltools-%s-%s-%s-%s%s", r.os(), r.arch(), r.Version, r.Commit, ext)
+}
+
+// URL returns the download URL of the release archive
+func (r *Release) URL() string {
+	base := r.BaseURL
+	if base == "" {
+		base = DefaultReleaseURL
+	}
+	return strings.TrimSuffix(base, "/") + "/" + r.Archive()
+}
+
+func (r *Release) os() string {
+	if r.OS == "" {
+		return runtime.GOOS
+	}
+	return r.OS
+}
+
+func (r *Release) arch() string {
+	if r.Arch == "" {
+		return runtime.GOARCH
+	}
+	return r.Arch
+}
+
+func (r *Release) maxSize() int64 {
+	if r.MaxSize <= 0 {
+		return DefaultMaxReleaseSize
+	}
+	return r.MaxSize
+}
+
+// readLimited reads r, failing if it is larger than limit
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("larger than %d bytes", limit)
+	}
+	return data, nil
+}
+
+func (r *Release) binaryName() string {
+	if r.os() == "windows" {
+		return DefaultBinary + ".exe"
+	}
+	return DefaultBinary
+}
+
+// Install downloads and verifies the release archive and extracts the Clef
+// binary into dir, returning its path. Binaries of different releases are
+// installed side by side.
+func (r *Release) Install(ctx context.Context, dir string) (string, error) {
+	if r.Version == "" || r.Commit == "" {
+		return "", fmt.Errorf("release version and commit are required")
+	}
+	if r.SHA256 == "" && len(r.SigningKeys) == 0 {
+		return "", ErrUnverifiedRelease
+	}
+
+	archive, err := r.fetch(ctx, r.URL())
+	if err != nil {
+		return "", err
+	}
+	if err := r.verify(ctx, archive); err != nil {
+		return "", err
+	}
+
+	binary, err := r.extract(archive)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create install directory: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s-%s", DefaultBinary, r.Version, r.Commit)
+	if r.os() == "windows" {
+		name += ".exe"
+	}
+	dest := filepath.Join(dir, name)
+	tmp := dest + ".tmp"
+	if err := os.WriteFile(tmp, binary, 0o755); err != nil {
+		return "", fmt.Errorf("failed to install clef: %w", err)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to install clef: %w", err)
+	}
+	return dest, nil
+}
+
+// InstallRelease installs the release into dir and makes Config.Binary use
+// it
+func (c *Config) InstallRelease(ctx context.Context, r *Release, dir string) error {
+	binary, err := r.Install(ctx, dir)
+	if err != nil {
+		return err
+	}
+	c.Binary = binary
+	return nil
+}
+
+func (r *Release) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := r.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", path.Base(url), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", path.Base(url), resp.Status)
+	}
+	data, err := readLimited(resp.Body, r.maxSize())
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", path.Base(url), err)
+	}
+	return data, nil
+}
+
+// verify checks the archive against the checksum and signing keys
+func (r *Release) verify(ctx context.Context, archive []byte) error {
+	if r.SHA256 != "" {
+		sum := sha256.Sum256(archive)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, r.SHA256) {
+			return fmt.Errorf("checksum mismatch for %s: got %s, expected %s", r.Archive(), got, r.SHA256)
+		}
+	}
+	if len(r.SigningKeys) == 0 {
+		return nil
+	}
+
+	keyring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(r.SigningKeys))
+	if err != nil {
+		return fmt.Errorf("failed to read signing keys: %w", err)
+	}
+	signature, err := r.fetch(ctx, r.URL()+".asc")
+	if err != nil {
+		return err
+	}
+	if _, err := openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(archive), bytes.NewReader(signature)); err != nil {
+		return fmt.Errorf("invalid signature for %s: %w", r.Archive(), err)
+	}
+	return nil
+}
+
+// extract returns the Clef binary of the archive
+func (r *Release) extract(archive []byte) ([]byte, error) {
+	if r.os() == "windows" {
+		return extractZip(archive, r.binaryName(), r.maxSize())
+	}
+	return extractTarGz(archive, r.binaryName(), r.maxSize())
+}
+
+func extractTarGz(archive []byte, name string, limit int64) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("archive has no %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Base(header.Name) == name {
+			return extracted(readLimited(tr, limit))
+		}
+	}
+}
+
+func extractZip(archive []byte, name string, limit int64) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || path.Base(f.Name) != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		defer rc.Close()
+		return extracted(readLimited(rc, limit))
+	}
+	return nil, fmt.Errorf("archive has no %s", name)
+}
+
+// extracted wraps the error of reading the binary from an archive
+func extracted(binary []byte, err error) ([]byte, error) {
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	return binary, nil
+}
@@ -0,0 +1,109 @@
+package process
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+func testArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		tw.Write([]byte(content))
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestReleaseInstall(t *testing.T) {
+	release := &Release{Version: "1.14.11", Commit: "f3c696fa", OS: "linux", Arch: "amd64"}
+	name := "geth-alltools-linux-amd64-1.14.11-f3c696fa"
+	archive := testArchive(t, map[string]string{name + "/geth": "geth", name + "/clef": "clef binary"})
+
+	entity, err := openpgp.NewEntity("Geth Builder", "", "builder@example.com", nil)
+	assert.NoError(t, err)
+	var keys, signature bytes.Buffer
+	w, err := armor.Encode(&keys, openpgp.PublicKeyType, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, entity.Serialize(w))
+	w.Close()
+	assert.NoError(t, openpgp.ArmoredDetachSign(&signature, entity, bytes.NewReader(archive), nil))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/" + name + ".tar.gz":
+			w.Write(archive)
+		case "/" + name + ".tar.gz.asc":
+			w.Write(signature.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	release.BaseURL = server.URL
+	assert.Equal(t, server.URL+"/"+name+".tar.gz", release.URL())
+
+	sum := sha256.Sum256(archive)
+	release.SHA256 = hex.EncodeToString(sum[:])
+	release.SigningKeys = keys.Bytes()
+	config := &Config{}
+	dir := t.TempDir()
+	assert.NoError(t, config.InstallRelease(context.Background(), release, dir))
+	assert.Equal(t, filepath.Join(dir, "clef-1.14.11-f3c696fa"), config.Binary)
+	installed, err := os.ReadFile(config.Binary)
+	assert.NoError(t, err)
+	assert.Equal(t, "clef binary", string(installed))
+
+	bad := *release
+	bad.SHA256 = hex.EncodeToString(make([]byte, 32))
+	_, err = bad.Install(context.Background(), dir)
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	other, err := openpgp.NewEntity("Someone Else", "", "else@example.com", nil)
+	assert.NoError(t, err)
+	keys.Reset()
+	w, _ = armor.Encode(&keys, openpgp.PublicKeyType, nil)
+	assert.NoError(t, other.Serialize(w))
+	w.Close()
+	bad = *release
+	bad.SigningKeys = keys.Bytes()
+	_, err = bad.Install(context.Background(), dir)
+	assert.ErrorContains(t, err, "invalid signature")
+
+	bad = *release
+	bad.SHA256, bad.SigningKeys = "", nil
+	_, err = bad.Install(context.Background(), dir)
+	assert.ErrorIs(t, err, ErrUnverifiedRelease)
+
+	bad = *release
+	bad.MaxSize = int64(len(archive)) - 1
+	_, err = bad.Install(context.Background(), dir)
+	assert.ErrorContains(t, err, "larger than")
+
+	bad = *release
+	bad.Version = "1.14.12"
+	_, err = bad.Install(context.Background(), dir)
+	assert.ErrorContains(t, err, "404")
+}
+
+func TestReleaseArchive(t *testing.T) {
+	release := &Release{Version: "1.14.11", Commit: "f3c696fa", OS: "windows", Arch: "amd64"}
+	assert.Equal(t, "geth-alltools-windows-amd64-1.14.11-f3c696fa.zip", release.Archive())
+	assert.Equal(t, DefaultReleaseURL+"/geth-alltools-windows-amd64-1.14.11-f3c696fa.zip", release.URL())
+}
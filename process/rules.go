@@ -0,0 +1,78 @@
+package process
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/AxLabs/clef-client/internal/secret"
+)
+
+// ErrNoRulesPath is returned when rules are deployed without Config.RulesPath
+var ErrNoRulesPath = errors.New("no rules path configured")
+
+// Ruleset is a Clef rules file together with its attestation hash
+type Ruleset struct {
+	Source []byte
+	// Hash is the hex encoded sha256 of Source, as expected by clef attest
+	Hash string
+}
+
+// NewRuleset computes the attestation hash of the rules source
+func NewRuleset(source []byte) *Ruleset {
+	sum := sha256.Sum256(source)
+	return &Ruleset{Source: source, Hash: hex.EncodeToString(sum[:])}
+}
+
+// LoadRules reads a rules.js file
+func LoadRules(path string) (*Ruleset, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+	return NewRuleset(source), nil
+}
+
+// Attest records the ruleset hash as trusted in Clef's config directory.
+// The password unlocks Clef's master seed. It is passed to Clef from locked
+// memory, and both that memory and password are wiped afterwards.
+func (c *Config) Attest(ctx context.Context, hash string, password []byte) error {
+	defer secret.Wipe(password)
+	stdin := secret.New(len(password) + 1)
+	defer stdin.Destroy()
+	copy(stdin.Bytes(), password)
+	stdin.Bytes()[len(password)] = '\n'
+	_, err := c.run(ctx, stdin.Bytes(), "attest", hash)
+	return err
+}
+
+// DeployRules installs the ruleset at Config.RulesPath and attests it, so
+// that Clef accepts it on its next start. The password is wiped afterwards,
+// also on failure.
+func (c *Config) DeployRules(ctx context.Context, rules *Ruleset, password []byte) error {
+	defer secret.Wipe(password)
+	if c.RulesPath == "" {
+		return ErrNoRulesPath
+	}
+
+	// Write to a temporary file first so a running Clef never sees a partial ruleset
+	tmp := c.RulesPath + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(c.RulesPath), 0o700); err != nil {
+		return fmt.Errorf("failed to create rules directory: %w", err)
+	}
+	if err := os.WriteFile(tmp, rules.Source, 0o600); err != nil {
+		return fmt.Errorf("failed to write rules: %w", err)
+	}
+	if err := c.Attest(ctx, rules.Hash, password); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, c.RulesPath); err != nil {
+		return fmt.Errorf("failed to install rules: %w", err)
+	}
+	return nil
+}
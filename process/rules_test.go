@@ -0,0 +1,68 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClef writes a shell script that records its arguments and stdin
+func fakeClef(t *testing.T, dir string, exitCode int) string {
+	script := filepath.Join(dir, "clef")
+	content := "#!/bin/sh\n" +
+		"echo \"$@\" > " + filepath.Join(dir, "args") + "\n" +
+		"cat > " + filepath.Join(dir, "stdin") + "\n" +
+		"echo 'fake clef output'\n" +
+		fmt.Sprintf("exit %d\n", exitCode)
+	assert.NoError(t, os.WriteFile(script, []byte(content), 0o755))
+	return script
+}
+
+func TestNewRuleset(t *testing.T) {
+	rules := NewRuleset([]byte("function ApproveListing(){ return 'Approve' }"))
+	assert.Len(t, rules.Hash, 64)
+	assert.Equal(t, NewRuleset(rules.Source).Hash, rules.Hash)
+}
+
+func TestDeployRules(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{
+		Binary:    fakeClef(t, dir, 0),
+		ConfigDir: filepath.Join(dir, "config"),
+		RulesPath: filepath.Join(dir, "rules", "rules.js"),
+	}
+
+	rules := NewRuleset([]byte("function OnSignerStartup(info){}"))
+	password := []byte("seed-password")
+	assert.NoError(t, config.DeployRules(context.Background(), rules, password))
+	assert.Equal(t, make([]byte, len(password)), password)
+
+	installed, err := os.ReadFile(config.RulesPath)
+	assert.NoError(t, err)
+	assert.Equal(t, rules.Source, installed)
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	assert.Equal(t, "--suppress-bootwarn --configdir "+config.ConfigDir+" attest "+rules.Hash+"\n", string(args))
+	stdin, _ := os.ReadFile(filepath.Join(dir, "stdin"))
+	assert.Equal(t, "seed-password\n", string(stdin))
+}
+
+func TestDeployRulesAttestFailure(t *testing.T) {
+	dir := t.TempDir()
+	config := &Config{Binary: fakeClef(t, dir, 1), RulesPath: filepath.Join(dir, "rules.js")}
+
+	password := []byte("pw")
+	err := config.DeployRules(context.Background(), NewRuleset([]byte("x")), password)
+	assert.ErrorContains(t, err, "fake clef output")
+	assert.NoFileExists(t, config.RulesPath)
+	assert.NoFileExists(t, config.RulesPath+".tmp")
+	assert.Equal(t, []byte{0, 0}, password)
+
+	password = []byte("pw")
+	assert.ErrorIs(t, (&Config{}).DeployRules(context.Background(), NewRuleset(nil), password), ErrNoRulesPath)
+	assert.Equal(t, []byte{0, 0}, password)
+}
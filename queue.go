@@ -0,0 +1,205 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned when a signing request is submitted to a full queue
+var ErrQueueFull = errors.New("signing queue is full")
+
+// ErrQueueClosed is returned when a signing request is submitted after Close
+var ErrQueueClosed = errors.New("signing queue is closed")
+
+// SigningQueueConfig configures a SigningQueue
+type SigningQueueConfig struct {
+	// Workers is the number of requests signed in parallel. Requests for the
+	// same account are always handled by the same worker, so they are
+	// signed in submission order. A single worker serializes all requests.
+	Workers int
+	// QueueSize is the number of requests each worker can hold before
+	// submissions fail with ErrQueueFull
+	QueueSize int
+	// MaxAge is how long a request may wait in the queue. Requests that are
+	// older when a worker picks them up fail with ErrRequestExpired instead
+	// of being signed with stale assumptions, e.g. outdated fees. With a
+	// ClefClient the age is also checked before retries and after a
+	// TimeLock, see WithRequestDeadline. Zero disables the limit.
+	MaxAge time.Duration
+}
+
+// QueueStats is a snapshot of the state of a SigningQueue
+type QueueStats struct {
+	// Depth is the number of requests waiting for a worker
+	Depth int64
+	// InFlight is the number of requests currently being signed
+	InFlight int64
+	// Completed is the number of requests signed successfully
+	Completed uint64
+	// Failed is the number of requests that returned an error
+	Failed uint64
+	// Expired is the number of requests dropped because they exceeded the
+	// maximum age, also counted in Failed
+	Expired uint64
+}
+
+// signJob is a queued transaction signing request
+type signJob struct {
+	ctx       context.Context
+	tx        *Transaction
+	submitted time.Time
+	result    chan SignTxResult
+}
+
+// SigningQueue funnels transaction signing requests through a bounded queue
+// and a fixed set of workers with per-account ordering
+type SigningQueue struct {
+	client Signer
+	queues []chan *signJob
+	maxAge time.Duration
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	depth     atomic.Int64
+	inFlight  atomic.Int64
+	completed atomic.Uint64
+	failed    atomic.Uint64
+	expired   atomic.Uint64
+}
+
+// NewSigningQueue creates a SigningQueue and starts its workers
+func NewSigningQueue(client Signer, config SigningQueueConfig) *SigningQueue {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1
+	}
+
+	q := &SigningQueue{client: client, queues: make([]chan *signJob, config.Workers), maxAge: config.MaxAge}
+	for i := range q.queues {
+		q.queues[i] = make(chan *signJob, config.QueueSize)
+		q.wg.Add(1)
+		go q.work(q.queues[i])
+	}
+	return q
+}
+
+// Submit enqueues the transaction without blocking. The returned channel
+// receives exactly one result once the transaction has been handled.
+func (q *SigningQueue) Submit(ctx context.Context, tx *Transaction) (<-chan SignTxResult, error) {
+	if tx == nil {
+		return nil, nilRequestError("transaction")
+	}
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return nil, ErrQueueClosed
+	}
+
+	job := &signJob{ctx: ctx, tx: tx, submitted: time.Now(), result: make(chan SignTxResult, 1)}
+	select {
+	case q.queues[q.worker(tx.From)] <- job:
+		q.depth.Add(1)
+		return job.result, nil
+	default:
+		return nil, ErrQueueFull
+	}
+}
+
+// SignTransaction enqueues the transaction and waits for its result
+func (q *SigningQueue) SignTransaction(ctx context.Context, tx *Transaction) (*SignTxResponse, error) {
+	result, err := q.Submit(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case r := <-result:
+		return r.Response, r.Err
+	case <-ctx.Done():
+		return nil, contextError(ctx.Err())
+	}
+}
+
+// Stats returns a snapshot of the queue metrics
+func (q *SigningQueue) Stats() QueueStats {
+	return QueueStats{
+		Depth:     q.depth.Load(),
+		InFlight:  q.inFlight.Load(),
+		Completed: q.completed.Load(),
+		Failed:    q.failed.Load(),
+		Expired:   q.expired.Load(),
+	}
+}
+
+// Close stops accepting requests and waits for queued requests to be handled
+func (q *SigningQueue) Close() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	for _, queue := range q.queues {
+		close(queue)
+	}
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+// worker returns the index of the worker responsible for the account
+func (q *SigningQueue) worker(account string) int {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(account)))
+	return int(h.Sum32() % uint32(len(q.queues)))
+}
+
+func (q *SigningQueue) work(queue chan *signJob) {
+	defer q.wg.Done()
+	for job := range queue {
+		q.depth.Add(-1)
+
+		// Requests whose caller already gave up are not sent to Clef
+		if err := job.ctx.Err(); err != nil {
+			q.failed.Add(1)
+			job.result <- SignTxResult{Err: contextError(err)}
+			continue
+		}
+
+		// Neither are requests that waited too long to still be valid
+		if age := time.Since(job.submitted); q.maxAge > 0 && age > q.maxAge {
+			q.failed.Add(1)
+			q.expired.Add(1)
+			job.result <- SignTxResult{Err: fmt.Errorf("%w: queued for %s, max age %s", ErrRequestExpired, age.Round(time.Millisecond), q.maxAge)}
+			continue
+		}
+
+		signer := q.client
+		if cc, ok := signer.(*ClefClient); ok && q.maxAge > 0 {
+			signer = cc.WithContext(WithRequestDeadline(job.ctx, job.submitted.Add(q.maxAge)))
+		}
+		q.inFlight.Add(1)
+		resp, err := signer.SignTransaction(job.tx)
+		q.inFlight.Add(-1)
+
+		if errors.Is(err, ErrRequestExpired) {
+			q.expired.Add(1)
+		}
+		if err != nil {
+			q.failed.Add(1)
+		} else {
+			q.completed.Add(1)
+		}
+		job.result <- SignTxResult{Response: resp, Err: err}
+	}
+}
@@ -0,0 +1,127 @@
+package clefclient
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigningQueueSignTransaction(t *testing.T) {
+	client, server := setupEchoSignServer(t)
+	defer server.Close()
+
+	q := NewSigningQueue(client, SigningQueueConfig{Workers: 2, QueueSize: 4})
+	defer q.Close()
+
+	resp, err := q.SignTransaction(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000001", Nonce: "0x1", GasPrice: "0x2"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x1-0x2", resp.Raw)
+	assert.Equal(t, uint64(1), q.Stats().Completed)
+}
+
+func TestSigningQueuePerAccountOrder(t *testing.T) {
+	client, server := setupEchoSignServer(t)
+	defer server.Close()
+
+	q := NewSigningQueue(client, SigningQueueConfig{Workers: 4, QueueSize: 10})
+
+	var results []<-chan SignTxResult
+	for i := 0; i < 10; i++ {
+		result, err := q.Submit(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000abc", Nonce: fmt.Sprintf("0x%x", i)})
+		assert.NoError(t, err)
+		results = append(results, result)
+	}
+	q.Close()
+
+	for i, result := range results {
+		r := <-result
+		assert.NoError(t, r.Err)
+		assert.Equal(t, fmt.Sprintf("0x%x-", i), r.Response.Raw)
+	}
+	assert.Equal(t, QueueStats{Completed: 10}, q.Stats())
+}
+
+func TestSigningQueueFullAndClosed(t *testing.T) {
+	client, server := setupEchoSignServer(t)
+	defer server.Close()
+
+	// Fill the queue before any worker exists to drain it
+	q := &SigningQueue{client: client, queues: []chan *signJob{make(chan *signJob, 1)}}
+	_, err := q.Submit(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrNilRequest)
+	_, err = q.Submit(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000001"})
+	assert.NoError(t, err)
+	_, err = q.Submit(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000001"})
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.Equal(t, int64(1), q.Stats().Depth)
+
+	q.wg.Add(1)
+	go q.work(q.queues[0])
+	q.Close()
+
+	_, err = q.Submit(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000001"})
+	assert.ErrorIs(t, err, ErrQueueClosed)
+}
+
+func TestSigningQueueSkipsCanceledRequests(t *testing.T) {
+	client, server := setupEchoSignServer(t)
+	defer server.Close()
+
+	q := &SigningQueue{client: client, queues: []chan *signJob{make(chan *signJob, 1)}}
+	ctx, cancel := context.WithCancel(context.Background())
+	result, err := q.Submit(ctx, &Transaction{From: "0x0000000000000000000000000000000000000001"})
+	assert.NoError(t, err)
+	cancel()
+
+	q.wg.Add(1)
+	go q.work(q.queues[0])
+	q.Close()
+
+	r := <-result
+	assert.ErrorIs(t, r.Err, context.Canceled)
+	assert.ErrorIs(t, r.Err, ErrCanceled)
+	assert.Equal(t, uint64(1), q.Stats().Failed)
+}
+
+func TestSigningQueueExpiresStaleRequests(t *testing.T) {
+	client, server := setupEchoSignServer(t)
+	defer server.Close()
+
+	q := &SigningQueue{client: client, queues: []chan *signJob{make(chan *signJob, 2)}, maxAge: time.Minute}
+	stale := &signJob{ctx: context.Background(), tx: &Transaction{From: "0x0000000000000000000000000000000000000001", Nonce: "0x1"}, submitted: time.Now().Add(-2 * time.Minute), result: make(chan SignTxResult, 1)}
+	q.queues[0] <- stale
+	q.depth.Add(1)
+	fresh, err := q.Submit(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000001", Nonce: "0x2"})
+	assert.NoError(t, err)
+
+	q.wg.Add(1)
+	go q.work(q.queues[0])
+	q.Close()
+
+	r := <-stale.result
+	assert.ErrorIs(t, r.Err, ErrRequestExpired)
+	assert.Nil(t, r.Response)
+	r = <-fresh
+	assert.NoError(t, r.Err)
+	assert.Equal(t, QueueStats{Completed: 1, Failed: 1, Expired: 1}, q.Stats())
+}
+
+func TestSigningQueueExpiresRetriedRequests(t *testing.T) {
+	next := &flakyTransport{failures: 5}
+	client := newClient(next, "", []Option{WithRetry(RetryPolicy{
+		Backoff:   20 * time.Millisecond,
+		Retryable: func(method string, err error) bool { return true },
+	})})
+	q := NewSigningQueue(client, SigningQueueConfig{MaxAge: 10 * time.Millisecond})
+	defer q.Close()
+
+	result, err := q.Submit(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000001", Nonce: "0x1"})
+	assert.NoError(t, err)
+	r := <-result
+	assert.ErrorIs(t, r.Err, ErrRequestExpired)
+	assert.Equal(t, 1, next.calls)
+	assert.Equal(t, QueueStats{Failed: 1, Expired: 1}, q.Stats())
+}
@@ -0,0 +1,162 @@
+package clefclient
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// The request and response types print and log summaries of themselves:
+// data payloads are reduced to their size and signatures are redacted, so
+// that logging them with %v or slog does not leak signed material.
+
+// String implements fmt.Stringer
+func (tx Transaction) String() string {
+	return formatAttrs("Transaction", tx.logAttrs())
+}
+
+// LogValue implements slog.LogValuer
+func (tx Transaction) LogValue() slog.Value {
+	return slog.GroupValue(tx.logAttrs()...)
+}
+
+func (tx Transaction) logAttrs() []slog.Attr {
+	var attrs []slog.Attr
+	attrs = appendAttr(attrs, "from", tx.From)
+	attrs = appendAttr(attrs, "to", tx.To)
+	attrs = appendAttr(attrs, "value", tx.Value)
+	attrs = appendAttr(attrs, "nonce", tx.Nonce)
+	attrs = appendAttr(attrs, "gas", tx.Gas)
+	attrs = appendAttr(attrs, "chainId", tx.ChainID)
+	attrs = appendAttr(attrs, "data", hexPayloadSize(tx.Data))
+	if len(tx.BlobVersionedHashes) > 0 {
+		attrs = append(attrs, slog.Int("blobs", len(tx.BlobVersionedHashes)))
+	}
+	return attrs
+}
+
+// String implements fmt.Stringer
+func (req SignDataRequest) String() string {
+	return formatAttrs("SignDataRequest", req.logAttrs())
+}
+
+// LogValue implements slog.LogValuer
+func (req SignDataRequest) LogValue() slog.Value {
+	return slog.GroupValue(req.logAttrs()...)
+}
+
+func (req SignDataRequest) logAttrs() []slog.Attr {
+	var attrs []slog.Attr
+	attrs = appendAttr(attrs, "address", req.Address)
+	attrs = appendAttr(attrs, "content_type", req.ContentType)
+	return appendAttr(attrs, "data", hexPayloadSize(req.Data))
+}
+
+// String implements fmt.Stringer
+func (req TypedDataRequest) String() string {
+	return formatAttrs("TypedDataRequest", req.logAttrs())
+}
+
+// LogValue implements slog.LogValuer
+func (req TypedDataRequest) LogValue() slog.Value {
+	return slog.GroupValue(req.logAttrs()...)
+}
+
+func (req TypedDataRequest) logAttrs() []slog.Attr {
+	var attrs []slog.Attr
+	attrs = appendAttr(attrs, "address", req.Address)
+	attrs = appendAttr(attrs, "raw_version", req.RawVersion)
+	if len(req.TypedData) > 0 {
+		attrs = appendAttr(attrs, "data", payloadSize(len(req.TypedData)))
+	}
+	return attrs
+}
+
+// String implements fmt.Stringer
+func (req EcRecoverRequest) String() string {
+	return formatAttrs("EcRecoverRequest", req.logAttrs())
+}
+
+// LogValue implements slog.LogValuer
+func (req EcRecoverRequest) LogValue() slog.Value {
+	return slog.GroupValue(req.logAttrs()...)
+}
+
+func (req EcRecoverRequest) logAttrs() []slog.Attr {
+	var attrs []slog.Attr
+	attrs = appendAttr(attrs, "data", hexPayloadSize(req.Data))
+	return appendAttr(attrs, "sig", redacted(req.Signature))
+}
+
+// String implements fmt.Stringer
+func (resp SignTxResponse) String() string {
+	return formatAttrs("SignTxResponse", resp.logAttrs())
+}
+
+// LogValue implements slog.LogValuer
+func (resp SignTxResponse) LogValue() slog.Value {
+	return slog.GroupValue(resp.logAttrs()...)
+}
+
+func (resp SignTxResponse) logAttrs() []slog.Attr {
+	var attrs []slog.Attr
+	attrs = appendAttr(attrs, "hash", resp.Tx.Hash)
+	attrs = appendAttr(attrs, "to", resp.Tx.To)
+	attrs = appendAttr(attrs, "value", resp.Tx.Value)
+	attrs = appendAttr(attrs, "nonce", resp.Tx.Nonce)
+	return appendAttr(attrs, "raw", redacted(resp.Raw))
+}
+
+// String implements fmt.Stringer
+func (resp SignDataResponse) String() string {
+	return formatAttrs("SignDataResponse", resp.logAttrs())
+}
+
+// LogValue implements slog.LogValuer
+func (resp SignDataResponse) LogValue() slog.Value {
+	return slog.GroupValue(resp.logAttrs()...)
+}
+
+func (resp SignDataResponse) logAttrs() []slog.Attr {
+	return appendAttr(nil, "signature", redacted(resp.Signature))
+}
+
+// appendAttr appends a string attribute unless value is empty
+func appendAttr(attrs []slog.Attr, key, value string) []slog.Attr {
+	if value == "" {
+		return attrs
+	}
+	return append(attrs, slog.String(key, value))
+}
+
+// formatAttrs formats attributes as name{key=value ...}
+func formatAttrs(name string, attrs []slog.Attr) string {
+	parts := make([]string, len(attrs))
+	for i, attr := range attrs {
+		parts[i] = attr.String()
+	}
+	return name + "{" + strings.Join(parts, " ") + "}"
+}
+
+// hexPayloadSize summarizes a hex payload by its size
+func hexPayloadSize(data string) string {
+	if data == "" || data == "0x" {
+		return ""
+	}
+	if hex, ok := strings.CutPrefix(data, "0x"); ok {
+		return payloadSize((len(hex) + 1) / 2)
+	}
+	return payloadSize(len(data))
+}
+
+func payloadSize(n int) string {
+	return fmt.Sprintf("[%d bytes]", n)
+}
+
+// redacted replaces a non-empty secret with a placeholder
+func redacted(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redactedValue
+}
@@ -0,0 +1,39 @@
+package clefclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactingString(t *testing.T) {
+	tx := &Transaction{From: "0x01", To: "0x02", Value: "0x10", ChainID: "0x1", Data: "0xa9059cbb0000"}
+	assert.Equal(t, "Transaction{from=0x01 to=0x02 value=0x10 chainId=0x1 data=[6 bytes]}", fmt.Sprintf("%v", tx))
+	assert.Equal(t, "Transaction{from=0x01 to=0x02 value=0x10 chainId=0x1 data=[6 bytes]}", fmt.Sprint(*tx))
+
+	assert.Equal(t, "SignDataRequest{address=0x01 content_type=text/plain data=[5 bytes]}", SignDataRequest{Address: "0x01", ContentType: ContentTypeTextPlain, Data: "0x68656c6c6f"}.String())
+	assert.Equal(t, "TypedDataRequest{address=0x01 data=[2 bytes]}", TypedDataRequest{Address: "0x01", TypedData: json.RawMessage(`{}`)}.String())
+	assert.Equal(t, "EcRecoverRequest{data=[1 bytes] sig=[REDACTED]}", EcRecoverRequest{Data: "0x01", Signature: testSignature(1)}.String())
+	assert.Equal(t, "SignDataResponse{signature=[REDACTED]}", SignDataResponse{Signature: testSignature(1)}.String())
+
+	var resp SignTxResponse
+	resp.Raw = "0xf86c"
+	resp.Tx.Hash = "0xabc"
+	resp.Tx.R = "0x1234"
+	assert.Equal(t, "SignTxResponse{hash=0xabc raw=[REDACTED]}", resp.String())
+}
+
+func TestRedactingLogValue(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, nil))
+	logger.Info("signed", "request", &SignDataRequest{Address: "0x01", Data: "0x68656c6c6f"}, "response", &SignDataResponse{Signature: testSignature(1)})
+
+	assert.NotContains(t, out.String(), testSignature(1))
+	assert.NotContains(t, out.String(), "68656c6c6f")
+	assert.Contains(t, out.String(), `"request":{"address":"0x01","data":"[5 bytes]"}`)
+	assert.Contains(t, out.String(), `"response":{"signature":"[REDACTED]"}`)
+}
@@ -0,0 +1,156 @@
+package clefclient
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MinReplacementBumpPercent is the minimum fee increase nodes accept for a
+// transaction replacing a pending one
+const MinReplacementBumpPercent = 10
+
+// ErrInsufficientBump is returned when a replacement does not raise fees
+// enough to replace the original transaction
+var ErrInsufficientBump = errors.New("replacement fee bump too low")
+
+// NewCancelTx builds a replacement cancelling original: a 0-value transfer
+// from the sender to itself at the same nonce, with fees raised by percent
+func NewCancelTx(original *Transaction, percent int64) (*Transaction, error) {
+	if original == nil {
+		return nil, nilRequestError("transaction")
+	}
+	if original.Nonce == "" {
+		return nil, fmt.Errorf("cannot replace a transaction without nonce")
+	}
+	cancel := &Transaction{
+		From:                 original.From,
+		To:                   original.From,
+		Gas:                  encodeQuantity(big.NewInt(txGas)),
+		GasPrice:             original.GasPrice,
+		MaxFeePerGas:         original.MaxFeePerGas,
+		MaxPriorityFeePerGas: original.MaxPriorityFeePerGas,
+		Value:                "0x0",
+		Nonce:                original.Nonce,
+		ChainID:              original.ChainID,
+	}
+	if err := bumpFees(cancel, percent, nil); err != nil {
+		return nil, err
+	}
+	return cancel, nil
+}
+
+// NewSpeedUpTx builds a replacement of original with the same content and
+// fees raised by percent
+func NewSpeedUpTx(original *Transaction, percent int64) (*Transaction, error) {
+	if original == nil {
+		return nil, nilRequestError("transaction")
+	}
+	if original.Nonce == "" {
+		return nil, fmt.Errorf("cannot replace a transaction without nonce")
+	}
+	speedUp := *original
+	if err := bumpFees(&speedUp, percent, nil); err != nil {
+		return nil, err
+	}
+	return &speedUp, nil
+}
+
+// CheckReplacement verifies that replacement can replace original: same
+// sender and nonce, and both the fee cap and the tip raised by at least
+// minBumpPercent. Legacy gas prices count as both fee cap and tip.
+func CheckReplacement(original, replacement *Transaction, minBumpPercent int64) error {
+	if original == nil || replacement == nil {
+		return nilRequestError("transaction")
+	}
+	if original.Nonce == "" || replacement.Nonce == "" {
+		return fmt.Errorf("replacement requires explicit nonces")
+	}
+	if !strings.EqualFold(original.From, replacement.From) {
+		return fmt.Errorf("replacement sender %s differs from %s", replacement.From, original.From)
+	}
+	origNonce, err := decodeQuantity(original.Nonce)
+	if err != nil {
+		return fmt.Errorf("nonce: %w", err)
+	}
+	replNonce, err := decodeQuantity(replacement.Nonce)
+	if err != nil {
+		return fmt.Errorf("nonce: %w", err)
+	}
+	if origNonce.Cmp(replNonce) != 0 {
+		return fmt.Errorf("replacement nonce %s differs from %s", replNonce, origNonce)
+	}
+
+	origCap, origTip, err := feeCaps(original)
+	if err != nil {
+		return err
+	}
+	replCap, replTip, err := feeCaps(replacement)
+	if err != nil {
+		return err
+	}
+	if minCap := bumpValue(origCap, minBumpPercent); replCap.Cmp(minCap) < 0 {
+		return fmt.Errorf("%w: fee cap %s, need at least %s", ErrInsufficientBump, replCap, minCap)
+	}
+	if minTip := bumpValue(origTip, minBumpPercent); replTip.Cmp(minTip) < 0 {
+		return fmt.Errorf("%w: tip %s, need at least %s", ErrInsufficientBump, replTip, minTip)
+	}
+	return nil
+}
+
+// feeCaps returns the fee cap and tip of tx
+func feeCaps(tx *Transaction) (*big.Int, *big.Int, error) {
+	if tx.GasPrice != "" {
+		price, err := decodeQuantity(tx.GasPrice)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gasPrice: %w", err)
+		}
+		return price, price, nil
+	}
+	if tx.MaxFeePerGas == "" {
+		return nil, nil, ErrNoFeeToBump
+	}
+	feeCap, err := decodeQuantity(tx.MaxFeePerGas)
+	if err != nil {
+		return nil, nil, fmt.Errorf("maxFeePerGas: %w", err)
+	}
+	tip := new(big.Int)
+	if tx.MaxPriorityFeePerGas != "" {
+		if tip, err = decodeQuantity(tx.MaxPriorityFeePerGas); err != nil {
+			return nil, nil, fmt.Errorf("maxPriorityFeePerGas: %w", err)
+		}
+	}
+	return feeCap, tip, nil
+}
+
+// ReplaceTx validates that replacement raises the fees of original by at
+// least MinReplacementBumpPercent and signs it
+func (cc *ClefClient) ReplaceTx(original, replacement *Transaction) (*SignTxResponse, error) {
+	if err := CheckReplacement(original, replacement, MinReplacementBumpPercent); err != nil {
+		return nil, err
+	}
+	return cc.SignTransaction(replacement)
+}
+
+// CancelTx signs a replacement cancelling the pending original transaction
+func (cc *ClefClient) CancelTx(original *Transaction) (*SignTxResponse, error) {
+	cancel, err := NewCancelTx(original, MinReplacementBumpPercent)
+	if err != nil {
+		return nil, err
+	}
+	return cc.ReplaceTx(original, cancel)
+}
+
+// SpeedUpTx signs a copy of the pending original transaction with fees
+// raised by percent, at least MinReplacementBumpPercent
+func (cc *ClefClient) SpeedUpTx(original *Transaction, percent int64) (*SignTxResponse, error) {
+	if percent < MinReplacementBumpPercent {
+		percent = MinReplacementBumpPercent
+	}
+	speedUp, err := NewSpeedUpTx(original, percent)
+	if err != nil {
+		return nil, err
+	}
+	return cc.ReplaceTx(original, speedUp)
+}
@@ -0,0 +1,65 @@
+package clefclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCancelTx(t *testing.T) {
+	original := &Transaction{
+		From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002", Value: "0xde0b6b3a7640000", Data: "0xabcd", Nonce: "0x7",
+		MaxFeePerGas: "0x64", MaxPriorityFeePerGas: "0xa",
+	}
+	cancel, err := NewCancelTx(original, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, &Transaction{
+		From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000001", Gas: "0x5208", Value: "0x0", Nonce: "0x7",
+		MaxFeePerGas: "0x6e", MaxPriorityFeePerGas: "0xb",
+	}, cancel)
+	assert.NoError(t, CheckReplacement(original, cancel, 10))
+
+	_, err = NewCancelTx(&Transaction{From: "0x0000000000000000000000000000000000000001", GasPrice: "0x1"}, 10)
+	assert.ErrorContains(t, err, "without nonce")
+}
+
+func TestNewSpeedUpTx(t *testing.T) {
+	original := &Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002", Data: "0xabcd", Nonce: "0x7", GasPrice: "0x3b9aca00"}
+	speedUp, err := NewSpeedUpTx(original, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x47868c00", speedUp.GasPrice)
+	assert.Equal(t, "0xabcd", speedUp.Data)
+	assert.Equal(t, "0x3b9aca00", original.GasPrice)
+}
+
+func TestCheckReplacement(t *testing.T) {
+	original := &Transaction{From: "0xAb", Nonce: "0x1", MaxFeePerGas: "0x64", MaxPriorityFeePerGas: "0xa"}
+
+	err := CheckReplacement(original, &Transaction{From: "0xab", Nonce: "0x1", MaxFeePerGas: "0x6e", MaxPriorityFeePerGas: "0xa"}, 10)
+	assert.ErrorIs(t, err, ErrInsufficientBump)
+	assert.ErrorContains(t, err, "tip 10")
+
+	err = CheckReplacement(original, &Transaction{From: "0xab", Nonce: "0x2", GasPrice: "0x100"}, 10)
+	assert.ErrorContains(t, err, "nonce")
+	err = CheckReplacement(original, &Transaction{From: "0xcd", Nonce: "0x1", GasPrice: "0x100"}, 10)
+	assert.ErrorContains(t, err, "sender")
+
+	// a legacy replacement counts its gas price as both fee cap and tip
+	assert.NoError(t, CheckReplacement(original, &Transaction{From: "0xab", Nonce: "0x1", GasPrice: "0x6e"}, 10))
+}
+
+func TestReplaceTx(t *testing.T) {
+	client, server := setupHTTPTestServer(t, "account_signTransaction", SignTxResponse{Raw: "0xraw"})
+	defer server.Close()
+
+	original := &Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002", Nonce: "0x1", GasPrice: "0x64"}
+	signed, err := client.CancelTx(original)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xraw", signed.Raw)
+
+	_, err = client.SpeedUpTx(original, 5)
+	assert.NoError(t, err)
+
+	_, err = client.ReplaceTx(original, &Transaction{From: "0x0000000000000000000000000000000000000001", Nonce: "0x1", GasPrice: "0x65"})
+	assert.ErrorIs(t, err, ErrInsufficientBump)
+}
@@ -0,0 +1,175 @@
+// Package restgateway serves Clef account and signing methods as a REST+JSON
+// API, described in openapi.yaml, for services that do not speak JSON-RPC.
+package restgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// maxBodySize bounds the size of request bodies
+const maxBodySize = 4 << 20
+
+// Config configures a Server
+type Config struct {
+	// Signer serves the requests, usually a ClefClient with audit enabled
+	Signer clefclient.Signer
+	// Authenticate, if set, is called for every request and rejects it with
+	// 401 Unauthorized on error
+	Authenticate func(r *http.Request) error
+	// Policies are applied to signing requests before they reach Clef and
+	// reject them with 403 Forbidden
+	Policies []clefclient.DryRunPolicy
+}
+
+// Server is an http.Handler serving the REST API
+type Server struct {
+	config Config
+	mux    *http.ServeMux
+}
+
+// errorResponse is the body of failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// typedDataBody is the body of POST /sign/typed-data
+type typedDataBody struct {
+	Address   string          `json:"address"`
+	TypedData json.RawMessage `json:"typedData"`
+}
+
+// httpError is an error carrying an HTTP status code
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string { return e.err.Error() }
+
+// NewServer creates a new Server
+func NewServer(config Config) *Server {
+	s := &Server{config: config, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /accounts", s.wrap(s.accounts))
+	s.mux.HandleFunc("POST /sign/tx", s.wrap(s.signTransaction))
+	s.mux.HandleFunc("POST /sign/data", s.wrap(s.signData))
+	s.mux.HandleFunc("POST /sign/typed-data", s.wrap(s.signTypedData))
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// wrap authenticates requests and encodes results and errors as JSON
+func (s *Server) wrap(handler func(r *http.Request) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var result interface{}
+		var err error
+		if s.config.Authenticate != nil {
+			if authErr := s.config.Authenticate(r); authErr != nil {
+				err = &httpError{http.StatusUnauthorized, authErr}
+			}
+		}
+		if err == nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+			result, err = handler(r)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(statusOf(err))
+			json.NewEncoder(w).Encode(errorResponse{Error: err.Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// statusOf returns the status code of a failed request: 403 for policy
+// violations, 502 when Clef could not be reached, failed or denied the
+// request, and 400 for requests refused by the client's own validation
+func statusOf(err error) int {
+	var he *httpError
+	switch {
+	case errors.As(err, &he):
+		return he.status
+	case errors.Is(err, clefclient.ErrPolicyViolation):
+		return http.StatusForbidden
+	case errors.Is(err, clefclient.ErrConnection), errors.Is(err, clefclient.ErrSigner),
+		errors.Is(err, clefclient.ErrMalformedResponse), errors.Is(err, clefclient.ErrSigningTimeout),
+		errors.Is(err, clefclient.ErrCanceled):
+		return http.StatusBadGateway
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func (s *Server) accounts(r *http.Request) (interface{}, error) {
+	accounts, err := s.config.Signer.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]string{"accounts": accounts}, nil
+}
+
+func (s *Server) signTransaction(r *http.Request) (interface{}, error) {
+	var tx clefclient.Transaction
+	if err := decodeBody(r, &tx); err != nil {
+		return nil, err
+	}
+	if err := s.checkPolicies("account_signTransaction", &tx); err != nil {
+		return nil, err
+	}
+	return s.config.Signer.SignTransaction(&tx)
+}
+
+func (s *Server) signData(r *http.Request) (interface{}, error) {
+	var req clefclient.SignDataRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if err := s.checkPolicies("account_signData", &req); err != nil {
+		return nil, err
+	}
+	return s.config.Signer.SignData(&req)
+}
+
+func (s *Server) signTypedData(r *http.Request) (interface{}, error) {
+	var body typedDataBody
+	if err := decodeBody(r, &body); err != nil {
+		return nil, err
+	}
+	if len(body.TypedData) == 0 {
+		return nil, &httpError{http.StatusBadRequest, errors.New("typedData is required")}
+	}
+	req := &clefclient.TypedDataRequest{Address: body.Address, TypedData: body.TypedData, RawVersion: "V4"}
+	if err := s.checkPolicies("account_signTypedData", req); err != nil {
+		return nil, err
+	}
+	return s.config.Signer.SignTypedData(req)
+}
+
+func (s *Server) checkPolicies(method string, params interface{}) error {
+	for _, policy := range s.config.Policies {
+		if err := policy(method, params); err != nil {
+			return &httpError{http.StatusForbidden, err}
+		}
+	}
+	return nil
+}
+
+// decodeBody decodes a JSON request body, rejecting unknown fields
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return &httpError{http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err)}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package restgateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubSigner records requests and answers with fixed values
+type stubSigner struct {
+	tx        *clefclient.Transaction
+	typedData *clefclient.TypedDataRequest
+	// dataErr is the error of SignData, a denial by Clef if nil
+	dataErr error
+}
+
+func (s *stubSigner) ListAccounts() ([]string, error) {
+	return []string{"0x01"}, nil
+}
+
+func (s *stubSigner) SignTransaction(tx *clefclient.Transaction) (*clefclient.SignTxResponse, error) {
+	s.tx = tx
+	return &clefclient.SignTxResponse{Raw: "0xraw"}, nil
+}
+
+func (s *stubSigner) SignData(req *clefclient.SignDataRequest) (*clefclient.SignDataResponse, error) {
+	if s.dataErr != nil {
+		return nil, s.dataErr
+	}
+	return nil, &clefclient.RPCError{Code: -32000, Message: "Request denied"}
+}
+
+func (s *stubSigner) SignTypedData(req *clefclient.TypedDataRequest) (*clefclient.SignDataResponse, error) {
+	s.typedData = req
+	return &clefclient.SignDataResponse{Signature: "0xsig"}, nil
+}
+
+func post(t *testing.T, handler http.Handler, path, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var out map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	return rec.Code, out
+}
+
+func TestRESTGateway(t *testing.T) {
+	signer := &stubSigner{}
+	server := NewServer(Config{Signer: signer})
+
+	code, out := post(t, server, "/accounts", "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"0x01"}, out["accounts"])
+
+	code, out = post(t, server, "/sign/tx", `{"from": "0x01", "to": "0x02", "value": "0x1"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "0xraw", out["raw"])
+	assert.Equal(t, &clefclient.Transaction{From: "0x01", To: "0x02", Value: "0x1"}, signer.tx)
+
+	code, out = post(t, server, "/sign/typed-data", `{"address": "0x01", "typedData": {"primaryType": "Mail"}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "0xsig", out["signature"])
+	assert.JSONEq(t, `{"primaryType": "Mail"}`, string(signer.typedData.TypedData))
+	assert.Equal(t, "V4", signer.typedData.RawVersion)
+
+	code, out = post(t, server, "/sign/data", `{"address": "0x01", "data": "0x00"}`)
+	assert.Equal(t, http.StatusBadGateway, code)
+	assert.Equal(t, "Request denied", out["error"])
+
+	code, _ = post(t, server, "/sign/tx", `{"from": "0x01", "gasLimit": "0x1"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = post(t, server, "/sign/typed-data", `{"address": "0x01"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestRESTGatewayErrorStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("%w: value too high", clefclient.ErrPolicyViolation), http.StatusForbidden},
+		{fmt.Errorf("account_signData: %w", clefclient.ErrConnection), http.StatusBadGateway},
+		{errors.New("invalid address \"0x01\""), http.StatusBadRequest},
+	} {
+		server := NewServer(Config{Signer: &stubSigner{dataErr: tc.err}})
+		code, out := post(t, server, "/sign/data", `{"address": "0x01", "data": "0x00"}`)
+		assert.Equal(t, tc.status, code, tc.err.Error())
+		assert.Equal(t, tc.err.Error(), out["error"])
+	}
+}
+
+func TestRESTGatewayAuthAndPolicies(t *testing.T) {
+	server := NewServer(Config{
+		Signer: &stubSigner{},
+		Authenticate: func(r *http.Request) error {
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				return errors.New("invalid token")
+			}
+			return nil
+		},
+		Policies: []clefclient.DryRunPolicy{func(method string, params interface{}) error {
+			if tx, ok := params.(*clefclient.Transaction); ok && tx.Value != "" {
+				return errors.New("value transfers are not allowed")
+			}
+			return nil
+		}},
+	})
+
+	code, out := post(t, server, "/sign/tx", `{"from": "0x01", "value": "0x1"}`)
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, "value transfers are not allowed", out["error"])
+
+	req := httptest.NewRequest(http.MethodPost, "/accounts", nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,77 @@
+package clefclient
+
+import (
+	"errors"
+	"math/big"
+)
+
+// rlpBytes encodes a byte string as RLP
+func rlpBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(rlpHeader(0x80, len(b)), b...)
+}
+
+// rlpInt encodes a non-negative integer as RLP, zero being the empty string
+func rlpInt(v *big.Int) []byte {
+	return rlpBytes(v.Bytes())
+}
+
+// rlpList encodes already encoded items as an RLP list
+func rlpList(items ...[]byte) []byte {
+	var payload []byte
+	for _, item := range items {
+		payload = append(payload, item...)
+	}
+	return append(rlpHeader(0xc0, len(payload)), payload...)
+}
+
+func rlpHeader(offset byte, size int) []byte {
+	if size < 56 {
+		return []byte{offset + byte(size)}
+	}
+	length := new(big.Int).SetInt64(int64(size)).Bytes()
+	return append([]byte{offset + 55 + byte(len(length))}, length...)
+}
+
+var errRLP = errors.New("invalid RLP")
+
+// rlpSplit splits the first item off b, returning its content, whether it
+// is a list, and the bytes following it
+func rlpSplit(b []byte) (content []byte, list bool, rest []byte, err error) {
+	if len(b) == 0 {
+		return nil, false, nil, errRLP
+	}
+	prefix := b[0]
+	var offset, size int
+	switch {
+	case prefix < 0x80:
+		return b[:1], false, b[1:], nil
+	case prefix < 0xb8:
+		offset, size = 1, int(prefix-0x80)
+	case prefix < 0xc0:
+		offset, size, err = rlpLongSize(b, int(prefix-0xb7))
+	case prefix < 0xf8:
+		list, offset, size = true, 1, int(prefix-0xc0)
+	default:
+		list = true
+		offset, size, err = rlpLongSize(b, int(prefix-0xf7))
+	}
+	if err != nil || len(b) < offset+size {
+		return nil, false, nil, errRLP
+	}
+	return b[offset : offset+size], list, b[offset+size:], nil
+}
+
+// rlpLongSize decodes the size of a long string or list whose size takes n
+// bytes
+func rlpLongSize(b []byte, n int) (offset, size int, err error) {
+	if n > 4 || len(b) < 1+n {
+		return 0, 0, errRLP
+	}
+	for _, c := range b[1 : 1+n] {
+		size = size<<8 | int(c)
+	}
+	return 1 + n, size, nil
+}
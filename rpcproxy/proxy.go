@@ -0,0 +1,290 @@
+// Package rpcproxy implements an Ethereum node JSON-RPC endpoint whose account
+// and signing methods are served by Clef, so that tooling which only speaks
+// node RPC can use Clef-held keys. All other methods are forwarded to a node.
+package rpcproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	clefclient "github.com/AxLabs/clef-client"
+)
+
+// rpcRequest represents a JSON-RPC request received by the proxy
+type rpcRequest struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+}
+
+// rpcResponse represents a JSON-RPC response returned by the proxy
+type rpcResponse struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcError represents a JSON-RPC error.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	errCodeParse         = -32700
+	errCodeInvalidParams = -32602
+	errCodeServer        = -32000
+)
+
+// errNotHandled is returned by dispatch for methods forwarded to the node
+var errNotHandled = errors.New("method not handled by proxy")
+
+// Config configures a Server
+type Config struct {
+	// Signer serves account and signing methods, usually a ClefClient
+	Signer clefclient.Signer
+	// NodeURL is the node receiving raw transactions and all other methods
+	NodeURL string
+	// HTTPClient is used to forward requests, http.DefaultClient if nil
+	HTTPClient *http.Client
+}
+
+// Server is an http.Handler serving node JSON-RPC with Clef-backed signing
+type Server struct {
+	signer     clefclient.Signer
+	node       *clefclient.NodeClient
+	nodeURL    string
+	httpClient *http.Client
+}
+
+// NewServer creates a new Server
+func NewServer(config Config) *Server {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Server{
+		signer:     config.Signer,
+		node:       clefclient.NewNodeClient(config.NodeURL),
+		nodeURL:    config.NodeURL,
+		httpClient: httpClient,
+	}
+}
+
+// ServeHTTP handles single and batch JSON-RPC requests
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result interface{}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			result = parseError(err)
+		} else {
+			resps := make([]json.RawMessage, len(reqs))
+			for i := range reqs {
+				resps[i] = s.handle(&reqs[i])
+			}
+			result = resps
+		}
+	} else {
+		result = s.Handle(body)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// Handle serves a single encoded JSON-RPC request and returns the encoded
+// response, for use with transports other than HTTP
+func (s *Server) Handle(body []byte) json.RawMessage {
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		encoded, _ := json.Marshal(parseError(err))
+		return encoded
+	}
+	return s.handle(&req)
+}
+
+// handle serves a request through Clef or forwards it to the node, returning
+// the encoded response
+func (s *Server) handle(req *rpcRequest) json.RawMessage {
+	result, err := s.dispatch(req.Method, req.Params)
+	if errors.Is(err, errNotHandled) {
+		return s.forward(req)
+	}
+
+	resp := &rpcResponse{Jsonrpc: "2.0", ID: req.ID}
+	switch {
+	case errors.As(err, new(*paramsError)):
+		resp.Error = &rpcError{Code: errCodeInvalidParams, Message: err.Error()}
+	case err != nil:
+		resp.Error = &rpcError{Code: errCodeServer, Message: err.Error()}
+	default:
+		resp.Result = result
+	}
+	encoded, _ := json.Marshal(resp)
+	return encoded
+}
+
+// dispatch serves the account and signing methods through the signer
+func (s *Server) dispatch(method string, params []json.RawMessage) (interface{}, error) {
+	switch method {
+	case "eth_accounts":
+		return s.signer.ListAccounts()
+	case "eth_sign":
+		var address, data string
+		if err := decodeParams(params, &address, &data); err != nil {
+			return nil, err
+		}
+		return s.signText(address, data)
+	case "personal_sign":
+		var data, address string
+		if err := decodeParams(params, &data, &address); err != nil {
+			return nil, err
+		}
+		return s.signText(address, data)
+	case "eth_signTypedData_v4":
+		var address string
+		var typedData json.RawMessage
+		if err := decodeParams(params, &address, &typedData); err != nil {
+			return nil, err
+		}
+		// Wallets receive the typed data either as an object or as a JSON string
+		var encoded string
+		if err := json.Unmarshal(typedData, &encoded); err == nil {
+			typedData = json.RawMessage(encoded)
+		}
+		resp, err := s.signer.SignTypedData(&clefclient.TypedDataRequest{Address: address, TypedData: typedData, RawVersion: "V4"})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Signature, nil
+	case "eth_signTransaction":
+		tx, err := s.decodeTransaction(params)
+		if err != nil {
+			return nil, err
+		}
+		return s.signer.SignTransaction(tx)
+	case "eth_sendTransaction":
+		tx, err := s.decodeTransaction(params)
+		if err != nil {
+			return nil, err
+		}
+		signed, err := s.signer.SignTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		return s.node.SendRawTransaction(signed.Raw)
+	}
+	return nil, errNotHandled
+}
+
+func (s *Server) signText(address, data string) (string, error) {
+	resp, err := s.signer.SignData(&clefclient.SignDataRequest{
+		ContentType: clefclient.ContentTypeTextPlain,
+		Address:     address,
+		Data:        data,
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.Signature, nil
+}
+
+// decodeTransaction decodes a node-style transaction object and fills in the
+// nonce, gas and gas price from the node where they are missing, since Clef
+// requires them
+func (s *Server) decodeTransaction(params []json.RawMessage) (*clefclient.Transaction, error) {
+	var args struct {
+		clefclient.Transaction
+		Input string `json:"input"`
+	}
+	if err := decodeParams(params, &args); err != nil {
+		return nil, err
+	}
+	tx := args.Transaction
+	if tx.Data == "" {
+		tx.Data = args.Input
+	}
+
+	var err error
+	if tx.Nonce == "" {
+		if tx.Nonce, err = s.node.PendingNonceAt(tx.From); err != nil {
+			return nil, fmt.Errorf("failed to get nonce: %w", err)
+		}
+	}
+	if tx.Gas == "" {
+		if tx.Gas, err = s.node.EstimateGas(&tx); err != nil {
+			return nil, fmt.Errorf("failed to estimate gas: %w", err)
+		}
+	}
+	if tx.GasPrice == "" && tx.MaxFeePerGas == "" {
+		if tx.GasPrice, err = s.node.GasPrice(); err != nil {
+			return nil, fmt.Errorf("failed to get gas price: %w", err)
+		}
+	}
+	return &tx, nil
+}
+
+// forward sends the request to the node and returns its response verbatim
+func (s *Server) forward(req *rpcRequest) json.RawMessage {
+	body, err := json.Marshal(req)
+	if err == nil {
+		var resp *http.Response
+		if resp, err = s.httpClient.Post(s.nodeURL, "application/json", bytes.NewReader(body)); err == nil {
+			defer resp.Body.Close()
+			var result json.RawMessage
+			if err = json.NewDecoder(resp.Body).Decode(&result); err == nil {
+				return result
+			}
+		}
+	}
+
+	encoded, _ := json.Marshal(&rpcResponse{
+		Jsonrpc: "2.0",
+		ID:      req.ID,
+		Error:   &rpcError{Code: errCodeServer, Message: fmt.Sprintf("failed to forward %s: %v", req.Method, err)},
+	})
+	return encoded
+}
+
+func parseError(err error) *rpcResponse {
+	return &rpcResponse{Jsonrpc: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: errCodeParse, Message: err.Error()}}
+}
+
+// paramsError is returned when request parameters cannot be decoded
+type paramsError struct {
+	err error
+}
+
+func (e *paramsError) Error() string {
+	return fmt.Sprintf("invalid params: %v", e.err)
+}
+
+// decodeParams decodes positional parameters into vs
+func decodeParams(params []json.RawMessage, vs ...interface{}) error {
+	if len(params) != len(vs) {
+		return &paramsError{err: fmt.Errorf("expected %d parameters, got %d", len(vs), len(params))}
+	}
+	for i, v := range vs {
+		if err := json.Unmarshal(params[i], v); err != nil {
+			return &paramsError{err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package rpcproxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSigner records the requests it signs
+type fakeSigner struct {
+	tx       *clefclient.Transaction
+	data     *clefclient.SignDataRequest
+	typedReq *clefclient.TypedDataRequest
+}
+
+func (f *fakeSigner) ListAccounts() ([]string, error) {
+	return []string{"0x0000000000000000000000000000000000000001"}, nil
+}
+
+func (f *fakeSigner) SignTransaction(tx *clefclient.Transaction) (*clefclient.SignTxResponse, error) {
+	f.tx = tx
+	return &clefclient.SignTxResponse{Raw: "0xraw"}, nil
+}
+
+func (f *fakeSigner) SignData(req *clefclient.SignDataRequest) (*clefclient.SignDataResponse, error) {
+	f.data = req
+	return &clefclient.SignDataResponse{Signature: "0xsig"}, nil
+}
+
+func (f *fakeSigner) SignTypedData(req *clefclient.TypedDataRequest) (*clefclient.SignDataResponse, error) {
+	f.typedReq = req
+	return &clefclient.SignDataResponse{Signature: "0xtyped"}, nil
+}
+
+func setupProxy(t *testing.T) (*fakeSigner, *httptest.Server, *[]string) {
+	var nodeCalls []string
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		nodeCalls = append(nodeCalls, req.Method)
+
+		results := map[string]string{
+			"eth_getTransactionCount": "0x7",
+			"eth_estimateGas":         "0x5208",
+			"eth_gasPrice":            "0x64",
+			"eth_sendRawTransaction":  "0xhash",
+			"eth_blockNumber":         "0x10",
+		}
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: req.ID, Result: results[req.Method]})
+	}))
+	t.Cleanup(node.Close)
+
+	signer := &fakeSigner{}
+	proxy := httptest.NewServer(NewServer(Config{Signer: signer, NodeURL: node.URL}))
+	t.Cleanup(proxy.Close)
+	return signer, proxy, &nodeCalls
+}
+
+func call(t *testing.T, url, body string) map[string]interface{} {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	var result map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return result
+}
+
+func TestProxySendTransaction(t *testing.T) {
+	signer, proxy, nodeCalls := setupProxy(t)
+
+	resp := call(t, proxy.URL, `{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":[{"from":"0x01","to":"0x02","value":"0x1","input":"0xabcd"}]}`)
+	assert.Equal(t, "0xhash", resp["result"])
+	assert.Equal(t, "0x7", signer.tx.Nonce)
+	assert.Equal(t, "0x5208", signer.tx.Gas)
+	assert.Equal(t, "0x64", signer.tx.GasPrice)
+	assert.Equal(t, "0xabcd", signer.tx.Data)
+	assert.Equal(t, []string{"eth_getTransactionCount", "eth_estimateGas", "eth_gasPrice", "eth_sendRawTransaction"}, *nodeCalls)
+}
+
+func TestProxySigning(t *testing.T) {
+	signer, proxy, _ := setupProxy(t)
+
+	resp := call(t, proxy.URL, `{"jsonrpc":"2.0","id":1,"method":"personal_sign","params":["0x68656c6c6f","0x01"]}`)
+	assert.Equal(t, "0xsig", resp["result"])
+	assert.Equal(t, clefclient.ContentTypeTextPlain, signer.data.ContentType)
+	assert.Equal(t, "0x01", signer.data.Address)
+
+	resp = call(t, proxy.URL, `{"jsonrpc":"2.0","id":2,"method":"eth_signTypedData_v4","params":["0x01","{\"primaryType\":\"Mail\"}"]}`)
+	assert.Equal(t, "0xtyped", resp["result"])
+	assert.JSONEq(t, `{"primaryType":"Mail"}`, string(signer.typedReq.TypedData))
+
+	resp = call(t, proxy.URL, `{"jsonrpc":"2.0","id":3,"method":"eth_sign","params":["0x01"]}`)
+	assert.Equal(t, float64(errCodeInvalidParams), resp["error"].(map[string]interface{})["code"])
+}
+
+func TestProxyForwardsAndBatches(t *testing.T) {
+	_, proxy, nodeCalls := setupProxy(t)
+
+	resp, err := http.Post(proxy.URL, "application/json", strings.NewReader(
+		`[{"jsonrpc":"2.0","id":1,"method":"eth_accounts","params":[]},{"jsonrpc":"2.0","id":2,"method":"eth_blockNumber","params":[]}]`))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var results []rpcResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+	assert.Len(t, results, 2)
+	assert.Equal(t, []interface{}{"0x0000000000000000000000000000000000000001"}, results[0].Result)
+	assert.Equal(t, "0x10", results[1].Result)
+	assert.Equal(t, "2", string(results[1].ID))
+	assert.Equal(t, []string{"eth_blockNumber"}, *nodeCalls)
+}
@@ -0,0 +1,204 @@
+package clefclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/AxLabs/clef-client/internal/secret"
+)
+
+// secp256k1 curve parameters (y² = x³ + 7 over the field of size p)
+var (
+	secp256k1P, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	secp256k1N, _  = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	secp256k1Gx, _ = new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	secp256k1Gy, _ = new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+	secp256k1B     = big.NewInt(7)
+)
+
+// errInvalidSignature is returned for signatures that cannot be recovered
+var errInvalidSignature = errors.New("invalid signature")
+
+// ecPoint is an affine point on secp256k1, nil coordinates denote infinity
+type ecPoint struct {
+	x, y *big.Int
+}
+
+func (pt ecPoint) isInfinity() bool {
+	return pt.x == nil
+}
+
+// ecAdd returns a + b
+func ecAdd(a, b ecPoint) ecPoint {
+	if a.isInfinity() {
+		return b
+	}
+	if b.isInfinity() {
+		return a
+	}
+
+	p := secp256k1P
+	var lambda *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if new(big.Int).Add(a.y, b.y).Mod(new(big.Int).Add(a.y, b.y), p).Sign() == 0 {
+			return ecPoint{}
+		}
+		// Doubling: λ = 3x² / 2y
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		lambda = num.Mul(num, den.ModInverse(den.Mod(den, p), p))
+	} else {
+		// Addition: λ = (y₂ - y₁) / (x₂ - x₁)
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		lambda = num.Mul(num, den.ModInverse(den.Mod(den, p), p))
+	}
+	lambda.Mod(lambda, p)
+
+	x := new(big.Int).Mul(lambda, lambda)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, p)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, lambda).Sub(y, a.y).Mod(y, p)
+	return ecPoint{x: x, y: y}
+}
+
+// ecMul returns k * pt using double-and-add
+func ecMul(pt ecPoint, k *big.Int) ecPoint {
+	result := ecPoint{}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = ecAdd(result, result)
+		if k.Bit(i) == 1 {
+			result = ecAdd(result, pt)
+		}
+	}
+	return result
+}
+
+// ecBaseMul returns k * G
+func ecBaseMul(k *big.Int) ecPoint {
+	return ecMul(ecPoint{x: secp256k1Gx, y: secp256k1Gy}, k)
+}
+
+// ecRecover recovers the public key that produced the 65 byte [R || S || V]
+// signature over hash, with V being the recovery id 0 or 1
+func ecRecover(hash, sig []byte) (ecPoint, error) {
+	if len(sig) != 65 || sig[64] > 1 {
+		return ecPoint{}, errInvalidSignature
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(secp256k1N) >= 0 || s.Cmp(secp256k1N) >= 0 {
+		return ecPoint{}, errInvalidSignature
+	}
+
+	// Recover R from its x coordinate r and the parity of its y coordinate
+	p := secp256k1P
+	y2 := new(big.Int).Exp(r, big.NewInt(3), p)
+	y2.Add(y2, secp256k1B).Mod(y2, p)
+	exp := new(big.Int).Add(p, big.NewInt(1))
+	y := new(big.Int).Exp(y2, exp.Rsh(exp, 2), p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(y2) != 0 {
+		return ecPoint{}, errInvalidSignature
+	}
+	if y.Bit(0) != uint(sig[64]) {
+		y.Sub(p, y)
+	}
+	R := ecPoint{x: r, y: y}
+
+	// Q = r⁻¹ (sR - eG)
+	n := secp256k1N
+	e := new(big.Int).SetBytes(hash)
+	rInv := new(big.Int).ModInverse(r, n)
+	u1 := new(big.Int).Neg(e)
+	u1.Mul(u1, rInv).Mod(u1, n)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, n)
+
+	q := ecAdd(ecBaseMul(u1), ecMul(R, u2))
+	if q.isInfinity() {
+		return ecPoint{}, errInvalidSignature
+	}
+	return q, nil
+}
+
+// pubkeyAddress returns the Ethereum address of a public key
+func pubkeyAddress(pub ecPoint) []byte {
+	return keccak256(pub.x.FillBytes(make([]byte, 32)), pub.y.FillBytes(make([]byte, 32)))[12:]
+}
+
+// ecSign signs hash with the private key d, returning a 65 byte
+// [R || S || V] signature with V being the recovery id 0 or 1. The nonce is
+// derived deterministically as in RFC 6979 and S is normalized to the lower
+// half of the order, matching the signatures produced by Clef. The
+// arithmetic is not constant time, so it must not be used for keys that
+// protect real funds.
+func ecSign(hash []byte, d *big.Int) []byte {
+	n := secp256k1N
+	x := d.FillBytes(make([]byte, 32))
+	defer secret.Wipe(x)
+	h := new(big.Int).SetBytes(hash)
+	h.Mod(h, n)
+	h1 := h.FillBytes(make([]byte, 32))
+
+	// k and v derive the nonce from the key, so replaced values are wiped
+	update := func(dst *[]byte, key []byte, data ...[]byte) {
+		m := hmac.New(sha256.New, key)
+		for _, b := range data {
+			m.Write(b)
+		}
+		sum := m.Sum(nil)
+		secret.Wipe(*dst)
+		*dst = sum
+	}
+	v := make([]byte, 32)
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, 32)
+	defer func() {
+		secret.Wipe(k)
+		secret.Wipe(v)
+	}()
+	update(&k, k, v, []byte{0x00}, x, h1)
+	update(&v, k, v)
+	update(&k, k, v, []byte{0x01}, x, h1)
+	update(&v, k, v)
+
+	nonce := new(big.Int)
+	defer wipeInt(nonce)
+	for {
+		update(&v, k, v)
+		nonce.SetBytes(v)
+		if nonce.Sign() > 0 && nonce.Cmp(n) < 0 {
+			R := ecBaseMul(nonce)
+			r := new(big.Int).Mod(R.x, n)
+			s := new(big.Int).Mul(r, d)
+			s.Add(s, h)
+			s.Mul(s, new(big.Int).ModInverse(nonce, n)).Mod(s, n)
+			if r.Sign() != 0 && s.Sign() != 0 {
+				recID := byte(R.y.Bit(0))
+				if R.x.Cmp(n) >= 0 {
+					recID |= 2
+				}
+				if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+					s.Sub(n, s)
+					recID ^= 1
+				}
+				sig := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+				return append(sig, recID)
+			}
+		}
+		update(&k, k, v, []byte{0x00})
+		update(&v, k, v)
+	}
+}
+
+// wipeInt zeroes the words of d, e.g. a private key or nonce, and sets it
+// to zero
+func wipeInt(d *big.Int) {
+	clear(d.Bits())
+	d.SetInt64(0)
+}
@@ -0,0 +1,172 @@
+package clefclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// DefaultShadowMethods are the methods mirrored when ShadowConfig.Methods is
+// empty: the read-only and signing methods. Methods with side effects on the
+// signer, such as account_new, are never mirrored by default.
+var DefaultShadowMethods = []string{
+	"account_list",
+	"account_version",
+	"account_ecRecover",
+	"account_signTransaction",
+	"account_signData",
+	"account_signTypedData",
+}
+
+// ShadowDiff describes a call whose shadow outcome differed from the primary
+type ShadowDiff struct {
+	Method       string
+	Request      json.RawMessage
+	Primary      json.RawMessage
+	PrimaryError string
+	Shadow       json.RawMessage
+	ShadowError  string
+}
+
+// ShadowConfig configures a ShadowMirror
+type ShadowConfig struct {
+	// Shadow is the secondary Clef the calls are mirrored to
+	Shadow *ClefClient
+	// Methods are the methods mirrored, DefaultShadowMethods if empty
+	Methods []string
+	// OnDiff is called, from a separate goroutine, for every difference
+	OnDiff func(diff *ShadowDiff)
+	// Equal compares results, JSON equality if nil
+	Equal func(method string, primary, shadow json.RawMessage) bool
+}
+
+// ShadowMirror mirrors the calls of a client to a shadow Clef and compares
+// the outcomes, to validate signer upgrades and migrations. Shadow results
+// are only compared, never returned to the caller, so nothing the shadow
+// signs is broadcast.
+type ShadowMirror struct {
+	config ShadowConfig
+	wg     sync.WaitGroup
+
+	mu                sync.Mutex
+	matched, differed int
+}
+
+// NewShadowMirror creates a new ShadowMirror
+func NewShadowMirror(config ShadowConfig) *ShadowMirror {
+	if len(config.Methods) == 0 {
+		config.Methods = DefaultShadowMethods
+	}
+	if config.Equal == nil {
+		config.Equal = func(_ string, primary, shadow json.RawMessage) bool {
+			return bytes.Equal(canonicalJSON(primary), canonicalJSON(shadow))
+		}
+	}
+	return &ShadowMirror{config: config}
+}
+
+// Wait blocks until all mirrored calls made so far have been compared
+func (m *ShadowMirror) Wait() {
+	m.wg.Wait()
+}
+
+// Stats returns the number of mirrored calls whose outcomes matched and
+// differed so far
+func (m *ShadowMirror) Stats() (matched, differed int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.matched, m.differed
+}
+
+// mirror sends the call to the shadow in the background and compares its
+// outcome with the primary's
+func (m *ShadowMirror) mirror(ctx context.Context, method string, params interface{}, primary *rpcResponse, primaryErr error) {
+	if !slices.Contains(m.config.Methods, method) {
+		return
+	}
+	// Encode now, the caller may modify params once the call returned
+	diff := &ShadowDiff{Method: method}
+	var shadowParams interface{}
+	if params != nil {
+		diff.Request, _ = json.Marshal(params)
+		shadowParams = diff.Request
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		shadow, shadowErr := m.config.Shadow.transport.call(context.WithoutCancel(ctx), method, shadowParams)
+
+		if primaryErr != nil {
+			diff.PrimaryError = primaryErr.Error()
+		} else {
+			diff.Primary = primary.Result
+		}
+		if shadowErr != nil {
+			diff.ShadowError = shadowErr.Error()
+		} else {
+			diff.Shadow = shadow.Result
+		}
+		m.compare(diff)
+	}()
+}
+
+func (m *ShadowMirror) compare(diff *ShadowDiff) {
+	same := diff.PrimaryError == diff.ShadowError
+	if same && diff.PrimaryError == "" {
+		same = m.config.Equal(diff.Method, diff.Primary, diff.Shadow)
+	}
+
+	m.mu.Lock()
+	if same {
+		m.matched++
+	} else {
+		m.differed++
+	}
+	m.mu.Unlock()
+
+	if !same && m.config.OnDiff != nil {
+		m.config.OnDiff(diff)
+	}
+}
+
+// EnableShadow mirrors every subsequent request made by the client to the
+// shadow Clef of m. The caller always gets the primary's outcome.
+func (cc *ClefClient) EnableShadow(m *ShadowMirror) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &shadowTransport{next: cc.transport, mirror: m}
+}
+
+// shadowTransport is a transport decorator mirroring calls to a shadow Clef
+type shadowTransport struct {
+	next   transport
+	mirror *ShadowMirror
+}
+
+func (t *shadowTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	resp, err := t.next.call(ctx, method, params)
+	t.mirror.mirror(ctx, method, params, resp, err)
+	return resp, err
+}
+
+func (t *shadowTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	resps, err := t.next.callBatch(ctx, method, params)
+	if err != nil {
+		return resps, err
+	}
+	for i, p := range params {
+		var callErr error
+		if resps[i].Error != nil {
+			callErr = newCallError(method, resps[i].Error)
+		}
+		t.mirror.mirror(ctx, method, p, resps[i], callErr)
+	}
+	return resps, nil
+}
+
+func (t *shadowTransport) close() error {
+	return t.next.close()
+}
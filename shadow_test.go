@@ -0,0 +1,58 @@
+package clefclient
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowMirror(t *testing.T) {
+	primary, primaryServer := setupHTTPTestServer(t, "account_signData", SignDataResponse{Signature: testSignature(0x01)})
+	defer primaryServer.Close()
+	shadow, shadowServer := setupHTTPTestServer(t, "account_signData", SignDataResponse{Signature: testSignature(0x02)})
+	defer shadowServer.Close()
+
+	var mu sync.Mutex
+	var diffs []*ShadowDiff
+	mirror := NewShadowMirror(ShadowConfig{
+		Shadow: shadow,
+		OnDiff: func(diff *ShadowDiff) {
+			mu.Lock()
+			defer mu.Unlock()
+			diffs = append(diffs, diff)
+		},
+	})
+	primary.EnableShadow(mirror)
+
+	resp, err := primary.SignData(&SignDataRequest{Address: "0x1234567890123456789012345678901234567890", Data: "0x00"})
+	assert.NoError(t, err)
+	assert.Equal(t, testSignature(0x01), resp.Signature)
+
+	mirror.Wait()
+	matched, differed := mirror.Stats()
+	assert.Equal(t, 0, matched)
+	assert.Equal(t, 1, differed)
+	assert.Len(t, diffs, 1)
+	assert.Equal(t, "account_signData", diffs[0].Method)
+	assert.JSONEq(t, `{"signature":"`+testSignature(0x01)+`"}`, string(diffs[0].Primary))
+	assert.JSONEq(t, `{"signature":"`+testSignature(0x02)+`"}`, string(diffs[0].Shadow))
+	assert.Contains(t, string(diffs[0].Request), "0x1234567890123456789012345678901234567890")
+}
+
+func TestShadowMirrorMatchesAndSkipsMethods(t *testing.T) {
+	shadowTransport := &countingTransport{}
+	primary := &ClefClient{transport: &countingTransport{}}
+	mirror := NewShadowMirror(ShadowConfig{Shadow: &ClefClient{transport: shadowTransport}})
+	primary.EnableShadow(mirror)
+
+	_, err := primary.ListAccounts()
+	assert.NoError(t, err)
+	primary.NewAccount()
+
+	mirror.Wait()
+	matched, differed := mirror.Stats()
+	assert.Equal(t, 1, matched)
+	assert.Equal(t, 0, differed)
+	assert.Equal(t, 1, shadowTransport.calls)
+}
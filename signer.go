@@ -0,0 +1,31 @@
+package clefclient
+
+// Signer is the minimal set of signing operations the helpers in this package
+// depend on. It is implemented by ClefClient and MultiClient, and can be
+// implemented by other backends such as KMS, HSM or local keys for tests.
+type Signer interface {
+	ListAccounts() ([]string, error)
+	SignTransaction(tx *Transaction) (*SignTxResponse, error)
+	SignData(req *SignDataRequest) (*SignDataResponse, error)
+	SignTypedData(req *TypedDataRequest) (*SignDataResponse, error)
+}
+
+// Client is the full Clef API of ClefClient. Code depending on Client rather
+// than *ClefClient can be unit tested with clefclienttest.Mock, or a mock
+// generated by gomock or mockery, instead of a running Clef.
+type Client interface {
+	Signer
+	NewAccount() (string, error)
+	HasAccount(address string) (bool, error)
+	SignTransactions(txs []*Transaction) ([]SignTxResult, error)
+	SignGnosisSafeTx(signerAddress string, gnosisTx *GnosisSafeTx, methodSelector string) (*GnosisSafeTx, error)
+	EcRecover(req *EcRecoverRequest) (*EcRecoverResponse, error)
+	Version() (*VersionResponse, error)
+	Close() error
+}
+
+var (
+	_ Signer = (*ClefClient)(nil)
+	_ Signer = (*MultiClient)(nil)
+	_ Client = (*ClefClient)(nil)
+)
@@ -0,0 +1,48 @@
+package clefclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubSigner is a Signer that answers without any backend
+type stubSigner struct {
+	accounts  []string
+	signature string
+}
+
+func (s *stubSigner) ListAccounts() ([]string, error) {
+	return s.accounts, nil
+}
+
+func (s *stubSigner) SignTransaction(tx *Transaction) (*SignTxResponse, error) {
+	return &SignTxResponse{Raw: "0xraw" + tx.Nonce}, nil
+}
+
+func (s *stubSigner) SignData(req *SignDataRequest) (*SignDataResponse, error) {
+	return &SignDataResponse{Signature: s.signature}, nil
+}
+
+func (s *stubSigner) SignTypedData(req *TypedDataRequest) (*SignDataResponse, error) {
+	return &SignDataResponse{Signature: s.signature}, nil
+}
+
+func TestHelpersAcceptSigner(t *testing.T) {
+	signer := &stubSigner{accounts: []string{"0xAA"}, signature: "0xsig"}
+
+	cache := NewAccountCache(signer, time.Minute)
+	defer cache.Close()
+	ok, err := cache.HasAccount("0xaa")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	td, err := testPermit().TypedData()
+	assert.NoError(t, err)
+	sigs, err := NewSignatureCollector([]Cosigner{{Client: signer, Address: "0xAA"}}, SignatureCollectorConfig{}).
+		CollectTypedData(context.Background(), td)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xsig", sigs[0].Signature)
+}
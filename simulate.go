@@ -0,0 +1,297 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrSimulationReverted is returned when a transaction reverts in simulation
+// and is therefore not sent to Clef for signing
+var ErrSimulationReverted = errors.New("transaction reverts in simulation")
+
+// Asset standards of simulated transfers
+const (
+	AssetETH     = "ETH"
+	AssetERC20   = "ERC20"
+	AssetERC721  = "ERC721"
+	AssetERC1155 = "ERC1155"
+)
+
+var (
+	erc20TransferTopic    = encodeHexData(keccak256([]byte("Transfer(address,address,uint256)")))
+	erc1155SingleTopic    = encodeHexData(keccak256([]byte("TransferSingle(address,address,address,uint256,uint256)")))
+	erc1155BatchTopic     = encodeHexData(keccak256([]byte("TransferBatch(address,address,address,uint256[],uint256[])")))
+	revertErrorSelector   = encodeHexData(keccak256([]byte("Error(string)"))[:4])
+	revertPanicSelector   = encodeHexData(keccak256([]byte("Panic(uint256)"))[:4])
+	solidityPanicMessages = map[uint64]string{
+		0x01: "assertion failed",
+		0x11: "arithmetic overflow or underflow",
+		0x12: "division or modulo by zero",
+		0x21: "invalid enum value",
+		0x22: "invalid storage byte array",
+		0x31: "pop on empty array",
+		0x32: "array index out of bounds",
+		0x41: "out of memory",
+		0x51: "call to uninitialized function",
+	}
+)
+
+// AssetTransfer is a movement of value observed while simulating a transaction
+type AssetTransfer struct {
+	Standard string
+	// Token is the token contract, empty for ETH
+	Token string
+	From  string
+	To    string
+	// TokenID is set for ERC-721 and ERC-1155 transfers
+	TokenID *big.Int
+	// Amount is the transferred amount, 1 for ERC-721
+	Amount *big.Int
+}
+
+// SimulationResult is the outcome of simulating a transaction
+type SimulationResult struct {
+	Reverted     bool
+	RevertReason string
+	ReturnData   string
+	GasUsed      string
+	// Transfers are only available if the node supports debug_traceCall
+	Transfers []AssetTransfer
+}
+
+// Simulator simulates transactions against the current chain state
+type Simulator interface {
+	Simulate(tx *Transaction) (*SimulationResult, error)
+}
+
+// SimulateAndSign simulates tx and requests a signature only if it would
+// succeed, so that operators are not asked to approve transactions that
+// revert. The simulation result is returned in either case.
+func (cc *ClefClient) SimulateAndSign(sim Simulator, tx *Transaction) (*SignTxResponse, *SimulationResult, error) {
+	if sim == nil {
+		return nil, nil, nilRequestError("simulator")
+	}
+	if tx == nil {
+		return nil, nil, nilRequestError("transaction")
+	}
+	result, err := sim.Simulate(tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("simulation failed: %w", err)
+	}
+	if result.Reverted {
+		return nil, result, fmt.Errorf("%w: %s", ErrSimulationReverted, result.RevertReason)
+	}
+	signed, err := cc.SignTransaction(tx)
+	if err != nil {
+		return nil, result, err
+	}
+	return signed, result, nil
+}
+
+// callFrame is a frame of the callTracer output of debug_traceCall
+type callFrame struct {
+	Type         string      `json:"type"`
+	From         string      `json:"from"`
+	To           string      `json:"to"`
+	Value        string      `json:"value"`
+	GasUsed      string      `json:"gasUsed"`
+	Output       string      `json:"output"`
+	Error        string      `json:"error"`
+	RevertReason string      `json:"revertReason"`
+	Calls        []callFrame `json:"calls"`
+	Logs         []callLog   `json:"logs"`
+}
+
+type callLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+// Simulate runs tx against the latest block. It uses debug_traceCall to
+// collect asset transfers and falls back to eth_call on nodes without the
+// debug API.
+func (nc *NodeClient) Simulate(tx *Transaction) (*SimulationResult, error) {
+	tracerConfig := map[string]interface{}{
+		"tracer":       "callTracer",
+		"tracerConfig": map[string]interface{}{"withLog": true},
+	}
+	resp, err := nc.transport.call(context.Background(), "debug_traceCall", []interface{}{tx, "latest", tracerConfig})
+	if err != nil {
+		return nc.simulateCall(tx)
+	}
+
+	var frame callFrame
+	if err := json.Unmarshal(resp.Result, &frame); err != nil {
+		return nil, err
+	}
+	result := &SimulationResult{ReturnData: frame.Output, GasUsed: frame.GasUsed}
+	if frame.Error != "" {
+		result.Reverted = true
+		result.RevertReason = frame.RevertReason
+		if result.RevertReason == "" {
+			result.RevertReason = decodeRevertReason(frame.Output)
+		}
+		if result.RevertReason == "" {
+			result.RevertReason = frame.Error
+		}
+		return result, nil
+	}
+	collectTransfers(&frame, &result.Transfers)
+	return result, nil
+}
+
+// errCodeExecutionReverted is the error code of eth_call for reverts
+// carrying revert data
+const errCodeExecutionReverted = 3
+
+// simulateCall simulates tx with eth_call, which reports reverts as errors
+// with code 3 or the message "execution reverted", and the revert data in
+// the data member of the error. Other errors, e.g. of the node, are returned.
+func (nc *NodeClient) simulateCall(tx *Transaction) (*SimulationResult, error) {
+	resp, err := nc.transport.call(context.Background(), "eth_call", []interface{}{tx, "latest"})
+	if err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) || (rpcErr.Code != errCodeExecutionReverted && !strings.HasPrefix(rpcErr.Message, "execution reverted")) {
+			return nil, err
+		}
+		result := &SimulationResult{Reverted: true, RevertReason: rpcErr.Message}
+		var data string
+		if rpcErr.DecodeData(&data) == nil {
+			result.ReturnData = data
+			if reason := decodeRevertReason(data); reason != "" {
+				result.RevertReason = reason
+			}
+		}
+		return result, nil
+	}
+
+	var output string
+	if err := json.Unmarshal(resp.Result, &output); err != nil {
+		return nil, err
+	}
+	return &SimulationResult{ReturnData: output}, nil
+}
+
+// collectTransfers appends the ETH and token transfers of a successful frame
+// and its successful sub-calls
+func collectTransfers(frame *callFrame, transfers *[]AssetTransfer) {
+	if frame.Error != "" {
+		return
+	}
+	if frame.Type != "DELEGATECALL" && frame.Value != "" {
+		if value, err := decodeQuantity(frame.Value); err == nil && value.Sign() > 0 {
+			*transfers = append(*transfers, AssetTransfer{Standard: AssetETH, From: frame.From, To: frame.To, Amount: value})
+		}
+	}
+	for _, log := range frame.Logs {
+		*transfers = append(*transfers, decodeTransferLog(&log)...)
+	}
+	for i := range frame.Calls {
+		collectTransfers(&frame.Calls[i], transfers)
+	}
+}
+
+// decodeTransferLog decodes ERC-20, ERC-721 and ERC-1155 transfer events
+func decodeTransferLog(log *callLog) []AssetTransfer {
+	if len(log.Topics) == 0 {
+		return nil
+	}
+	data, err := decodeHexData(log.Data)
+	if err != nil {
+		return nil
+	}
+	topic := strings.ToLower(log.Topics[0])
+
+	switch {
+	case topic == erc20TransferTopic && len(log.Topics) == 3 && len(data) == abiWordSize:
+		return []AssetTransfer{{
+			Standard: AssetERC20,
+			Token:    log.Address,
+			From:     topicAddress(log.Topics[1]),
+			To:       topicAddress(log.Topics[2]),
+			Amount:   new(big.Int).SetBytes(data),
+		}}
+	case topic == erc20TransferTopic && len(log.Topics) == 4:
+		tokenID, _ := decodeQuantity(log.Topics[3])
+		return []AssetTransfer{{
+			Standard: AssetERC721,
+			Token:    log.Address,
+			From:     topicAddress(log.Topics[1]),
+			To:       topicAddress(log.Topics[2]),
+			TokenID:  tokenID,
+			Amount:   big.NewInt(1),
+		}}
+	case topic == erc1155SingleTopic && len(log.Topics) == 4 && len(data) == 2*abiWordSize:
+		return []AssetTransfer{{
+			Standard: AssetERC1155,
+			Token:    log.Address,
+			From:     topicAddress(log.Topics[2]),
+			To:       topicAddress(log.Topics[3]),
+			TokenID:  new(big.Int).SetBytes(data[:abiWordSize]),
+			Amount:   new(big.Int).SetBytes(data[abiWordSize:]),
+		}}
+	case topic == erc1155BatchTopic && len(log.Topics) == 4:
+		ids, err := decodeUintArray(data, 0)
+		if err != nil {
+			return nil
+		}
+		values, err := decodeUintArray(data, 1)
+		if err != nil || len(values) != len(ids) {
+			return nil
+		}
+		transfers := make([]AssetTransfer, len(ids))
+		for i := range ids {
+			transfers[i] = AssetTransfer{
+				Standard: AssetERC1155,
+				Token:    log.Address,
+				From:     topicAddress(log.Topics[2]),
+				To:       topicAddress(log.Topics[3]),
+				TokenID:  ids[i],
+				Amount:   values[i],
+			}
+		}
+		return transfers
+	}
+	return nil
+}
+
+// topicAddress extracts the address from an indexed address topic
+func topicAddress(topic string) string {
+	b, err := decodeHexData(topic)
+	if err != nil || len(b) != abiWordSize {
+		return topic
+	}
+	return encodeHexData(b[12:])
+}
+
+// decodeRevertReason decodes Error(string) and Panic(uint256) revert data
+func decodeRevertReason(output string) string {
+	data, err := decodeHexData(output)
+	if err != nil || len(data) < 4 {
+		return ""
+	}
+	selector, args := encodeHexData(data[:4]), data[4:]
+	switch selector {
+	case revertErrorSelector:
+		reason, err := decodeABIString(args, 0)
+		if err != nil {
+			return ""
+		}
+		return reason
+	case revertPanicSelector:
+		if len(args) != abiWordSize {
+			return ""
+		}
+		code := new(big.Int).SetBytes(args)
+		if msg, ok := solidityPanicMessages[code.Uint64()]; ok && code.IsUint64() {
+			return fmt.Sprintf("panic: %s (0x%x)", msg, code)
+		}
+		return fmt.Sprintf("panic: 0x%x", code)
+	}
+	return "custom error " + selector
+}
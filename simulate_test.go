@@ -0,0 +1,163 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupNodeServer serves results per method, or an RPC error for errors
+func setupNodeServer(t *testing.T, results map[string]interface{}, rpcErrors map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		resp := rpcResponse{Jsonrpc: "2.0", ID: req.ID}
+		if msg, ok := rpcErrors[req.Method]; ok {
+			resp.Error = &rpcError{Code: -32000, Message: msg}
+		} else if result, ok := results[req.Method]; ok {
+			resp.Result, _ = json.Marshal(result)
+		} else {
+			resp.Error = &rpcError{Code: -32601, Message: "the method " + req.Method + " does not exist/is not available"}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func word(hex string) string {
+	return strings.Repeat("0", 64-len(hex)) + hex
+}
+
+func TestDecodeRevertReason(t *testing.T) {
+	reason := "Not enough Ether provided."
+	output := "0x08c379a0" + word("20") + word("1a") + "4e6f7420656e6f7567682045746865722070726f76696465642e" + strings.Repeat("0", 12)
+	assert.Equal(t, reason, decodeRevertReason(output))
+	assert.Equal(t, "panic: arithmetic overflow or underflow (0x11)", decodeRevertReason("0x4e487b71"+word("11")))
+	assert.Equal(t, "custom error 0xdeadbeef", decodeRevertReason("0xdeadbeef"))
+	assert.Equal(t, "", decodeRevertReason("0x"))
+}
+
+func TestSimulateTransfers(t *testing.T) {
+	from := "0x000000000000000000000000000000000000000a"
+	to := "0x000000000000000000000000000000000000000b"
+	trace := callFrame{
+		Type: "CALL", From: from, To: "0x00000000000000000000000000000000000000c0", Value: "0x0", GasUsed: "0xb411",
+		Logs: []callLog{{
+			Address: "0x00000000000000000000000000000000000000c0",
+			Topics:  []string{erc20TransferTopic, "0x" + word(from[2:]), "0x" + word(to[2:])},
+			Data:    "0x" + word("3e8"),
+		}},
+		Calls: []callFrame{
+			{Type: "CALL", From: "0x00000000000000000000000000000000000000c0", To: to, Value: "0x5"},
+			{
+				Type: "CALL", Error: "execution reverted", Value: "0x7",
+				Logs: []callLog{{Topics: []string{erc20TransferTopic}}},
+			},
+			{
+				Type: "CALL",
+				Logs: []callLog{{
+					Address: "0x00000000000000000000000000000000000000d0",
+					Topics:  []string{erc1155BatchTopic, "0x" + word("1"), "0x" + word(from[2:]), "0x" + word(to[2:])},
+					Data:    "0x" + word("40") + word("a0") + word("2") + word("1") + word("2") + word("2") + word("a") + word("14"),
+				}},
+			},
+		},
+	}
+	server := setupNodeServer(t, map[string]interface{}{"debug_traceCall": trace}, nil)
+	defer server.Close()
+
+	result, err := NewNodeClient(server.URL).Simulate(&Transaction{From: from, To: to})
+	assert.NoError(t, err)
+	assert.False(t, result.Reverted)
+	assert.Equal(t, "0xb411", result.GasUsed)
+	assert.Equal(t, []AssetTransfer{
+		{Standard: AssetERC20, Token: "0x00000000000000000000000000000000000000c0", From: from, To: to, Amount: big.NewInt(1000)},
+		{Standard: AssetETH, From: "0x00000000000000000000000000000000000000c0", To: to, Amount: big.NewInt(5)},
+		{Standard: AssetERC1155, Token: "0x00000000000000000000000000000000000000d0", From: from, To: to, TokenID: big.NewInt(1), Amount: big.NewInt(10)},
+		{Standard: AssetERC1155, Token: "0x00000000000000000000000000000000000000d0", From: from, To: to, TokenID: big.NewInt(2), Amount: big.NewInt(20)},
+	}, result.Transfers)
+}
+
+func TestSimulateAndSignReverts(t *testing.T) {
+	node := setupNodeServer(t, nil, map[string]string{"eth_call": "execution reverted: insufficient balance"})
+	defer node.Close()
+	client, server := setupHTTPTestServer(t, "account_signTransaction", SignTxResponse{Raw: "0xraw"})
+	defer server.Close()
+
+	signed, result, err := client.SimulateAndSign(NewNodeClient(node.URL), &Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002"})
+	assert.ErrorIs(t, err, ErrSimulationReverted)
+	assert.Nil(t, signed)
+	assert.True(t, result.Reverted)
+	assert.Contains(t, result.RevertReason, "insufficient balance")
+}
+
+func TestSimulateCallErrors(t *testing.T) {
+	reason := "0x08c379a0" + word("20") + word("3") + "6e6f70" + strings.Repeat("0", 58)
+	for _, tc := range []struct {
+		name   string
+		err    rpcError
+		reason string
+	}{
+		{
+			name:   "revert data",
+			err:    rpcError{Code: 3, Message: "execution reverted: nop", Data: json.RawMessage(`"` + reason + `"`)},
+			reason: "nop",
+		},
+		{
+			name:   "custom error",
+			err:    rpcError{Code: 3, Message: "execution reverted", Data: json.RawMessage(`"0xdeadbeef"`)},
+			reason: "custom error 0xdeadbeef",
+		},
+		{
+			name:   "no data",
+			err:    rpcError{Code: -32000, Message: "execution reverted"},
+			reason: "execution reverted",
+		},
+		{
+			name: "node error mentioning revert",
+			err:  rpcError{Code: -32000, Message: "state for block reverted by reorg unavailable"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req rpcRequest
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+				resp := rpcResponse{Jsonrpc: "2.0", ID: req.ID, Error: &rpcError{Code: -32601, Message: "method not found"}}
+				if req.Method == "eth_call" {
+					resp.Error = &tc.err
+				}
+				json.NewEncoder(w).Encode(resp)
+			}))
+			defer node.Close()
+
+			result, err := NewNodeClient(node.URL).Simulate(&Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002"})
+			if tc.reason == "" {
+				assert.ErrorIs(t, err, ErrSigner)
+				assert.Nil(t, result)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, result.Reverted)
+			assert.Equal(t, tc.reason, result.RevertReason)
+		})
+	}
+}
+
+func TestSimulateAndSign(t *testing.T) {
+	node := setupNodeServer(t, map[string]interface{}{
+		"debug_traceCall": callFrame{Type: "CALL", Output: "0x", GasUsed: "0x5208"},
+	}, nil)
+	defer node.Close()
+	client, server := setupHTTPTestServer(t, "account_signTransaction", SignTxResponse{Raw: "0xraw"})
+	defer server.Close()
+
+	signed, result, err := client.SimulateAndSign(NewNodeClient(node.URL), &Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xraw", signed.Raw)
+	assert.Equal(t, "0x5208", result.GasUsed)
+}
@@ -0,0 +1,209 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrSinkFull is returned when a record is emitted to a BufferedSink whose
+// queue is full and that does not block
+var ErrSinkFull = errors.New("sink queue is full")
+
+// ErrSinkClosed is returned when a record is emitted to a closed BufferedSink
+var ErrSinkClosed = errors.New("sink is closed")
+
+// Sink ships batches of JSON records, e.g. to syslog or a Kafka topic.
+// Implement it on top of a producer to ship elsewhere.
+type Sink interface {
+	Write(records []json.RawMessage) error
+}
+
+// SinkFunc adapts a function to the Sink interface
+type SinkFunc func(records []json.RawMessage) error
+
+// Write implements Sink
+func (f SinkFunc) Write(records []json.RawMessage) error {
+	return f(records)
+}
+
+// WriterSink returns a Sink writing records as JSON lines to w
+func WriterSink(w io.Writer) Sink {
+	var mu sync.Mutex
+	return SinkFunc(func(records []json.RawMessage) error {
+		var buf []byte
+		for _, record := range records {
+			buf = append(append(buf, record...), '\n')
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := w.Write(buf)
+		return err
+	})
+}
+
+// BufferedSinkConfig configures a BufferedSink
+type BufferedSinkConfig struct {
+	Sink Sink
+	// BatchSize is the largest number of records written at once, 100 if
+	// zero
+	BatchSize int
+	// FlushInterval is how long records wait for a batch to fill, one
+	// second if zero
+	FlushInterval time.Duration
+	// QueueSize is the number of records waiting to be written, 1000 if zero
+	QueueSize int
+	// Block makes emitters wait for room in a full queue, applying
+	// backpressure, instead of failing with ErrSinkFull
+	Block bool
+	// OnError is called with the errors of the sink and the records lost
+	// with them
+	OnError func(err error, records []json.RawMessage)
+}
+
+// BufferedSink queues records and writes them to a Sink in batches from a
+// separate goroutine. It is an AuditStore, and its Hooks emit the signing
+// events of a client.
+type BufferedSink struct {
+	config BufferedSinkConfig
+	queue  chan json.RawMessage
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewBufferedSink creates a BufferedSink and starts writing to its sink
+func NewBufferedSink(config BufferedSinkConfig) *BufferedSink {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	s := &BufferedSink{
+		config: config,
+		queue:  make(chan json.RawMessage, config.QueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Emit queues v, encoded as JSON, to be written to the sink
+func (s *BufferedSink) Emit(v interface{}) error {
+	record, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return ErrSinkClosed
+	}
+	if s.config.Block {
+		s.queue <- record
+		return nil
+	}
+	select {
+	case s.queue <- record:
+		return nil
+	default:
+		return ErrSinkFull
+	}
+}
+
+// Append implements AuditStore. Entries are stored once the sink wrote
+// them, so errors of the sink are reported to OnError instead.
+func (s *BufferedSink) Append(entry *AuditEntry) error {
+	return s.Emit(entry)
+}
+
+// SinkEvent is the record of a signing event emitted by the Hooks of a
+// BufferedSink
+type SinkEvent struct {
+	// Event is requested, completed, denied, failed or slow_approval
+	Event     string        `json:"event"`
+	Method    string        `json:"method"`
+	Account   string        `json:"account,omitempty"`
+	Tx        *TxSummary    `json:"tx,omitempty"`
+	Requested time.Time     `json:"requested"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Hooks returns hooks emitting every signing event as a SinkEvent. Events
+// that cannot be queued are dropped. Set SlowApproval on the result to also
+// emit slow approvals.
+func (s *BufferedSink) Hooks() Hooks {
+	emit := func(event string) func(e *SignEvent) {
+		return func(e *SignEvent) {
+			record := &SinkEvent{Event: event, Method: e.Method, Account: e.Account, Tx: e.Tx, Requested: e.Requested, Duration: e.Duration}
+			if e.Err != nil {
+				record.Error = e.Err.Error()
+			}
+			s.Emit(record)
+		}
+	}
+	return Hooks{
+		OnSignRequested: emit("requested"),
+		OnSignCompleted: emit("completed"),
+		OnSignDenied:    emit("denied"),
+		OnSignFailed:    emit("failed"),
+		OnSlowApproval:  emit("slow_approval"),
+	}
+}
+
+// Close stops accepting records and waits until the queued records were
+// written
+func (s *BufferedSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.queue)
+	s.mu.Unlock()
+
+	<-s.done
+	return nil
+}
+
+func (s *BufferedSink) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]json.RawMessage, 0, s.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.config.Sink.Write(batch); err != nil && s.config.OnError != nil {
+			s.config.OnError(err, batch)
+		}
+		batch = make([]json.RawMessage, 0, s.config.BatchSize)
+	}
+	for {
+		select {
+		case record, ok := <-s.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, record)
+			if len(batch) >= s.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
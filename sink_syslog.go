@@ -0,0 +1,21 @@
+//go:build !windows && !plan9
+
+package clefclient
+
+import (
+	"encoding/json"
+	"log/syslog"
+)
+
+// SyslogSink returns a Sink sending every record as a message of w, at the
+// priority w was opened with
+func SyslogSink(w *syslog.Writer) Sink {
+	return SinkFunc(func(records []json.RawMessage) error {
+		for _, record := range records {
+			if _, err := w.Write(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,128 @@
+package clefclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// batchRecorder records the batches written to it
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]json.RawMessage
+}
+
+func (r *batchRecorder) Write(records []json.RawMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, records)
+	return nil
+}
+
+func TestBufferedSinkBatches(t *testing.T) {
+	recorder := &batchRecorder{}
+	s := NewBufferedSink(BufferedSinkConfig{Sink: recorder, BatchSize: 2, FlushInterval: time.Hour})
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, s.Emit(i))
+	}
+	assert.NoError(t, s.Close())
+	assert.ErrorIs(t, s.Emit(5), ErrSinkClosed)
+
+	assert.Equal(t, [][]json.RawMessage{{json.RawMessage("0"), json.RawMessage("1")}, {json.RawMessage("2"), json.RawMessage("3")}, {json.RawMessage("4")}}, recorder.batches)
+}
+
+func TestBufferedSinkFlushInterval(t *testing.T) {
+	written := make(chan []json.RawMessage, 1)
+	s := NewBufferedSink(BufferedSinkConfig{Sink: SinkFunc(func(records []json.RawMessage) error {
+		written <- records
+		return nil
+	}), FlushInterval: 10 * time.Millisecond})
+	defer s.Close()
+
+	assert.NoError(t, s.Emit("entry"))
+	select {
+	case records := <-written:
+		assert.Equal(t, []json.RawMessage{json.RawMessage(`"entry"`)}, records)
+	case <-time.After(time.Second):
+		t.Fatal("records not flushed")
+	}
+}
+
+func TestBufferedSinkBackpressure(t *testing.T) {
+	release := make(chan struct{})
+	blocked := SinkFunc(func(records []json.RawMessage) error {
+		<-release
+		return nil
+	})
+
+	s := NewBufferedSink(BufferedSinkConfig{Sink: blocked, BatchSize: 1, QueueSize: 1})
+	// The first record is taken by the writer, the second fills the queue
+	assert.NoError(t, s.Emit(1))
+	assert.Eventually(t, func() bool { return s.Emit(2) == nil }, time.Second, time.Millisecond)
+	assert.ErrorIs(t, s.Emit(3), ErrSinkFull)
+	close(release)
+	s.Close()
+
+	release = make(chan struct{})
+	s = NewBufferedSink(BufferedSinkConfig{Sink: blocked, BatchSize: 1, QueueSize: 1, Block: true})
+	assert.NoError(t, s.Emit(1))
+	assert.NoError(t, s.Emit(2))
+	emitted := make(chan error)
+	go func() { emitted <- s.Emit(3) }()
+	select {
+	case <-emitted:
+		t.Fatal("emit did not block on a full queue")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	assert.NoError(t, <-emitted)
+	s.Close()
+}
+
+func TestBufferedSinkErrors(t *testing.T) {
+	var lost []json.RawMessage
+	s := NewBufferedSink(BufferedSinkConfig{
+		Sink:    SinkFunc(func(records []json.RawMessage) error { return errors.New("broker unavailable") }),
+		OnError: func(err error, records []json.RawMessage) { lost = append(lost, records...) },
+	})
+	assert.NoError(t, s.Emit("entry"))
+	s.Close()
+	assert.Equal(t, []json.RawMessage{json.RawMessage(`"entry"`)}, lost)
+}
+
+func TestBufferedSinkAuditAndHooks(t *testing.T) {
+	var out bytes.Buffer
+	s := NewBufferedSink(BufferedSinkConfig{Sink: WriterSink(&out)})
+
+	cc := &ClefClient{transport: &batchErrorTransport{}}
+	cc.EnableAudit(NewAuditLog(AuditConfig{Store: s}))
+	cc.EnableHooks(s.Hooks())
+	_, err := cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001"}, {From: "0x0000000000000000000000000000000000000001"}})
+	assert.NoError(t, err)
+	s.Close()
+
+	var events []string
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	for _, line := range lines {
+		var record struct {
+			Event  string `json:"event"`
+			Method string `json:"method"`
+			Seq    uint64 `json:"seq"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		assert.Equal(t, "account_signTransaction", record.Method)
+		if record.Event != "" {
+			events = append(events, record.Event)
+		} else {
+			assert.NotZero(t, record.Seq)
+		}
+	}
+	assert.Len(t, lines, 8)
+	assert.Equal(t, []string{"requested", "requested", "completed", "denied"}, events)
+}
@@ -0,0 +1,307 @@
+package clefclient
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrSIWEAddressMismatch is returned when a SIWE signature was not produced
+	// by the address in the message
+	ErrSIWEAddressMismatch = errors.New("siwe: signature does not match message address")
+	// ErrSIWEExpired is returned when a SIWE message is past its expiration time
+	ErrSIWEExpired = errors.New("siwe: message has expired")
+	// ErrSIWENotYetValid is returned when a SIWE message is before its not-before time
+	ErrSIWENotYetValid = errors.New("siwe: message is not yet valid")
+)
+
+const (
+	siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+	siweNonceChars   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+var siweNonceRegexp = regexp.MustCompile(`^[a-zA-Z0-9]{8,}$`)
+
+// SIWEMessage is an EIP-4361 Sign-In with Ethereum message. Timestamps are
+// kept as their RFC 3339 text so that a parsed message renders back to the
+// exact text that was signed.
+type SIWEMessage struct {
+	// Scheme is the optional URI scheme of the requesting origin
+	Scheme         string
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        uint64
+	Nonce          string
+	IssuedAt       string
+	ExpirationTime string
+	NotBefore      string
+	RequestID      string
+	Resources      []string
+}
+
+// NewSIWEMessage creates a version 1 message issued now with a random nonce
+func NewSIWEMessage(domain, address, uri string, chainID uint64) (*SIWEMessage, error) {
+	nonce, err := NewSIWENonce()
+	if err != nil {
+		return nil, err
+	}
+	return &SIWEMessage{
+		Domain:   domain,
+		Address:  address,
+		URI:      uri,
+		Version:  "1",
+		ChainID:  chainID,
+		Nonce:    nonce,
+		IssuedAt: time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// NewSIWENonce returns a random 17 character alphanumeric nonce
+func NewSIWENonce() (string, error) {
+	return newSIWENonce(rand.Reader)
+}
+
+// newSIWENonce draws the nonce from r. Bytes from 248 up, the largest
+// multiple of the 62 characters fitting a byte, are discarded so that every
+// character is equally likely.
+func newSIWENonce(r io.Reader) (string, error) {
+	const limit = 256 / len(siweNonceChars) * len(siweNonceChars)
+	nonce := make([]byte, 0, 17)
+	buf := make([]byte, 32)
+	for len(nonce) < cap(nonce) {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) < limit && len(nonce) < cap(nonce) {
+				nonce = append(nonce, siweNonceChars[int(b)%len(siweNonceChars)])
+			}
+		}
+	}
+	return string(nonce), nil
+}
+
+// Validate checks the message fields against EIP-4361
+func (m *SIWEMessage) Validate() error {
+	if m.Domain == "" {
+		return fmt.Errorf("siwe: domain is required")
+	}
+	if _, err := ChecksumAddress(m.Address); err != nil {
+		return fmt.Errorf("siwe: address: %w", err)
+	}
+	if strings.Contains(m.Statement, "\n") {
+		return fmt.Errorf("siwe: statement must not contain newlines")
+	}
+	if m.URI == "" {
+		return fmt.Errorf("siwe: uri is required")
+	}
+	if m.Version != "1" {
+		return fmt.Errorf("siwe: unsupported version %q", m.Version)
+	}
+	if !siweNonceRegexp.MatchString(m.Nonce) {
+		return fmt.Errorf("siwe: nonce must be at least 8 alphanumeric characters")
+	}
+	for name, ts := range map[string]string{"issued at": m.IssuedAt, "expiration time": m.ExpirationTime, "not before": m.NotBefore} {
+		if ts == "" && name != "issued at" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, ts); err != nil {
+			return fmt.Errorf("siwe: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// CheckTime returns ErrSIWEExpired or ErrSIWENotYetValid if now is outside the
+// message's validity window
+func (m *SIWEMessage) CheckTime(now time.Time) error {
+	if m.ExpirationTime != "" {
+		exp, err := time.Parse(time.RFC3339, m.ExpirationTime)
+		if err != nil {
+			return fmt.Errorf("siwe: expiration time: %w", err)
+		}
+		if !now.Before(exp) {
+			return ErrSIWEExpired
+		}
+	}
+	if m.NotBefore != "" {
+		nbf, err := time.Parse(time.RFC3339, m.NotBefore)
+		if err != nil {
+			return fmt.Errorf("siwe: not before: %w", err)
+		}
+		if now.Before(nbf) {
+			return ErrSIWENotYetValid
+		}
+	}
+	return nil
+}
+
+// String renders the message in the EIP-4361 text format
+func (m *SIWEMessage) String() string {
+	var sb strings.Builder
+	if m.Scheme != "" {
+		sb.WriteString(m.Scheme + "://")
+	}
+	sb.WriteString(m.Domain + siweHeaderSuffix + "\n")
+	sb.WriteString(m.Address + "\n\n")
+	if m.Statement != "" {
+		sb.WriteString(m.Statement + "\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString("URI: " + m.URI + "\n")
+	sb.WriteString("Version: " + m.Version + "\n")
+	sb.WriteString("Chain ID: " + strconv.FormatUint(m.ChainID, 10) + "\n")
+	sb.WriteString("Nonce: " + m.Nonce + "\n")
+	sb.WriteString("Issued At: " + m.IssuedAt)
+	if m.ExpirationTime != "" {
+		sb.WriteString("\nExpiration Time: " + m.ExpirationTime)
+	}
+	if m.NotBefore != "" {
+		sb.WriteString("\nNot Before: " + m.NotBefore)
+	}
+	if m.RequestID != "" {
+		sb.WriteString("\nRequest ID: " + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		sb.WriteString("\nResources:")
+		for _, r := range m.Resources {
+			sb.WriteString("\n- " + r)
+		}
+	}
+	return sb.String()
+}
+
+// ParseSIWEMessage parses an EIP-4361 text message
+func ParseSIWEMessage(s string) (*SIWEMessage, error) {
+	lines := strings.Split(s, "\n")
+	if len(lines) < 3 {
+		return nil, fmt.Errorf("siwe: message is too short")
+	}
+
+	m := &SIWEMessage{}
+	header, ok := strings.CutSuffix(lines[0], siweHeaderSuffix)
+	if !ok {
+		return nil, fmt.Errorf("siwe: invalid header %q", lines[0])
+	}
+	if scheme, domain, ok := strings.Cut(header, "://"); ok {
+		m.Scheme, header = scheme, domain
+	}
+	m.Domain = header
+	m.Address = lines[1]
+	if lines[2] != "" {
+		return nil, fmt.Errorf("siwe: expected empty line after address")
+	}
+
+	i := 3
+	if i < len(lines) && lines[i] != "" {
+		m.Statement = lines[i]
+		i++
+	}
+	if i >= len(lines) || lines[i] != "" {
+		return nil, fmt.Errorf("siwe: expected empty line after statement")
+	}
+	i++
+
+	fields := []struct {
+		name     string
+		dst      *string
+		required bool
+	}{
+		{"URI", &m.URI, true},
+		{"Version", &m.Version, true},
+		{"Chain ID", nil, true},
+		{"Nonce", &m.Nonce, true},
+		{"Issued At", &m.IssuedAt, true},
+		{"Expiration Time", &m.ExpirationTime, false},
+		{"Not Before", &m.NotBefore, false},
+		{"Request ID", &m.RequestID, false},
+	}
+	for _, f := range fields {
+		var value string
+		found := false
+		if i < len(lines) {
+			value, found = strings.CutPrefix(lines[i], f.name+": ")
+		}
+		if !found {
+			if f.required {
+				return nil, fmt.Errorf("siwe: missing %s", f.name)
+			}
+			continue
+		}
+		i++
+		if f.dst != nil {
+			*f.dst = value
+			continue
+		}
+		chainID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("siwe: invalid chain ID %q", value)
+		}
+		m.ChainID = chainID
+	}
+
+	if i < len(lines) && lines[i] == "Resources:" {
+		for i++; i < len(lines); i++ {
+			r, ok := strings.CutPrefix(lines[i], "- ")
+			if !ok {
+				break
+			}
+			m.Resources = append(m.Resources, r)
+		}
+	}
+	if i != len(lines) {
+		return nil, fmt.Errorf("siwe: unexpected line %q", lines[i])
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SignSIWE signs the message with the given account using clef's text signing
+func (cc *ClefClient) SignSIWE(address string, msg *SIWEMessage) (*SignDataResponse, error) {
+	if msg == nil {
+		return nil, nilRequestError("siwe message")
+	}
+	if err := msg.Validate(); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(address, msg.Address) {
+		return nil, fmt.Errorf("siwe: signing account %s does not match message address %s", address, msg.Address)
+	}
+	return cc.SignData(&SignDataRequest{
+		ContentType: ContentTypeTextPlain,
+		Address:     address,
+		Data:        encodeHexData([]byte(msg.String())),
+	})
+}
+
+// VerifySIWE parses the signed message text, checks that signature was
+// produced by the message address and that the message is currently valid
+func VerifySIWE(message, signature string) (*SIWEMessage, error) {
+	m, err := ParseSIWEMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := RecoverAddress(TextHash([]byte(message)), signature)
+	if err != nil {
+		return nil, fmt.Errorf("siwe: %w", err)
+	}
+	if !strings.EqualFold(signer, m.Address) {
+		return nil, ErrSIWEAddressMismatch
+	}
+	if err := m.CheckTime(time.Now()); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
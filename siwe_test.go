@@ -0,0 +1,117 @@
+package clefclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSIWEText = `https://example.com wants you to sign in with your Ethereum account:
+0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf
+
+I accept the ExampleOrg Terms of Service: https://example.com/tos
+
+URI: https://example.com/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Expiration Time: 2099-01-01T00:00:00Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+func TestParseSIWEMessage(t *testing.T) {
+	m, err := ParseSIWEMessage(testSIWEText)
+	assert.NoError(t, err)
+	assert.Equal(t, "https", m.Scheme)
+	assert.Equal(t, "example.com", m.Domain)
+	assert.Equal(t, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", m.Address)
+	assert.Equal(t, "I accept the ExampleOrg Terms of Service: https://example.com/tos", m.Statement)
+	assert.Equal(t, uint64(1), m.ChainID)
+	assert.Equal(t, "32891756", m.Nonce)
+	assert.Equal(t, "2099-01-01T00:00:00Z", m.ExpirationTime)
+	assert.Len(t, m.Resources, 2)
+	assert.Equal(t, testSIWEText, m.String())
+
+	m.Statement = ""
+	m.Resources = nil
+	parsed, err := ParseSIWEMessage(m.String())
+	assert.NoError(t, err)
+	assert.Equal(t, m, parsed)
+
+	_, err = ParseSIWEMessage("example.com wants you to sign in with your Ethereum account:\n0x01\n\n\nURI: x")
+	assert.Error(t, err)
+}
+
+func TestNewSIWENonce(t *testing.T) {
+	nonce, err := NewSIWENonce()
+	assert.NoError(t, err)
+	assert.Regexp(t, siweNonceRegexp, nonce)
+	assert.Len(t, nonce, 17)
+
+	// Bytes from 248 up would favor the first characters and are skipped
+	random := append([]byte{255, 248, 0, 61, 62, 247}, bytes.Repeat([]byte{255}, 26)...)
+	random = append(random, bytes.Repeat([]byte{1}, 32)...)
+	nonce, err = newSIWENonce(bytes.NewReader(random))
+	assert.NoError(t, err)
+	assert.Equal(t, "a9a9"+strings.Repeat("b", 13), nonce)
+
+	_, err = newSIWENonce(bytes.NewReader(bytes.Repeat([]byte{255}, 64)))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestSIWEMessageCheckTime(t *testing.T) {
+	m := &SIWEMessage{ExpirationTime: "2021-10-01T00:00:00Z", NotBefore: "2021-09-01T00:00:00Z"}
+	assert.NoError(t, m.CheckTime(time.Date(2021, 9, 15, 0, 0, 0, 0, time.UTC)))
+	assert.ErrorIs(t, m.CheckTime(time.Date(2021, 10, 2, 0, 0, 0, 0, time.UTC)), ErrSIWEExpired)
+	assert.ErrorIs(t, m.CheckTime(time.Date(2021, 8, 2, 0, 0, 0, 0, time.UTC)), ErrSIWENotYetValid)
+}
+
+func TestSignSIWE(t *testing.T) {
+	msg, err := NewSIWEMessage("example.com", "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", "https://example.com/login", 1)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params SignDataRequest `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, ContentTypeTextPlain, req.Params.ContentType)
+		text, err := decodeHexData(req.Params.Data)
+		assert.NoError(t, err)
+
+		sig := testSign(t, 1, 424242, TextHash(text))
+		result, _ := json.Marshal(SignDataResponse{Signature: sig})
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: result})
+	}))
+	defer server.Close()
+
+	resp, err := NewHTTPClient(server.URL).SignSIWE(msg.Address, msg)
+	assert.NoError(t, err)
+
+	verified, err := VerifySIWE(msg.String(), resp.Signature)
+	assert.NoError(t, err)
+	assert.Equal(t, msg, verified)
+
+	_, err = NewHTTPClient(server.URL).SignSIWE("0x2B5AD5c4795c026514f8317c7a215E218DcCD6cF", msg)
+	assert.ErrorContains(t, err, "does not match")
+}
+
+func TestVerifySIWE(t *testing.T) {
+	sig := testSign(t, 2, 1111, TextHash([]byte(testSIWEText)))
+	_, err := VerifySIWE(testSIWEText, sig)
+	assert.ErrorIs(t, err, ErrSIWEAddressMismatch)
+
+	sig = testSign(t, 1, 1111, TextHash([]byte(testSIWEText)))
+	m, err := VerifySIWE(testSIWEText, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", m.Domain)
+}
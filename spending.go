@@ -0,0 +1,327 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrSpendingLimit is returned when signing a transaction would exceed a
+// spending limit of its account
+var ErrSpendingLimit = errors.New("spending limit exceeded")
+
+// SpendingLimit caps the value, in wei, of the transactions an account may
+// sign within a sliding time window, e.g. 10 ether per hour
+type SpendingLimit struct {
+	// Account is the limited account, every account separately if empty
+	Account string
+	Max     *big.Int
+	Window  time.Duration
+}
+
+// SpendingRecord is the value of a transaction signed, or being signed, by
+// an account
+type SpendingRecord struct {
+	ID      string    `json:"id"`
+	Account string    `json:"account"`
+	Time    time.Time `json:"time"`
+	// Value is the 0x-prefixed hex value in wei
+	Value string `json:"value"`
+}
+
+// SpendingStore persists spending records, so that limits survive restarts.
+// Accounts are passed in lowercase.
+type SpendingStore interface {
+	// Records returns the records of account made at or after since
+	Records(account string, since time.Time) ([]*SpendingRecord, error)
+	Add(record *SpendingRecord) error
+	Remove(id string) error
+	// Prune removes the records made before the given time
+	Prune(before time.Time) error
+}
+
+// EnableSpendingLimits enforces the limits on every subsequent transaction
+// signing request. The value of a transaction counts towards the limits of
+// its sender from the moment it is sent to Clef, and is only given back if
+// the request failed before reaching Clef or Clef answers with an error such
+// as a rejection: a request that timed out may still have been signed.
+// Requests exceeding a limit fail with both ErrPolicyViolation and
+// ErrSpendingLimit, and those with a malformed value with a validation
+// error; neither is sent.
+func (cc *ClefClient) EnableSpendingLimits(store SpendingStore, limits ...SpendingLimit) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &spendingTransport{next: cc.transport, store: store, limits: limits, now: time.Now}
+}
+
+// spendingTransport is a transport decorator enforcing spending limits
+type spendingTransport struct {
+	next   transport
+	store  SpendingStore
+	limits []SpendingLimit
+	now    func() time.Time
+
+	// mu serializes the checks, so that concurrent requests cannot both fit
+	// under a limit they exceed together
+	mu sync.Mutex
+}
+
+func (t *spendingTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	record, err := t.reserve(method, params)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.next.call(ctx, method, params)
+	if record != nil && !maySign(resp, err) {
+		t.store.Remove(record.ID)
+	}
+	return resp, err
+}
+
+// maySign reports whether the request of a call may have been signed: it
+// succeeded, or failed on the way to or from Clef or while waiting for it.
+// Calls that Clef answered with an error, or that failed before reaching
+// Clef, e.g. on validation, were not signed.
+func maySign(resp *rpcResponse, err error) bool {
+	if err == nil {
+		return resp == nil || resp.Error == nil
+	}
+	return errors.Is(err, ErrConnection) || errors.Is(err, ErrMalformedResponse) ||
+		errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)
+}
+
+// callBatch rejects the whole batch if its transactions together exceed a
+// limit
+func (t *spendingTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	records := make([]*SpendingRecord, len(params))
+	for i, p := range params {
+		record, err := t.reserve(method, p)
+		if err != nil {
+			for _, r := range records[:i] {
+				if r != nil {
+					t.store.Remove(r.ID)
+				}
+			}
+			return nil, fmt.Errorf("batch entry %d: %w", i+1, err)
+		}
+		records[i] = record
+	}
+	resps, err := t.next.callBatch(ctx, method, params)
+	for i, record := range records {
+		if record == nil {
+			continue
+		}
+		if err != nil && !maySign(nil, err) || err == nil && !maySign(resps[i], nil) {
+			t.store.Remove(record.ID)
+		}
+	}
+	return resps, err
+}
+
+func (t *spendingTransport) close() error {
+	return t.next.close()
+}
+
+// reserve checks a transaction against the limits of its sender and records
+// its value. Other requests and transactions without value are let through
+// without a record.
+func (t *spendingTransport) reserve(method string, params interface{}) (*SpendingRecord, error) {
+	tx, ok := params.(*Transaction)
+	if method != "account_signTransaction" || !ok || tx.Value == "" {
+		return nil, nil
+	}
+	// Checked here, as negative values would be recorded as credit
+	if err := checkHexQuantity(tx.Value); err != nil {
+		return nil, fmt.Errorf("transaction: value: %w", err)
+	}
+	value, err := decodeQuantity(tx.Value)
+	if err != nil || value.Sign() == 0 {
+		return nil, nil
+	}
+	account := strings.ToLower(tx.From)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	limited := false
+	for _, limit := range t.limits {
+		if limit.Account != "" && !strings.EqualFold(limit.Account, account) {
+			continue
+		}
+		limited = true
+		records, err := t.store.Records(account, now.Add(-limit.Window))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spending records: %w", err)
+		}
+		spent := new(big.Int)
+		for _, record := range records {
+			if v, err := decodeQuantity(record.Value); err == nil {
+				spent.Add(spent, v)
+			}
+		}
+		if spent.Add(spent, value).Cmp(limit.Max) > 0 {
+			return nil, fmt.Errorf("%w: %w: %s would spend %s wei within %s, limit is %s", ErrPolicyViolation, ErrSpendingLimit, tx.From, spent, limit.Window, limit.Max)
+		}
+	}
+	if !limited {
+		return nil, nil
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	record := &SpendingRecord{ID: id, Account: account, Time: now.UTC(), Value: encodeQuantity(value)}
+	if err := t.store.Add(record); err != nil {
+		return nil, fmt.Errorf("failed to record spending: %w", err)
+	}
+	t.store.Prune(now.Add(-t.retention()))
+	return record, nil
+}
+
+// retention is the longest window of all limits
+func (t *spendingTransport) retention() time.Duration {
+	var longest time.Duration
+	for _, limit := range t.limits {
+		longest = max(longest, limit.Window)
+	}
+	return longest
+}
+
+// MemorySpendingStore keeps spending records in memory
+type MemorySpendingStore struct {
+	mu      sync.RWMutex
+	records []*SpendingRecord
+}
+
+// NewMemorySpendingStore creates an empty MemorySpendingStore
+func NewMemorySpendingStore() *MemorySpendingStore {
+	return &MemorySpendingStore{}
+}
+
+// Records implements SpendingStore
+func (s *MemorySpendingStore) Records(account string, since time.Time) ([]*SpendingRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var records []*SpendingRecord
+	for _, record := range s.records {
+		if record.Account == account && !record.Time.Before(since) {
+			copied := *record
+			records = append(records, &copied)
+		}
+	}
+	return records, nil
+}
+
+// Add implements SpendingStore
+func (s *MemorySpendingStore) Add(record *SpendingRecord) error {
+	copied := *record
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, &copied)
+	return nil
+}
+
+// Remove implements SpendingStore
+func (s *MemorySpendingStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, record := range s.records {
+		if record.ID == id {
+			s.records = append(s.records[:i], s.records[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Prune implements SpendingStore
+func (s *MemorySpendingStore) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.records[:0]
+	for _, record := range s.records {
+		if !record.Time.Before(before) {
+			kept = append(kept, record)
+		}
+	}
+	s.records = kept
+	return nil
+}
+
+func (s *MemorySpendingStore) all() []*SpendingRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*SpendingRecord(nil), s.records...)
+}
+
+// FileSpendingStore keeps spending records in a JSON file, rewritten on
+// every change
+type FileSpendingStore struct {
+	path string
+	mem  *MemorySpendingStore
+	mu   sync.Mutex
+}
+
+// NewFileSpendingStore loads the spending records at path, which need not
+// exist
+func NewFileSpendingStore(path string) (*FileSpendingStore, error) {
+	s := &FileSpendingStore{path: path, mem: NewMemorySpendingStore()}
+	var records []*SpendingRecord
+	if err := readJSONFile(path, &records); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load spending records: %w", err)
+	}
+	for _, record := range records {
+		s.mem.Add(record)
+	}
+	return s, nil
+}
+
+// Records implements SpendingStore
+func (s *FileSpendingStore) Records(account string, since time.Time) ([]*SpendingRecord, error) {
+	return s.mem.Records(account, since)
+}
+
+// Add implements SpendingStore
+func (s *FileSpendingStore) Add(record *SpendingRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem.Add(record)
+	if err := s.save(); err != nil {
+		s.mem.Remove(record.ID)
+		return err
+	}
+	return nil
+}
+
+// Remove implements SpendingStore
+func (s *FileSpendingStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem.Remove(id)
+	return s.save()
+}
+
+// Prune implements SpendingStore
+func (s *FileSpendingStore) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mem.Prune(before)
+	return s.save()
+}
+
+func (s *FileSpendingStore) save() error {
+	records := s.mem.all()
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+	return writeJSONFile(s.path, records)
+}
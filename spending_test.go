@@ -0,0 +1,179 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// approvingTransport signs transactions unless their value is in rejected
+type approvingTransport struct {
+	rejected map[string]bool
+	// failed maps transaction values to errors returned without reaching
+	// Clef
+	failed map[string]error
+	calls  int
+}
+
+func (t *approvingTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	t.calls++
+	if tx, ok := params.(*Transaction); ok && t.rejected[tx.Value] {
+		// Like the HTTP and IPC transports
+		return nil, newCallError(method, &rpcError{Code: -32000, Message: "Request denied"})
+	}
+	if tx, ok := params.(*Transaction); ok && t.failed[tx.Value] != nil {
+		return nil, t.failed[tx.Value]
+	}
+	return &rpcResponse{Result: json.RawMessage(`{"raw":"0x","tx":{}}`)}, nil
+}
+
+func (t *approvingTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	resps := make([]*rpcResponse, len(params))
+	for i := range params {
+		var err error
+		if resps[i], err = t.call(ctx, method, params[i]); err != nil {
+			resps[i] = &rpcResponse{Error: &rpcError{Code: -32000, Message: err.Error()}}
+		}
+	}
+	return resps, nil
+}
+
+func (t *approvingTransport) close() error {
+	return nil
+}
+
+func TestEnableSpendingLimits(t *testing.T) {
+	alice := "0x0000000000000000000000000000000000000001"
+	bob := "0x0000000000000000000000000000000000000002"
+	ether := func(n int64) *big.Int {
+		return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+	}
+	tx := func(from string, value *big.Int) *Transaction {
+		return &Transaction{From: from, To: bob, Value: encodeQuantity(value)}
+	}
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	next := &approvingTransport{rejected: map[string]bool{encodeQuantity(ether(3)): true}}
+	store := NewMemorySpendingStore()
+	cc := &ClefClient{transport: next}
+	cc.EnableSpendingLimits(store,
+		SpendingLimit{Max: ether(10), Window: time.Hour},
+		SpendingLimit{Account: bob, Max: ether(1), Window: 24 * time.Hour},
+	)
+	cc.transport.(*spendingTransport).now = func() time.Time { return now }
+
+	_, err := cc.SignTransaction(tx(alice, ether(6)))
+	assert.NoError(t, err)
+	_, err = cc.SignTransaction(tx(alice, ether(5)))
+	assert.ErrorIs(t, err, ErrSpendingLimit)
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.ErrorContains(t, err, "0x0000000000000000000000000000000000000001 would spend 11000000000000000000 wei within 1h0m0s, limit is 10000000000000000000")
+	assert.Equal(t, 1, next.calls)
+
+	// Rejected requests do not count
+	_, err = cc.SignTransaction(tx(alice, ether(3)))
+	assert.Error(t, err)
+	_, err = cc.SignTransaction(tx(alice, ether(4)))
+	assert.NoError(t, err)
+
+	// Transactions without value and other accounts are not affected
+	_, err = cc.SignTransaction(&Transaction{From: alice, To: bob, Data: "0xa9059cbb"})
+	assert.NoError(t, err)
+	_, err = cc.SignTransaction(tx(bob, ether(1)))
+	assert.NoError(t, err)
+	_, err = cc.SignTransaction(tx(bob, big.NewInt(1)))
+	assert.ErrorIs(t, err, ErrSpendingLimit)
+
+	// The window slides
+	now = now.Add(time.Hour + time.Second)
+	_, err = cc.SignTransaction(tx(alice, ether(10)))
+	assert.NoError(t, err)
+
+	// Batches are checked as a whole
+	now = now.Add(2 * time.Hour)
+	calls := next.calls
+	_, err = cc.SignTransactions([]*Transaction{tx(alice, ether(6)), tx(alice, ether(6))})
+	assert.ErrorIs(t, err, ErrSpendingLimit)
+	assert.ErrorContains(t, err, "batch entry 2")
+	assert.Equal(t, calls, next.calls)
+	records, _ := store.Records(alice, now.Add(-time.Hour))
+	assert.Empty(t, records)
+	_, err = cc.SignTransactions([]*Transaction{tx(alice, ether(3)), tx(alice, ether(6))})
+	assert.NoError(t, err)
+	records, _ = store.Records(alice, now.Add(-time.Hour))
+	assert.Len(t, records, 1)
+
+	// Records older than the longest window are pruned
+	assert.Len(t, store.all(), 5)
+	now = now.Add(24*time.Hour + time.Second)
+	_, err = cc.SignTransaction(tx(alice, ether(1)))
+	assert.NoError(t, err)
+	assert.Len(t, store.all(), 1)
+}
+
+func TestSpendingLimitsFailedRequests(t *testing.T) {
+	alice := "0x0000000000000000000000000000000000000001"
+	tx := func(value string) *Transaction {
+		return &Transaction{From: alice, To: "0x0000000000000000000000000000000000000002", Value: value}
+	}
+	next := &approvingTransport{failed: map[string]error{
+		"0x2": errors.New("transaction: gas is below the intrinsic gas"),
+		"0x3": fmt.Errorf("account_signTransaction: %w", ErrConnection),
+	}}
+	store := NewMemorySpendingStore()
+	cc := &ClefClient{transport: next}
+	cc.EnableSpendingLimits(store, SpendingLimit{Max: big.NewInt(10), Window: time.Hour})
+
+	// Negative values are refused instead of being recorded as credit
+	_, err := cc.SignTransaction(tx("0x-de0b6b3a7640000"))
+	assert.ErrorContains(t, err, "transaction: value")
+	assert.Equal(t, 0, next.calls)
+	_, err = cc.SignTransaction(tx("0x01"))
+	assert.ErrorContains(t, err, "leading zeros")
+	assert.Empty(t, store.all())
+
+	// Requests failing before Clef do not count, those that may have
+	// reached it do
+	_, err = cc.SignTransaction(tx("0x2"))
+	assert.Error(t, err)
+	assert.Empty(t, store.all())
+	_, err = cc.SignTransaction(tx("0x3"))
+	assert.ErrorIs(t, err, ErrConnection)
+	assert.Len(t, store.all(), 1)
+	_, err = cc.SignTransaction(tx("0x8"))
+	assert.ErrorIs(t, err, ErrSpendingLimit)
+}
+
+func TestFileSpendingStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spending.json")
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := NewFileSpendingStore(path)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Add(&SpendingRecord{ID: "a", Account: "0x01", Time: now.Add(-2 * time.Hour), Value: "0x1"}))
+	assert.NoError(t, store.Add(&SpendingRecord{ID: "b", Account: "0x01", Time: now, Value: "0x2"}))
+	assert.NoError(t, store.Add(&SpendingRecord{ID: "c", Account: "0x02", Time: now, Value: "0x3"}))
+	assert.NoError(t, store.Remove("c"))
+
+	// Records survive restarts
+	store, err = NewFileSpendingStore(path)
+	assert.NoError(t, err)
+	records, err := store.Records("0x01", now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []*SpendingRecord{{ID: "b", Account: "0x01", Time: now, Value: "0x2"}}, records)
+	records, _ = store.Records("0x02", time.Time{})
+	assert.Empty(t, records)
+
+	assert.NoError(t, store.Prune(now.Add(-time.Hour)))
+	store, err = NewFileSpendingStore(path)
+	assert.NoError(t, err)
+	records, _ = store.Records("0x01", time.Time{})
+	assert.Len(t, records, 1)
+}
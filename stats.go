@@ -0,0 +1,67 @@
+package clefclient
+
+import (
+	"context"
+	"time"
+)
+
+// Stats receives an observation for every call made to Clef, so that any
+// metrics backend can be wired in
+type Stats interface {
+	// ObserveCall is called once a call of method returned after duration,
+	// with its error or nil on success. It must not block.
+	ObserveCall(method string, duration time.Duration, err error)
+}
+
+// StatsFunc adapts a function to the Stats interface
+type StatsFunc func(method string, duration time.Duration, err error)
+
+// ObserveCall implements Stats
+func (f StatsFunc) ObserveCall(method string, duration time.Duration, err error) {
+	f(method, duration, err)
+}
+
+// EnableStats reports every subsequent request made by the client to s.
+// Entries of a batch are observed one by one with their own error and the
+// duration of the whole batch.
+func (cc *ClefClient) EnableStats(s Stats) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &statsTransport{next: cc.transport, stats: s}
+}
+
+// statsTransport is a transport decorator reporting calls to Stats
+type statsTransport struct {
+	next  transport
+	stats Stats
+}
+
+func (t *statsTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	start := time.Now()
+	resp, err := t.next.call(ctx, method, params)
+	callErr := err
+	if err == nil && resp.Error != nil {
+		callErr = newCallError(method, resp.Error)
+	}
+	t.stats.ObserveCall(method, time.Since(start), callErr)
+	return resp, err
+}
+
+func (t *statsTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	start := time.Now()
+	resps, err := t.next.callBatch(ctx, method, params)
+	duration := time.Since(start)
+	for i := range params {
+		callErr := err
+		if err == nil && resps[i].Error != nil {
+			callErr = newCallError(method, resps[i].Error)
+		}
+		t.stats.ObserveCall(method, duration, callErr)
+	}
+	return resps, err
+}
+
+func (t *statsTransport) close() error {
+	return t.next.close()
+}
@@ -0,0 +1,75 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type observation struct {
+	method string
+	err    error
+}
+
+func TestStats(t *testing.T) {
+	var observed []observation
+	stats := StatsFunc(func(method string, duration time.Duration, err error) {
+		assert.GreaterOrEqual(t, duration, time.Duration(0))
+		observed = append(observed, observation{method, err})
+	})
+
+	cc := &ClefClient{transport: &countingTransport{}}
+	cc.EnableStats(stats)
+	_, err := cc.ListAccounts()
+	assert.NoError(t, err)
+	_, err = cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001"}, {From: "0x0000000000000000000000000000000000000001"}})
+	assert.NoError(t, err)
+
+	cc = &ClefClient{transport: &failingTransport{err: connectionError(errors.New("connection refused"))}}
+	cc.EnableStats(stats)
+	_, err = cc.ListAccounts()
+	assert.Error(t, err)
+
+	if assert.Len(t, observed, 4) {
+		assert.Equal(t, observation{"account_list", nil}, observed[0])
+		assert.Equal(t, "account_signTransaction", observed[1].method)
+		assert.Equal(t, "account_signTransaction", observed[2].method)
+		assert.ErrorIs(t, observed[3].err, ErrConnection)
+	}
+}
+
+func TestStatsBatchEntryErrors(t *testing.T) {
+	var errs []error
+	next := &batchErrorTransport{}
+	cc := &ClefClient{transport: next}
+	cc.EnableStats(StatsFunc(func(method string, duration time.Duration, err error) {
+		errs = append(errs, err)
+	}))
+
+	_, err := cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001"}, {From: "0x0000000000000000000000000000000000000001"}})
+	assert.NoError(t, err)
+	if assert.Len(t, errs, 2) {
+		assert.NoError(t, errs[0])
+		assert.ErrorIs(t, errs[1], ErrRequestDenied)
+	}
+}
+
+// batchErrorTransport denies every second entry of a batch
+type batchErrorTransport struct {
+	failingTransport
+}
+
+func (t *batchErrorTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	resps := make([]*rpcResponse, len(params))
+	for i := range params {
+		resps[i] = &rpcResponse{Result: json.RawMessage(`{"raw":"0x01"}`)}
+		if i%2 == 1 {
+			resps[i] = &rpcResponse{Error: &rpcError{Code: -32000, Message: "Request denied"}}
+		}
+	}
+	return resps, nil
+}
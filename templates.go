@@ -0,0 +1,573 @@
+package clefclient
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Verifying contracts of the protocol templates, deployed at the same address
+// on every supported chain
+const (
+	Permit2Address       = "0x000000000022D473030F116dDEE9F6B43aC78BA3"
+	CowSettlementAddress = "0x9008D19f58AAbD9eD0D60971565AA8510560ab41"
+)
+
+// SeaportAddresses maps supported Seaport versions to their contracts
+var SeaportAddresses = map[string]string{
+	"1.5": "0x00000000000000ADc04C56Bf30aC9d3c0aAF14dC",
+	"1.6": "0x0000000000000068F116a894984e2DB1123eB395",
+}
+
+// SeaportLatestVersion is the Seaport version used when none is given
+const SeaportLatestVersion = "1.6"
+
+// eip712DomainNoVersion are the EIP712Domain fields of contracts without a
+// version in their domain
+var eip712DomainNoVersion = []TypedDataField{
+	{Name: "name", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+}
+
+// checkTemplateAddresses validates the named addresses of a template
+func checkTemplateAddresses(template string, addresses map[string]string) error {
+	for name, address := range addresses {
+		if _, err := encodeAddressWord(address); err != nil {
+			return fmt.Errorf("%s: %s: %w", template, name, err)
+		}
+	}
+	return nil
+}
+
+// checkTemplateUints validates that the named values are set and fit in bits
+func checkTemplateUints(template string, bits int, values map[string]*big.Int) error {
+	for name, v := range values {
+		if v == nil {
+			return fmt.Errorf("%s: %s is required", template, name)
+		}
+		if v.Sign() < 0 || v.BitLen() > bits {
+			return fmt.Errorf("%s: %s %s does not fit in uint%d", template, name, v, bits)
+		}
+	}
+	return nil
+}
+
+// bytes32OrZero returns the hex bytes32 value, or the zero word if empty
+func bytes32OrZero(template, name, value string) (string, error) {
+	if value == "" {
+		return encodeHexData(make([]byte, 32)), nil
+	}
+	b, err := decodeHexData(value)
+	if err != nil || len(b) != 32 {
+		return "", fmt.Errorf("%s: invalid %s %q", template, name, value)
+	}
+	return encodeHexData(b), nil
+}
+
+// Permit2Single is a Uniswap Permit2 PermitSingle, an allowance of amount
+// tokens to spender signed by owner
+type Permit2Single struct {
+	ChainID *big.Int
+	Owner   string
+	Token   string
+	// Amount is limited to uint160
+	Amount *big.Int
+	// Expiration is when the allowance expires, limited to uint48
+	Expiration *big.Int
+	// Nonce is the owner's Permit2 nonce for token and spender, limited to uint48
+	Nonce       *big.Int
+	Spender     string
+	SigDeadline *big.Int
+}
+
+// TypedData validates the permit and builds its EIP-712 typed data
+func (p *Permit2Single) TypedData() (*TypedData, error) {
+	if err := checkTemplateAddresses("permit2", map[string]string{"owner": p.Owner, "token": p.Token, "spender": p.Spender}); err != nil {
+		return nil, err
+	}
+	if err := checkTemplateUints("permit2", 256, map[string]*big.Int{"chain ID": p.ChainID, "signature deadline": p.SigDeadline}); err != nil {
+		return nil, err
+	}
+	if err := checkTemplateUints("permit2", 160, map[string]*big.Int{"amount": p.Amount}); err != nil {
+		return nil, err
+	}
+	if err := checkTemplateUints("permit2", 48, map[string]*big.Int{"expiration": p.Expiration, "nonce": p.Nonce}); err != nil {
+		return nil, err
+	}
+	return &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": eip712DomainNoVersion,
+			"PermitSingle": {
+				{Name: "details", Type: "PermitDetails"},
+				{Name: "spender", Type: "address"},
+				{Name: "sigDeadline", Type: "uint256"},
+			},
+			"PermitDetails": {
+				{Name: "token", Type: "address"},
+				{Name: "amount", Type: "uint160"},
+				{Name: "expiration", Type: "uint48"},
+				{Name: "nonce", Type: "uint48"},
+			},
+		},
+		PrimaryType: "PermitSingle",
+		Domain:      permit2Domain(p.ChainID),
+		Message: map[string]interface{}{
+			"details": map[string]interface{}{
+				"token":      p.Token,
+				"amount":     p.Amount.String(),
+				"expiration": p.Expiration.String(),
+				"nonce":      p.Nonce.String(),
+			},
+			"spender":     p.Spender,
+			"sigDeadline": p.SigDeadline.String(),
+		},
+	}, nil
+}
+
+// Request builds the request for signing the permit with the owner account
+func (p *Permit2Single) Request() (*TypedDataRequest, error) {
+	td, err := p.TypedData()
+	if err != nil {
+		return nil, err
+	}
+	return td.Request(p.Owner)
+}
+
+// Permit2Transfer is a Uniswap Permit2 PermitTransferFrom, a one-time
+// signature transfer of amount tokens by spender
+type Permit2Transfer struct {
+	ChainID *big.Int
+	Owner   string
+	Token   string
+	Amount  *big.Int
+	Spender string
+	// Nonce is an unordered nonce, any unused value of the owner's bitmap
+	Nonce    *big.Int
+	Deadline *big.Int
+}
+
+// TypedData validates the permit and builds its EIP-712 typed data
+func (p *Permit2Transfer) TypedData() (*TypedData, error) {
+	if err := checkTemplateAddresses("permit2", map[string]string{"owner": p.Owner, "token": p.Token, "spender": p.Spender}); err != nil {
+		return nil, err
+	}
+	if err := checkTemplateUints("permit2", 256, map[string]*big.Int{"chain ID": p.ChainID, "amount": p.Amount, "nonce": p.Nonce, "deadline": p.Deadline}); err != nil {
+		return nil, err
+	}
+	return &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": eip712DomainNoVersion,
+			"PermitTransferFrom": {
+				{Name: "permitted", Type: "TokenPermissions"},
+				{Name: "spender", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+			"TokenPermissions": {
+				{Name: "token", Type: "address"},
+				{Name: "amount", Type: "uint256"},
+			},
+		},
+		PrimaryType: "PermitTransferFrom",
+		Domain:      permit2Domain(p.ChainID),
+		Message: map[string]interface{}{
+			"permitted": map[string]interface{}{
+				"token":  p.Token,
+				"amount": p.Amount.String(),
+			},
+			"spender":  p.Spender,
+			"nonce":    p.Nonce.String(),
+			"deadline": p.Deadline.String(),
+		},
+	}, nil
+}
+
+// Request builds the request for signing the permit with the owner account
+func (p *Permit2Transfer) Request() (*TypedDataRequest, error) {
+	td, err := p.TypedData()
+	if err != nil {
+		return nil, err
+	}
+	return td.Request(p.Owner)
+}
+
+func permit2Domain(chainID *big.Int) map[string]interface{} {
+	return map[string]interface{}{
+		"name":              "Permit2",
+		"chainId":           chainID.String(),
+		"verifyingContract": Permit2Address,
+	}
+}
+
+// Seaport item types
+const (
+	SeaportNative = iota
+	SeaportERC20
+	SeaportERC721
+	SeaportERC1155
+	SeaportERC721WithCriteria
+	SeaportERC1155WithCriteria
+)
+
+// SeaportItem is an offer or consideration item of a Seaport order. Recipient
+// is only used for consideration items.
+type SeaportItem struct {
+	ItemType             int
+	Token                string
+	IdentifierOrCriteria *big.Int
+	StartAmount          *big.Int
+	EndAmount            *big.Int
+	Recipient            string
+}
+
+// SeaportOrder is the OrderComponents of a Seaport order signed by the offerer
+type SeaportOrder struct {
+	// Version selects the Seaport deployment, SeaportLatestVersion if empty
+	Version       string
+	ChainID       *big.Int
+	Offerer       string
+	Zone          string
+	Offer         []SeaportItem
+	Consideration []SeaportItem
+	OrderType     int
+	StartTime     *big.Int
+	EndTime       *big.Int
+	// ZoneHash and ConduitKey are hex bytes32 values, zero if empty
+	ZoneHash   string
+	Salt       *big.Int
+	ConduitKey string
+	// Counter is the offerer's current counter on the Seaport contract
+	Counter *big.Int
+}
+
+// TypedData validates the order and builds its EIP-712 typed data
+func (o *SeaportOrder) TypedData() (*TypedData, error) {
+	version := o.Version
+	if version == "" {
+		version = SeaportLatestVersion
+	}
+	contract, ok := SeaportAddresses[version]
+	if !ok {
+		return nil, fmt.Errorf("seaport: unsupported version %q", version)
+	}
+	zone := o.Zone
+	if zone == "" {
+		zone = "0x0000000000000000000000000000000000000000"
+	}
+	if err := checkTemplateAddresses("seaport", map[string]string{"offerer": o.Offerer, "zone": zone}); err != nil {
+		return nil, err
+	}
+	if err := checkTemplateUints("seaport", 256, map[string]*big.Int{
+		"chain ID": o.ChainID, "start time": o.StartTime, "end time": o.EndTime, "salt": o.Salt, "counter": o.Counter,
+	}); err != nil {
+		return nil, err
+	}
+	if o.OrderType < 0 || o.OrderType > 4 {
+		return nil, fmt.Errorf("seaport: invalid order type %d", o.OrderType)
+	}
+	zoneHash, err := bytes32OrZero("seaport", "zone hash", o.ZoneHash)
+	if err != nil {
+		return nil, err
+	}
+	conduitKey, err := bytes32OrZero("seaport", "conduit key", o.ConduitKey)
+	if err != nil {
+		return nil, err
+	}
+	offer, err := seaportItems(o.Offer, false)
+	if err != nil {
+		return nil, fmt.Errorf("seaport: offer %w", err)
+	}
+	consideration, err := seaportItems(o.Consideration, true)
+	if err != nil {
+		return nil, fmt.Errorf("seaport: consideration %w", err)
+	}
+
+	return &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": eip712DomainFields,
+			"OrderComponents": {
+				{Name: "offerer", Type: "address"},
+				{Name: "zone", Type: "address"},
+				{Name: "offer", Type: "OfferItem[]"},
+				{Name: "consideration", Type: "ConsiderationItem[]"},
+				{Name: "orderType", Type: "uint8"},
+				{Name: "startTime", Type: "uint256"},
+				{Name: "endTime", Type: "uint256"},
+				{Name: "zoneHash", Type: "bytes32"},
+				{Name: "salt", Type: "uint256"},
+				{Name: "conduitKey", Type: "bytes32"},
+				{Name: "counter", Type: "uint256"},
+			},
+			"OfferItem":         seaportItemFields[:5],
+			"ConsiderationItem": seaportItemFields,
+		},
+		PrimaryType: "OrderComponents",
+		Domain: map[string]interface{}{
+			"name":              "Seaport",
+			"version":           version,
+			"chainId":           o.ChainID.String(),
+			"verifyingContract": contract,
+		},
+		Message: map[string]interface{}{
+			"offerer":       o.Offerer,
+			"zone":          zone,
+			"offer":         offer,
+			"consideration": consideration,
+			"orderType":     o.OrderType,
+			"startTime":     o.StartTime.String(),
+			"endTime":       o.EndTime.String(),
+			"zoneHash":      zoneHash,
+			"salt":          o.Salt.String(),
+			"conduitKey":    conduitKey,
+			"counter":       o.Counter.String(),
+		},
+	}, nil
+}
+
+// Request builds the request for signing the order with the offerer account
+func (o *SeaportOrder) Request() (*TypedDataRequest, error) {
+	td, err := o.TypedData()
+	if err != nil {
+		return nil, err
+	}
+	return td.Request(o.Offerer)
+}
+
+var seaportItemFields = []TypedDataField{
+	{Name: "itemType", Type: "uint8"},
+	{Name: "token", Type: "address"},
+	{Name: "identifierOrCriteria", Type: "uint256"},
+	{Name: "startAmount", Type: "uint256"},
+	{Name: "endAmount", Type: "uint256"},
+	{Name: "recipient", Type: "address"},
+}
+
+func seaportItems(items []SeaportItem, consideration bool) ([]interface{}, error) {
+	out := make([]interface{}, len(items))
+	for i, item := range items {
+		if item.ItemType < SeaportNative || item.ItemType > SeaportERC1155WithCriteria {
+			return nil, fmt.Errorf("item %d: invalid item type %d", i, item.ItemType)
+		}
+		identifier := item.IdentifierOrCriteria
+		if identifier == nil {
+			identifier = new(big.Int)
+		}
+		addresses := map[string]string{"token": item.Token}
+		if consideration {
+			addresses["recipient"] = item.Recipient
+		}
+		if err := checkTemplateAddresses(fmt.Sprintf("item %d", i), addresses); err != nil {
+			return nil, err
+		}
+		if err := checkTemplateUints(fmt.Sprintf("item %d", i), 256, map[string]*big.Int{
+			"identifier": identifier, "start amount": item.StartAmount, "end amount": item.EndAmount,
+		}); err != nil {
+			return nil, err
+		}
+		fields := map[string]interface{}{
+			"itemType":             item.ItemType,
+			"token":                item.Token,
+			"identifierOrCriteria": identifier.String(),
+			"startAmount":          item.StartAmount.String(),
+			"endAmount":            item.EndAmount.String(),
+		}
+		if consideration {
+			fields["recipient"] = item.Recipient
+		}
+		out[i] = fields
+	}
+	return out, nil
+}
+
+// CoW Protocol order kinds and token balance sources
+const (
+	CowKindSell        = "sell"
+	CowKindBuy         = "buy"
+	CowBalanceERC20    = "erc20"
+	CowBalanceExternal = "external"
+	CowBalanceInternal = "internal"
+)
+
+// CowOrder is a CoW Protocol (GPv2) order signed by its owner
+type CowOrder struct {
+	ChainID   *big.Int
+	Owner     string
+	SellToken string
+	BuyToken  string
+	// Receiver gets the bought tokens, the owner if empty
+	Receiver   string
+	SellAmount *big.Int
+	BuyAmount  *big.Int
+	ValidTo    *big.Int
+	// AppData is the hex bytes32 app data hash, zero if empty
+	AppData           string
+	FeeAmount         *big.Int
+	Kind              string
+	PartiallyFillable bool
+	// SellTokenBalance and BuyTokenBalance default to CowBalanceERC20
+	SellTokenBalance string
+	BuyTokenBalance  string
+}
+
+// TypedData validates the order and builds its EIP-712 typed data
+func (o *CowOrder) TypedData() (*TypedData, error) {
+	receiver := o.Receiver
+	if receiver == "" {
+		receiver = "0x0000000000000000000000000000000000000000"
+	}
+	if err := checkTemplateAddresses("cow", map[string]string{"owner": o.Owner, "sell token": o.SellToken, "buy token": o.BuyToken, "receiver": receiver}); err != nil {
+		return nil, err
+	}
+	feeAmount := o.FeeAmount
+	if feeAmount == nil {
+		feeAmount = new(big.Int)
+	}
+	if err := checkTemplateUints("cow", 256, map[string]*big.Int{"chain ID": o.ChainID, "sell amount": o.SellAmount, "buy amount": o.BuyAmount, "fee amount": feeAmount}); err != nil {
+		return nil, err
+	}
+	if err := checkTemplateUints("cow", 32, map[string]*big.Int{"valid to": o.ValidTo}); err != nil {
+		return nil, err
+	}
+	if o.Kind != CowKindSell && o.Kind != CowKindBuy {
+		return nil, fmt.Errorf("cow: invalid kind %q", o.Kind)
+	}
+	balances := []string{o.SellTokenBalance, o.BuyTokenBalance}
+	for i, balance := range balances {
+		switch balance {
+		case "":
+			balances[i] = CowBalanceERC20
+		case CowBalanceERC20, CowBalanceExternal, CowBalanceInternal:
+		default:
+			return nil, fmt.Errorf("cow: invalid token balance %q", balance)
+		}
+	}
+	if balances[1] == CowBalanceExternal {
+		return nil, fmt.Errorf("cow: buy token balance cannot be %q", CowBalanceExternal)
+	}
+	appData, err := bytes32OrZero("cow", "app data", o.AppData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": eip712DomainFields,
+			"Order": {
+				{Name: "sellToken", Type: "address"},
+				{Name: "buyToken", Type: "address"},
+				{Name: "receiver", Type: "address"},
+				{Name: "sellAmount", Type: "uint256"},
+				{Name: "buyAmount", Type: "uint256"},
+				{Name: "validTo", Type: "uint32"},
+				{Name: "appData", Type: "bytes32"},
+				{Name: "feeAmount", Type: "uint256"},
+				{Name: "kind", Type: "string"},
+				{Name: "partiallyFillable", Type: "bool"},
+				{Name: "sellTokenBalance", Type: "string"},
+				{Name: "buyTokenBalance", Type: "string"},
+			},
+		},
+		PrimaryType: "Order",
+		Domain: map[string]interface{}{
+			"name":              "Gnosis Protocol",
+			"version":           "v2",
+			"chainId":           o.ChainID.String(),
+			"verifyingContract": CowSettlementAddress,
+		},
+		Message: map[string]interface{}{
+			"sellToken":         o.SellToken,
+			"buyToken":          o.BuyToken,
+			"receiver":          receiver,
+			"sellAmount":        o.SellAmount.String(),
+			"buyAmount":         o.BuyAmount.String(),
+			"validTo":           o.ValidTo.String(),
+			"appData":           appData,
+			"feeAmount":         feeAmount.String(),
+			"kind":              o.Kind,
+			"partiallyFillable": o.PartiallyFillable,
+			"sellTokenBalance":  balances[0],
+			"buyTokenBalance":   balances[1],
+		},
+	}, nil
+}
+
+// Request builds the request for signing the order with the owner account
+func (o *CowOrder) Request() (*TypedDataRequest, error) {
+	td, err := o.TypedData()
+	if err != nil {
+		return nil, err
+	}
+	return td.Request(o.Owner)
+}
+
+// Delegation is a vote delegation by signature for governance tokens such as
+// OpenZeppelin ERC20Votes and Compound
+type Delegation struct {
+	// TokenName is the name in the token's EIP-712 domain
+	TokenName string
+	// TokenVersion is the version in the token's EIP-712 domain, "1" if empty.
+	// Set NoVersion for tokens whose domain has no version, such as COMP.
+	TokenVersion string
+	NoVersion    bool
+	ChainID      *big.Int
+	Token        string
+
+	Delegator string
+	Delegatee string
+	Nonce     *big.Int
+	Expiry    *big.Int
+}
+
+// TypedData validates the delegation and builds its EIP-712 typed data
+func (d *Delegation) TypedData() (*TypedData, error) {
+	if d.TokenName == "" {
+		return nil, fmt.Errorf("delegation: token name is required")
+	}
+	if err := checkTemplateAddresses("delegation", map[string]string{"token": d.Token, "delegator": d.Delegator, "delegatee": d.Delegatee}); err != nil {
+		return nil, err
+	}
+	if err := checkTemplateUints("delegation", 256, map[string]*big.Int{"chain ID": d.ChainID, "nonce": d.Nonce, "expiry": d.Expiry}); err != nil {
+		return nil, err
+	}
+
+	domainFields := eip712DomainFields
+	domain := map[string]interface{}{
+		"name":              d.TokenName,
+		"chainId":           d.ChainID.String(),
+		"verifyingContract": d.Token,
+	}
+	if d.NoVersion {
+		domainFields = eip712DomainNoVersion
+	} else if d.TokenVersion == "" {
+		domain["version"] = "1"
+	} else {
+		domain["version"] = d.TokenVersion
+	}
+	return &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": domainFields,
+			"Delegation": {
+				{Name: "delegatee", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "expiry", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Delegation",
+		Domain:      domain,
+		Message: map[string]interface{}{
+			"delegatee": d.Delegatee,
+			"nonce":     d.Nonce.String(),
+			"expiry":    d.Expiry.String(),
+		},
+	}, nil
+}
+
+// Request builds the request for signing the delegation with the delegator
+// account
+func (d *Delegation) Request() (*TypedDataRequest, error) {
+	td, err := d.TypedData()
+	if err != nil {
+		return nil, err
+	}
+	return td.Request(d.Delegator)
+}
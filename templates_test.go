@@ -0,0 +1,114 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testOwner = "0x0000000000000000000000000000000000000001"
+	testToken = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+)
+
+// typeHashOf returns the hex typehash of the primary type of a request
+func typeHashOf(t *testing.T, req *TypedDataRequest) string {
+	var td TypedData
+	assert.NoError(t, json.Unmarshal(req.TypedData, &td))
+	typeHash, err := td.TypeHash(td.PrimaryType)
+	assert.NoError(t, err)
+	_, err = td.Hash()
+	assert.NoError(t, err)
+	return encodeHexData(typeHash)
+}
+
+func TestPermit2Templates(t *testing.T) {
+	single := &Permit2Single{
+		ChainID: big.NewInt(1), Owner: testOwner, Token: testToken, Spender: testOwner,
+		Amount: big.NewInt(1000), Expiration: big.NewInt(1700000000), Nonce: big.NewInt(0), SigDeadline: big.NewInt(1700000000),
+	}
+	req, err := single.Request()
+	assert.NoError(t, err)
+	assert.Equal(t, testOwner, req.Address)
+	// PERMIT_SINGLE_TYPEHASH of the Permit2 contract
+	assert.Equal(t, "0xf3841cd1ff0085026a6327b620b67997ce40f282c88a8e905a7a5626e310f3d0", typeHashOf(t, req))
+
+	single.Amount = new(big.Int).Lsh(big.NewInt(1), 160)
+	_, err = single.Request()
+	assert.ErrorContains(t, err, "does not fit in uint160")
+
+	transfer := &Permit2Transfer{
+		ChainID: big.NewInt(1), Owner: testOwner, Token: testToken, Spender: testOwner,
+		Amount: big.NewInt(1000), Nonce: big.NewInt(7), Deadline: big.NewInt(1700000000),
+	}
+	req, err = transfer.Request()
+	assert.NoError(t, err)
+	// PERMIT_TRANSFER_FROM_TYPEHASH of the Permit2 contract
+	assert.Equal(t, "0x939c21a48a8dbe3a9a2404a1d46691e4d39f6583d6ec6b35714604c986d80106", typeHashOf(t, req))
+}
+
+func TestSeaportTemplate(t *testing.T) {
+	order := &SeaportOrder{
+		ChainID: big.NewInt(1), Offerer: testOwner,
+		Offer:         []SeaportItem{{ItemType: SeaportERC721, Token: testToken, IdentifierOrCriteria: big.NewInt(42), StartAmount: big.NewInt(1), EndAmount: big.NewInt(1)}},
+		Consideration: []SeaportItem{{ItemType: SeaportNative, Token: "0x0000000000000000000000000000000000000000", StartAmount: big.NewInt(1e18), EndAmount: big.NewInt(1e18), Recipient: testOwner}},
+		StartTime:     big.NewInt(0), EndTime: big.NewInt(1700000000), Salt: big.NewInt(1), Counter: big.NewInt(0),
+	}
+	req, err := order.Request()
+	assert.NoError(t, err)
+	// _ORDER_TYPEHASH of Seaport
+	assert.Equal(t, "0xfa445660b7e21515a59617fcd68910b487aa5808b8abda3d78bc85df364b2c2f", typeHashOf(t, req))
+
+	var td TypedData
+	assert.NoError(t, json.Unmarshal(req.TypedData, &td))
+	assert.Equal(t, "1.6", td.Domain["version"])
+	assert.Equal(t, SeaportAddresses["1.6"], td.Domain["verifyingContract"])
+
+	order.Version = "1.5"
+	td2, err := order.TypedData()
+	assert.NoError(t, err)
+	assert.Equal(t, SeaportAddresses["1.5"], td2.Domain["verifyingContract"])
+
+	order.Version = "1.1"
+	_, err = order.TypedData()
+	assert.ErrorContains(t, err, "unsupported version")
+}
+
+func TestCowTemplate(t *testing.T) {
+	order := &CowOrder{
+		ChainID: big.NewInt(1), Owner: testOwner, SellToken: testToken, BuyToken: testOwner,
+		SellAmount: big.NewInt(1000), BuyAmount: big.NewInt(900), ValidTo: big.NewInt(1700000000), Kind: CowKindSell,
+	}
+	req, err := order.Request()
+	assert.NoError(t, err)
+	// GPv2Order.TYPE_HASH
+	assert.Equal(t, "0xd5a25ba2e97094ad7d83dc28a6572da797d6b3e7fc6663bd93efb789fc17e489", typeHashOf(t, req))
+
+	order.BuyTokenBalance = CowBalanceExternal
+	_, err = order.Request()
+	assert.Error(t, err)
+	order.BuyTokenBalance, order.Kind = "", "swap"
+	_, err = order.Request()
+	assert.ErrorContains(t, err, "invalid kind")
+}
+
+func TestDelegationTemplate(t *testing.T) {
+	delegation := &Delegation{
+		TokenName: "Compound", NoVersion: true, ChainID: big.NewInt(1), Token: testToken,
+		Delegator: testOwner, Delegatee: testOwner, Nonce: big.NewInt(0), Expiry: big.NewInt(1700000000),
+	}
+	req, err := delegation.Request()
+	assert.NoError(t, err)
+	// DELEGATION_TYPEHASH of OpenZeppelin Votes and COMP
+	assert.Equal(t, "0xe48329057bfd03d55e49b547132e39cffd9c1820ad7b9d4c5307691425d15adf", typeHashOf(t, req))
+
+	td, err := delegation.TypedData()
+	assert.NoError(t, err)
+	assert.NotContains(t, td.Domain, "version")
+	delegation.NoVersion = false
+	td, err = delegation.TypedData()
+	assert.NoError(t, err)
+	assert.Equal(t, "1", td.Domain["version"])
+}
@@ -0,0 +1,228 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTimeLockCancelled is returned for signing requests cancelled while held
+// by a TimeLock
+var ErrTimeLockCancelled = errors.New("signing request cancelled during time lock")
+
+// TimeLockConfig configures a TimeLock
+type TimeLockConfig struct {
+	// Threshold is the value, in wei, above which transactions are held
+	Threshold *big.Int
+	// Delay is how long transactions are held before being sent to Clef
+	Delay time.Duration
+	// OnHold is called when a transaction is held, e.g. to notify the
+	// operators who may cancel it with TimeLock.Cancel
+	OnHold func(h *HeldRequest)
+}
+
+// HeldRequest is a transaction signing request held by a TimeLock
+type HeldRequest struct {
+	// ID identifies the request for TimeLock.Cancel
+	ID string
+	Tx *TxSummary
+	// Held is when the request was held and Release when it is sent to Clef
+	// unless cancelled
+	Held    time.Time
+	Release time.Time
+}
+
+// TimeLock holds transaction signing requests above a value threshold for a
+// delay, during which they can be cancelled, before they are sent to Clef,
+// so that a compromised caller cannot move large amounts unnoticed
+type TimeLock struct {
+	config TimeLockConfig
+	now    func() time.Time
+	after  func(time.Duration) <-chan time.Time
+
+	mu   sync.Mutex
+	held map[string]*heldRequest
+}
+
+// heldRequest is a HeldRequest with the hold of its call, shared by the
+// entries of a batch
+type heldRequest struct {
+	HeldRequest
+	hold *hold
+}
+
+// hold is the waiting of a call for the delay of its held requests
+type hold struct {
+	cancelled chan struct{}
+	// id is the ID of the cancelled request
+	id string
+}
+
+// NewTimeLock creates a TimeLock
+func NewTimeLock(config TimeLockConfig) *TimeLock {
+	return &TimeLock{config: config, now: time.Now, after: time.After, held: map[string]*heldRequest{}}
+}
+
+// Held returns the requests currently held, oldest first
+func (tl *TimeLock) Held() []*HeldRequest {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	held := make([]*HeldRequest, 0, len(tl.held))
+	for _, h := range tl.held {
+		r := h.HeldRequest
+		held = append(held, &r)
+	}
+	sort.Slice(held, func(i, j int) bool { return held[i].Held.Before(held[j].Held) })
+	return held
+}
+
+// Cancel cancels the held request with the given ID, which then fails with
+// ErrTimeLockCancelled, together with the rest of its batch. It reports
+// whether the request was still held.
+func (tl *TimeLock) Cancel(id string) bool {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	h, ok := tl.held[id]
+	if !ok {
+		return false
+	}
+	for heldID, other := range tl.held {
+		if other.hold == h.hold {
+			delete(tl.held, heldID)
+		}
+	}
+	h.hold.id = id
+	close(h.hold.cancelled)
+	return true
+}
+
+// wait holds the transactions above the threshold among the requests of a
+// call for the delay, failing if one of them is cancelled or ctx is done.
+// The index of the cancelled request is returned with ErrTimeLockCancelled.
+func (tl *TimeLock) wait(ctx context.Context, method string, params []interface{}) (int, error) {
+	if method != "account_signTransaction" {
+		return 0, nil
+	}
+	hd := &hold{cancelled: make(chan struct{})}
+	var held []*heldRequest
+	indexes := map[string]int{}
+	now := tl.now()
+	for i, p := range params {
+		tx, ok := p.(*Transaction)
+		if !ok || tx.Value == "" {
+			continue
+		}
+		value, err := decodeQuantity(tx.Value)
+		if err != nil || value.Cmp(tl.config.Threshold) <= 0 {
+			// Invalid values are reported by Clef
+			continue
+		}
+		id, err := randomID()
+		if err != nil {
+			return 0, err
+		}
+		indexes[id] = i
+		held = append(held, &heldRequest{
+			HeldRequest: HeldRequest{
+				ID:      id,
+				Tx:      &TxSummary{From: tx.From, To: tx.To, Value: tx.Value, Nonce: tx.Nonce, ChainID: tx.ChainID},
+				Held:    now,
+				Release: now.Add(tl.config.Delay),
+			},
+			hold: hd,
+		})
+	}
+	if len(held) == 0 {
+		return 0, nil
+	}
+
+	tl.mu.Lock()
+	for _, h := range held {
+		tl.held[h.ID] = h
+	}
+	tl.mu.Unlock()
+	defer func() {
+		tl.mu.Lock()
+		defer tl.mu.Unlock()
+		for _, h := range held {
+			delete(tl.held, h.ID)
+		}
+	}()
+	if tl.config.OnHold != nil {
+		for _, h := range held {
+			r := h.HeldRequest
+			tl.config.OnHold(&r)
+		}
+	}
+
+	cancelled := func() (int, error) {
+		tl.mu.Lock()
+		defer tl.mu.Unlock()
+		return indexes[hd.id], fmt.Errorf("%w: %w: held request %s", ErrPolicyViolation, ErrTimeLockCancelled, hd.id)
+	}
+	select {
+	case <-hd.cancelled:
+		return cancelled()
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-tl.after(tl.config.Delay):
+	}
+	// A cancellation racing the end of the delay wins
+	select {
+	case <-hd.cancelled:
+		return cancelled()
+	default:
+		return 0, nil
+	}
+}
+
+// EnableTimeLock holds every subsequent transaction signing request above
+// the threshold of tl for its delay before sending it to Clef. Requests
+// cancelled meanwhile fail with both ErrPolicyViolation and
+// ErrTimeLockCancelled and are not sent; a batch is cancelled as a whole.
+// The client's timeout includes the delay, so it must be longer. Requests
+// whose deadline, see WithRequestDeadline, passed during the delay fail with
+// ErrRequestExpired.
+func (cc *ClefClient) EnableTimeLock(tl *TimeLock) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &timeLockTransport{next: cc.transport, lock: tl}
+}
+
+// timeLockTransport is a transport decorator holding requests in a TimeLock
+type timeLockTransport struct {
+	next transport
+	lock *TimeLock
+}
+
+func (t *timeLockTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if _, err := t.lock.wait(ctx, method, []interface{}{params}); err != nil {
+		return nil, err
+	}
+	if err := checkRequestDeadline(ctx); err != nil {
+		return nil, err
+	}
+	return t.next.call(ctx, method, params)
+}
+
+func (t *timeLockTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	if i, err := t.lock.wait(ctx, method, params); err != nil {
+		if errors.Is(err, ErrTimeLockCancelled) {
+			return nil, fmt.Errorf("batch entry %d: %w", i+1, err)
+		}
+		return nil, err
+	}
+	if err := checkRequestDeadline(ctx); err != nil {
+		return nil, err
+	}
+	return t.next.callBatch(ctx, method, params)
+}
+
+func (t *timeLockTransport) close() error {
+	return t.next.close()
+}
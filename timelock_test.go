@@ -0,0 +1,102 @@
+package clefclient
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableTimeLock(t *testing.T) {
+	alice := "0x0000000000000000000000000000000000000001"
+	bob := "0x0000000000000000000000000000000000000002"
+	ether := func(n int64) *big.Int {
+		return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e18))
+	}
+	tx := func(value *big.Int) *Transaction {
+		return &Transaction{From: alice, To: bob, Value: encodeQuantity(value)}
+	}
+
+	held := make(chan *HeldRequest, 2)
+	delay := make(chan time.Time)
+	tl := NewTimeLock(TimeLockConfig{Threshold: ether(10), Delay: time.Hour, OnHold: func(h *HeldRequest) { held <- h }})
+	tl.after = func(d time.Duration) <-chan time.Time {
+		assert.Equal(t, time.Hour, d)
+		return delay
+	}
+	next := &approvingTransport{}
+	cc := &ClefClient{transport: next}
+	cc.EnableTimeLock(tl)
+
+	// Transactions up to the threshold are not held
+	_, err := cc.SignTransaction(tx(ether(10)))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, next.calls)
+
+	// Held transactions are sent after the delay
+	done := make(chan error)
+	go func() {
+		_, err := cc.SignTransaction(tx(ether(11)))
+		done <- err
+	}()
+	h := <-held
+	assert.Equal(t, encodeQuantity(ether(11)), h.Tx.Value)
+	assert.Equal(t, time.Hour, h.Release.Sub(h.Held))
+	assert.Len(t, tl.Held(), 1)
+	delay <- time.Now()
+	assert.NoError(t, <-done)
+	assert.Equal(t, 2, next.calls)
+	assert.Empty(t, tl.Held())
+	assert.False(t, tl.Cancel(h.ID))
+
+	// Cancelled transactions are not sent
+	go func() {
+		_, err := cc.SignTransaction(tx(ether(11)))
+		done <- err
+	}()
+	h = <-held
+	assert.True(t, tl.Cancel(h.ID))
+	err = <-done
+	assert.ErrorIs(t, err, ErrPolicyViolation)
+	assert.ErrorIs(t, err, ErrTimeLockCancelled)
+	assert.Equal(t, 2, next.calls)
+	assert.Empty(t, tl.Held())
+
+	// Cancelling an entry cancels its batch
+	go func() {
+		_, err := cc.SignTransactions([]*Transaction{tx(ether(1)), tx(ether(20)), tx(ether(30))})
+		done <- err
+	}()
+	<-held
+	h = <-held
+	assert.True(t, tl.Cancel(h.ID))
+	err = <-done
+	assert.ErrorIs(t, err, ErrTimeLockCancelled)
+	assert.ErrorContains(t, err, "batch entry 3")
+	assert.Equal(t, 2, next.calls)
+	assert.Empty(t, tl.Held())
+
+	// The context bounds the delay
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_, err := cc.WithContext(ctx).SignTransaction(tx(ether(11)))
+		done <- err
+	}()
+	<-held
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Empty(t, tl.Held())
+
+	// Requests whose deadline passed during the delay are not sent
+	ctx = WithRequestDeadline(context.Background(), time.Now().Add(-time.Second))
+	go func() {
+		_, err := cc.WithContext(ctx).SignTransaction(tx(ether(11)))
+		done <- err
+	}()
+	<-held
+	delay <- time.Now()
+	assert.ErrorIs(t, <-done, ErrRequestExpired)
+	assert.Equal(t, 2, next.calls)
+}
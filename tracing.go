@@ -0,0 +1,93 @@
+package clefclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Tracer starts the spans of calls to Clef. Implement it on top of a
+// tracing library, e.g. OpenTelemetry, to see calls and the time users take
+// to approve them in distributed traces.
+type Tracer interface {
+	// Start starts the span of a call of method to endpoint as a child of
+	// the span in ctx and returns the context carrying the new span
+	Start(ctx context.Context, method, endpoint string) (context.Context, Span)
+}
+
+// Span is the span of a call to Clef
+type Span interface {
+	// Inject adds the trace context of the span to the headers of the HTTP
+	// request, e.g. as a W3C traceparent header
+	Inject(header http.Header)
+	// End records the error of the call, nil on success, and ends the span
+	End(err error)
+}
+
+// EnableTracing wraps every subsequent request made by the client in a span
+// of tracer. The spans are children of the span in the context of the
+// client, see WithContext, and their trace context is propagated to Clef in
+// the headers of HTTP requests.
+func (cc *ClefClient) EnableTracing(tracer Tracer) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &tracingTransport{next: cc.transport, tracer: tracer, endpoint: redactEndpoint(cc.endpoint)}
+}
+
+// tracingTransport is a transport decorator wrapping calls in spans
+type tracingTransport struct {
+	next     transport
+	tracer   Tracer
+	endpoint string
+}
+
+func (t *tracingTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	ctx, span := t.start(ctx, method)
+	resp, err := t.next.call(ctx, method, params)
+	if err == nil && resp.Error != nil {
+		span.End(newCallError(method, resp.Error))
+	} else {
+		span.End(err)
+	}
+	return resp, err
+}
+
+// callBatch wraps the whole batch in one span, failed entries of the batch
+// do not fail the span
+func (t *tracingTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	ctx, span := t.start(ctx, method)
+	resps, err := t.next.callBatch(ctx, method, params)
+	span.End(err)
+	return resps, err
+}
+
+func (t *tracingTransport) close() error {
+	return t.next.close()
+}
+
+// start starts the span of a call and adds its trace context to the headers
+// carried by the returned context
+func (t *tracingTransport) start(ctx context.Context, method string) (context.Context, Span) {
+	ctx, span := t.tracer.Start(ctx, method, t.endpoint)
+	header := http.Header{}
+	span.Inject(header)
+	if len(header) > 0 {
+		ctx = withRequestHeader(ctx, header)
+	}
+	return ctx, span
+}
+
+// redactEndpoint removes credentials and query parameters from an endpoint
+// URL, so that they do not end up in traces. Socket paths are kept as they
+// are.
+func redactEndpoint(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return endpoint
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
@@ -1,29 +1,183 @@
 package clefclient
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 )
 
 // transport defines the interface for different transport mechanisms
 type transport interface {
-	call(method string, params interface{}) (*rpcResponse, error)
+	call(ctx context.Context, method string, params interface{}) (*rpcResponse, error)
+	// callBatch sends one JSON-RPC batch calling method once per params entry
+	// and returns the responses in the order of params. Per-call errors are
+	// left in the Error field of the individual responses.
+	callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error)
 	close() error
 }
 
+// newBatchRequest builds a JSON-RPC batch with sequential IDs starting at 1
+func newBatchRequest(method string, params []interface{}) []rpcRequest {
+	reqs := make([]rpcRequest, len(params))
+	for i, p := range params {
+		reqs[i] = rpcRequest{
+			Jsonrpc: "2.0",
+			Method:  method,
+			Params:  p,
+			ID:      i + 1,
+		}
+	}
+	return reqs
+}
+
+// checkEnvelope checks that resp is a JSON-RPC 2.0 response to the request
+// with the given id
+func checkEnvelope(resp *rpcResponse, id int) error {
+	if resp.Jsonrpc != "2.0" {
+		return malformedError(fmt.Errorf("invalid response: jsonrpc version %q, want \"2.0\"", resp.Jsonrpc))
+	}
+	if resp.ID != id {
+		return malformedError(fmt.Errorf("invalid response: id %d does not match request id %d", resp.ID, id))
+	}
+	return nil
+}
+
+// settleResponse makes responses carrying both a result and an error, as
+// sent by some gateways, fail deterministically: the error wins and the
+// result is dropped from the response, kept on the error for logging
+func settleResponse(resp *rpcResponse) {
+	if resp.Error == nil {
+		return
+	}
+	if len(resp.Result) > 0 && string(resp.Result) != "null" {
+		resp.Error.result = resp.Result
+	}
+	resp.Result = nil
+}
+
+// checkBatchEnvelopes checks that resps are JSON-RPC 2.0 responses to a batch
+// of n requests built by newBatchRequest
+func checkBatchEnvelopes(n int, resps []rpcResponse) error {
+	for i := range resps {
+		if resps[i].Jsonrpc != "2.0" {
+			return malformedError(fmt.Errorf("invalid response: jsonrpc version %q, want \"2.0\"", resps[i].Jsonrpc))
+		}
+		if id := resps[i].ID; id < 1 || id > n {
+			return malformedError(fmt.Errorf("invalid response: id %d does not match any request of the batch", id))
+		}
+	}
+	return nil
+}
+
+// orderBatchResponses matches batch responses to requests by ID, since
+// servers may answer batch entries in any order
+func orderBatchResponses(n int, resps []rpcResponse) []*rpcResponse {
+	ordered := make([]*rpcResponse, n)
+	for i := range resps {
+		if id := resps[i].ID; id >= 1 && id <= n {
+			ordered[id-1] = &resps[i]
+		}
+	}
+	for i, resp := range ordered {
+		if resp == nil {
+			ordered[i] = &rpcResponse{
+				Jsonrpc: "2.0",
+				ID:      i + 1,
+				Error:   &rpcError{Message: fmt.Sprintf("missing response for batch entry %d", i+1)},
+			}
+		}
+	}
+	return ordered
+}
+
+// maxErrorBodySnippet is the number of bytes of a non-JSON answer quoted in
+// the error
+const maxErrorBodySnippet = 200
+
+// jsonBody returns the body of an HTTP answer for decoding, or a descriptive
+// error when it is not JSON, as when a reverse proxy in front of Clef
+// answers with an HTML error page. Server errors of the intermediary are
+// classified as ErrConnection, other non-JSON answers as
+// ErrMalformedResponse.
+func jsonBody(resp *http.Response) (io.Reader, error) {
+	br := bufio.NewReader(resp.Body)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			// Leave empty bodies to the decoder
+			return br, nil
+		}
+		if b[0] == '{' || b[0] == '[' {
+			return br, nil
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b[0])) {
+			break
+		}
+		br.ReadByte()
+	}
+
+	snippet, _ := io.ReadAll(io.LimitReader(br, maxErrorBodySnippet))
+	err := fmt.Errorf("non-JSON answer from an intermediary in front of clef: %s %s (%s): %q",
+		resp.Proto, resp.Status, resp.Header.Get("Content-Type"), strings.TrimSpace(string(snippet)))
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return nil, connectionError(err)
+	}
+	return nil, malformedError(err)
+}
+
 // httpTransport implements transport interface for HTTP JSON-RPC
 type httpTransport struct {
-	url string
+	url    string
+	client *http.Client
+	// header is sent with every request
+	header http.Header
+	// httpsOnly refuses plaintext requests, see WithHTTPSOnly
+	httpsOnly bool
+	// pinErr fails every request if certificates could not be pinned, see
+	// WithPinnedPublicKeys
+	pinErr error
+	// signer signs every request, see WithRequestSigning
+	signer *requestSigner
 }
 
 func newHTTPTransport(url string) *httpTransport {
-	return &httpTransport{url: url}
+	return &httpTransport{url: url, client: http.DefaultClient}
+}
+
+// requireHTTPS refuses plaintext requests and redirects to plaintext URLs.
+// The client is copied, as it may be shared.
+func (t *httpTransport) requireHTTPS() {
+	t.httpsOnly = true
+	client := *t.client
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := checkSecureURL(req.URL.String()); err != nil {
+			return err
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	t.client = &client
 }
 
-func (t *httpTransport) call(method string, params interface{}) (*rpcResponse, error) {
+func (t *httpTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if err := checkRequest(method, params); err != nil {
+		return nil, err
+	}
 	reqBody, err := json.Marshal(rpcRequest{
 		Jsonrpc: "2.0",
 		Method:  method,
@@ -34,42 +188,233 @@ func (t *httpTransport) call(method string, params interface{}) (*rpcResponse, e
 		return nil, err
 	}
 
-	resp, err := http.Post(t.url, "application/json", bytes.NewBuffer(reqBody))
+	resp, err := t.post(ctx, reqBody)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	body, err := jsonBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
 	var rpcResp rpcResponse
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+	if err := json.NewDecoder(body).Decode(&rpcResp); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if err := checkEnvelope(&rpcResp, 1); err != nil {
 		return nil, err
 	}
+	settleResponse(&rpcResp)
 
 	if rpcResp.Error != nil {
-		return nil, errors.New(rpcResp.Error.Message)
+		return nil, newCallError(method, rpcResp.Error)
 	}
 
 	return &rpcResp, nil
 }
 
+func (t *httpTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	if err := checkBatchRequest(method, params); err != nil {
+		return nil, err
+	}
+	reqBody, err := json.Marshal(newBatchRequest(method, params))
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.post(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := jsonBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var rpcResps []rpcResponse
+	if err := json.NewDecoder(body).Decode(&rpcResps); err != nil {
+		return nil, decodeError(err)
+	}
+
+	if err := checkBatchEnvelopes(len(params), rpcResps); err != nil {
+		return nil, err
+	}
+	for i := range rpcResps {
+		settleResponse(&rpcResps[i])
+	}
+
+	return orderBatchResponses(len(params), rpcResps), nil
+}
+
+// post sends a JSON-RPC request body with the headers carried by ctx
+func (t *httpTransport) post(ctx context.Context, body []byte) (*http.Response, error) {
+	if t.pinErr != nil {
+		return nil, t.pinErr
+	}
+	if t.httpsOnly {
+		if err := checkSecureURL(t.url); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, connectionError(err)
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	if id := requestClientID(ctx); id != "" {
+		req.Header.Set("X-Client-Id", id)
+	}
+	for name, values := range t.header {
+		req.Header[name] = values
+	}
+	for name, values := range requestHeader(ctx) {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.signer != nil {
+		t.signer.sign(req.Header, body)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, contextError(err)
+		}
+		if errors.Is(err, ErrInsecureEndpoint) || errors.Is(err, ErrPinMismatch) {
+			// Refused redirect or certificate, not worth retrying
+			return nil, err
+		}
+		return nil, connectionError(err)
+	}
+	if observe, ok := ctx.Value(byteObserverKey{}).(byteObserver); ok {
+		resp.Body = &countingBody{ReadCloser: resp.Body, sent: int64(len(body)), observe: observe}
+	}
+	return resp, nil
+}
+
+// byteObserver is called with the number of bytes sent and received for a
+// call
+type byteObserver func(sent, received int64)
+
+// byteObserverKey is the context key of the byteObserver of a call
+type byteObserverKey struct{}
+
+// withByteObserver returns a context whose calls report the bytes they
+// exchange with Clef to observe
+func withByteObserver(ctx context.Context, observe byteObserver) context.Context {
+	return context.WithValue(ctx, byteObserverKey{}, observe)
+}
+
+// observeBytes reports the bytes exchanged by a call to the byteObserver of
+// ctx, if any
+func observeBytes(ctx context.Context, sent, received int64) {
+	if observe, ok := ctx.Value(byteObserverKey{}).(byteObserver); ok {
+		observe(sent, received)
+	}
+}
+
+// countingBody counts the bytes read from a response body and reports them
+// once the body is closed
+type countingBody struct {
+	io.ReadCloser
+	sent, received int64
+	observe        byteObserver
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.received += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.observe(b.sent, b.received)
+	return b.ReadCloser.Close()
+}
+
+// clientIDKey is the context key of the identity of the client making a
+// request, see WithClientID
+type clientIDKey struct{}
+
+// withClientID returns a context carrying the identity of a client
+func withClientID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, clientIDKey{}, id)
+}
+
+// requestClientID returns the client identity carried by ctx
+func requestClientID(ctx context.Context) string {
+	id, _ := ctx.Value(clientIDKey{}).(string)
+	return id
+}
+
+// requestHeaderKey is the context key of the headers added to HTTP requests
+type requestHeaderKey struct{}
+
+// withRequestHeader returns a context carrying header in addition to the
+// headers already carried by ctx
+func withRequestHeader(ctx context.Context, header http.Header) context.Context {
+	merged := requestHeader(ctx).Clone()
+	if merged == nil {
+		merged = http.Header{}
+	}
+	for name, values := range header {
+		merged[name] = values
+	}
+	return context.WithValue(ctx, requestHeaderKey{}, merged)
+}
+
+// requestHeader returns the headers carried by ctx
+func requestHeader(ctx context.Context) http.Header {
+	header, _ := ctx.Value(requestHeaderKey{}).(http.Header)
+	return header
+}
+
 func (t *httpTransport) close() error {
 	return nil // HTTP transport doesn't need explicit cleanup
 }
 
-// ipcTransport implements transport interface for IPC
+// ipcTransport implements transport interface for IPC. Requests share a
+// single connection, so calls are serialized.
 type ipcTransport struct {
+	mu   sync.Mutex
 	conn net.Conn
+	dec  *json.Decoder
+	// broken is set once a request was written only in part or its answer
+	// was abandoned, after which the stream cannot be framed anymore
+	broken error
 }
 
-func newIPCTransport(socketPath string) (*ipcTransport, error) {
-	conn, err := net.Dial("unix", socketPath)
+// ipcWriteTimeout bounds the time writing a request to the socket may take,
+// so a stalled Clef cannot block callers forever
+const ipcWriteTimeout = 30 * time.Second
+
+func newIPCTransport(ctx context.Context, socketPath string) (*ipcTransport, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socketPath)
 	if err != nil {
-		return nil, err
+		return nil, connectionError(err)
 	}
-	return &ipcTransport{conn: conn}, nil
+	return newStreamTransport(conn), nil
+}
+
+// newStreamTransport creates an ipcTransport exchanging newline separated
+// JSON-RPC messages over conn
+func newStreamTransport(conn net.Conn) *ipcTransport {
+	return &ipcTransport{conn: conn, dec: json.NewDecoder(conn)}
 }
 
-func (t *ipcTransport) call(method string, params interface{}) (*rpcResponse, error) {
+func (t *ipcTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if err := checkRequest(method, params); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	reqBody, err := json.Marshal(rpcRequest{
 		Jsonrpc: "2.0",
 		Method:  method,
@@ -80,23 +425,129 @@ func (t *ipcTransport) call(method string, params interface{}) (*rpcResponse, er
 		return nil, err
 	}
 
-	_, err = t.conn.Write(append(reqBody, '\n'))
-	if err != nil {
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+	if err := t.writeFrame(reqBody); err != nil {
 		return nil, err
 	}
 
 	var rpcResp rpcResponse
-	if err := json.NewDecoder(t.conn).Decode(&rpcResp); err != nil {
+	offset := t.dec.InputOffset()
+	err = t.readFrame(ctx, &rpcResp)
+	observeBytes(ctx, int64(len(reqBody))+1, t.dec.InputOffset()-offset)
+	if err != nil {
 		return nil, err
 	}
 
+	if err := checkEnvelope(&rpcResp, 1); err != nil {
+		return nil, err
+	}
+	settleResponse(&rpcResp)
+
 	if rpcResp.Error != nil {
-		return nil, errors.New(rpcResp.Error.Message)
+		return nil, newCallError(method, rpcResp.Error)
 	}
 
 	return &rpcResp, nil
 }
 
+func (t *ipcTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	if err := checkBatchRequest(method, params); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reqBody, err := json.Marshal(newBatchRequest(method, params))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err)
+	}
+	if err := t.writeFrame(reqBody); err != nil {
+		return nil, err
+	}
+
+	var rpcResps []rpcResponse
+	offset := t.dec.InputOffset()
+	err = t.readFrame(ctx, &rpcResps)
+	observeBytes(ctx, int64(len(reqBody))+1, t.dec.InputOffset()-offset)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkBatchEnvelopes(len(params), rpcResps); err != nil {
+		return nil, err
+	}
+	for i := range rpcResps {
+		settleResponse(&rpcResps[i])
+	}
+
+	return orderBatchResponses(len(params), rpcResps), nil
+}
+
+// writeFrame writes reqBody followed by the newline delimiting requests on
+// the socket, looping over short writes so large requests such as big
+// EIP-712 payloads are written whole. JSON encoding escapes newlines, so the
+// frame holds exactly one. A request written only in part would corrupt the
+// stream, so the connection is closed and later calls fail. The caller must
+// hold t.mu.
+func (t *ipcTransport) writeFrame(reqBody []byte) error {
+	if t.broken != nil {
+		return t.broken
+	}
+	frame := append(reqBody, '\n')
+	t.conn.SetWriteDeadline(time.Now().Add(ipcWriteTimeout))
+	defer t.conn.SetWriteDeadline(time.Time{})
+
+	written := 0
+	for written < len(frame) {
+		n, err := t.conn.Write(frame[written:])
+		written += n
+		if err == nil && n == 0 {
+			err = io.ErrShortWrite
+		}
+		if err == nil {
+			continue
+		}
+		if written > 0 {
+			t.broken = connectionError(fmt.Errorf("ipc connection closed after a partial write: %w", err))
+			t.conn.Close()
+		}
+		return connectionError(err)
+	}
+	return nil
+}
+
+// readFrame decodes the answer to the request just written. Once ctx is done
+// the read is aborted and the connection closed, since the late answer would
+// otherwise be taken for the answer to the next request.
+func (t *ipcTransport) readFrame(ctx context.Context, v interface{}) error {
+	aborted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		t.conn.SetReadDeadline(time.Now())
+		close(aborted)
+	})
+	err := t.dec.Decode(v)
+	if !stop() {
+		<-aborted
+		if err != nil {
+			t.broken = connectionError(fmt.Errorf("ipc connection closed after an aborted call: %w", ctx.Err()))
+			t.conn.Close()
+			return contextError(fmt.Errorf("ipc call aborted: %w", ctx.Err()))
+		}
+		t.conn.SetReadDeadline(time.Time{})
+	}
+	if err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
 func (t *ipcTransport) close() error {
 	return t.conn.Close()
 }
@@ -0,0 +1,177 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ErrNoFeeToBump is returned when a transaction has neither gasPrice nor
+// maxFeePerGas set, so a replacement with higher fees cannot be built
+var ErrNoFeeToBump = errors.New("transaction has no fee fields to bump")
+
+// ErrMaxAttempts is returned when a transaction was not mined after the
+// configured number of submissions
+var ErrMaxAttempts = errors.New("transaction not mined after maximum number of attempts")
+
+// TxManagerConfig configures the resubmission behaviour of a TxManager
+type TxManagerConfig struct {
+	// ResubmitTimeout is how long to wait for inclusion before re-signing with bumped fees
+	ResubmitTimeout time.Duration
+	// PollInterval is how often receipts are polled
+	PollInterval time.Duration
+	// FeeBumpPercent is the percentage by which fees are raised on every resubmission
+	FeeBumpPercent int64
+	// MaxFeePerGas caps the fee a bumped transaction may pay, nil means no cap
+	MaxFeePerGas *big.Int
+	// MaxAttempts is the maximum number of submissions, 0 means no limit
+	MaxAttempts int
+}
+
+// DefaultTxManagerConfig returns a config suitable for most networks
+func DefaultTxManagerConfig() TxManagerConfig {
+	return TxManagerConfig{
+		ResubmitTimeout: 3 * time.Minute,
+		PollInterval:    5 * time.Second,
+		FeeBumpPercent:  12,
+		MaxAttempts:     5,
+	}
+}
+
+// TxManager signs transactions through Clef, broadcasts them and resubmits
+// them at the same nonce with bumped fees until they are mined
+type TxManager struct {
+	client  Signer
+	backend Backend
+	config  TxManagerConfig
+}
+
+// NewTxManager creates a new TxManager
+func NewTxManager(client Signer, backend Backend, config TxManagerConfig) *TxManager {
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	// Nodes reject replacements that raise fees by less than 10%
+	if config.FeeBumpPercent < MinReplacementBumpPercent {
+		config.FeeBumpPercent = MinReplacementBumpPercent
+	}
+	return &TxManager{client: client, backend: backend, config: config}
+}
+
+// Send signs and broadcasts the transaction and blocks until one of its
+// submissions is mined, the context is done or the attempts are exhausted
+func (m *TxManager) Send(ctx context.Context, tx *Transaction) (*Receipt, error) {
+	if tx == nil {
+		return nil, nilRequestError("transaction")
+	}
+	cur := *tx
+	if cur.Nonce == "" {
+		nonce, err := m.backend.PendingNonceAt(cur.From)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch nonce: %w", err)
+		}
+		cur.Nonce = nonce
+	}
+
+	var hashes []string
+	for attempt := 1; ; attempt++ {
+		signed, err := m.client.SignTransaction(&cur)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign transaction: %w", err)
+		}
+
+		hash, err := m.backend.SendRawTransaction(signed.Raw)
+		if err != nil {
+			// An earlier submission may still be mined, so only give up
+			// if nothing has been broadcast yet
+			if len(hashes) == 0 {
+				return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+			}
+		} else {
+			hashes = append(hashes, hash)
+		}
+
+		receipt, err := m.waitAny(ctx, hashes)
+		if err != nil || receipt != nil {
+			return receipt, err
+		}
+
+		if m.config.MaxAttempts > 0 && attempt >= m.config.MaxAttempts {
+			return nil, ErrMaxAttempts
+		}
+		if err := bumpFees(&cur, m.config.FeeBumpPercent, m.config.MaxFeePerGas); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitAny polls the receipts of all submitted hashes until one is mined or
+// the resubmit timeout expires, in which case it returns a nil receipt
+func (m *TxManager) waitAny(ctx context.Context, hashes []string) (*Receipt, error) {
+	ticks, stop, err := newBlockTicker(ctx, m.backend, m.config.PollInterval)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	var timeout <-chan time.Time
+	if m.config.ResubmitTimeout > 0 {
+		timer := time.NewTimer(m.config.ResubmitTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		for _, hash := range hashes {
+			receipt, err := confirmedReceipt(m.backend, hash, 1)
+			if err != nil {
+				return nil, err
+			}
+			if receipt != nil {
+				return receipt, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, nil
+		case <-ticks:
+		}
+	}
+}
+
+// bumpFees raises the fee fields of tx by percent, capped at maxFee
+func bumpFees(tx *Transaction, percent int64, maxFee *big.Int) error {
+	fields := []*string{&tx.GasPrice, &tx.MaxFeePerGas, &tx.MaxPriorityFeePerGas}
+	bumped := false
+	for _, field := range fields {
+		if *field == "" {
+			continue
+		}
+		v, err := decodeQuantity(*field)
+		if err != nil {
+			return err
+		}
+		v = bumpValue(v, percent)
+		if maxFee != nil && v.Cmp(maxFee) > 0 {
+			return fmt.Errorf("bumped fee %s exceeds maximum %s", v, maxFee)
+		}
+		*field = encodeQuantity(v)
+		bumped = true
+	}
+	if !bumped {
+		return ErrNoFeeToBump
+	}
+	return nil
+}
+
+// bumpValue returns v increased by percent, rounded up
+func bumpValue(v *big.Int, percent int64) *big.Int {
+	n := new(big.Int).Mul(v, big.NewInt(100+percent))
+	n.Add(n, big.NewInt(99))
+	return n.Div(n, big.NewInt(100))
+}
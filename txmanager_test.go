@@ -0,0 +1,128 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBackend is an in-memory Backend that mines a transaction once its raw
+// payload matches minedRaw
+type fakeBackend struct {
+	mu       sync.Mutex
+	nonce    string
+	head     string
+	minedRaw string
+	sent     []string
+	receipts map[string]*Receipt
+}
+
+func (b *fakeBackend) PendingNonceAt(account string) (string, error) {
+	return b.nonce, nil
+}
+
+func (b *fakeBackend) SendRawTransaction(raw string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, raw)
+	hash := "0xhash" + raw
+	if raw == b.minedRaw {
+		b.receipts[hash] = &Receipt{TransactionHash: hash, Status: "0x1", BlockNumber: "0x10"}
+	}
+	return hash, nil
+}
+
+func (b *fakeBackend) BlockNumber() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.head, nil
+}
+
+func (b *fakeBackend) TransactionReceipt(hash string) (*Receipt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.receipts[hash], nil
+}
+
+// setupEchoSignServer returns a client whose signed raw transaction encodes the nonce and gas price
+func setupEchoSignServer(t *testing.T) (*ClefClient, *httptest.Server) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string      `json:"method"`
+			Params Transaction `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "account_signTransaction", req.Method)
+
+		result, _ := json.Marshal(SignTxResponse{Raw: req.Params.Nonce + "-" + req.Params.GasPrice})
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: result})
+	}))
+	return NewHTTPClient(server.URL), server
+}
+
+func TestTxManagerSendMinedFirstAttempt(t *testing.T) {
+	client, server := setupEchoSignServer(t)
+	defer server.Close()
+
+	backend := &fakeBackend{nonce: "0x7", minedRaw: "0x7-0x64", receipts: map[string]*Receipt{}}
+	m := NewTxManager(client, backend, TxManagerConfig{PollInterval: time.Millisecond, ResubmitTimeout: time.Second})
+
+	receipt, err := m.Send(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002", GasPrice: "0x64"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xhash0x7-0x64", receipt.TransactionHash)
+	assert.Equal(t, []string{"0x7-0x64"}, backend.sent)
+
+	_, err = m.Send(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrNilRequest)
+}
+
+func TestTxManagerSendResubmitsWithBumpedFees(t *testing.T) {
+	client, server := setupEchoSignServer(t)
+	defer server.Close()
+
+	// 0x64 (100) bumped by 10% twice is 0x79 (121)
+	backend := &fakeBackend{minedRaw: "0x1-0x79", receipts: map[string]*Receipt{}}
+	m := NewTxManager(client, backend, TxManagerConfig{
+		PollInterval:    time.Millisecond,
+		ResubmitTimeout: 10 * time.Millisecond,
+		FeeBumpPercent:  10,
+	})
+
+	receipt, err := m.Send(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002", GasPrice: "0x64", Nonce: "0x1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xhash0x1-0x79", receipt.TransactionHash)
+	assert.Equal(t, []string{"0x1-0x64", "0x1-0x6e", "0x1-0x79"}, backend.sent)
+}
+
+func TestTxManagerSendMaxAttempts(t *testing.T) {
+	client, server := setupEchoSignServer(t)
+	defer server.Close()
+
+	backend := &fakeBackend{receipts: map[string]*Receipt{}}
+	m := NewTxManager(client, backend, TxManagerConfig{
+		PollInterval:    time.Millisecond,
+		ResubmitTimeout: time.Millisecond,
+		MaxAttempts:     2,
+	})
+
+	_, err := m.Send(context.Background(), &Transaction{From: "0x0000000000000000000000000000000000000001", GasPrice: "0x64", Nonce: "0x1"})
+	assert.ErrorIs(t, err, ErrMaxAttempts)
+	assert.Len(t, backend.sent, 2)
+}
+
+func TestBumpFees(t *testing.T) {
+	tx := &Transaction{MaxFeePerGas: "0x64", MaxPriorityFeePerGas: "0xa"}
+	assert.NoError(t, bumpFees(tx, 12, nil))
+	assert.Equal(t, "0x70", tx.MaxFeePerGas)
+	assert.Equal(t, "0xc", tx.MaxPriorityFeePerGas)
+
+	assert.Error(t, bumpFees(&Transaction{GasPrice: "0x64"}, 12, big.NewInt(100)))
+	assert.ErrorIs(t, bumpFees(&Transaction{}, 12, nil), ErrNoFeeToBump)
+}
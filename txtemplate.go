@@ -0,0 +1,174 @@
+package clefclient
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxTrackedIntents bounds the number of instantiated transactions whose
+// intent a registry remembers
+const maxTrackedIntents = 1024
+
+// ErrUnknownTemplate is returned when instantiating an unregistered template
+var ErrUnknownTemplate = errors.New("unknown transaction template")
+
+// TxTemplate is a named, parameterized transaction such as "treasury-payout"
+type TxTemplate struct {
+	Name        string
+	Description string
+	// Params are the names of the arguments Build expects, all required
+	Params []string
+	Build  func(args map[string]string) (*Transaction, error)
+}
+
+// TxIntent records which template and arguments a transaction was built from
+type TxIntent struct {
+	Template string            `json:"template"`
+	Args     map[string]string `json:"args,omitempty"`
+}
+
+// TxTemplateRegistry holds transaction templates and remembers the intent of
+// the transactions instantiated from them, for audit logs and policies
+type TxTemplateRegistry struct {
+	mu        sync.Mutex
+	templates map[string]*TxTemplate
+	intents   map[string]*TxIntent
+	order     []string
+}
+
+// NewTxTemplateRegistry creates an empty TxTemplateRegistry
+func NewTxTemplateRegistry() *TxTemplateRegistry {
+	return &TxTemplateRegistry{templates: map[string]*TxTemplate{}, intents: map[string]*TxIntent{}}
+}
+
+// Register adds a template, failing if its name is taken
+func (r *TxTemplateRegistry) Register(template TxTemplate) error {
+	if template.Name == "" || template.Build == nil {
+		return fmt.Errorf("template requires a name and a build function")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.templates[template.Name]; ok {
+		return fmt.Errorf("template %s is already registered", template.Name)
+	}
+	r.templates[template.Name] = &template
+	return nil
+}
+
+// Templates returns the registered templates ordered by name
+func (r *TxTemplateRegistry) Templates() []TxTemplate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	templates := make([]TxTemplate, 0, len(r.templates))
+	for _, t := range r.templates {
+		templates = append(templates, *t)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates
+}
+
+// Instantiate builds a transaction from the named template. Arguments must
+// match the template's parameters exactly.
+func (r *TxTemplateRegistry) Instantiate(name string, args map[string]string) (*Transaction, error) {
+	r.mu.Lock()
+	template, ok := r.templates[name]
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	for _, param := range template.Params {
+		if _, ok := args[param]; !ok {
+			return nil, fmt.Errorf("template %s: missing argument %s", name, param)
+		}
+	}
+	if len(args) != len(template.Params) {
+		for arg := range args {
+			if !slices.Contains(template.Params, arg) {
+				return nil, fmt.Errorf("template %s: unknown argument %s", name, arg)
+			}
+		}
+	}
+
+	copied := make(map[string]string, len(args))
+	for k, v := range args {
+		copied[k] = v
+	}
+	tx, err := template.Build(copied)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	r.remember(tx, &TxIntent{Template: name, Args: copied})
+	return tx, nil
+}
+
+// IntentOf returns the intent of a transaction instantiated by the registry.
+// Transactions are matched on sender, recipient, value and data, so fields
+// filled in later such as the nonce or fees do not matter.
+func (r *TxTemplateRegistry) IntentOf(tx *Transaction) (*TxIntent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	intent, ok := r.intents[intentKey(tx)]
+	return intent, ok
+}
+
+func (r *TxTemplateRegistry) remember(tx *Transaction, intent *TxIntent) {
+	key := intentKey(tx)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.intents[key]; !ok {
+		r.order = append(r.order, key)
+	}
+	r.intents[key] = intent
+	if len(r.order) > maxTrackedIntents {
+		delete(r.intents, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// intentKey identifies the content of a transaction independent of its
+// nonce, gas and fees
+func intentKey(tx *Transaction) string {
+	value := tx.Value
+	if v, err := decodeQuantity(value); err == nil {
+		value = encodeQuantity(v)
+	} else if value == "" {
+		value = "0x0"
+	}
+	return strings.ToLower(strings.Join([]string{tx.From, tx.To, value, tx.Data}, "|"))
+}
+
+// SignTemplate instantiates the named template and signs the transaction
+func (cc *ClefClient) SignTemplate(registry *TxTemplateRegistry, name string, args map[string]string) (*SignTxResponse, error) {
+	if registry == nil {
+		return nil, nilRequestError("template registry")
+	}
+	tx, err := registry.Instantiate(name, args)
+	if err != nil {
+		return nil, err
+	}
+	return cc.SignTransaction(tx)
+}
+
+// RequireTemplate is a DryRunPolicy rejecting transactions that were not
+// instantiated from one of the named templates, or from any template if no
+// names are given
+func RequireTemplate(registry *TxTemplateRegistry, names ...string) DryRunPolicy {
+	return func(method string, params interface{}) error {
+		tx, ok := params.(*Transaction)
+		if !ok {
+			return nil
+		}
+		intent, ok := registry.IntentOf(tx)
+		if !ok {
+			return fmt.Errorf("transaction was not built from a template")
+		}
+		if len(names) > 0 && !slices.Contains(names, intent.Template) {
+			return fmt.Errorf("template %s is not allowed", intent.Template)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+package clefclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testTemplateRegistry(t *testing.T) *TxTemplateRegistry {
+	registry := NewTxTemplateRegistry()
+	assert.NoError(t, registry.Register(TxTemplate{
+		Name:   "treasury-payout",
+		Params: []string{"recipient", "amount"},
+		Build: func(args map[string]string) (*Transaction, error) {
+			usdc := ERC20Token{Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Decimals: 6}
+			return usdc.Transfer("0x0000000000000000000000000000000000000001", args["recipient"], args["amount"])
+		},
+	}))
+	return registry
+}
+
+func TestTxTemplateRegistry(t *testing.T) {
+	registry := testTemplateRegistry(t)
+	assert.Error(t, registry.Register(TxTemplate{Name: "treasury-payout", Build: registry.templates["treasury-payout"].Build}))
+	assert.Len(t, registry.Templates(), 1)
+
+	args := map[string]string{"recipient": "0x0000000000000000000000000000000000000002", "amount": "10"}
+	tx, err := registry.Instantiate("treasury-payout", args)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xa9059cbb"+word("2")+word("989680"), tx.Data)
+
+	filled := *tx
+	filled.Nonce, filled.GasPrice = "0x3", "0x1"
+	intent, ok := registry.IntentOf(&filled)
+	assert.True(t, ok)
+	assert.Equal(t, &TxIntent{Template: "treasury-payout", Args: args}, intent)
+
+	_, ok = registry.IntentOf(&Transaction{From: tx.From, To: tx.To})
+	assert.False(t, ok)
+
+	_, err = registry.Instantiate("validator-exit", nil)
+	assert.ErrorIs(t, err, ErrUnknownTemplate)
+	_, err = registry.Instantiate("treasury-payout", map[string]string{"recipient": "0x02"})
+	assert.ErrorContains(t, err, "missing argument amount")
+	_, err = registry.Instantiate("treasury-payout", map[string]string{"recipient": "0x02", "amount": "1", "memo": "x"})
+	assert.ErrorContains(t, err, "unknown argument memo")
+	_, err = registry.Instantiate("treasury-payout", map[string]string{"recipient": "0x02", "amount": "1"})
+	assert.ErrorContains(t, err, "template treasury-payout")
+}
+
+func TestTemplateIntentInAudit(t *testing.T) {
+	client, server := setupHTTPTestServer(t, "account_signTransaction", SignTxResponse{Raw: "0xraw"})
+	defer server.Close()
+
+	registry := testTemplateRegistry(t)
+	store := &MemoryAuditStore{}
+	client.EnableAudit(NewAuditLog(AuditConfig{Store: store, Templates: registry}))
+
+	_, err := client.SignTemplate(registry, "treasury-payout", map[string]string{
+		"recipient": "0x0000000000000000000000000000000000000002", "amount": "1.5",
+	})
+	assert.NoError(t, err)
+	_, err = client.SignTransaction(&Transaction{From: "0x0000000000000000000000000000000000000001", To: "0x0000000000000000000000000000000000000002"})
+	assert.NoError(t, err)
+
+	entries := store.Entries()
+	if assert.Len(t, entries, 4) {
+		assert.Equal(t, "treasury-payout", entries[1].Intent.Template)
+		assert.Equal(t, "1.5", entries[1].Intent.Args["amount"])
+		assert.Nil(t, entries[3].Intent)
+	}
+}
+
+func TestRequireTemplate(t *testing.T) {
+	registry := testTemplateRegistry(t)
+	dryRun := NewHTTPClient("http://localhost:0").DryRun(DryRunConfig{Policies: []DryRunPolicy{RequireTemplate(registry, "treasury-payout")}})
+
+	tx, err := registry.Instantiate("treasury-payout", map[string]string{"recipient": "0x0000000000000000000000000000000000000002", "amount": "1"})
+	assert.NoError(t, err)
+	_, err = dryRun.SignTransaction(tx)
+	assert.NoError(t, err)
+
+	_, err = dryRun.SignTransaction(&Transaction{From: tx.From, To: tx.To, Data: "0x"})
+	assert.ErrorContains(t, err, "not built from a template")
+}
@@ -0,0 +1,285 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TypedDataField is a field of an EIP-712 struct type
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is an EIP-712 typed data document as accepted by clef
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// eip712DomainFields are the fields of the EIP712Domain type used by most contracts
+var eip712DomainFields = []TypedDataField{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+}
+
+// Request builds the request for signing the typed data with the given account
+func (td *TypedData) Request(address string) (*TypedDataRequest, error) {
+	data, err := json.Marshal(td)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedDataRequest{Address: address, TypedData: data, RawVersion: "V4"}, nil
+}
+
+// Hash returns the EIP-712 digest of the typed data, the hash that is signed
+func (td *TypedData) Hash() ([]byte, error) {
+	domainSeparator, err := td.HashStruct("EIP712Domain", td.Domain)
+	if err != nil {
+		return nil, fmt.Errorf("domain: %w", err)
+	}
+	messageHash, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, fmt.Errorf("message: %w", err)
+	}
+	return keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash), nil
+}
+
+// HashStruct returns the EIP-712 hashStruct of data as an instance of typeName
+func (td *TypedData) HashStruct(typeName string, data map[string]interface{}) ([]byte, error) {
+	typeHash, err := td.TypeHash(typeName)
+	if err != nil {
+		return nil, err
+	}
+	encoded := [][]byte{typeHash}
+	for _, field := range td.Types[typeName] {
+		word, err := td.encodeValue(field.Type, data[field.Name])
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", typeName, field.Name, err)
+		}
+		encoded = append(encoded, word)
+	}
+	return keccak256(encoded...), nil
+}
+
+// TypeHash returns the hash of the EIP-712 encoded type
+func (td *TypedData) TypeHash(typeName string) ([]byte, error) {
+	encoded, err := td.EncodeType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	return keccak256([]byte(encoded)), nil
+}
+
+// EncodeType returns the EIP-712 type string of typeName followed by its
+// referenced struct types in alphabetical order
+func (td *TypedData) EncodeType(typeName string) (string, error) {
+	if _, ok := td.Types[typeName]; !ok {
+		return "", fmt.Errorf("type %s is not defined", typeName)
+	}
+	deps := map[string]bool{}
+	td.collectDependencies(typeName, deps)
+	delete(deps, typeName)
+	sorted := make([]string, 0, len(deps))
+	for dep := range deps {
+		sorted = append(sorted, dep)
+	}
+	sort.Strings(sorted)
+
+	var sb strings.Builder
+	for _, name := range append([]string{typeName}, sorted...) {
+		sb.WriteString(name + "(")
+		for i, field := range td.Types[name] {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(field.Type + " " + field.Name)
+		}
+		sb.WriteString(")")
+	}
+	return sb.String(), nil
+}
+
+func (td *TypedData) collectDependencies(typeName string, deps map[string]bool) {
+	if deps[typeName] {
+		return
+	}
+	if _, ok := td.Types[typeName]; !ok {
+		return
+	}
+	deps[typeName] = true
+	for _, field := range td.Types[typeName] {
+		td.collectDependencies(baseType(field.Type), deps)
+	}
+}
+
+// baseType strips array suffixes from a type
+func baseType(typ string) string {
+	if i := strings.Index(typ, "["); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
+// encodeValue encodes a single member value as a 32 byte word
+func (td *TypedData) encodeValue(typ string, value interface{}) ([]byte, error) {
+	if strings.HasSuffix(typ, "]") {
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array for %s", typ)
+		}
+		elemType := typ[:strings.LastIndex(typ, "[")]
+		var encoded [][]byte
+		for i, item := range items {
+			word, err := td.encodeValue(elemType, item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			encoded = append(encoded, word)
+		}
+		return keccak256(encoded...), nil
+	}
+
+	if _, ok := td.Types[typ]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object for %s", typ)
+		}
+		return td.HashStruct(typ, data)
+	}
+
+	switch {
+	case typ == "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string")
+		}
+		return keccak256([]byte(s)), nil
+	case typ == "bytes":
+		b, err := typedBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return keccak256(b), nil
+	}
+	return encodeStaticWord(typ, value)
+}
+
+// encodeStaticWord encodes a value of an elementary static type (bool,
+// address, bytesN, intN or uintN) as a 32 byte word
+func encodeStaticWord(typ string, value interface{}) ([]byte, error) {
+	switch {
+	case typ == "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected bool")
+		}
+		word := make([]byte, abiWordSize)
+		if b {
+			word[abiWordSize-1] = 1
+		}
+		return word, nil
+	case typ == "address":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected address string")
+		}
+		return encodeAddressWord(s)
+	case strings.HasPrefix(typ, "bytes"):
+		size, err := strconv.Atoi(typ[len("bytes"):])
+		if err != nil || size < 1 || size > 32 {
+			return nil, fmt.Errorf("invalid type %s", typ)
+		}
+		b, err := typedBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != size {
+			return nil, fmt.Errorf("expected %d bytes, got %d", size, len(b))
+		}
+		word := make([]byte, abiWordSize)
+		copy(word, b)
+		return word, nil
+	case strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "int"):
+		signed := strings.HasPrefix(typ, "int")
+		bits, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int"))
+		if err != nil || bits < 8 || bits > 256 || bits%8 != 0 {
+			return nil, fmt.Errorf("invalid type %s", typ)
+		}
+		v, err := typedInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		if !signed {
+			if v.Sign() < 0 || v.BitLen() > bits {
+				return nil, fmt.Errorf("value %s does not fit in %s", v, typ)
+			}
+			return v.FillBytes(make([]byte, abiWordSize)), nil
+		}
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+		if v.Cmp(limit) >= 0 || v.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return nil, fmt.Errorf("value %s does not fit in %s", v, typ)
+		}
+		if v.Sign() < 0 {
+			v = new(big.Int).Add(v, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return v.FillBytes(make([]byte, abiWordSize)), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+// typedBytes converts a hex string or byte slice member value to bytes
+func typedBytes(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return decodeHexData(v)
+	}
+	return nil, fmt.Errorf("expected hex bytes")
+}
+
+// typedInteger converts an integer member value given as a decimal or hex
+// string, JSON number or Go integer
+func typedInteger(value interface{}) (*big.Int, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		if v != nil {
+			return v, nil
+		}
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int32:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint32:
+		return new(big.Int).SetUint64(uint64(v)), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("expected integer, got %v", v)
+		}
+		return big.NewInt(int64(v)), nil
+	case json.Number:
+		if n, ok := new(big.Int).SetString(v.String(), 10); ok {
+			return n, nil
+		}
+	case string:
+		if n, ok := new(big.Int).SetString(v, 0); ok {
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("expected integer, got %v", value)
+}
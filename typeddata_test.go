@@ -0,0 +1,103 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mailTypedData is the example from the EIP-712 specification
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedDataHash(t *testing.T) {
+	var td TypedData
+	assert.NoError(t, json.Unmarshal([]byte(mailTypedData), &td))
+
+	encoded, err := td.EncodeType("Mail")
+	assert.NoError(t, err)
+	assert.Equal(t, "Mail(Person from,Person to,string contents)Person(string name,address wallet)", encoded)
+
+	domainSeparator, err := td.HashStruct("EIP712Domain", td.Domain)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f", encodeHexData(domainSeparator))
+
+	structHash, err := td.HashStruct("Mail", td.Message)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e", encodeHexData(structHash))
+
+	digest, err := td.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", encodeHexData(digest))
+}
+
+func TestTypedDataEncodeValues(t *testing.T) {
+	td := &TypedData{Types: map[string][]TypedDataField{
+		"Values": {
+			{Name: "flag", Type: "bool"},
+			{Name: "delta", Type: "int8"},
+			{Name: "id", Type: "bytes4"},
+			{Name: "amounts", Type: "uint16[]"},
+		},
+	}}
+	_, err := td.HashStruct("Values", map[string]interface{}{
+		"flag":    true,
+		"delta":   -1,
+		"id":      "0x01020304",
+		"amounts": []interface{}{"1", big.NewInt(2), float64(3)},
+	})
+	assert.NoError(t, err)
+
+	word, err := td.encodeValue("int8", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x"+strings.Repeat("ff", 32), encodeHexData(word))
+
+	_, err = td.encodeValue("uint8", 256)
+	assert.ErrorContains(t, err, "does not fit")
+	_, err = td.encodeValue("bytes4", "0x01")
+	assert.Error(t, err)
+}
+
+func TestUserOpTypedDataHash(t *testing.T) {
+	ep := EntryPoint{Address: "0x4337084D9E255Ff0702461CF8895CE9E3b5Ff108", ChainID: big.NewInt(1), Version: EntryPointV08}
+	req, err := ep.UserOpTypedData("0x0000000000000000000000000000000000000001", testUserOp)
+	assert.NoError(t, err)
+
+	var td TypedData
+	assert.NoError(t, json.Unmarshal(req.TypedData, &td))
+	digest, err := td.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, userOpHashV08, encodeHexData(digest))
+}
@@ -6,19 +6,38 @@ import (
 
 // Transaction represents an Ethereum transaction
 type Transaction struct {
-	From     string `json:"from"`
-	To       string `json:"to"`
-	Gas      string `json:"gas,omitempty"`
-	GasPrice string `json:"gasPrice,omitempty"`
-	Value    string `json:"value,omitempty"`
-	Nonce    string `json:"nonce,omitempty"`
-	Data     string `json:"data,omitempty"`
+	From                 string `json:"from"`
+	To                   string `json:"to"`
+	Gas                  string `json:"gas,omitempty"`
+	GasPrice             string `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"`
+	Value                string `json:"value,omitempty"`
+	Nonce                string `json:"nonce,omitempty"`
+	Data                 string `json:"data,omitempty"`
+	ChainID              string `json:"chainId,omitempty"`
+	// MaxFeePerBlobGas and BlobVersionedHashes make an EIP-4844 blob transaction
+	MaxFeePerBlobGas    string   `json:"maxFeePerBlobGas,omitempty"`
+	BlobVersionedHashes []string `json:"blobVersionedHashes,omitempty"`
 }
 
+// Content types accepted by clef for signing data
+const (
+	// ContentTypeTextPlain signs data as an EIP-191 personal message
+	ContentTypeTextPlain = "text/plain"
+	// ContentTypeDataTyped signs EIP-712 typed data
+	ContentTypeDataTyped = "data/typed"
+	// ContentTypeCliqueHeader signs a clique block header
+	ContentTypeCliqueHeader = "application/x-clique-header"
+	// ContentTypeDataValidator signs data with intended validator (EIP-191 version 0x00)
+	ContentTypeDataValidator = "data/validator"
+)
+
 // SignDataRequest represents the parameters for signing data
 type SignDataRequest struct {
-	Address string `json:"address"`
-	Data    string `json:"data"`
+	ContentType string `json:"content_type,omitempty"`
+	Address     string `json:"address"`
+	Data        string `json:"data"`
 }
 
 // TypedDataRequest represents the parameters for signing typed data
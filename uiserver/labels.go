@@ -0,0 +1,70 @@
+package uiserver
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AddressLabeler looks up human labels and risk categories of addresses, e.g.
+// a clefclient.AddressBook
+type AddressLabeler interface {
+	LookupLabel(address string) (label, category string, ok bool)
+}
+
+// AnnotateAddresses returns a handler that adds the labels of the accounts
+// and recipients involved to the call info of approval requests before
+// passing them on to next
+func AnnotateAddresses(next Handler, labeler AddressLabeler) Handler {
+	return &annotatingHandler{Handler: next, labeler: labeler}
+}
+
+type annotatingHandler struct {
+	Handler
+	labeler AddressLabeler
+}
+
+func (h *annotatingHandler) ApproveTx(req *SignTxRequest) (*SignTxResponse, error) {
+	req.Callinfo = append(req.Callinfo, h.annotate("from", req.Transaction.From)...)
+	req.Callinfo = append(req.Callinfo, h.annotate("to", req.Transaction.To)...)
+	return h.Handler.ApproveTx(req)
+}
+
+func (h *annotatingHandler) ApproveSignData(req *SignDataRequest) (*SignDataResponse, error) {
+	req.Callinfo = append(req.Callinfo, h.annotate("signer", req.Address)...)
+	return h.Handler.ApproveSignData(req)
+}
+
+func (h *annotatingHandler) annotate(role, address string) []ValidationInfo {
+	if address == "" {
+		return nil
+	}
+	label, category, ok := h.labeler.LookupLabel(address)
+	if !ok {
+		return []ValidationInfo{{Typ: "WARNING", Message: fmt.Sprintf("%s %s is not in the address book", role, address)}}
+	}
+	msg := fmt.Sprintf("%s %s is %s", role, address, label)
+	if category != "" {
+		msg += " [" + category + "]"
+	}
+	return []ValidationInfo{{Typ: "INFO", Message: msg}}
+}
+
+// Categories returns a rule for transactions that makes the given decision
+// when the recipient's category is one of categories, and abstains otherwise.
+// Recipients unknown to the labeler have the empty category.
+func Categories(labeler AddressLabeler, d Decision, categories ...string) Rule {
+	set := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		set[strings.ToLower(c)] = true
+	}
+	return RuleFunc(func(req *Request) Decision {
+		if req.Tx == nil || req.Tx.Transaction.To == "" {
+			return Abstain
+		}
+		_, category, _ := labeler.LookupLabel(req.Tx.Transaction.To)
+		if set[strings.ToLower(category)] {
+			return d
+		}
+		return Abstain
+	})
+}
@@ -0,0 +1,64 @@
+package uiserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mapLabeler labels addresses from a map of address to label and category
+type mapLabeler map[string][2]string
+
+func (m mapLabeler) LookupLabel(address string) (string, string, bool) {
+	entry, ok := m[address]
+	return entry[0], entry[1], ok
+}
+
+// capturingHandler records the transaction requests it is asked to approve
+type capturingHandler struct {
+	recordingHandler
+	txs []*SignTxRequest
+}
+
+func (h *capturingHandler) ApproveTx(req *SignTxRequest) (*SignTxResponse, error) {
+	h.txs = append(h.txs, req)
+	return h.recordingHandler.ApproveTx(req)
+}
+
+var testLabeler = mapLabeler{
+	"0xaa": {"Hot wallet", "internal"},
+	"0xbb": {"Mixer", "sanctioned"},
+}
+
+func TestAnnotateAddresses(t *testing.T) {
+	next := &capturingHandler{}
+	handler := AnnotateAddresses(next, testLabeler)
+
+	resp, err := handler.ApproveTx(&SignTxRequest{Transaction: SendTxArgs{From: "0xaa", To: "0xcc"}})
+	assert.NoError(t, err)
+	assert.True(t, resp.Approved)
+	assert.Equal(t, []ValidationInfo{
+		{Typ: "INFO", Message: "from 0xaa is Hot wallet [internal]"},
+		{Typ: "WARNING", Message: "to 0xcc is not in the address book"},
+	}, next.txs[0].Callinfo)
+
+	// Other methods are passed through unchanged
+	handler.ShowInfo(&Message{Text: "hi"})
+	assert.Equal(t, []string{"hi"}, next.infos)
+}
+
+func TestCategoriesRule(t *testing.T) {
+	engine := NewRuleEngine(nil)
+	engine.Add(Categories(testLabeler, Reject, "sanctioned"))
+	engine.Add(Categories(testLabeler, Approve, "internal"))
+
+	resp, err := engine.ApproveTx(&SignTxRequest{Transaction: SendTxArgs{To: "0xbb"}})
+	assert.NoError(t, err)
+	assert.False(t, resp.Approved)
+
+	resp, err = engine.ApproveTx(&SignTxRequest{Transaction: SendTxArgs{To: "0xaa"}})
+	assert.NoError(t, err)
+	assert.True(t, resp.Approved)
+
+	assert.Equal(t, Abstain, Categories(testLabeler, Reject, "sanctioned").Evaluate(&Request{Tx: &SignTxRequest{Transaction: SendTxArgs{To: "0xcc"}}}))
+}
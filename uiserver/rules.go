@@ -0,0 +1,261 @@
+package uiserver
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// ErrNoEscalation is returned for input requests when the rule engine has no
+// escalation handler to forward them to
+var ErrNoEscalation = errors.New("no escalation handler configured")
+
+// Decision is the outcome of evaluating a rule
+type Decision int
+
+const (
+	// Abstain leaves the decision to the next rule
+	Abstain Decision = iota
+	// Approve approves the request
+	Approve
+	// Reject rejects the request
+	Reject
+	// Escalate forwards the request to the escalation handler
+	Escalate
+)
+
+// String returns the name of the decision
+func (d Decision) String() string {
+	switch d {
+	case Approve:
+		return "approve"
+	case Reject:
+		return "reject"
+	case Escalate:
+		return "escalate"
+	}
+	return "abstain"
+}
+
+// Request is an approval request as seen by rules. Exactly one of the
+// request fields is set, depending on Method.
+type Request struct {
+	// Method is the UI API method, e.g. "ui_approveTx"
+	Method string
+	// Account is the account the request acts on, empty for listings and new accounts
+	Account string
+
+	Tx         *SignTxRequest
+	SignData   *SignDataRequest
+	Listing    *ListRequest
+	NewAccount *NewAccountRequest
+}
+
+// Rule evaluates an approval request
+type Rule interface {
+	Evaluate(req *Request) Decision
+}
+
+// RuleFunc adapts a function to the Rule interface
+type RuleFunc func(req *Request) Decision
+
+// Evaluate calls f(req)
+func (f RuleFunc) Evaluate(req *Request) Decision {
+	return f(req)
+}
+
+// registeredRule is a rule with the filters it was registered with
+type registeredRule struct {
+	method  string
+	account string
+	rule    Rule
+}
+
+// RuleEngine is a Handler that decides approval requests using registered
+// rules. Rules are evaluated in registration order and the first decision
+// other than Abstain wins. Requests no rule decides are escalated.
+type RuleEngine struct {
+	mu         sync.RWMutex
+	rules      []registeredRule
+	escalation Handler
+}
+
+// NewRuleEngine creates a RuleEngine. Escalated requests, input requests and
+// notifications are forwarded to escalation; if it is nil, escalated
+// requests are rejected.
+func NewRuleEngine(escalation Handler) *RuleEngine {
+	return &RuleEngine{escalation: escalation}
+}
+
+// Add registers a rule for all requests
+func (e *RuleEngine) Add(rule Rule) {
+	e.add(registeredRule{rule: rule})
+}
+
+// AddForMethod registers a rule for requests of the given UI API method
+func (e *RuleEngine) AddForMethod(method string, rule Rule) {
+	e.add(registeredRule{method: method, rule: rule})
+}
+
+// AddForAccount registers a rule for requests acting on the given account
+func (e *RuleEngine) AddForAccount(account string, rule Rule) {
+	e.add(registeredRule{account: strings.ToLower(account), rule: rule})
+}
+
+func (e *RuleEngine) add(r registeredRule) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules = append(e.rules, r)
+}
+
+// Decide evaluates the rules for the request
+func (e *RuleEngine) Decide(req *Request) Decision {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	account := strings.ToLower(req.Account)
+	for _, r := range e.rules {
+		if r.method != "" && r.method != req.Method {
+			continue
+		}
+		if r.account != "" && r.account != account {
+			continue
+		}
+		if d := r.rule.Evaluate(req); d != Abstain {
+			return d
+		}
+	}
+	return Escalate
+}
+
+// decide evaluates the rules and resolves escalations without a handler to Reject
+func (e *RuleEngine) decide(req *Request) Decision {
+	d := e.Decide(req)
+	if d == Escalate && e.escalation == nil {
+		return Reject
+	}
+	return d
+}
+
+// ApproveTx implements Handler
+func (e *RuleEngine) ApproveTx(req *SignTxRequest) (*SignTxResponse, error) {
+	switch e.decide(&Request{Method: "ui_approveTx", Account: req.Transaction.From, Tx: req}) {
+	case Approve:
+		return &SignTxResponse{Transaction: req.Transaction, Approved: true}, nil
+	case Escalate:
+		return e.escalation.ApproveTx(req)
+	}
+	return &SignTxResponse{Transaction: req.Transaction, Approved: false}, nil
+}
+
+// ApproveSignData implements Handler
+func (e *RuleEngine) ApproveSignData(req *SignDataRequest) (*SignDataResponse, error) {
+	switch e.decide(&Request{Method: "ui_approveSignData", Account: req.Address, SignData: req}) {
+	case Approve:
+		return &SignDataResponse{Approved: true}, nil
+	case Escalate:
+		return e.escalation.ApproveSignData(req)
+	}
+	return &SignDataResponse{Approved: false}, nil
+}
+
+// ApproveListing implements Handler. Rejected listings reveal no accounts.
+func (e *RuleEngine) ApproveListing(req *ListRequest) (*ListResponse, error) {
+	switch e.decide(&Request{Method: "ui_approveListing", Listing: req}) {
+	case Approve:
+		return &ListResponse{Accounts: req.Accounts}, nil
+	case Escalate:
+		return e.escalation.ApproveListing(req)
+	}
+	return &ListResponse{Accounts: []Account{}}, nil
+}
+
+// ApproveNewAccount implements Handler
+func (e *RuleEngine) ApproveNewAccount(req *NewAccountRequest) (*NewAccountResponse, error) {
+	switch e.decide(&Request{Method: "ui_approveNewAccount", NewAccount: req}) {
+	case Approve:
+		return &NewAccountResponse{Approved: true}, nil
+	case Escalate:
+		return e.escalation.ApproveNewAccount(req)
+	}
+	return &NewAccountResponse{Approved: false}, nil
+}
+
+// OnInputRequired implements Handler by forwarding to the escalation handler
+func (e *RuleEngine) OnInputRequired(req *UserInputRequest) (*UserInputResponse, error) {
+	if e.escalation == nil {
+		return nil, ErrNoEscalation
+	}
+	return e.escalation.OnInputRequired(req)
+}
+
+// ShowError implements Handler by forwarding to the escalation handler
+func (e *RuleEngine) ShowError(msg *Message) {
+	if e.escalation != nil {
+		e.escalation.ShowError(msg)
+	}
+}
+
+// ShowInfo implements Handler by forwarding to the escalation handler
+func (e *RuleEngine) ShowInfo(msg *Message) {
+	if e.escalation != nil {
+		e.escalation.ShowInfo(msg)
+	}
+}
+
+// OnApprovedTx implements Handler by forwarding to the escalation handler
+func (e *RuleEngine) OnApprovedTx(tx *SignTxResponse) {
+	if e.escalation != nil {
+		e.escalation.OnApprovedTx(tx)
+	}
+}
+
+// OnSignerStartup implements Handler by forwarding to the escalation handler
+func (e *RuleEngine) OnSignerStartup(info *StartupInfo) {
+	if e.escalation != nil {
+		e.escalation.OnSignerStartup(info)
+	}
+}
+
+// Always returns a rule that always makes the given decision
+func Always(d Decision) Rule {
+	return RuleFunc(func(req *Request) Decision {
+		return d
+	})
+}
+
+// ValueThreshold returns a rule for transactions that makes the decision
+// above when the value exceeds limit, and abstains otherwise
+func ValueThreshold(limit *big.Int, above Decision) Rule {
+	return RuleFunc(func(req *Request) Decision {
+		if req.Tx == nil {
+			return Abstain
+		}
+		value := req.Tx.Transaction.Value
+		if value == "" {
+			return Abstain
+		}
+		v, ok := new(big.Int).SetString(strings.TrimPrefix(value, "0x"), 16)
+		if !ok || v.Cmp(limit) > 0 {
+			// Values that cannot be parsed are treated as exceeding the limit
+			return above
+		}
+		return Abstain
+	})
+}
+
+// Recipients returns a rule for transactions that makes the given decision
+// when the recipient is one of the addresses, and abstains otherwise
+func Recipients(addresses []string, d Decision) Rule {
+	set := make(map[string]bool, len(addresses))
+	for _, a := range addresses {
+		set[strings.ToLower(a)] = true
+	}
+	return RuleFunc(func(req *Request) Decision {
+		if req.Tx != nil && set[strings.ToLower(req.Tx.Transaction.To)] {
+			return d
+		}
+		return Abstain
+	})
+}
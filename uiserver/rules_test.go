@@ -0,0 +1,64 @@
+package uiserver
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleEngineDecisions(t *testing.T) {
+	engine := NewRuleEngine(nil)
+	engine.Add(ValueThreshold(big.NewInt(1000), Reject))
+	engine.AddForAccount("0xAB", Always(Approve))
+	engine.AddForMethod("ui_approveListing", Always(Approve))
+
+	// Approved by the account rule
+	resp, err := engine.ApproveTx(&SignTxRequest{Transaction: SendTxArgs{From: "0xab", Value: "0x10"}})
+	assert.NoError(t, err)
+	assert.True(t, resp.Approved)
+
+	// Rejected by the value threshold before the account rule is consulted
+	resp, err = engine.ApproveTx(&SignTxRequest{Transaction: SendTxArgs{From: "0xab", Value: "0x1000"}})
+	assert.NoError(t, err)
+	assert.False(t, resp.Approved)
+
+	// No rule decides and there is no escalation handler
+	dataResp, err := engine.ApproveSignData(&SignDataRequest{Address: "0xcd"})
+	assert.NoError(t, err)
+	assert.False(t, dataResp.Approved)
+
+	listResp, err := engine.ApproveListing(&ListRequest{Accounts: []Account{{Address: "0xab"}}})
+	assert.NoError(t, err)
+	assert.Len(t, listResp.Accounts, 1)
+
+	_, err = engine.OnInputRequired(&UserInputRequest{})
+	assert.ErrorIs(t, err, ErrNoEscalation)
+}
+
+func TestRuleEngineEscalation(t *testing.T) {
+	escalation := &recordingHandler{}
+	engine := NewRuleEngine(escalation)
+	engine.Add(Recipients([]string{"0xBAD"}, Reject))
+
+	resp, err := engine.ApproveTx(&SignTxRequest{Transaction: SendTxArgs{To: "0xbad"}})
+	assert.NoError(t, err)
+	assert.False(t, resp.Approved)
+
+	// Escalated to the recording handler, which approves transactions
+	resp, err = engine.ApproveTx(&SignTxRequest{Transaction: SendTxArgs{To: "0xgood"}})
+	assert.NoError(t, err)
+	assert.True(t, resp.Approved)
+
+	input, err := engine.OnInputRequired(&UserInputRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), input.Text)
+
+	engine.ShowInfo(&Message{Text: "hi"})
+	assert.Equal(t, []string{"hi"}, escalation.infos)
+}
+
+func TestDecisionString(t *testing.T) {
+	assert.Equal(t, "approve", Approve.String())
+	assert.Equal(t, "abstain", Abstain.String())
+}
@@ -0,0 +1,261 @@
+// Package uiserver implements the UI side of Clef's external UI API, so that Go
+// programs can approve or reject requests made to a Clef started with
+// --stdio-ui.
+package uiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+
+	"github.com/AxLabs/clef-client/internal/secret"
+)
+
+// Handler is implemented by custom approval UIs. Approval methods returning an
+// error make Clef treat the request as rejected.
+type Handler interface {
+	ApproveTx(req *SignTxRequest) (*SignTxResponse, error)
+	ApproveSignData(req *SignDataRequest) (*SignDataResponse, error)
+	ApproveListing(req *ListRequest) (*ListResponse, error)
+	ApproveNewAccount(req *NewAccountRequest) (*NewAccountResponse, error)
+	OnInputRequired(req *UserInputRequest) (*UserInputResponse, error)
+	ShowError(msg *Message)
+	ShowInfo(msg *Message)
+	OnApprovedTx(tx *SignTxResponse)
+	OnSignerStartup(info *StartupInfo)
+}
+
+// rpcRequest represents a JSON-RPC request sent by Clef
+type rpcRequest struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+}
+
+// rpcResponse represents a JSON-RPC response sent back to Clef
+type rpcResponse struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// rpcError represents a JSON-RPC error.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+const (
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	errCodeServer         = -32000
+)
+
+// errMethodNotFound is returned by dispatch for methods that are not part of the UI API
+var errMethodNotFound = errors.New("method not found")
+
+// Server dispatches Clef's UI requests to a Handler
+type Server struct {
+	handler Handler
+	mu      sync.Mutex
+}
+
+// NewServer creates a new Server
+func NewServer(handler Handler) *Server {
+	return &Server{handler: handler}
+}
+
+// Serve reads requests from r and writes responses to w until r is exhausted.
+// For a Clef started with --stdio-ui, r is Clef's stdout and w its stdin.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	dec := json.NewDecoder(r)
+	enc := json.NewEncoder(w)
+	for {
+		var req rpcRequest
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		resp := s.handle(&req)
+		// Notifications carry no ID and expect no response
+		if len(req.ID) == 0 {
+			continue
+		}
+
+		var err error
+		s.mu.Lock()
+		if input, ok := resp.Result.(*UserInputResponse); ok && input != nil {
+			err = writeInputResponse(w, resp.ID, input)
+		} else {
+			err = enc.Encode(resp)
+		}
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeInputResponse writes the response to ui_onInputRequired, usually a
+// password, from locked memory that is wiped afterwards, rather than from
+// the buffers of encoding/json which are pooled and reused. The text of the
+// response is wiped as well.
+func writeInputResponse(w io.Writer, id json.RawMessage, input *UserInputResponse) error {
+	defer secret.Wipe(input.Text)
+	const prefix, infix, suffix = `{"jsonrpc":"2.0","result":{"text":"`, `"},"id":`, "}\n"
+	buf := secret.New(len(prefix) + 6*len(input.Text) + len(infix) + len(id) + len(suffix))
+	defer buf.Destroy()
+
+	b := append(buf.Bytes()[:0], prefix...)
+	for i := 0; i < len(input.Text); i++ {
+		switch c := input.Text[i]; {
+		case c == '"' || c == '\\':
+			b = append(b, '\\', c)
+		case c < 0x20:
+			b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+		default:
+			b = append(b, c)
+		}
+	}
+	b = append(b, infix...)
+	b = append(b, id...)
+	b = append(b, suffix...)
+	_, err := w.Write(b)
+	return err
+}
+
+const hexDigits = "0123456789abcdef"
+
+// ServeCommand starts a Clef command with its stdio attached to the server and
+// serves it until the process exits. The command must include --stdio-ui.
+func (s *Server) ServeCommand(cmd *exec.Cmd) error {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start clef: %w", err)
+	}
+
+	serveErr := s.Serve(stdout, stdin)
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+	return serveErr
+}
+
+// handle executes a single request and builds its response
+func (s *Server) handle(req *rpcRequest) *rpcResponse {
+	resp := &rpcResponse{Jsonrpc: "2.0", ID: req.ID}
+
+	result, err := s.dispatch(req.Method, req.Params)
+	switch {
+	case errors.Is(err, errMethodNotFound):
+		resp.Error = &rpcError{Code: errCodeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)}
+	case errors.As(err, new(*paramsError)):
+		resp.Error = &rpcError{Code: errCodeInvalidParams, Message: err.Error()}
+	case err != nil:
+		resp.Error = &rpcError{Code: errCodeServer, Message: err.Error()}
+	default:
+		resp.Result = result
+	}
+	return resp
+}
+
+// dispatch calls the handler method for the given UI API method
+func (s *Server) dispatch(method string, params []json.RawMessage) (interface{}, error) {
+	switch method {
+	case "ui_approveTx":
+		var req SignTxRequest
+		if err := decodeParam(params, &req); err != nil {
+			return nil, err
+		}
+		return s.handler.ApproveTx(&req)
+	case "ui_approveSignData":
+		var req SignDataRequest
+		if err := decodeParam(params, &req); err != nil {
+			return nil, err
+		}
+		return s.handler.ApproveSignData(&req)
+	case "ui_approveListing":
+		var req ListRequest
+		if err := decodeParam(params, &req); err != nil {
+			return nil, err
+		}
+		return s.handler.ApproveListing(&req)
+	case "ui_approveNewAccount":
+		var req NewAccountRequest
+		if err := decodeParam(params, &req); err != nil {
+			return nil, err
+		}
+		return s.handler.ApproveNewAccount(&req)
+	case "ui_onInputRequired":
+		var req UserInputRequest
+		if err := decodeParam(params, &req); err != nil {
+			return nil, err
+		}
+		return s.handler.OnInputRequired(&req)
+	case "ui_showError":
+		var msg Message
+		if err := decodeParam(params, &msg); err != nil {
+			return nil, err
+		}
+		s.handler.ShowError(&msg)
+		return nil, nil
+	case "ui_showInfo":
+		var msg Message
+		if err := decodeParam(params, &msg); err != nil {
+			return nil, err
+		}
+		s.handler.ShowInfo(&msg)
+		return nil, nil
+	case "ui_onApprovedTx":
+		var tx SignTxResponse
+		if err := decodeParam(params, &tx); err != nil {
+			return nil, err
+		}
+		s.handler.OnApprovedTx(&tx)
+		return nil, nil
+	case "ui_onSignerStartup":
+		var info StartupInfo
+		if err := decodeParam(params, &info); err != nil {
+			return nil, err
+		}
+		s.handler.OnSignerStartup(&info)
+		return nil, nil
+	}
+	return nil, errMethodNotFound
+}
+
+// paramsError is returned when request parameters cannot be decoded
+type paramsError struct {
+	err error
+}
+
+func (e *paramsError) Error() string {
+	return fmt.Sprintf("invalid params: %v", e.err)
+}
+
+// decodeParam decodes the single positional parameter of a UI request
+func decodeParam(params []json.RawMessage, v interface{}) error {
+	if len(params) != 1 {
+		return &paramsError{err: fmt.Errorf("expected 1 parameter, got %d", len(params))}
+	}
+	if err := json.Unmarshal(params[0], v); err != nil {
+		return &paramsError{err: err}
+	}
+	return nil
+}
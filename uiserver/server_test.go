@@ -0,0 +1,125 @@
+package uiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingHandler approves everything and records notifications
+type recordingHandler struct {
+	infos   []string
+	errs    []string
+	startup *StartupInfo
+}
+
+func (h *recordingHandler) ApproveTx(req *SignTxRequest) (*SignTxResponse, error) {
+	return &SignTxResponse{Transaction: req.Transaction, Approved: true}, nil
+}
+
+func (h *recordingHandler) ApproveSignData(req *SignDataRequest) (*SignDataResponse, error) {
+	return &SignDataResponse{Approved: req.ContentType == "text/plain"}, nil
+}
+
+func (h *recordingHandler) ApproveListing(req *ListRequest) (*ListResponse, error) {
+	return &ListResponse{Accounts: req.Accounts[:1]}, nil
+}
+
+func (h *recordingHandler) ApproveNewAccount(req *NewAccountRequest) (*NewAccountResponse, error) {
+	return nil, errors.New("new accounts are not allowed")
+}
+
+func (h *recordingHandler) OnInputRequired(req *UserInputRequest) (*UserInputResponse, error) {
+	return &UserInputResponse{Text: []byte("secret")}, nil
+}
+
+func (h *recordingHandler) ShowError(msg *Message)          { h.errs = append(h.errs, msg.Text) }
+func (h *recordingHandler) ShowInfo(msg *Message)           { h.infos = append(h.infos, msg.Text) }
+func (h *recordingHandler) OnApprovedTx(tx *SignTxResponse) {}
+func (h *recordingHandler) OnSignerStartup(info *StartupInfo) {
+	h.startup = info
+}
+
+func serve(t *testing.T, handler Handler, requests ...string) []rpcResponse {
+	var out bytes.Buffer
+	err := NewServer(handler).Serve(strings.NewReader(strings.Join(requests, "\n")), &out)
+	assert.NoError(t, err)
+
+	var resps []rpcResponse
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp rpcResponse
+		assert.NoError(t, dec.Decode(&resp))
+		resps = append(resps, resp)
+	}
+	return resps
+}
+
+func TestServeApprovals(t *testing.T) {
+	handler := &recordingHandler{}
+	resps := serve(t, handler,
+		`{"jsonrpc":"2.0","id":1,"method":"ui_approveTx","params":[{"transaction":{"from":"0x01","to":"0x02","gas":"0x5208","value":"0x1","nonce":"0x0"},"call_info":null,"meta":{"remote":"local"}}]}`,
+		`{"jsonrpc":"2.0","id":2,"method":"ui_approveSignData","params":[{"content_type":"text/plain","address":"0x01","raw_data":"aGk=","messages":[],"hash":"0x00","meta":{}}]}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ui_approveListing","params":[{"accounts":[{"address":"0x01","url":"keystore://a"},{"address":"0x02","url":"keystore://b"}],"meta":{}}]}`,
+		`{"jsonrpc":"2.0","id":4,"method":"ui_onInputRequired","params":[{"title":"Password","prompt":"Enter password","isPassword":true}]}`,
+	)
+
+	assert.Len(t, resps, 4)
+	assert.JSONEq(t, `{"transaction":{"from":"0x01","to":"0x02","gas":"0x5208","value":"0x1","nonce":"0x0"},"approved":true}`, mustMarshal(t, resps[0].Result))
+	assert.JSONEq(t, `{"approved":true}`, mustMarshal(t, resps[1].Result))
+	assert.JSONEq(t, `{"accounts":[{"address":"0x01","url":"keystore://a"}]}`, mustMarshal(t, resps[2].Result))
+	assert.JSONEq(t, `{"text":"secret"}`, mustMarshal(t, resps[3].Result))
+	assert.Equal(t, "4", string(resps[3].ID))
+}
+
+func TestServeNotificationsAndErrors(t *testing.T) {
+	handler := &recordingHandler{}
+	resps := serve(t, handler,
+		`{"jsonrpc":"2.0","method":"ui_showInfo","params":[{"text":"hello"}]}`,
+		`{"jsonrpc":"2.0","method":"ui_showError","params":[{"text":"oops"}]}`,
+		`{"jsonrpc":"2.0","method":"ui_onSignerStartup","params":[{"info":{"intapi_version":"7.0.1"}}]}`,
+		`{"jsonrpc":"2.0","id":5,"method":"ui_approveNewAccount","params":[{"meta":{}}]}`,
+		`{"jsonrpc":"2.0","id":6,"method":"ui_unknown","params":[]}`,
+		`{"jsonrpc":"2.0","id":7,"method":"ui_approveTx","params":[]}`,
+	)
+
+	assert.Equal(t, []string{"hello"}, handler.infos)
+	assert.Equal(t, []string{"oops"}, handler.errs)
+	assert.Equal(t, "7.0.1", handler.startup.Info["intapi_version"])
+
+	assert.Len(t, resps, 3)
+	assert.Equal(t, errCodeServer, resps[0].Error.Code)
+	assert.Equal(t, "new accounts are not allowed", resps[0].Error.Message)
+	assert.Equal(t, errCodeMethodNotFound, resps[1].Error.Code)
+	assert.Equal(t, errCodeInvalidParams, resps[2].Error.Code)
+}
+
+func mustMarshal(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	return string(b)
+}
+
+func TestWriteInputResponse(t *testing.T) {
+	text := "p\"a\\s\ns\x01wörd"
+	var out bytes.Buffer
+	input := &UserInputResponse{Text: []byte(text)}
+	assert.NoError(t, writeInputResponse(&out, json.RawMessage(`"abc"`), input))
+	assert.Equal(t, make([]byte, len(text)), input.Text)
+
+	var resp struct {
+		Jsonrpc string `json:"jsonrpc"`
+		Result  struct {
+			Text string `json:"text"`
+		} `json:"result"`
+		ID string `json:"id"`
+	}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &resp))
+	assert.Equal(t, "2.0", resp.Jsonrpc)
+	assert.Equal(t, text, resp.Result.Text)
+	assert.Equal(t, "abc", resp.ID)
+}
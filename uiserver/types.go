@@ -0,0 +1,118 @@
+package uiserver
+
+// Metadata describes the origin of a request made to Clef
+type Metadata struct {
+	Remote    string `json:"remote"`
+	Local     string `json:"local"`
+	Scheme    string `json:"scheme"`
+	UserAgent string `json:"User-Agent"`
+	Origin    string `json:"Origin"`
+}
+
+// ValidationInfo is a warning or info message produced by Clef's request validation
+type ValidationInfo struct {
+	Typ     string `json:"type"`
+	Message string `json:"message"`
+}
+
+// NameValueType is a single human readable field of data being signed
+type NameValueType struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+	Typ   string      `json:"type"`
+}
+
+// Account is an account known to Clef
+type Account struct {
+	Address string `json:"address"`
+	URL     string `json:"url"`
+}
+
+// SendTxArgs represents the transaction submitted for approval
+type SendTxArgs struct {
+	From                 string `json:"from"`
+	To                   string `json:"to,omitempty"`
+	Gas                  string `json:"gas"`
+	GasPrice             string `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas,omitempty"`
+	Value                string `json:"value"`
+	Nonce                string `json:"nonce"`
+	Data                 string `json:"data,omitempty"`
+	Input                string `json:"input,omitempty"`
+	ChainID              string `json:"chainId,omitempty"`
+}
+
+// SignTxRequest is sent by ui_approveTx
+type SignTxRequest struct {
+	Transaction SendTxArgs       `json:"transaction"`
+	Callinfo    []ValidationInfo `json:"call_info"`
+	Meta        Metadata         `json:"meta"`
+}
+
+// SignTxResponse answers ui_approveTx. The transaction may be modified by the UI.
+type SignTxResponse struct {
+	Transaction SendTxArgs `json:"transaction"`
+	Approved    bool       `json:"approved"`
+}
+
+// SignDataRequest is sent by ui_approveSignData
+type SignDataRequest struct {
+	ContentType string           `json:"content_type"`
+	Address     string           `json:"address"`
+	Rawdata     []byte           `json:"raw_data"`
+	Messages    []*NameValueType `json:"messages"`
+	Callinfo    []ValidationInfo `json:"call_info"`
+	Hash        string           `json:"hash"`
+	Meta        Metadata         `json:"meta"`
+}
+
+// SignDataResponse answers ui_approveSignData
+type SignDataResponse struct {
+	Approved bool `json:"approved"`
+}
+
+// ListRequest is sent by ui_approveListing
+type ListRequest struct {
+	Accounts []Account `json:"accounts"`
+	Meta     Metadata  `json:"meta"`
+}
+
+// ListResponse answers ui_approveListing with the accounts to reveal
+type ListResponse struct {
+	Accounts []Account `json:"accounts"`
+}
+
+// NewAccountRequest is sent by ui_approveNewAccount
+type NewAccountRequest struct {
+	Meta Metadata `json:"meta"`
+}
+
+// NewAccountResponse answers ui_approveNewAccount
+type NewAccountResponse struct {
+	Approved bool `json:"approved"`
+}
+
+// Message is sent by ui_showInfo and ui_showError
+type Message struct {
+	Text string `json:"text"`
+}
+
+// StartupInfo is sent by ui_onSignerStartup
+type StartupInfo struct {
+	Info map[string]interface{} `json:"info"`
+}
+
+// UserInputRequest is sent by ui_onInputRequired
+type UserInputRequest struct {
+	Title      string `json:"title"`
+	Prompt     string `json:"prompt"`
+	IsPassword bool   `json:"isPassword"`
+}
+
+// UserInputResponse answers ui_onInputRequired
+type UserInputResponse struct {
+	// Text is the input, usually a password. The Server writes it to Clef
+	// itself rather than through encoding/json and wipes it afterwards.
+	Text []byte `json:"-"`
+}
@@ -0,0 +1,272 @@
+package clefclient
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// EntryPointVersion identifies the ERC-4337 entry point release, which
+// determines how the userOpHash is computed
+type EntryPointVersion int
+
+const (
+	// EntryPointV06 hashes the ABI encoded user operation
+	EntryPointV06 EntryPointVersion = iota
+	// EntryPointV07 hashes the ABI encoded packed user operation
+	EntryPointV07
+	// EntryPointV08 hashes the packed user operation as EIP-712 typed data
+	EntryPointV08
+)
+
+// packedUserOpType is the EIP-712 type of a packed user operation (entry point v0.8)
+const packedUserOpType = "PackedUserOperation(address sender,uint256 nonce,bytes initCode,bytes callData,bytes32 accountGasLimits,uint256 preVerificationGas,bytes32 gasFees,bytes paymasterAndData)"
+
+// eip712DomainType is the EIP-712 domain type used by the entry point
+const eip712DomainType = "EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"
+
+// UserOperation represents an ERC-4337 user operation as submitted to bundlers.
+// For entry points from v0.7 on, InitCode is the factory address followed by
+// the factory data, and PaymasterAndData is the paymaster address followed by
+// its verification gas limit, post-op gas limit and data.
+type UserOperation struct {
+	Sender               string `json:"sender"`
+	Nonce                string `json:"nonce"`
+	InitCode             string `json:"initCode"`
+	CallData             string `json:"callData"`
+	CallGasLimit         string `json:"callGasLimit"`
+	VerificationGasLimit string `json:"verificationGasLimit"`
+	PreVerificationGas   string `json:"preVerificationGas"`
+	MaxFeePerGas         string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+	PaymasterAndData     string `json:"paymasterAndData"`
+	Signature            string `json:"signature"`
+}
+
+// EntryPoint identifies an ERC-4337 entry point contract on a chain
+type EntryPoint struct {
+	Address string
+	ChainID *big.Int
+	Version EntryPointVersion
+}
+
+// userOpFields holds the decoded fields of a user operation
+type userOpFields struct {
+	sender, nonce, initCode, callData      []byte
+	callGas, verificationGas, preVerifyGas *big.Int
+	maxFee, maxPriorityFee                 *big.Int
+	paymasterAndData                       []byte
+}
+
+// decodeUserOp decodes the hex fields of a user operation
+func decodeUserOp(op *UserOperation) (*userOpFields, error) {
+	var f userOpFields
+	var err error
+	if f.sender, err = encodeAddressWord(op.Sender); err != nil {
+		return nil, fmt.Errorf("sender: %w", err)
+	}
+
+	bytesFields := []struct {
+		name  string
+		value string
+		dst   *[]byte
+	}{
+		{"initCode", op.InitCode, &f.initCode},
+		{"callData", op.CallData, &f.callData},
+		{"paymasterAndData", op.PaymasterAndData, &f.paymasterAndData},
+	}
+	for _, field := range bytesFields {
+		if field.value == "" {
+			field.value = "0x"
+		}
+		if *field.dst, err = decodeHexData(field.value); err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+
+	quantityFields := []struct {
+		name  string
+		value string
+		dst   **big.Int
+	}{
+		{"callGasLimit", op.CallGasLimit, &f.callGas},
+		{"verificationGasLimit", op.VerificationGasLimit, &f.verificationGas},
+		{"preVerificationGas", op.PreVerificationGas, &f.preVerifyGas},
+		{"maxFeePerGas", op.MaxFeePerGas, &f.maxFee},
+		{"maxPriorityFeePerGas", op.MaxPriorityFeePerGas, &f.maxPriorityFee},
+	}
+	for _, field := range quantityFields {
+		if *field.dst, err = decodeQuantity(field.value); err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+
+	nonce, err := decodeQuantity(op.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("nonce: %w", err)
+	}
+	if f.nonce, err = encodeUintWord(nonce); err != nil {
+		return nil, fmt.Errorf("nonce: %w", err)
+	}
+	return &f, nil
+}
+
+// packUint128Pair packs two 128 bit values into one word, high first
+func packUint128Pair(high, low *big.Int) ([]byte, error) {
+	if high.BitLen() > 128 || low.BitLen() > 128 {
+		return nil, fmt.Errorf("gas value does not fit in uint128")
+	}
+	return encodeUintWord(new(big.Int).Or(new(big.Int).Lsh(high, 128), low))
+}
+
+// UserOpHash computes the hash of the user operation that the entry point
+// expects the account to sign
+func (ep EntryPoint) UserOpHash(op *UserOperation) ([]byte, error) {
+	f, err := decodeUserOp(op)
+	if err != nil {
+		return nil, err
+	}
+	entryPoint, err := encodeAddressWord(ep.Address)
+	if err != nil {
+		return nil, fmt.Errorf("entry point: %w", err)
+	}
+	if ep.ChainID == nil {
+		return nil, fmt.Errorf("chain ID is required")
+	}
+	chainID, err := encodeUintWord(ep.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	words := [][]byte{f.sender, f.nonce, keccak256(f.initCode), keccak256(f.callData)}
+	if ep.Version == EntryPointV06 {
+		for _, v := range []*big.Int{f.callGas, f.verificationGas, f.preVerifyGas, f.maxFee, f.maxPriorityFee} {
+			word, err := encodeUintWord(v)
+			if err != nil {
+				return nil, err
+			}
+			words = append(words, word)
+		}
+	} else {
+		accountGasLimits, err := packUint128Pair(f.verificationGas, f.callGas)
+		if err != nil {
+			return nil, err
+		}
+		preVerificationGas, err := encodeUintWord(f.preVerifyGas)
+		if err != nil {
+			return nil, err
+		}
+		gasFees, err := packUint128Pair(f.maxPriorityFee, f.maxFee)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, accountGasLimits, preVerificationGas, gasFees)
+	}
+	words = append(words, keccak256(f.paymasterAndData))
+
+	switch ep.Version {
+	case EntryPointV06, EntryPointV07:
+		return keccak256(keccak256(words...), entryPoint, chainID), nil
+	case EntryPointV08:
+		structHash := keccak256(append([][]byte{keccak256([]byte(packedUserOpType))}, words...)...)
+		domainSeparator := keccak256(
+			keccak256([]byte(eip712DomainType)),
+			keccak256([]byte("ERC4337")),
+			keccak256([]byte("1")),
+			chainID,
+			entryPoint,
+		)
+		return keccak256([]byte{0x19, 0x01}, domainSeparator, structHash), nil
+	}
+	return nil, fmt.Errorf("unsupported entry point version %d", ep.Version)
+}
+
+// UserOpTypedData builds the EIP-712 typed data of a packed user operation,
+// as hashed by entry points from v0.8 on
+func (ep EntryPoint) UserOpTypedData(owner string, op *UserOperation) (*TypedDataRequest, error) {
+	f, err := decodeUserOp(op)
+	if err != nil {
+		return nil, err
+	}
+	if ep.ChainID == nil {
+		return nil, fmt.Errorf("chain ID is required")
+	}
+	accountGasLimits, err := packUint128Pair(f.verificationGas, f.callGas)
+	if err != nil {
+		return nil, err
+	}
+	gasFees, err := packUint128Pair(f.maxPriorityFee, f.maxFee)
+	if err != nil {
+		return nil, err
+	}
+
+	td := &TypedData{
+		Types: map[string][]TypedDataField{
+			"EIP712Domain": eip712DomainFields,
+			"PackedUserOperation": {
+				{Name: "sender", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "initCode", Type: "bytes"},
+				{Name: "callData", Type: "bytes"},
+				{Name: "accountGasLimits", Type: "bytes32"},
+				{Name: "preVerificationGas", Type: "uint256"},
+				{Name: "gasFees", Type: "bytes32"},
+				{Name: "paymasterAndData", Type: "bytes"},
+			},
+		},
+		PrimaryType: "PackedUserOperation",
+		Domain: map[string]interface{}{
+			"name":              "ERC4337",
+			"version":           "1",
+			"chainId":           ep.ChainID.String(),
+			"verifyingContract": ep.Address,
+		},
+		Message: map[string]interface{}{
+			"sender":             op.Sender,
+			"nonce":              new(big.Int).SetBytes(f.nonce).String(),
+			"initCode":           encodeHexData(f.initCode),
+			"callData":           encodeHexData(f.callData),
+			"accountGasLimits":   encodeHexData(accountGasLimits),
+			"preVerificationGas": f.preVerifyGas.String(),
+			"gasFees":            encodeHexData(gasFees),
+			"paymasterAndData":   encodeHexData(f.paymasterAndData),
+		},
+	}
+	return td.Request(owner)
+}
+
+// SignUserOperation signs the user operation with the owner account and
+// returns a copy carrying the signature, ready for a bundler. Entry points
+// before v0.8 get a personal-sign signature over the userOpHash, later ones a
+// typed-data signature over the packed user operation.
+func (cc *ClefClient) SignUserOperation(owner string, op *UserOperation, ep EntryPoint) (*UserOperation, error) {
+	if op == nil {
+		return nil, nilRequestError("user operation")
+	}
+	var sig *SignDataResponse
+	if ep.Version >= EntryPointV08 {
+		req, err := ep.UserOpTypedData(owner, op)
+		if err != nil {
+			return nil, err
+		}
+		if sig, err = cc.SignTypedData(req); err != nil {
+			return nil, err
+		}
+	} else {
+		hash, err := ep.UserOpHash(op)
+		if err != nil {
+			return nil, err
+		}
+		sig, err = cc.SignData(&SignDataRequest{
+			ContentType: ContentTypeTextPlain,
+			Address:     owner,
+			Data:        encodeHexData(hash),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	signed := *op
+	signed.Signature = sig.Signature
+	return &signed, nil
+}
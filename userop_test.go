@@ -0,0 +1,97 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testUserOp = &UserOperation{
+	Sender:               "0x1306b01bC3e4AD202612D3843387e94737673F53",
+	Nonce:                "0x7",
+	InitCode:             "0x",
+	CallData:             "0xb61d27f6",
+	CallGasLimit:         "0x186a0",
+	VerificationGasLimit: "0x30d40",
+	PreVerificationGas:   "0xc350",
+	MaxFeePerGas:         "0x77359400",
+	MaxPriorityFeePerGas: "0x3b9aca00",
+	PaymasterAndData:     "0x",
+}
+
+func TestUserOpHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		ep       EntryPoint
+		expected string
+	}{
+		{"v0.6", EntryPoint{Address: "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789", ChainID: big.NewInt(1), Version: EntryPointV06}, userOpHashV06},
+		{"v0.7", EntryPoint{Address: "0x0000000071727De22E5E9d8BAf0edAc6f37da032", ChainID: big.NewInt(1), Version: EntryPointV07}, userOpHashV07},
+		{"v0.8", EntryPoint{Address: "0x4337084D9E255Ff0702461CF8895CE9E3b5Ff108", ChainID: big.NewInt(1), Version: EntryPointV08}, userOpHashV08},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.ep.UserOpHash(testUserOp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, encodeHexData(hash))
+		})
+	}
+
+	_, err := EntryPoint{Address: "0x01", ChainID: big.NewInt(1)}.UserOpHash(testUserOp)
+	assert.ErrorContains(t, err, "entry point")
+}
+
+func TestSignUserOperation(t *testing.T) {
+	ep := EntryPoint{Address: "0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789", ChainID: big.NewInt(1), Version: EntryPointV06}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string          `json:"method"`
+			Params SignDataRequest `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "account_signData", req.Method)
+		assert.Equal(t, ContentTypeTextPlain, req.Params.ContentType)
+		assert.Equal(t, "0x0000000000000000000000000000000000000001", req.Params.Address)
+		assert.Equal(t, userOpHashV06, req.Params.Data)
+
+		result, _ := json.Marshal(SignDataResponse{Signature: testSignature(0x01)})
+		json.NewEncoder(w).Encode(rpcResponse{Jsonrpc: "2.0", ID: 1, Result: result})
+	}))
+	defer server.Close()
+
+	signed, err := NewHTTPClient(server.URL).SignUserOperation("0x0000000000000000000000000000000000000001", testUserOp, ep)
+	assert.NoError(t, err)
+	assert.Equal(t, testSignature(0x01), signed.Signature)
+	assert.Empty(t, testUserOp.Signature)
+}
+
+func TestSignUserOperationTypedData(t *testing.T) {
+	ep := EntryPoint{Address: "0x4337084D9E255Ff0702461CF8895CE9E3b5Ff108", ChainID: big.NewInt(1), Version: EntryPointV08}
+	client, server := setupHTTPTestServer(t, "account_signTypedData", SignDataResponse{Signature: testSignature(0x01)})
+	defer server.Close()
+
+	signed, err := client.SignUserOperation("0x0000000000000000000000000000000000000001", testUserOp, ep)
+	assert.NoError(t, err)
+	assert.Equal(t, testSignature(0x01), signed.Signature)
+
+	req, err := ep.UserOpTypedData("0x0000000000000000000000000000000000000001", testUserOp)
+	assert.NoError(t, err)
+	var typedData struct {
+		PrimaryType string                 `json:"primaryType"`
+		Message     map[string]interface{} `json:"message"`
+	}
+	assert.NoError(t, json.Unmarshal(req.TypedData, &typedData))
+	assert.Equal(t, "PackedUserOperation", typedData.PrimaryType)
+	assert.Equal(t, "0x00000000000000000000000000030d40000000000000000000000000000186a0", typedData.Message["accountGasLimits"])
+}
+
+// Expected userOpHashes of testUserOp on mainnet for each entry point version
+const (
+	userOpHashV06 = "0x2a17df5e092d2f66b12dec9dc1b23e94ba580149584b83176585fc06f893cd16"
+	userOpHashV07 = "0x3e392b812903aba361d54b79847212adf2565ddb148bf7e54994d943e4569f82"
+	userOpHashV08 = "0xea80e518dcb1adb331526d1ee56321dca87d7b006cfac79a9cfb182b0dd2ea56"
+)
@@ -0,0 +1,294 @@
+package clefclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ErrCassetteMismatch is returned by a replaying client when a request does
+// not match the next recorded interaction
+var ErrCassetteMismatch = errors.New("request does not match cassette")
+
+// Interaction is a single recorded call and its outcome
+type Interaction struct {
+	Method  string          `json:"method"`
+	Request json.RawMessage `json:"request,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	// ErrorCode and ErrorData are set for JSON-RPC errors
+	ErrorCode int             `json:"errorCode,omitempty"`
+	ErrorData json.RawMessage `json:"errorData,omitempty"`
+}
+
+// Cassette is a recorded sequence of interactions with Clef
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// cassetteVersion is the current Cassette format
+const cassetteVersion = 1
+
+// CassetteRedaction rewrites a string value of a recorded request or result
+// before it is stored. Key is the name of the enclosing object field, empty
+// for array elements and top level values.
+type CassetteRedaction func(key, value string) string
+
+// RedactSignatures replaces signatures and signed raw transactions with
+// zeros of the same length
+func RedactSignatures(key, value string) string {
+	switch {
+	case key == "raw" || key == "signature" || key == "sig",
+		isHexOfLength(value, 65):
+		if strings.HasPrefix(value, "0x") {
+			return "0x" + strings.Repeat("0", len(value)-2)
+		}
+	}
+	return value
+}
+
+// pseudonymPrefix starts every pseudonymous address, so that pseudonyms fed
+// back into requests during replay are left alone
+const pseudonymPrefix = "0x000000000000"
+
+// RedactAddresses replaces addresses with deterministic pseudonyms, so that
+// recordings do not reveal the accounts used while the same address still
+// maps to the same pseudonym throughout a cassette
+func RedactAddresses(key, value string) string {
+	if !isHexOfLength(value, 20) || strings.HasPrefix(value, pseudonymPrefix) {
+		return value
+	}
+	sum := hex.EncodeToString(keccak256([]byte(strings.ToLower(value))))
+	return pseudonymPrefix + sum[:42-len(pseudonymPrefix)]
+}
+
+func isHexOfLength(value string, n int) bool {
+	b, err := decodeHexData(value)
+	return err == nil && strings.HasPrefix(value, "0x") && len(b) == n
+}
+
+// RecorderConfig configures a Recorder
+type RecorderConfig struct {
+	// Path is the file the cassette is saved to
+	Path string
+	// Redactions are applied to requests and results before they are stored
+	Redactions []CassetteRedaction
+}
+
+// Recorder records the interactions of a client in a cassette file, for later
+// replay with NewReplayClient
+type Recorder struct {
+	config RecorderConfig
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a new Recorder
+func NewRecorder(config RecorderConfig) *Recorder {
+	return &Recorder{config: config, cassette: Cassette{Version: cassetteVersion}}
+}
+
+// Interactions returns the interactions recorded so far
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.cassette.Interactions...)
+}
+
+// Save writes the cassette to the configured path
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return writeJSONFile(r.config.Path, &r.cassette)
+}
+
+func (r *Recorder) record(method string, params interface{}, resp *rpcResponse, err error) {
+	in := Interaction{Method: method}
+	if params != nil {
+		raw, _ := json.Marshal(params)
+		in.Request = redactCassetteJSON(raw, r.config.Redactions)
+	}
+	var rpcErr *RPCError
+	switch {
+	case errors.As(err, &rpcErr):
+		in.Error, in.ErrorCode, in.ErrorData = rpcErr.Message, rpcErr.Code, rpcErr.Data
+	case err != nil:
+		in.Error = err.Error()
+	case resp.Error != nil:
+		in.Error, in.ErrorCode, in.ErrorData = resp.Error.Message, resp.Error.Code, resp.Error.Data
+	default:
+		in.Result = redactCassetteJSON(resp.Result, r.config.Redactions)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+}
+
+// EnableRecorder records every subsequent request made by the client and its
+// outcome in the recorder
+func (cc *ClefClient) EnableRecorder(r *Recorder) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &recordTransport{next: cc.transport, recorder: r}
+}
+
+// recordTransport is a transport decorator recording calls in a Recorder
+type recordTransport struct {
+	next     transport
+	recorder *Recorder
+}
+
+func (t *recordTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	resp, err := t.next.call(ctx, method, params)
+	t.recorder.record(method, params, resp, err)
+	return resp, err
+}
+
+func (t *recordTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	resps, err := t.next.callBatch(ctx, method, params)
+	if err != nil {
+		return resps, err
+	}
+	for i, p := range params {
+		t.recorder.record(method, p, resps[i], nil)
+	}
+	return resps, nil
+}
+
+func (t *recordTransport) close() error {
+	return t.next.close()
+}
+
+// NewReplayClient creates a ClefClient answering from a cassette recorded by
+// a Recorder, without contacting Clef. Requests must be made in the recorded
+// order and match the recorded requests once redactions, which should be
+// those used when recording, are applied. Results are returned as stored, so
+// redacted values appear redacted.
+func NewReplayClient(path string, redactions ...CassetteRedaction) (*ClefClient, error) {
+	var cassette Cassette
+	if err := readJSONFile(path, &cassette); err != nil {
+		return nil, err
+	}
+	if cassette.Version != cassetteVersion {
+		return nil, fmt.Errorf("unsupported cassette version %d", cassette.Version)
+	}
+	return &ClefClient{transport: &replayTransport{cassette: cassette, redactions: redactions}}, nil
+}
+
+// replayTransport answers calls from a cassette in order
+type replayTransport struct {
+	redactions []CassetteRedaction
+
+	mu       sync.Mutex
+	cassette Cassette
+	next     int
+}
+
+// replay returns the next interaction after checking it matches the call
+func (t *replayTransport) replay(method string, params interface{}) (*Interaction, error) {
+	if t.next >= len(t.cassette.Interactions) {
+		return nil, fmt.Errorf("%w: unexpected %s after %d interactions", ErrCassetteMismatch, method, t.next)
+	}
+	in := &t.cassette.Interactions[t.next]
+	var request json.RawMessage
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return nil, err
+		}
+		request = redactCassetteJSON(raw, t.redactions)
+	}
+	// The cassette file is indented, so compare canonical forms
+	if in.Method != method || !bytes.Equal(request, canonicalJSON(in.Request)) {
+		return nil, fmt.Errorf("%w: interaction %d is %s %s, got %s %s", ErrCassetteMismatch, t.next, in.Method, in.Request, method, request)
+	}
+	t.next++
+	return in, nil
+}
+
+func (t *replayTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	in, err := t.replay(method, params)
+	if err != nil {
+		return nil, err
+	}
+	if in.Error != "" {
+		return nil, newCallError(method, &rpcError{Code: in.ErrorCode, Message: in.Error, Data: in.ErrorData})
+	}
+	return &rpcResponse{Jsonrpc: "2.0", ID: 1, Result: in.Result}, nil
+}
+
+func (t *replayTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	resps := make([]*rpcResponse, len(params))
+	for i, p := range params {
+		in, err := t.replay(method, p)
+		if err != nil {
+			return nil, err
+		}
+		resps[i] = &rpcResponse{Jsonrpc: "2.0", ID: i + 1, Result: in.Result}
+		if in.Error != "" {
+			resps[i].Error = &rpcError{Code: in.ErrorCode, Message: in.Error, Data: in.ErrorData}
+		}
+	}
+	return resps, nil
+}
+
+func (t *replayTransport) close() error {
+	return nil
+}
+
+// redactCassetteJSON applies redactions to every string of a JSON value, returning
+// it in canonical form so that recorded and replayed requests compare equal
+func redactCassetteJSON(raw json.RawMessage, redactions []CassetteRedaction) json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return raw
+	}
+	out, err := json.Marshal(redactValue("", v, redactions))
+	if err != nil {
+		return raw
+	}
+	return out
+}
+
+func redactValue(key string, v interface{}, redactions []CassetteRedaction) interface{} {
+	switch v := v.(type) {
+	case string:
+		for _, redact := range redactions {
+			v = redact(key, v)
+		}
+		return v
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue("", v[i], redactions)
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = redactValue(k, v[k], redactions)
+		}
+	}
+	return v
+}
+
+// canonicalJSON re-encodes a JSON value with sorted object keys and no
+// insignificant whitespace, so that equal values compare equal as bytes
+func canonicalJSON(raw json.RawMessage) json.RawMessage {
+	return redactCassetteJSON(raw, nil)
+}
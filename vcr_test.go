@@ -0,0 +1,75 @@
+package clefclient
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	account := "0x1234567890123456789012345678901234567890"
+	signature := "0x" + strings.Repeat("ab", 64) + "1b"
+	client, server := setupHTTPTestServer(t, "account_signData", SignDataResponse{Signature: signature})
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := NewRecorder(RecorderConfig{
+		Path:       path,
+		Redactions: []CassetteRedaction{RedactSignatures, RedactAddresses},
+	})
+	client.EnableRecorder(recorder)
+
+	req := &SignDataRequest{ContentType: "text/plain", Address: account, Data: "0x68656c6c6f"}
+	resp, err := client.SignData(req)
+	assert.NoError(t, err)
+	assert.Equal(t, signature, resp.Signature)
+	assert.NoError(t, recorder.Save())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), account[2:])
+	assert.NotContains(t, string(data), signature[2:])
+
+	replay, err := NewReplayClient(path, RedactSignatures, RedactAddresses)
+	assert.NoError(t, err)
+	resp, err = replay.SignData(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x"+strings.Repeat("0", 130), resp.Signature)
+
+	_, err = replay.SignData(req)
+	assert.ErrorIs(t, err, ErrCassetteMismatch)
+}
+
+func TestReplayMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := NewRecorder(RecorderConfig{Path: path})
+	recorder.record("account_list", nil, &rpcResponse{Result: []byte(`["0x1234567890123456789012345678901234567890"]`)}, nil)
+	recorder.record("account_new", nil, &rpcResponse{Error: &rpcError{Message: "Request denied"}}, nil)
+	assert.NoError(t, recorder.Save())
+
+	replay, err := NewReplayClient(path)
+	assert.NoError(t, err)
+	_, err = replay.NewAccount()
+	assert.ErrorIs(t, err, ErrCassetteMismatch)
+
+	replay, err = NewReplayClient(path)
+	assert.NoError(t, err)
+	accounts, err := replay.ListAccounts()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x1234567890123456789012345678901234567890"}, accounts)
+	_, err = replay.NewAccount()
+	assert.EqualError(t, err, "account_new: Request denied")
+}
+
+func TestRedactAddresses(t *testing.T) {
+	account := "0x1234567890123456789012345678901234567890"
+	pseudonym := RedactAddresses("", account)
+	assert.Len(t, pseudonym, 42)
+	assert.NotEqual(t, account, pseudonym)
+	assert.Equal(t, pseudonym, RedactAddresses("to", strings.ToUpper(account[:2])[:1]+"x"+strings.ToUpper(account[2:])))
+	assert.Equal(t, pseudonym, RedactAddresses("", pseudonym))
+	assert.Equal(t, "hello", RedactAddresses("", "hello"))
+}
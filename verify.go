@@ -0,0 +1,110 @@
+package clefclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSignerMismatch is returned when a data signature returned by Clef does
+// not recover to the account it was requested from
+var ErrSignerMismatch = errors.New("signature does not recover to the requested account")
+
+// EnableSignerVerification recovers the signer of the data signatures
+// returned by subsequent SignData and SignTypedData calls locally, without
+// another call to Clef, and fails with ErrSignerMismatch unless it is the
+// requested account. This detects responses signed with the wrong key or
+// corrupted on the way before they are used. Data of content types other
+// than text/plain and data/typed is not checked.
+func (cc *ClefClient) EnableSignerVerification() {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &verifyTransport{next: cc.transport}
+}
+
+// verifyTransport is a transport decorator checking the signer of returned
+// data signatures
+type verifyTransport struct {
+	next transport
+}
+
+func (t *verifyTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	resp, err := t.next.call(ctx, method, params)
+	if err != nil || resp == nil || resp.Error != nil {
+		return resp, err
+	}
+	if err := t.check(method, params, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// callBatch only carries transaction signing, which is not checked
+func (t *verifyTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	return t.next.callBatch(ctx, method, params)
+}
+
+func (t *verifyTransport) close() error {
+	return t.next.close()
+}
+
+// check recovers the signer of the signature in resp and compares it with
+// the account of the request
+func (t *verifyTransport) check(method string, params interface{}, resp *rpcResponse) error {
+	var address string
+	var hash []byte
+	switch req := params.(type) {
+	case *SignDataRequest:
+		if method != "account_signData" {
+			return nil
+		}
+		address = req.Address
+		switch req.ContentType {
+		case "", ContentTypeTextPlain:
+			data, err := decodeHexData(req.Data)
+			if err != nil {
+				data = []byte(req.Data)
+			}
+			hash = TextHash(data)
+		case ContentTypeDataTyped:
+			var td TypedData
+			if err := json.Unmarshal([]byte(req.Data), &td); err != nil {
+				return nil
+			}
+			hash, _ = td.Hash()
+		}
+	case *TypedDataRequest:
+		if method != "account_signTypedData" {
+			return nil
+		}
+		address = req.Address
+		var td TypedData
+		if err := json.Unmarshal(req.TypedData, &td); err != nil {
+			return nil
+		}
+		hash, _ = td.Hash()
+	}
+	if hash == nil {
+		return nil
+	}
+
+	var result SignDataResponse
+	if len(resp.Result) == 0 || string(resp.Result) == "null" || json.Unmarshal(resp.Result, &result) != nil {
+		// Malformed results are reported by the method
+		return nil
+	}
+	if checkSignature(method, result.Signature) != nil {
+		return nil
+	}
+	recovered, err := RecoverAddress(hash, result.Signature)
+	if err != nil {
+		return fmt.Errorf("%s: %w: %v", method, ErrSignerMismatch, err)
+	}
+	if !strings.EqualFold(recovered, address) {
+		return fmt.Errorf("%s: %w: recovered %s, requested %s", method, ErrSignerMismatch, recovered, address)
+	}
+	return nil
+}
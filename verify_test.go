@@ -0,0 +1,52 @@
+package clefclient
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableSignerVerification(t *testing.T) {
+	signer := NewMemorySigner(1)
+	account, _ := signer.NewAccount()
+	other, _ := signer.NewAccount()
+
+	message := &SignDataRequest{ContentType: ContentTypeTextPlain, Address: account, Data: "0x68656c6c6f"}
+	signed, err := signer.SignData(message)
+	assert.NoError(t, err)
+
+	next := &signatureTransport{signature: signed.Signature}
+	cc := &ClefClient{transport: next}
+	cc.EnableSignerVerification()
+
+	_, err = cc.SignData(message)
+	assert.NoError(t, err)
+
+	// Signed with the wrong key
+	_, err = cc.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: other, Data: "0x68656c6c6f"})
+	assert.ErrorIs(t, err, ErrSignerMismatch)
+	assert.ErrorContains(t, err, "requested "+other)
+
+	// Corrupted on the way
+	sig, _ := decodeHexData(signed.Signature)
+	sig[10] ^= 0xff
+	next.signature = encodeHexData(sig)
+	_, err = cc.SignData(message)
+	assert.ErrorIs(t, err, ErrSignerMismatch)
+
+	typed := &TypedDataRequest{Address: account, TypedData: json.RawMessage(mailTypedData)}
+	signed, err = signer.SignTypedData(typed)
+	assert.NoError(t, err)
+	next.signature = signed.Signature
+	_, err = cc.SignTypedData(typed)
+	assert.NoError(t, err)
+	_, err = cc.SignData(&SignDataRequest{ContentType: ContentTypeDataTyped, Address: account, Data: mailTypedData})
+	assert.NoError(t, err)
+	_, err = cc.SignTypedData(&TypedDataRequest{Address: other, TypedData: json.RawMessage(mailTypedData)})
+	assert.ErrorIs(t, err, ErrSignerMismatch)
+
+	// Content types that cannot be hashed locally are not checked
+	_, err = cc.SignData(&SignDataRequest{ContentType: ContentTypeDataValidator, Address: other, Data: "0x00"})
+	assert.NoError(t, err)
+}
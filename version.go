@@ -0,0 +1,98 @@
+package clefclient
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrVersionTooOld is returned when Clef is older than the minimum version
+// of a VersionPolicy
+var ErrVersionTooOld = errors.New("clef version is older than the minimum")
+
+// ErrVersionBlocked is returned when Clef runs a version blocklisted by a
+// VersionPolicy, e.g. one with a known vulnerability
+var ErrVersionBlocked = errors.New("clef version is blocklisted")
+
+// VersionPolicy is the external API versions of Clef, as reported by
+// account_version, that a client agrees to operate against
+type VersionPolicy struct {
+	// Min is the oldest version accepted, e.g. 6.0.0, any if empty
+	Min string
+	// Blocked are versions refused even if not older than Min
+	Blocked []string
+}
+
+// WithVersionPolicy makes NewClient query the version of Clef and fail with
+// ErrVersionTooOld or ErrVersionBlocked if policy refuses it, so that a
+// service fails at startup instead of on its first signing request. It has
+// no effect on the other constructors and on clients derived with With; use
+// CheckVersion with those.
+func WithVersionPolicy(policy VersionPolicy) Option {
+	return func(o *clientOptions) {
+		o.versionPolicy = &policy
+	}
+}
+
+// CheckVersion queries the version of Clef and checks it against policy.
+// The version is returned even if it is refused. Versions that cannot be
+// parsed are refused unless policy accepts any version.
+func (cc *ClefClient) CheckVersion(policy VersionPolicy) (*VersionResponse, error) {
+	version, err := cc.Version()
+	if err != nil {
+		return nil, err
+	}
+	return version, policy.check(version.Version)
+}
+
+func (p VersionPolicy) check(version string) error {
+	if p.Min == "" && len(p.Blocked) == 0 {
+		return nil
+	}
+	v, ok := parseVersion(version)
+	if !ok {
+		return fmt.Errorf("%w: cannot parse version %q", ErrVersionTooOld, version)
+	}
+	if slices.ContainsFunc(p.Blocked, func(blocked string) bool {
+		b, ok := parseVersion(blocked)
+		return ok && slices.Equal(b, v)
+	}) {
+		return fmt.Errorf("%w: %s", ErrVersionBlocked, version)
+	}
+	if p.Min == "" {
+		return nil
+	}
+	minimum, ok := parseVersion(p.Min)
+	if !ok {
+		return fmt.Errorf("invalid minimum version %q", p.Min)
+	}
+	if slices.Compare(v, minimum) < 0 {
+		return fmt.Errorf("%w: %s, need %s or newer", ErrVersionTooOld, version, p.Min)
+	}
+	return nil
+}
+
+// parseVersion parses a version such as 6.1.0 or v6.1 into its major,
+// minor and patch numbers, missing numbers being zero. Pre-release and build
+// suffixes are ignored.
+func parseVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return nil, false
+	}
+	v := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
@@ -0,0 +1,37 @@
+package clefclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionPolicy(t *testing.T) {
+	policy := VersionPolicy{Min: "6.0.0", Blocked: []string{"6.1"}}
+
+	assert.NoError(t, policy.check("6.0.0"))
+	assert.NoError(t, policy.check("v6.2.0-unstable"))
+	assert.NoError(t, policy.check("10.0.0"))
+	assert.ErrorIs(t, policy.check("5.99.1"), ErrVersionTooOld)
+	assert.EqualError(t, policy.check("5.99.1"), "clef version is older than the minimum: 5.99.1, need 6.0.0 or newer")
+	assert.ErrorIs(t, policy.check("6.1.0"), ErrVersionBlocked)
+	assert.ErrorIs(t, policy.check("unknown"), ErrVersionTooOld)
+
+	assert.NoError(t, VersionPolicy{}.check("unknown"))
+	assert.EqualError(t, VersionPolicy{Min: "six"}.check("6.0.0"), `invalid minimum version "six"`)
+}
+
+func TestWithVersionPolicy(t *testing.T) {
+	_, server := setupHTTPTestServer(t, "account_version", VersionResponse{Version: "5.0.0"})
+	defer server.Close()
+
+	_, err := NewClient(context.Background(), server.URL, WithVersionPolicy(VersionPolicy{Min: "6.0.0"}))
+	assert.ErrorIs(t, err, ErrVersionTooOld)
+
+	cc, err := NewClient(context.Background(), server.URL, WithVersionPolicy(VersionPolicy{Min: "5.0.0"}))
+	assert.NoError(t, err)
+	version, err := cc.CheckVersion(VersionPolicy{Blocked: []string{"5.0.0"}})
+	assert.ErrorIs(t, err, ErrVersionBlocked)
+	assert.Equal(t, "5.0.0", version.Version)
+}
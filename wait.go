@@ -0,0 +1,185 @@
+package clefclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTransactionReverted is returned alongside the receipt when a mined
+// transaction failed during execution
+var ErrTransactionReverted = errors.New("transaction reverted")
+
+// DefaultPollInterval is used when no poll interval is configured
+const DefaultPollInterval = time.Second
+
+// HeadSubscriber is implemented by backends that can push new block numbers
+// instead of being polled
+type HeadSubscriber interface {
+	// SubscribeNewHeads delivers the number of every new block until ctx is done
+	SubscribeNewHeads(ctx context.Context) (<-chan uint64, error)
+}
+
+// PendingTx is a transaction that was signed through Clef and broadcast
+type PendingTx struct {
+	Hash string
+	Raw  string
+	// PollInterval is how often the backend is polled when it does not
+	// implement HeadSubscriber
+	PollInterval time.Duration
+
+	backend Backend
+}
+
+// SignAndSend signs the transaction through Clef and broadcasts it to the backend
+func (cc *ClefClient) SignAndSend(backend Backend, tx *Transaction) (*PendingTx, error) {
+	if backend == nil {
+		return nil, nilRequestError("backend")
+	}
+	signed, err := cc.SignTransaction(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	hash, err := backend.SendRawTransaction(signed.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	return &PendingTx{Hash: hash, Raw: signed.Raw, PollInterval: DefaultPollInterval, backend: backend}, nil
+}
+
+// WaitMined blocks until the transaction is mined
+func (p *PendingTx) WaitMined(ctx context.Context) (*Receipt, error) {
+	return WaitMined(ctx, p.backend, p.Hash, p.PollInterval)
+}
+
+// WaitConfirmed blocks until the transaction is mined and buried under the
+// given number of confirmations
+func (p *PendingTx) WaitConfirmed(ctx context.Context, confirmations uint64) (*Receipt, error) {
+	return WaitConfirmed(ctx, p.backend, p.Hash, confirmations, p.PollInterval)
+}
+
+// WaitMined blocks until the transaction with the given hash is mined. If it
+// reverted, the receipt is returned together with ErrTransactionReverted.
+func WaitMined(ctx context.Context, backend Backend, hash string, pollInterval time.Duration) (*Receipt, error) {
+	return WaitConfirmed(ctx, backend, hash, 1, pollInterval)
+}
+
+// WaitConfirmed blocks until the transaction with the given hash is included
+// in a block with at least the given number of confirmations, where the
+// including block counts as the first. If it reverted, the receipt is
+// returned together with ErrTransactionReverted.
+func WaitConfirmed(ctx context.Context, backend Backend, hash string, confirmations uint64, pollInterval time.Duration) (*Receipt, error) {
+	ticks, stop, err := newBlockTicker(ctx, backend, pollInterval)
+	if err != nil {
+		return nil, err
+	}
+	defer stop()
+
+	for {
+		receipt, err := confirmedReceipt(backend, hash, confirmations)
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			if receipt.Reverted() {
+				return receipt, ErrTransactionReverted
+			}
+			return receipt, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticks:
+		}
+	}
+}
+
+// confirmedReceipt returns the receipt if it has enough confirmations, or nil otherwise.
+// A receipt that disappears due to a reorg is treated as pending again.
+func confirmedReceipt(backend Backend, hash string, confirmations uint64) (*Receipt, error) {
+	receipt, err := backend.TransactionReceipt(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch receipt: %w", err)
+	}
+	if receipt == nil || confirmations <= 1 {
+		return receipt, nil
+	}
+
+	head, err := backend.BlockNumber()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch block number: %w", err)
+	}
+	headNum, err := decodeQuantity(head)
+	if err != nil {
+		return nil, err
+	}
+	minedNum, err := decodeQuantity(receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if headNum.Uint64() < minedNum.Uint64() || headNum.Uint64()-minedNum.Uint64()+1 < confirmations {
+		return nil, nil
+	}
+	return receipt, nil
+}
+
+// newBlockTicker returns a channel that fires on every new block when the
+// backend supports subscriptions, or on every poll interval otherwise. If
+// the subscription ends, e.g. because its websocket dropped, it falls back
+// to polling.
+func newBlockTicker(ctx context.Context, backend Backend, pollInterval time.Duration) (<-chan struct{}, func(), error) {
+	ticks := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(ctx)
+	tick := func() {
+		select {
+		case ticks <- struct{}{}:
+		default:
+		}
+	}
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+	poll := func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				tick()
+			}
+		}
+	}
+
+	if sub, ok := backend.(HeadSubscriber); ok {
+		heads, err := sub.SubscribeNewHeads(ctx)
+		if err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("failed to subscribe to new heads: %w", err)
+		}
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case _, ok := <-heads:
+					if !ok {
+						// Blocks may have been missed meanwhile
+						tick()
+						poll()
+						return
+					}
+					tick()
+				}
+			}
+		}()
+		return ticks, cancel, nil
+	}
+
+	go poll()
+	return ticks, cancel, nil
+}
@@ -0,0 +1,132 @@
+package clefclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// headBackend is a fakeBackend that pushes new heads through a channel
+type headBackend struct {
+	*fakeBackend
+	heads chan uint64
+}
+
+func (b *headBackend) SubscribeNewHeads(ctx context.Context) (<-chan uint64, error) {
+	return b.heads, nil
+}
+
+// checkedHeadBackend is a headBackend reporting reads of the head
+type checkedHeadBackend struct {
+	*headBackend
+	checked chan struct{}
+}
+
+func (b *checkedHeadBackend) BlockNumber() (string, error) {
+	select {
+	case b.checked <- struct{}{}:
+	default:
+	}
+	return b.headBackend.BlockNumber()
+}
+
+func TestSignAndSendWaitMined(t *testing.T) {
+	client, server := setupEchoSignServer(t)
+	defer server.Close()
+
+	backend := &fakeBackend{minedRaw: "0x1-0x64", receipts: map[string]*Receipt{}}
+	pending, err := client.SignAndSend(backend, &Transaction{From: "0x0000000000000000000000000000000000000001", GasPrice: "0x64", Nonce: "0x1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0xhash0x1-0x64", pending.Hash)
+
+	pending.PollInterval = time.Millisecond
+	receipt, err := pending.WaitMined(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, pending.Hash, receipt.TransactionHash)
+}
+
+func TestWaitMinedReverted(t *testing.T) {
+	backend := &fakeBackend{receipts: map[string]*Receipt{
+		"0x0000000000000000000000000000000000000abc": {TransactionHash: "0x0000000000000000000000000000000000000abc", Status: "0x0", BlockNumber: "0x1"},
+	}}
+
+	receipt, err := WaitMined(context.Background(), backend, "0x0000000000000000000000000000000000000abc", time.Millisecond)
+	assert.ErrorIs(t, err, ErrTransactionReverted)
+	assert.True(t, receipt.Reverted())
+}
+
+func TestWaitConfirmedSubscription(t *testing.T) {
+	backend := &checkedHeadBackend{
+		headBackend: &headBackend{
+			fakeBackend: &fakeBackend{head: "0x10", receipts: map[string]*Receipt{
+				"0x0000000000000000000000000000000000000abc": {TransactionHash: "0x0000000000000000000000000000000000000abc", Status: "0x1", BlockNumber: "0x10"},
+			}},
+			heads: make(chan uint64),
+		},
+		checked: make(chan struct{}, 1),
+	}
+
+	done := make(chan *Receipt)
+	go func() {
+		receipt, err := WaitConfirmed(context.Background(), backend, "0x0000000000000000000000000000000000000abc", 3, 0)
+		assert.NoError(t, err)
+		done <- receipt
+	}()
+
+	<-backend.checked
+	backend.mu.Lock()
+	backend.head = "0x12"
+	backend.mu.Unlock()
+	backend.heads <- 0x12
+
+	select {
+	case receipt := <-done:
+		assert.Equal(t, "0x0000000000000000000000000000000000000abc", receipt.TransactionHash)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for confirmations")
+	}
+}
+
+func TestWaitConfirmedSubscriptionClosed(t *testing.T) {
+	backend := &checkedHeadBackend{
+		headBackend: &headBackend{
+			fakeBackend: &fakeBackend{head: "0x10", receipts: map[string]*Receipt{
+				"0x0000000000000000000000000000000000000abc": {TransactionHash: "0x0000000000000000000000000000000000000abc", Status: "0x1", BlockNumber: "0x10"},
+			}},
+			heads: make(chan uint64),
+		},
+		checked: make(chan struct{}, 1),
+	}
+
+	done := make(chan *Receipt)
+	go func() {
+		receipt, err := WaitConfirmed(context.Background(), backend, "0x0000000000000000000000000000000000000abc", 3, time.Millisecond)
+		assert.NoError(t, err)
+		done <- receipt
+	}()
+
+	// The subscription drops after the first check, blocks keep coming
+	<-backend.checked
+	close(backend.heads)
+	backend.mu.Lock()
+	backend.head = "0x12"
+	backend.mu.Unlock()
+
+	select {
+	case receipt := <-done:
+		assert.Equal(t, "0x0000000000000000000000000000000000000abc", receipt.TransactionHash)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for confirmations")
+	}
+}
+
+func TestWaitMinedContextCanceled(t *testing.T) {
+	backend := &fakeBackend{receipts: map[string]*Receipt{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	_, err := WaitMined(ctx, backend, "0x0000000000000000000000000000000000000abc", time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
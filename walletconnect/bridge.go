@@ -0,0 +1,494 @@
+// Package walletconnect bridges WalletConnect v2 sessions to Clef, turning a
+// headless Clef into a wallet that dApps can connect to. Session requests are
+// served through the rpcproxy package, so Clef still approves every signature.
+//
+// The bridge talks to the relay network through the Relay interface, leaving
+// the choice of websocket client to the application.
+package walletconnect
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/AxLabs/clef-client/rpcproxy"
+)
+
+// Relay message tags of the WalletConnect sign protocol
+const (
+	tagSessionProposeResponse = 1101
+	tagSessionSettle          = 1102
+	tagSessionRequestResponse = 1109
+	tagSessionDeleteResponse  = 1113
+	tagSessionPingResponse    = 1115
+)
+
+// WalletConnect error codes
+const (
+	errCodeExpired            = 8
+	errCodeUserRejected       = 5000
+	errCodeUnsupportedChains  = 5100
+	errCodeUnsupportedMethods = 5101
+)
+
+const (
+	messageTTL    = 5 * time.Minute
+	sessionExpiry = 7 * 24 * time.Hour
+)
+
+// sessionMethods are the session request methods served by the bridge
+var sessionMethods = []string{
+	"eth_sendTransaction",
+	"eth_signTransaction",
+	"eth_sign",
+	"personal_sign",
+	"eth_signTypedData",
+	"eth_signTypedData_v4",
+}
+
+// Relay is a connection to a WalletConnect relay server
+type Relay interface {
+	// Subscribe starts delivering messages published on topic
+	Subscribe(ctx context.Context, topic string) error
+	// Publish sends an encrypted message on topic
+	Publish(ctx context.Context, topic, message string, tag int, ttl time.Duration) error
+	// Receive returns the next message on any subscribed topic
+	Receive(ctx context.Context) (*RelayMessage, error)
+}
+
+// RelayMessage is a message received from the relay
+type RelayMessage struct {
+	Topic   string
+	Message string
+}
+
+// Metadata describes a WalletConnect peer
+type Metadata struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	URL         string   `json:"url"`
+	Icons       []string `json:"icons"`
+}
+
+// Namespace is a set of chains, methods and events requested or granted for a
+// session
+type Namespace struct {
+	Chains   []string `json:"chains,omitempty"`
+	Methods  []string `json:"methods"`
+	Events   []string `json:"events"`
+	Accounts []string `json:"accounts,omitempty"`
+}
+
+// Proposal is a session proposal received from a dApp
+type Proposal struct {
+	ID                 int64                `json:"-"`
+	Proposer           Metadata             `json:"-"`
+	RequiredNamespaces map[string]Namespace `json:"requiredNamespaces"`
+	OptionalNamespaces map[string]Namespace `json:"optionalNamespaces"`
+}
+
+// Session is an established session with a dApp
+type Session struct {
+	Topic    string
+	Peer     Metadata
+	Accounts []string
+	Expiry   time.Time
+}
+
+// Config configures a Bridge
+type Config struct {
+	// Signer serves the signing requests, usually a ClefClient
+	Signer clefclient.Signer
+	// NodeURL is the node used to fill in and broadcast transactions of a
+	// bridge serving a single chain
+	NodeURL string
+	// NodeURLs are the nodes of the chains, by chain ID, required for
+	// bridges serving several chains. Chains without a node are not offered.
+	NodeURLs map[uint64]string
+	// ChainIDs are the EVM chains offered to dApps
+	ChainIDs []uint64
+	// Metadata describes the bridge to dApps
+	Metadata Metadata
+	// ApproveSession decides session proposals, all are approved if nil
+	ApproveSession func(p *Proposal) bool
+	// OnError is called with errors handling individual messages
+	OnError func(err error)
+}
+
+// Bridge services WalletConnect sessions by delegating to Clef
+type Bridge struct {
+	relay  Relay
+	config Config
+	// rpcs serve the requests of every chain offered
+	rpcs map[uint64]*rpcproxy.Server
+
+	mu       sync.Mutex
+	symKeys  map[string][]byte
+	sessions map[string]*Session
+}
+
+// NewBridge creates a Bridge on the given relay connection
+func NewBridge(relay Relay, config Config) *Bridge {
+	b := &Bridge{
+		relay:    relay,
+		config:   config,
+		rpcs:     map[uint64]*rpcproxy.Server{},
+		symKeys:  map[string][]byte{},
+		sessions: map[string]*Session{},
+	}
+	for _, chainID := range config.ChainIDs {
+		nodeURL, ok := config.NodeURLs[chainID]
+		if !ok && len(config.ChainIDs) == 1 {
+			nodeURL, ok = config.NodeURL, true
+		}
+		if ok {
+			b.rpcs[chainID] = rpcproxy.NewServer(rpcproxy.Config{Signer: config.Signer, NodeURL: nodeURL})
+		}
+	}
+	return b
+}
+
+// Pair subscribes to the pairing topic of a wc: URI, after which the dApp's
+// session proposal is handled by Run
+func (b *Bridge) Pair(ctx context.Context, uri string) error {
+	pairing, err := ParsePairingURI(uri)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.symKeys[pairing.Topic] = pairing.SymKey
+	b.mu.Unlock()
+	return b.relay.Subscribe(ctx, pairing.Topic)
+}
+
+// Sessions returns the established sessions that have not expired
+func (b *Bridge) Sessions() []Session {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	sessions := make([]Session, 0, len(b.sessions))
+	for _, s := range b.sessions {
+		if now.Before(s.Expiry) {
+			sessions = append(sessions, *s)
+		}
+	}
+	return sessions
+}
+
+// Run handles relay messages until ctx is done or the relay fails. Messages
+// that cannot be handled are reported to Config.OnError and dropped.
+func (b *Bridge) Run(ctx context.Context) error {
+	for {
+		msg, err := b.relay.Receive(ctx)
+		if err != nil {
+			return err
+		}
+		if err := b.handle(ctx, msg); err != nil && b.config.OnError != nil {
+			b.config.OnError(err)
+		}
+	}
+}
+
+// wcMessage is a JSON-RPC request or response exchanged with the dApp
+type wcMessage struct {
+	ID      int64           `json:"id"`
+	Jsonrpc string          `json:"jsonrpc"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *wcError        `json:"error,omitempty"`
+}
+
+type wcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (b *Bridge) handle(ctx context.Context, msg *RelayMessage) error {
+	b.mu.Lock()
+	symKey, ok := b.symKeys[msg.Topic]
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no key for topic %s", msg.Topic)
+	}
+	plaintext, err := decrypt(symKey, msg.Message)
+	if err != nil {
+		return err
+	}
+	var req wcMessage
+	if err := json.Unmarshal(plaintext, &req); err != nil {
+		return err
+	}
+
+	switch req.Method {
+	case "wc_sessionPropose":
+		return b.handlePropose(ctx, msg.Topic, &req)
+	case "wc_sessionRequest":
+		return b.handleRequest(ctx, msg.Topic, &req)
+	case "wc_sessionPing":
+		return b.respond(ctx, msg.Topic, tagSessionPingResponse, &wcMessage{ID: req.ID, Result: true})
+	case "wc_sessionDelete":
+		b.mu.Lock()
+		delete(b.sessions, msg.Topic)
+		b.mu.Unlock()
+		return b.respond(ctx, msg.Topic, tagSessionDeleteResponse, &wcMessage{ID: req.ID, Result: true})
+	}
+	// Responses to our own requests, e.g. the settlement acknowledgement
+	return nil
+}
+
+func (b *Bridge) handlePropose(ctx context.Context, topic string, req *wcMessage) error {
+	var params struct {
+		Proposer struct {
+			PublicKey string   `json:"publicKey"`
+			Metadata  Metadata `json:"metadata"`
+		} `json:"proposer"`
+		Proposal
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return err
+	}
+	proposal := params.Proposal
+	proposal.ID = req.ID
+	proposal.Proposer = params.Proposer.Metadata
+
+	if b.config.ApproveSession != nil && !b.config.ApproveSession(&proposal) {
+		return b.respond(ctx, topic, tagSessionProposeResponse, &wcMessage{
+			ID:    req.ID,
+			Error: &wcError{Code: errCodeUserRejected, Message: "User rejected."},
+		})
+	}
+
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	sessionKey, err := deriveSymKey(priv, params.Proposer.PublicKey)
+	if err != nil {
+		return err
+	}
+	accounts, err := b.sessionAccounts()
+	if err != nil {
+		return err
+	}
+
+	session := &Session{
+		Topic:    topicOf(sessionKey),
+		Peer:     proposal.Proposer,
+		Accounts: accounts,
+		Expiry:   time.Now().Add(sessionExpiry),
+	}
+	b.mu.Lock()
+	b.symKeys[session.Topic] = sessionKey
+	b.mu.Unlock()
+	if err := b.relay.Subscribe(ctx, session.Topic); err != nil {
+		return err
+	}
+
+	selfPublicKey := hex.EncodeToString(priv.PublicKey().Bytes())
+	if err := b.respond(ctx, topic, tagSessionProposeResponse, &wcMessage{
+		ID: req.ID,
+		Result: map[string]interface{}{
+			"relay":              map[string]string{"protocol": "irn"},
+			"responderPublicKey": selfPublicKey,
+		},
+	}); err != nil {
+		return err
+	}
+
+	settle, _ := json.Marshal(map[string]interface{}{
+		"relay": map[string]string{"protocol": "irn"},
+		"namespaces": map[string]Namespace{
+			"eip155": {Accounts: accounts, Methods: sessionMethods, Events: []string{"chainChanged", "accountsChanged"}},
+		},
+		"controller": map[string]interface{}{"publicKey": selfPublicKey, "metadata": b.config.Metadata},
+		"expiry":     session.Expiry.Unix(),
+	})
+	if err := b.respond(ctx, session.Topic, tagSessionSettle, &wcMessage{
+		ID:     time.Now().UnixNano() / int64(time.Microsecond),
+		Method: "wc_sessionSettle",
+		Params: settle,
+	}); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.sessions[session.Topic] = session
+	b.mu.Unlock()
+	return nil
+}
+
+// sessionAccounts returns the signer's accounts as CAIP-10 ids on every chain
+func (b *Bridge) sessionAccounts() ([]string, error) {
+	addresses, err := b.config.Signer.ListAccounts()
+	if err != nil {
+		return nil, err
+	}
+	var accounts []string
+	for _, chainID := range b.config.ChainIDs {
+		if b.rpcs[chainID] == nil {
+			continue
+		}
+		for _, address := range addresses {
+			accounts = append(accounts, fmt.Sprintf("eip155:%d:%s", chainID, address))
+		}
+	}
+	return accounts, nil
+}
+
+// handleRequest serves a session request through Clef and the node of its
+// chain. Requests of expired sessions, which are then dropped, for chains
+// not offered and of transactions for another chain are answered with an
+// error.
+func (b *Bridge) handleRequest(ctx context.Context, topic string, req *wcMessage) error {
+	b.mu.Lock()
+	session, ok := b.sessions[topic]
+	expired := ok && !time.Now().Before(session.Expiry)
+	if expired {
+		delete(b.sessions, topic)
+	}
+	b.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no session for topic %s", topic)
+	}
+	if expired {
+		return b.respond(ctx, topic, tagSessionRequestResponse, &wcMessage{
+			ID:    req.ID,
+			Error: &wcError{Code: errCodeExpired, Message: "Session expired."},
+		})
+	}
+
+	var params struct {
+		Request struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		} `json:"request"`
+		ChainID string `json:"chainId"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return err
+	}
+
+	chainID, ok := parseChainID(params.ChainID)
+	rpc := b.rpcs[chainID]
+	if !ok || rpc == nil {
+		return b.respond(ctx, topic, tagSessionRequestResponse, &wcMessage{
+			ID:    req.ID,
+			Error: &wcError{Code: errCodeUnsupportedChains, Message: fmt.Sprintf("Unsupported chain %q", params.ChainID)},
+		})
+	}
+	method := params.Request.Method
+	if !supportedMethod(method) {
+		return b.respond(ctx, topic, tagSessionRequestResponse, &wcMessage{
+			ID:    req.ID,
+			Error: &wcError{Code: errCodeUnsupportedMethods, Message: fmt.Sprintf("Unsupported method %s", method)},
+		})
+	}
+	// WalletConnect's eth_signTypedData carries v4 typed data
+	if method == "eth_signTypedData" {
+		method = "eth_signTypedData_v4"
+	}
+	if method == "eth_sendTransaction" || method == "eth_signTransaction" {
+		txParams, err := withChainID(params.Request.Params, chainID)
+		if err != nil {
+			return b.respond(ctx, topic, tagSessionRequestResponse, &wcMessage{
+				ID:    req.ID,
+				Error: &wcError{Code: errCodeUnsupportedChains, Message: err.Error()},
+			})
+		}
+		params.Request.Params = txParams
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      req.ID,
+		"method":  method,
+		"params":  params.Request.Params,
+	})
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *wcError        `json:"error"`
+	}
+	if err := json.Unmarshal(rpc.Handle(body), &rpcResp); err != nil {
+		return err
+	}
+
+	resp := &wcMessage{ID: req.ID, Error: rpcResp.Error}
+	if rpcResp.Error == nil {
+		resp.Result = rpcResp.Result
+	}
+	return b.respond(ctx, topic, tagSessionRequestResponse, resp)
+}
+
+// parseChainID returns the chain ID of a CAIP-2 id such as eip155:1
+func parseChainID(chainID string) (uint64, bool) {
+	reference, ok := strings.CutPrefix(chainID, "eip155:")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(reference, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// withChainID returns the params of a transaction request with the chainId
+// of the transaction set to chainID, failing if it is set to another chain
+func withChainID(params json.RawMessage, chainID uint64) (json.RawMessage, error) {
+	var args []map[string]json.RawMessage
+	if err := json.Unmarshal(params, &args); err != nil || len(args) == 0 || args[0] == nil {
+		return nil, fmt.Errorf("invalid transaction params")
+	}
+	expected := new(big.Int).SetUint64(chainID)
+	if raw, ok := args[0]["chainId"]; ok {
+		// Hex strings or JSON numbers
+		value := string(raw)
+		json.Unmarshal(raw, &value)
+		txChainID, ok := new(big.Int).SetString(value, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid chainId %s", raw)
+		}
+		if txChainID.Cmp(expected) != 0 {
+			return nil, fmt.Errorf("transaction chainId %s does not match eip155:%d", txChainID, chainID)
+		}
+	}
+	args[0]["chainId"], _ = json.Marshal(fmt.Sprintf("0x%x", expected))
+	return json.Marshal(args)
+}
+
+func supportedMethod(method string) bool {
+	for _, m := range sessionMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// respond encrypts msg with the topic's key and publishes it
+func (b *Bridge) respond(ctx context.Context, topic string, tag int, msg *wcMessage) error {
+	b.mu.Lock()
+	symKey := b.symKeys[topic]
+	b.mu.Unlock()
+
+	msg.Jsonrpc = "2.0"
+	plaintext, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	encrypted, err := encrypt(symKey, plaintext)
+	if err != nil {
+		return err
+	}
+	return b.relay.Publish(ctx, topic, encrypted, tag, messageTTL)
+}
@@ -0,0 +1,371 @@
+package walletconnect
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	clefclient "github.com/AxLabs/clef-client"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryRelay connects the bridge to a test playing the dApp
+type memoryRelay struct {
+	inbox     chan *RelayMessage
+	published chan publishedMessage
+}
+
+type publishedMessage struct {
+	topic   string
+	message string
+	tag     int
+}
+
+func newMemoryRelay() *memoryRelay {
+	return &memoryRelay{inbox: make(chan *RelayMessage, 8), published: make(chan publishedMessage, 8)}
+}
+
+func (r *memoryRelay) Subscribe(ctx context.Context, topic string) error {
+	return nil
+}
+
+func (r *memoryRelay) Publish(ctx context.Context, topic, message string, tag int, ttl time.Duration) error {
+	r.published <- publishedMessage{topic: topic, message: message, tag: tag}
+	return nil
+}
+
+func (r *memoryRelay) Receive(ctx context.Context) (*RelayMessage, error) {
+	select {
+	case msg := <-r.inbox:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// stubSigner signs everything with a fixed signature
+type stubSigner struct {
+	data *clefclient.SignDataRequest
+	tx   *clefclient.Transaction
+}
+
+func (s *stubSigner) ListAccounts() ([]string, error) {
+	return []string{"0x0000000000000000000000000000000000000001"}, nil
+}
+
+func (s *stubSigner) SignTransaction(tx *clefclient.Transaction) (*clefclient.SignTxResponse, error) {
+	s.tx = tx
+	return &clefclient.SignTxResponse{Raw: "0xraw"}, nil
+}
+
+func (s *stubSigner) SignData(req *clefclient.SignDataRequest) (*clefclient.SignDataResponse, error) {
+	s.data = req
+	return &clefclient.SignDataResponse{Signature: "0xsig"}, nil
+}
+
+func (s *stubSigner) SignTypedData(req *clefclient.TypedDataRequest) (*clefclient.SignDataResponse, error) {
+	return &clefclient.SignDataResponse{Signature: "0xtyped"}, nil
+}
+
+// dapp plays the dApp side of the protocol
+type dapp struct {
+	t     *testing.T
+	relay *memoryRelay
+}
+
+func (d *dapp) send(topic string, symKey []byte, msg interface{}) {
+	plaintext, err := json.Marshal(msg)
+	assert.NoError(d.t, err)
+	encrypted, err := encrypt(symKey, plaintext)
+	assert.NoError(d.t, err)
+	d.relay.inbox <- &RelayMessage{Topic: topic, Message: encrypted}
+}
+
+func (d *dapp) receive(symKey []byte, v interface{}) publishedMessage {
+	select {
+	case msg := <-d.relay.published:
+		plaintext, err := decrypt(symKey, msg.message)
+		assert.NoError(d.t, err)
+		assert.NoError(d.t, json.Unmarshal(plaintext, v))
+		return msg
+	case <-time.After(time.Second):
+		d.t.Fatal("timed out waiting for bridge message")
+		return publishedMessage{}
+	}
+}
+
+// connect pairs with the bridge and proposes a session, returning its
+// topic, key and accounts
+func (d *dapp) connect(ctx context.Context, bridge *Bridge) (string, []byte, []string) {
+	t := d.t
+	pairingKey := make([]byte, 32)
+	rand.Read(pairingKey)
+	pairing := &PairingURI{Topic: topicOf(pairingKey), SymKey: pairingKey, RelayProtocol: "irn"}
+	assert.NoError(t, bridge.Pair(ctx, pairing.String()))
+
+	dappKey, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	d.send(pairing.Topic, pairingKey, map[string]interface{}{
+		"id": 1, "jsonrpc": "2.0", "method": "wc_sessionPropose",
+		"params": map[string]interface{}{
+			"proposer": map[string]interface{}{
+				"publicKey": hex.EncodeToString(dappKey.PublicKey().Bytes()),
+				"metadata":  Metadata{Name: "Example dApp"},
+			},
+			"requiredNamespaces": map[string]Namespace{"eip155": {Chains: []string{"eip155:1"}, Methods: []string{"personal_sign"}}},
+		},
+	})
+
+	var proposeResp struct {
+		ID     int64 `json:"id"`
+		Result struct {
+			ResponderPublicKey string `json:"responderPublicKey"`
+		} `json:"result"`
+	}
+	msg := d.receive(pairingKey, &proposeResp)
+	assert.Equal(t, tagSessionProposeResponse, msg.tag)
+	assert.Equal(t, int64(1), proposeResp.ID)
+
+	sessionKey, err := deriveSymKey(dappKey, proposeResp.Result.ResponderPublicKey)
+	assert.NoError(t, err)
+	sessionTopic := topicOf(sessionKey)
+
+	var settle struct {
+		Method string `json:"method"`
+		Params struct {
+			Namespaces map[string]Namespace `json:"namespaces"`
+		} `json:"params"`
+	}
+	msg = d.receive(sessionKey, &settle)
+	assert.Equal(t, tagSessionSettle, msg.tag)
+	assert.Equal(t, sessionTopic, msg.topic)
+	assert.Equal(t, "wc_sessionSettle", settle.Method)
+	return sessionTopic, sessionKey, settle.Params.Namespaces["eip155"].Accounts
+}
+
+func TestParsePairingURI(t *testing.T) {
+	symKey := make([]byte, 32)
+	uri := (&PairingURI{Topic: topicOf(symKey), SymKey: symKey, RelayProtocol: "irn"}).String()
+
+	pairing, err := ParsePairingURI(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, "irn", pairing.RelayProtocol)
+	assert.Equal(t, symKey, pairing.SymKey)
+
+	_, err = ParsePairingURI("wc:abc@1?symKey=00")
+	assert.ErrorContains(t, err, "version")
+	_, err = ParsePairingURI("wc:abc@2?relay-protocol=irn&symKey=" + hex.EncodeToString(symKey))
+	assert.ErrorContains(t, err, "topic")
+}
+
+func TestBridgeSession(t *testing.T) {
+	relay := newMemoryRelay()
+	signer := &stubSigner{}
+	bridge := NewBridge(relay, Config{Signer: signer, ChainIDs: []uint64{1}, Metadata: Metadata{Name: "clef"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bridge.Run(ctx)
+
+	d := &dapp{t: t, relay: relay}
+	sessionTopic, sessionKey, accounts := d.connect(ctx, bridge)
+	assert.Equal(t, []string{"eip155:1:0x0000000000000000000000000000000000000001"}, accounts)
+
+	// Request a signature
+	d.send(sessionTopic, sessionKey, map[string]interface{}{
+		"id": 2, "jsonrpc": "2.0", "method": "wc_sessionRequest",
+		"params": map[string]interface{}{
+			"chainId": "eip155:1",
+			"request": map[string]interface{}{
+				"method": "personal_sign",
+				"params": []string{"0x68656c6c6f", "0x0000000000000000000000000000000000000001"},
+			},
+		},
+	})
+	var signResp struct {
+		ID     int64  `json:"id"`
+		Result string `json:"result"`
+	}
+	msg := d.receive(sessionKey, &signResp)
+	assert.Equal(t, tagSessionRequestResponse, msg.tag)
+	assert.Equal(t, int64(2), signResp.ID)
+	assert.Equal(t, "0xsig", signResp.Result)
+	assert.Equal(t, "0x68656c6c6f", signer.data.Data)
+
+	// Unsupported methods are rejected
+	d.send(sessionTopic, sessionKey, map[string]interface{}{
+		"id": 3, "jsonrpc": "2.0", "method": "wc_sessionRequest",
+		"params": map[string]interface{}{"chainId": "eip155:1", "request": map[string]interface{}{"method": "wallet_addEthereumChain"}},
+	})
+	var errResp struct {
+		ID    int64   `json:"id"`
+		Error wcError `json:"error"`
+	}
+	d.receive(sessionKey, &errResp)
+	assert.Equal(t, errCodeUnsupportedMethods, errResp.Error.Code)
+
+	// So are chains that were not offered
+	for i, chainID := range []string{"eip155:5", "", "eip155:0x1", "cosmos:1"} {
+		d.send(sessionTopic, sessionKey, map[string]interface{}{
+			"id": 4 + i, "jsonrpc": "2.0", "method": "wc_sessionRequest",
+			"params": map[string]interface{}{
+				"chainId": chainID,
+				"request": map[string]interface{}{"method": "personal_sign", "params": []string{"0x00", "0x0000000000000000000000000000000000000001"}},
+			},
+		})
+		errResp.Error = wcError{}
+		d.receive(sessionKey, &errResp)
+		assert.Equal(t, int64(4+i), errResp.ID)
+		assert.Equal(t, errCodeUnsupportedChains, errResp.Error.Code, chainID)
+	}
+	assert.Equal(t, "0x68656c6c6f", signer.data.Data)
+
+	sessions := bridge.Sessions()
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, "Example dApp", sessions[0].Peer.Name)
+	}
+
+	// Expired sessions are no longer served
+	bridge.mu.Lock()
+	bridge.sessions[sessionTopic].Expiry = time.Now().Add(-time.Second)
+	bridge.mu.Unlock()
+	assert.Empty(t, bridge.Sessions())
+	d.send(sessionTopic, sessionKey, map[string]interface{}{
+		"id": 10, "jsonrpc": "2.0", "method": "wc_sessionRequest",
+		"params": map[string]interface{}{
+			"chainId": "eip155:1",
+			"request": map[string]interface{}{"method": "personal_sign", "params": []string{"0x00", "0x0000000000000000000000000000000000000001"}},
+		},
+	})
+	errResp.Error = wcError{}
+	d.receive(sessionKey, &errResp)
+	assert.Equal(t, int64(10), errResp.ID)
+	assert.Equal(t, errCodeExpired, errResp.Error.Code)
+	assert.Equal(t, "0x68656c6c6f", signer.data.Data)
+	bridge.mu.Lock()
+	assert.NotContains(t, bridge.sessions, sessionTopic)
+	bridge.mu.Unlock()
+}
+
+// setupNode serves eth_sendRawTransaction, answering with the given hash
+func setupNode(t *testing.T, hash string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int64  `json:"id"`
+			Method string `json:"method"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "eth_sendRawTransaction", req.Method)
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": hash})
+	}))
+}
+
+func TestBridgeChains(t *testing.T) {
+	mainnet := setupNode(t, "0xmainnet")
+	defer mainnet.Close()
+	polygon := setupNode(t, "0xpolygon")
+	defer polygon.Close()
+
+	relay := newMemoryRelay()
+	signer := &stubSigner{}
+	bridge := NewBridge(relay, Config{
+		Signer:   signer,
+		ChainIDs: []uint64{1, 137, 10},
+		NodeURLs: map[uint64]string{1: mainnet.URL, 137: polygon.URL},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bridge.Run(ctx)
+
+	// Chains without a node are not offered
+	d := &dapp{t: t, relay: relay}
+	sessionTopic, sessionKey, accounts := d.connect(ctx, bridge)
+	assert.Equal(t, []string{
+		"eip155:1:0x0000000000000000000000000000000000000001",
+		"eip155:137:0x0000000000000000000000000000000000000001",
+	}, accounts)
+
+	send := func(id int, chainID string, tx map[string]interface{}) (string, *wcError) {
+		d.send(sessionTopic, sessionKey, map[string]interface{}{
+			"id": id, "jsonrpc": "2.0", "method": "wc_sessionRequest",
+			"params": map[string]interface{}{
+				"chainId": chainID,
+				"request": map[string]interface{}{"method": "eth_sendTransaction", "params": []interface{}{tx}},
+			},
+		})
+		var resp struct {
+			ID     int64    `json:"id"`
+			Result string   `json:"result"`
+			Error  *wcError `json:"error"`
+		}
+		d.receive(sessionKey, &resp)
+		assert.Equal(t, int64(id), resp.ID)
+		return resp.Result, resp.Error
+	}
+	tx := func(chainID interface{}) map[string]interface{} {
+		tx := map[string]interface{}{
+			"from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002",
+			"gas": "0x5208", "gasPrice": "0x1", "nonce": "0x0",
+		}
+		if chainID != nil {
+			tx["chainId"] = chainID
+		}
+		return tx
+	}
+
+	// Transactions are signed for and sent to the chain of the request
+	hash, wcErr := send(2, "eip155:137", tx(nil))
+	assert.Nil(t, wcErr)
+	assert.Equal(t, "0xpolygon", hash)
+	assert.Equal(t, "0x89", signer.tx.ChainID)
+	hash, wcErr = send(3, "eip155:1", tx("0x1"))
+	assert.Nil(t, wcErr)
+	assert.Equal(t, "0xmainnet", hash)
+	assert.Equal(t, "0x1", signer.tx.ChainID)
+	hash, wcErr = send(4, "eip155:137", tx(137))
+	assert.Nil(t, wcErr)
+	assert.Equal(t, "0xpolygon", hash)
+
+	// Transactions for another chain than the request are refused
+	signer.tx = nil
+	_, wcErr = send(5, "eip155:137", tx("0x1"))
+	if assert.NotNil(t, wcErr) {
+		assert.Equal(t, errCodeUnsupportedChains, wcErr.Code)
+		assert.Equal(t, "transaction chainId 1 does not match eip155:137", wcErr.Message)
+	}
+	_, wcErr = send(6, "eip155:10", tx(nil))
+	if assert.NotNil(t, wcErr) {
+		assert.Equal(t, errCodeUnsupportedChains, wcErr.Code)
+	}
+	assert.Nil(t, signer.tx)
+}
+
+func TestBridgeRejectsProposal(t *testing.T) {
+	relay := newMemoryRelay()
+	bridge := NewBridge(relay, Config{Signer: &stubSigner{}, ApproveSession: func(p *Proposal) bool { return false }})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go bridge.Run(ctx)
+
+	pairingKey := make([]byte, 32)
+	pairing := &PairingURI{Topic: topicOf(pairingKey), SymKey: pairingKey, RelayProtocol: "irn"}
+	assert.NoError(t, bridge.Pair(ctx, pairing.String()))
+
+	d := &dapp{t: t, relay: relay}
+	d.send(pairing.Topic, pairingKey, map[string]interface{}{
+		"id": 1, "jsonrpc": "2.0", "method": "wc_sessionPropose",
+		"params": map[string]interface{}{"proposer": map[string]interface{}{"publicKey": "00"}},
+	})
+	var resp struct {
+		Error wcError `json:"error"`
+	}
+	d.receive(pairingKey, &resp)
+	assert.Equal(t, errCodeUserRejected, resp.Error.Code)
+	assert.Empty(t, bridge.Sessions())
+}
@@ -0,0 +1,124 @@
+package walletconnect
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+// envelopeType0 is the WalletConnect envelope of messages encrypted with a
+// key known to both peers
+const envelopeType0 = 0
+
+// errInvalidEnvelope is returned for messages that cannot be decrypted
+var errInvalidEnvelope = errors.New("invalid envelope")
+
+// PairingURI is a parsed WalletConnect v2 pairing URI as shown by dApps
+type PairingURI struct {
+	Topic         string
+	SymKey        []byte
+	RelayProtocol string
+}
+
+// ParsePairingURI parses a wc:{topic}@2?relay-protocol=irn&symKey={key} URI
+func ParsePairingURI(uri string) (*PairingURI, error) {
+	rest, ok := strings.CutPrefix(uri, "wc:")
+	if !ok {
+		return nil, fmt.Errorf("pairing uri must start with wc:")
+	}
+	target, query, _ := strings.Cut(rest, "?")
+	topic, version, ok := strings.Cut(target, "@")
+	if !ok || version != "2" {
+		return nil, fmt.Errorf("unsupported pairing uri version %q", version)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pairing uri: %w", err)
+	}
+	symKey, err := hex.DecodeString(values.Get("symKey"))
+	if err != nil || len(symKey) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("invalid pairing uri symKey")
+	}
+	if topic != topicOf(symKey) {
+		return nil, fmt.Errorf("pairing uri topic does not match symKey")
+	}
+	return &PairingURI{Topic: topic, SymKey: symKey, RelayProtocol: values.Get("relay-protocol")}, nil
+}
+
+// String formats the pairing URI
+func (p *PairingURI) String() string {
+	return fmt.Sprintf("wc:%s@2?relay-protocol=%s&symKey=%s", p.Topic, p.RelayProtocol, hex.EncodeToString(p.SymKey))
+}
+
+// topicOf returns the relay topic of messages encrypted with symKey
+func topicOf(symKey []byte) string {
+	sum := sha256.Sum256(symKey)
+	return hex.EncodeToString(sum[:])
+}
+
+// deriveSymKey derives the session key from an X25519 key agreement
+func deriveSymKey(priv *ecdh.PrivateKey, peerPublicKey string) ([]byte, error) {
+	raw, err := hex.DecodeString(peerPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer public key: %w", err)
+	}
+	pub, err := ecdh.X25519().NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer public key: %w", err)
+	}
+	shared, err := priv.ECDH(pub)
+	if err != nil {
+		return nil, err
+	}
+	symKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, nil), symKey); err != nil {
+		return nil, err
+	}
+	return symKey, nil
+}
+
+// encrypt seals plaintext in a base64 encoded type 0 envelope
+func encrypt(symKey, plaintext []byte) (string, error) {
+	aead, err := chacha20poly1305.New(symKey)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	envelope := append([]byte{envelopeType0}, iv...)
+	envelope = aead.Seal(envelope, iv, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(envelope), nil
+}
+
+// decrypt opens a base64 encoded type 0 envelope
+func decrypt(symKey []byte, message string) ([]byte, error) {
+	envelope, err := base64.StdEncoding.DecodeString(message)
+	if err != nil {
+		return nil, errInvalidEnvelope
+	}
+	aead, err := chacha20poly1305.New(symKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope) < 1+aead.NonceSize() || envelope[0] != envelopeType0 {
+		return nil, errInvalidEnvelope
+	}
+	iv := envelope[1 : 1+aead.NonceSize()]
+	plaintext, err := aead.Open(nil, iv, envelope[1+aead.NonceSize():], nil)
+	if err != nil {
+		return nil, errInvalidEnvelope
+	}
+	return plaintext, nil
+}
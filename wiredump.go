@@ -0,0 +1,199 @@
+package clefclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultWireRedactions are the JSON fields redacted from wire dumps:
+// signatures, signed transactions, signed data and passwords
+var DefaultWireRedactions = []string{"signature", "sig", "raw", "data", "input", "r", "s", "v", "password"}
+
+// WireDumpConfig configures a WireDump
+type WireDumpConfig struct {
+	Writer io.Writer
+	// Redactions are the JSON fields whose values are replaced with a
+	// placeholder at any depth of params and results,
+	// DefaultWireRedactions if nil. An empty non-nil slice dumps everything.
+	Redactions []string
+}
+
+// WireDump writes the JSON-RPC requests and responses exchanged with Clef,
+// one line each, for diagnosing interoperability issues. It can be turned on
+// and off at runtime.
+type WireDump struct {
+	config  WireDumpConfig
+	enabled atomic.Bool
+
+	mu sync.Mutex
+}
+
+// NewWireDump creates an enabled WireDump
+func NewWireDump(config WireDumpConfig) *WireDump {
+	if config.Redactions == nil {
+		config.Redactions = DefaultWireRedactions
+	}
+	d := &WireDump{config: config}
+	d.enabled.Store(true)
+	return d
+}
+
+// SetEnabled turns dumping on or off
+func (d *WireDump) SetEnabled(enabled bool) {
+	d.enabled.Store(enabled)
+}
+
+// Enabled reports whether dumping is on
+func (d *WireDump) Enabled() bool {
+	return d.enabled.Load()
+}
+
+// EnableWireDump writes every subsequent request made by the client and its
+// response to d while d is enabled
+func (cc *ClefClient) EnableWireDump(d *WireDump) {
+	if !cc.ready() {
+		return
+	}
+	cc.transport = &wireDumpTransport{next: cc.transport, dump: d}
+}
+
+// wireDumpTransport is a transport decorator writing calls to a WireDump
+type wireDumpTransport struct {
+	next transport
+	dump *WireDump
+}
+
+func (t *wireDumpTransport) call(ctx context.Context, method string, params interface{}) (*rpcResponse, error) {
+	if !t.dump.Enabled() {
+		return t.next.call(ctx, method, params)
+	}
+	t.dump.write("-->", method, 0, rpcRequest{Jsonrpc: "2.0", Method: method, Params: params, ID: 1})
+	start := time.Now()
+	resp, err := t.next.call(ctx, method, params)
+	if err != nil {
+		t.dump.writeError(method, time.Since(start), err)
+	} else {
+		t.dump.write("<--", method, time.Since(start), resp)
+	}
+	return resp, err
+}
+
+func (t *wireDumpTransport) callBatch(ctx context.Context, method string, params []interface{}) ([]*rpcResponse, error) {
+	if !t.dump.Enabled() {
+		return t.next.callBatch(ctx, method, params)
+	}
+	t.dump.write("-->", method, 0, newBatchRequest(method, params))
+	start := time.Now()
+	resps, err := t.next.callBatch(ctx, method, params)
+	if err != nil {
+		t.dump.writeError(method, time.Since(start), err)
+	} else {
+		t.dump.write("<--", method, time.Since(start), resps)
+	}
+	return resps, err
+}
+
+func (t *wireDumpTransport) close() error {
+	return t.next.close()
+}
+
+// writeError writes a failed call, as the JSON-RPC error of Clef if there is
+// one
+func (d *WireDump) writeError(method string, latency time.Duration, err error) {
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		d.write("<--", method, latency, rpcResponse{Jsonrpc: "2.0", ID: 1, Error: &rpcError{Code: rpcErr.Code, Message: rpcErr.Message, Data: rpcErr.Data}})
+		return
+	}
+	d.writeLine(fmt.Sprintf("<-- %s %s error: %v", method, latency.Round(time.Millisecond), err))
+}
+
+// write writes a redacted JSON message with its direction and, for
+// responses, the latency of the call
+func (d *WireDump) write(direction, method string, latency time.Duration, msg interface{}) {
+	content, err := json.Marshal(msg)
+	if err != nil {
+		d.writeLine(fmt.Sprintf("%s %s unencodable message: %v", direction, method, err))
+		return
+	}
+	content = redactEnvelopes(content, d.config.Redactions)
+	if direction == "<--" {
+		d.writeLine(fmt.Sprintf("%s %s %s %s", direction, method, latency.Round(time.Millisecond), content))
+	} else {
+		d.writeLine(fmt.Sprintf("%s %s %s", direction, method, content))
+	}
+}
+
+func (d *WireDump) writeLine(line string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	io.WriteString(d.config.Writer, line+"\n")
+}
+
+// redactEnvelopes replaces the values of fields at any depth of the params
+// and results of JSON-RPC messages, comparing field names case-insensitively.
+// Errors are kept whole, their data is meant for diagnosis.
+func redactEnvelopes(raw json.RawMessage, fields []string) json.RawMessage {
+	if len(fields) == 0 {
+		return raw
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if dec.Decode(&v) != nil {
+		return raw
+	}
+	envelopes, ok := v.([]interface{})
+	if !ok {
+		envelopes = []interface{}{v}
+	}
+	for _, envelope := range envelopes {
+		if msg, ok := envelope.(map[string]interface{}); ok {
+			for _, member := range []string{"params", "result"} {
+				if value, ok := msg[member]; ok {
+					msg[member] = redactFieldValues(value, fields)
+				}
+			}
+		}
+	}
+	redacted, err := json.Marshal(v)
+	if err != nil {
+		return raw
+	}
+	return redacted
+}
+
+func redactFieldValues(v interface{}, fields []string) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if containsFold(fields, key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactFieldValues(value, fields)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactFieldValues(value, fields)
+		}
+	}
+	return v
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package clefclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWireDump(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		resp := rpcResponse{Jsonrpc: "2.0", ID: 1}
+		if req.Method == "account_signData" {
+			resp.Result, _ = json.Marshal(SignDataResponse{Signature: testSignature(1)})
+		} else {
+			resp.Error = &rpcError{Code: -32000, Message: "Request denied", Data: json.RawMessage(`{"reason":"rule"}`)}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	dump := NewWireDump(WireDumpConfig{Writer: &out})
+	client := NewHTTPClient(server.URL)
+	client.EnableWireDump(dump)
+
+	_, err := client.SignData(&SignDataRequest{ContentType: ContentTypeTextPlain, Address: "0x0000000000000000000000000000000000000001", Data: "0x68656c6c6f"})
+	assert.NoError(t, err)
+	_, err = client.ListAccounts()
+	assert.ErrorIs(t, err, ErrRequestDenied)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(t, lines, 4) {
+		assert.Equal(t, `--> account_signData {"id":1,"jsonrpc":"2.0","method":"account_signData","params":{"address":"0x0000000000000000000000000000000000000001","content_type":"text/plain","data":"[REDACTED]"}}`, lines[0])
+		assert.Regexp(t, `^<-- account_signData \S+ \{"error":null,"id":1,"jsonrpc":"2.0","result":\{"signature":"\[REDACTED\]"\}\}$`, lines[1])
+		assert.Equal(t, `--> account_list {"id":1,"jsonrpc":"2.0","method":"account_list","params":null}`, lines[2])
+		assert.Regexp(t, `^<-- account_list \S+ \{"error":\{"code":-32000,"data":\{"reason":"rule"\},"message":"Request denied"\},"id":1,"jsonrpc":"2.0","result":null\}$`, lines[3])
+	}
+
+	out.Reset()
+	dump.SetEnabled(false)
+	_, err = client.ListAccounts()
+	assert.Error(t, err)
+	assert.Empty(t, out.String())
+}
+
+func TestWireDumpWithoutRedactions(t *testing.T) {
+	var out bytes.Buffer
+	cc := &ClefClient{transport: &countingTransport{}}
+	cc.EnableWireDump(NewWireDump(WireDumpConfig{Writer: &out, Redactions: []string{}}))
+
+	_, err := cc.SignTransactions([]*Transaction{{From: "0x0000000000000000000000000000000000000001", Data: "0x01"}})
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), `--> account_signTransaction [{"jsonrpc":"2.0","method":"account_signTransaction","params":{"from":"0x0000000000000000000000000000000000000001","to":"","data":"0x01"},"id":1}]`)
+}
+
+func TestRedactEnvelopes(t *testing.T) {
+	raw := json.RawMessage(`[{"id":1,"params":{"tx":{"Input":"0x01","to":"0x02"}}},{"id":2,"result":[{"sig":"0x03"}]}]`)
+	assert.JSONEq(t, `[{"id":1,"params":{"tx":{"Input":"[REDACTED]","to":"0x02"}}},{"id":2,"result":[{"sig":"[REDACTED]"}]}]`, string(redactEnvelopes(raw, DefaultWireRedactions)))
+}